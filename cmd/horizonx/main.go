@@ -37,7 +37,7 @@ func main() {
 		fmt.Println("horizonx " + version.Version)
 		return
 	case "upgrade":
-		err = app.RunUpgrade()
+		err = runUpgrade(os.Args[2:])
 	case "migrate":
 		err = runMigrate(os.Args[2:])
 	case "help", "--help", "-h":
@@ -81,6 +81,15 @@ func runInstall(args []string) error {
 	}
 }
 
+// runUpgrade parses the upgrade flags and runs the upgrade.
+func runUpgrade(args []string) error {
+	opts, err := app.UpgradeFlags(args)
+	if err != nil {
+		return err
+	}
+	return app.RunUpgrade(opts)
+}
+
 func usage() {
 	fmt.Println(`horizonx — HorizonX control plane
 
@@ -93,7 +102,7 @@ Usage:
   horizonx agent              Run an app-host agent (deploys docker-compose apps)
   horizonx migrate            Apply/rollback database migrations (-op=up|down|version|force)
   horizonx version            Print the build version
-  horizonx upgrade            Self-update to the latest release
+  horizonx upgrade            Self-update to the latest release (--version v0.4.0 to pin one)
   horizonx help               Show this help
 
 Install the binary (one-liner, auto-sudo):
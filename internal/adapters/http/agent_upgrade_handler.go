@@ -0,0 +1,136 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type AgentUpgradeHandler struct {
+	svc domain.AgentUpgradeService
+
+	decoder   request.RequestDecoder
+	writer    response.ResponseWriter
+	validator validator.Validator
+}
+
+func NewAgentUpgradeHandler(
+	svc domain.AgentUpgradeService,
+	d request.RequestDecoder,
+	w response.ResponseWriter,
+	v validator.Validator,
+) *AgentUpgradeHandler {
+	return &AgentUpgradeHandler{
+		svc:       svc,
+		decoder:   d,
+		writer:    w,
+		validator: v,
+	}
+}
+
+// Upgrade queues an agent_upgrade job for one server. The body is optional:
+// without a version the agent installs the latest release.
+func (h *AgentUpgradeHandler) Upgrade(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	serverID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid server ID",
+		})
+		return
+	}
+
+	var req domain.AgentUpgradeRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	job, err := h.svc.Upgrade(r.Context(), serverID, req)
+	if err != nil {
+		h.writeError(w, err, "failed to queue agent upgrade")
+		return
+	}
+
+	h.writer.Write(w, http.StatusAccepted, &response.Response{
+		Message: "agent upgrade queued",
+		Data:    job,
+	})
+}
+
+// Rollout upgrades the given servers, or every online one, one at a time,
+// stopping at the first upgrade that does not succeed. Progress is the job
+// list filtered by the returned trace_id.
+func (h *AgentUpgradeHandler) Rollout(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req domain.AgentRolloutRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	rollout, err := h.svc.Rollout(r.Context(), req)
+	if err != nil {
+		h.writeError(w, err, "failed to start agent rollout")
+		return
+	}
+
+	message := "agent rollout started"
+	if len(rollout.ServerIDs) == 0 {
+		message = "every agent is already up to date"
+	}
+
+	h.writer.Write(w, http.StatusAccepted, &response.Response{
+		Message: message,
+		Data:    rollout,
+	})
+}
+
+func (h *AgentUpgradeHandler) decode(w http.ResponseWriter, r *http.Request, req any) bool {
+	if r.ContentLength == 0 {
+		return true
+	}
+
+	if err := h.decoder.Decode(r, req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return false
+	}
+
+	if errs := h.validator.Validate(req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return false
+	}
+
+	return true
+}
+
+func (h *AgentUpgradeHandler) writeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, domain.ErrServerNotFound):
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "server not found",
+		})
+	case errors.Is(err, domain.ErrInvalidAgentVersion):
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrJobUnsupported),
+		errors.Is(err, domain.ErrAgentUpgradeInProgress),
+		errors.Is(err, domain.ErrServerOffline):
+		h.writer.Write(w, http.StatusConflict, &response.Response{
+			Message: err.Error(),
+		})
+	default:
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: fallback,
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAgentUpgradeService struct {
+	domain.AgentUpgradeService
+	err error
+	req domain.AgentUpgradeRequest
+}
+
+func (f *fakeAgentUpgradeService) Upgrade(ctx context.Context, serverID uuid.UUID, req domain.AgentUpgradeRequest) (*domain.Job, error) {
+	f.req = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &domain.Job{ID: 1, ServerID: serverID, Type: domain.JobTypeAgentUpgrade}, nil
+}
+
+func TestAgentUpgradeHandler_Upgrade(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		err  error
+		want int
+	}{
+		{"latest, no body", "", nil, http.StatusAccepted},
+		{"pinned", `{"version":"v0.4.0"}`, nil, http.StatusAccepted},
+		{"invalid version", `{"version":"nope"}`, domain.ErrInvalidAgentVersion, http.StatusBadRequest},
+		{"already running", "", domain.ErrAgentUpgradeInProgress, http.StatusConflict},
+		{"legacy agent", "", domain.ErrJobUnsupported, http.StatusConflict},
+		{"unknown server", "", domain.ErrServerNotFound, http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeAgentUpgradeService{err: tc.err}
+			h := NewAgentUpgradeHandler(svc, request.NewJSONDecoder(), response.NewJSONWriter(stubLogger{}), validator.NewValidator())
+
+			req := httptest.NewRequest(http.MethodPost, "/servers/x/agent/upgrade", strings.NewReader(tc.body))
+			req.SetPathValue("id", uuid.NewString())
+			rec := httptest.NewRecorder()
+
+			h.Upgrade(rec, req)
+
+			assert.Equal(t, tc.want, rec.Code)
+			if tc.body == `{"version":"v0.4.0"}` {
+				assert.Equal(t, "v0.4.0", svc.req.Version)
+			}
+		})
+	}
+}
@@ -135,6 +135,12 @@ func (h *ApplicationHandler) Store(w http.ResponseWriter, r *http.Request) {
 
 	app, err := h.svc.Create(r.Context(), req)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidDockerCompose) || errors.Is(err, domain.ErrInvalidApplicationSource) || errors.Is(err, domain.ErrInvalidReadinessProbe) {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
 		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
 			Message: "failed to create application",
 		})
@@ -178,6 +184,12 @@ func (h *ApplicationHandler) Update(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+		if errors.Is(err, domain.ErrInvalidDockerCompose) || errors.Is(err, domain.ErrInvalidApplicationSource) || errors.Is(err, domain.ErrInvalidReadinessProbe) {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
 		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
 			Message: "failed to update application",
 		})
@@ -233,7 +245,23 @@ func (h *ApplicationHandler) Deploy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	deployment, err := h.svc.Deploy(r.Context(), appID, userCtx.ID)
+	// The body is optional: an empty one deploys the branch tip.
+	var req domain.ApplicationDeployRequest
+	if r.ContentLength != 0 {
+		if err := h.decoder.Decode(r, &req); err != nil {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
+
+		if errs := h.validator.Validate(&req); len(errs) > 0 {
+			h.writer.WriteValidationError(w, errs)
+			return
+		}
+	}
+
+	deployment, err := h.svc.Deploy(r.Context(), appID, req, userCtx.ID)
 	if err != nil {
 		if errors.Is(err, domain.ErrApplicationNotFound) {
 			h.writer.Write(w, http.StatusNotFound, &response.Response{
@@ -241,6 +269,18 @@ func (h *ApplicationHandler) Deploy(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
+		if errors.Is(err, domain.ErrInvalidGitRef) || errors.Is(err, domain.ErrInvalidApplicationSource) {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrJobUnsupported) {
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
 		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
 			Message: err.Error(),
 		})
@@ -291,6 +331,65 @@ func (h *ApplicationHandler) Rollback(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// RollbackTo re-deploys the image and env snapshot of a chosen deployment.
+func (h *ApplicationHandler) RollbackTo(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := domain.GetUserContext(r.Context())
+	if !ok {
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: "unauthorized",
+		})
+		return
+	}
+
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	deploymentID, err := strconv.ParseInt(r.PathValue("deployment_id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid deployment id",
+		})
+		return
+	}
+
+	deployment, err := h.svc.RollbackTo(r.Context(), appID, deploymentID, userCtx.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApplicationNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+		case errors.Is(err, domain.ErrDeploymentNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "deployment not found",
+			})
+		case errors.Is(err, domain.ErrDeploymentNotRollbackable):
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: "only successful deployments with a recorded commit can be rolled back to",
+			})
+		case errors.Is(err, domain.ErrJobUnsupported):
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: err.Error(),
+			})
+		default:
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+		}
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "rollback started",
+		Data:    deployment,
+	})
+}
+
 func (h *ApplicationHandler) Start(w http.ResponseWriter, r *http.Request) {
 	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
@@ -372,6 +471,102 @@ func (h *ApplicationHandler) Restart(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *ApplicationHandler) Services(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	services, err := h.svc.ListServices(r.Context(), appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrApplicationNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to list services",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: services,
+	})
+}
+
+func (h *ApplicationHandler) StartService(w http.ResponseWriter, r *http.Request) {
+	h.serviceAction(w, r, domain.ServiceStart, 0)
+}
+
+func (h *ApplicationHandler) StopService(w http.ResponseWriter, r *http.Request) {
+	h.serviceAction(w, r, domain.ServiceStop, 0)
+}
+
+func (h *ApplicationHandler) RestartService(w http.ResponseWriter, r *http.Request) {
+	h.serviceAction(w, r, domain.ServiceRestart, 0)
+}
+
+func (h *ApplicationHandler) ScaleService(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var req domain.ApplicationServiceScaleRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	h.serviceAction(w, r, domain.ServiceScale, *req.Replicas)
+}
+
+func (h *ApplicationHandler) serviceAction(w http.ResponseWriter, r *http.Request, action domain.ServiceAction, replicas int) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	service := r.PathValue("service")
+	if err := h.svc.ServiceAction(r.Context(), appID, service, action, replicas); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApplicationNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+		case errors.Is(err, domain.ErrServiceNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "service not found",
+			})
+		case errors.Is(err, domain.ErrJobUnsupported):
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: err.Error(),
+			})
+		default:
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+		}
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: string(action) + " queued for service " + service,
+	})
+}
+
 func (h *ApplicationHandler) AddEnvVar(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+)
+
+type BuildVariableHandler struct {
+	svc domain.BuildVariableService
+
+	decoder   request.RequestDecoder
+	writer    response.ResponseWriter
+	validator validator.Validator
+}
+
+func NewBuildVariableHandler(
+	svc domain.BuildVariableService,
+	d request.RequestDecoder,
+	w response.ResponseWriter,
+	v validator.Validator,
+) *BuildVariableHandler {
+	return &BuildVariableHandler{
+		svc:       svc,
+		decoder:   d,
+		writer:    w,
+		validator: v,
+	}
+}
+
+func (h *BuildVariableHandler) Index(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	vars, err := h.svc.List(r.Context(), appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrApplicationNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to list build variables",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: vars,
+	})
+}
+
+func (h *BuildVariableHandler) Update(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	var req domain.BuildVariableRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	v, err := h.svc.Set(r.Context(), appID, r.PathValue("key"), req)
+	if err != nil {
+		if errors.Is(err, domain.ErrApplicationNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidBuildVariable) {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidApplicationSource) {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: "build variables are only used by git applications",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to save build variable",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "build variable saved successfully",
+		Data:    v,
+	})
+}
+
+func (h *BuildVariableHandler) Destroy(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), appID, r.PathValue("key")); err != nil {
+		if errors.Is(err, domain.ErrBuildVariableNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "build variable not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to delete build variable",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "build variable deleted successfully",
+	})
+}
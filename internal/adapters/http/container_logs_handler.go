@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+)
+
+type ContainerLogsHandler struct {
+	svc domain.ContainerLogService
+
+	decoder   request.RequestDecoder
+	writer    response.ResponseWriter
+	validator validator.Validator
+}
+
+func NewContainerLogsHandler(
+	svc domain.ContainerLogService,
+	d request.RequestDecoder,
+	w response.ResponseWriter,
+	v validator.Validator,
+) *ContainerLogsHandler {
+	return &ContainerLogsHandler{
+		svc:       svc,
+		decoder:   d,
+		writer:    w,
+		validator: v,
+	}
+}
+
+// Index reads an app's container logs once, without following them. Live
+// logs are streamed on the app:{id}:container-logs WS channel instead.
+func (h *ContainerLogsHandler) Index(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	q := r.URL.Query()
+
+	lines, err := h.svc.Fetch(r.Context(), appID, domain.ContainerLogsQuery{
+		Service: GetString(q, "service", ""),
+		Tail:    GetInt(q, "tail", 0),
+		Since:   GetString(q, "since", ""),
+		Until:   GetString(q, "until", ""),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApplicationNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+		case errors.Is(err, domain.ErrInvalidContainerLogsQuery):
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+		case errors.Is(err, domain.ErrServerOffline):
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: err.Error(),
+			})
+		case errors.Is(err, domain.ErrContainerLogsFailed):
+			h.writer.Write(w, http.StatusBadGateway, &response.Response{
+				Message: err.Error(),
+			})
+		case errors.Is(err, context.DeadlineExceeded):
+			h.writer.Write(w, http.StatusGatewayTimeout, &response.Response{
+				Message: "agent did not send the logs in time",
+			})
+		default:
+			h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+				Message: "failed to get container logs",
+			})
+		}
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: lines,
+	})
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeContainerLogService struct {
+	domain.ContainerLogService
+	err   error
+	query domain.ContainerLogsQuery
+}
+
+func (f *fakeContainerLogService) Fetch(_ context.Context, _ int64, query domain.ContainerLogsQuery) ([]domain.ContainerLogLine, error) {
+	f.query = query
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []domain.ContainerLogLine{{Container: "web-1", Line: "hello"}}, nil
+}
+
+func TestContainerLogsHandler_Index(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"ok", nil, http.StatusOK},
+		{"unknown app", domain.ErrApplicationNotFound, http.StatusNotFound},
+		{"bad query", fmt.Errorf("%w: tail", domain.ErrInvalidContainerLogsQuery), http.StatusBadRequest},
+		{"server offline", domain.ErrServerOffline, http.StatusConflict},
+		{"agent failed", fmt.Errorf("%w: no such service", domain.ErrContainerLogsFailed), http.StatusBadGateway},
+		{"agent timed out", context.DeadlineExceeded, http.StatusGatewayTimeout},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeContainerLogService{err: tc.err}
+			h := NewContainerLogsHandler(svc, request.NewJSONDecoder(), response.NewJSONWriter(stubLogger{}), validator.NewValidator())
+
+			req := httptest.NewRequest(http.MethodGet, "/applications/7/container-logs?service=web&tail=50&since=1h", nil)
+			req.SetPathValue("id", "7")
+			rec := httptest.NewRecorder()
+
+			h.Index(rec, req)
+
+			assert.Equal(t, tc.want, rec.Code)
+			assert.Equal(t, domain.ContainerLogsQuery{Service: "web", Tail: 50, Since: "1h"}, svc.query)
+		})
+	}
+}
@@ -161,7 +161,13 @@ func (h *DeploymentHandler) UpdateCommitInfo(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := h.svc.UpdateCommitInfo(r.Context(), deploymentID, req.CommitHash, req.CommitMessage); err != nil {
+	// Image deploys have no commit, only the digest they pulled.
+	if req.ImageDigest != "" {
+		err = h.svc.UpdateImageDigest(r.Context(), deploymentID, req.ImageDigest)
+	} else {
+		err = h.svc.UpdateCommitInfo(r.Context(), deploymentID, req.CommitHash, req.CommitMessage)
+	}
+	if err != nil {
 		if errors.Is(err, domain.ErrDeploymentNotFound) {
 			h.writer.Write(w, http.StatusNotFound, &response.Response{
 				Message: "deployment not found",
@@ -179,3 +185,41 @@ func (h *DeploymentHandler) UpdateCommitInfo(w http.ResponseWriter, r *http.Requ
 		Message: "commit info updated",
 	})
 }
+
+func (h *DeploymentHandler) AutoRollback(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	deploymentID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid deployment id",
+		})
+		return
+	}
+
+	var req domain.DeploymentAutoRollbackRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.svc.RecordAutoRollback(r.Context(), deploymentID, req); err != nil {
+		if errors.Is(err, domain.ErrDeploymentNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "deployment not found",
+			})
+			return
+		}
+
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to record auto rollback",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "auto rollback recorded",
+	})
+}
@@ -0,0 +1,187 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+)
+
+type GitCredentialHandler struct {
+	svc domain.GitCredentialService
+
+	decoder   request.RequestDecoder
+	writer    response.ResponseWriter
+	validator validator.Validator
+}
+
+func NewGitCredentialHandler(
+	svc domain.GitCredentialService,
+	d request.RequestDecoder,
+	w response.ResponseWriter,
+	v validator.Validator,
+) *GitCredentialHandler {
+	return &GitCredentialHandler{
+		svc:       svc,
+		decoder:   d,
+		writer:    w,
+		validator: v,
+	}
+}
+
+func (h *GitCredentialHandler) Show(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	creds, err := h.svc.Get(r.Context(), appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrGitCredentialsNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "git credentials not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to get git credentials",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: creds,
+	})
+}
+
+func (h *GitCredentialHandler) GenerateDeployKey(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	creds, err := h.svc.GenerateDeployKey(r.Context(), appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrApplicationNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to generate deploy key",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "deploy key generated, add the public key to the repository as a read-only deploy key",
+		Data:    creds,
+	})
+}
+
+func (h *GitCredentialHandler) DeleteDeployKey(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	if err := h.svc.DeleteDeployKey(r.Context(), appID); err != nil {
+		if errors.Is(err, domain.ErrGitCredentialsNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "git credentials not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to delete deploy key",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "deploy key deleted successfully",
+	})
+}
+
+func (h *GitCredentialHandler) UpdateHTTPS(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	var req domain.GitHTTPSCredentialsRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	if err := h.svc.SetHTTPSCredentials(r.Context(), appID, req); err != nil {
+		if errors.Is(err, domain.ErrApplicationNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to save https credentials",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "https credentials saved successfully",
+	})
+}
+
+func (h *GitCredentialHandler) DeleteHTTPS(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	if err := h.svc.DeleteHTTPSCredentials(r.Context(), appID); err != nil {
+		if errors.Is(err, domain.ErrGitCredentialsNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "git credentials not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to delete https credentials",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "https credentials deleted successfully",
+	})
+}
@@ -0,0 +1,255 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+)
+
+// gitWebhookBodyLimit caps push payloads; large pushes from GitHub stay well
+// under this.
+const gitWebhookBodyLimit = 5 << 20
+
+type GitWebhookHandler struct {
+	svc domain.GitWebhookService
+
+	decoder   request.RequestDecoder
+	writer    response.ResponseWriter
+	validator validator.Validator
+}
+
+func NewGitWebhookHandler(
+	svc domain.GitWebhookService,
+	d request.RequestDecoder,
+	w response.ResponseWriter,
+	v validator.Validator,
+) *GitWebhookHandler {
+	return &GitWebhookHandler{
+		svc:       svc,
+		decoder:   d,
+		writer:    w,
+		validator: v,
+	}
+}
+
+// Receive is the public push receiver. The token in the path identifies the
+// app; the delivery itself is authenticated by the provider signature.
+func (h *GitWebhookHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	req, ok := gitWebhookRequest(r)
+	if !ok {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "unrecognized git provider",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, gitWebhookBodyLimit))
+	if err != nil {
+		h.writer.Write(w, http.StatusRequestEntityTooLarge, &response.Response{
+			Message: "payload too large",
+		})
+		return
+	}
+	req.Body = body
+
+	delivery, err := h.svc.Receive(r.Context(), r.PathValue("app_token"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrGitWebhookNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "webhook not found",
+			})
+		case errors.Is(err, domain.ErrInvalidWebhookSignature):
+			h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+				Message: "invalid signature",
+			})
+		default:
+			h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+				Message: "failed to process delivery",
+			})
+		}
+		return
+	}
+
+	// Providers retry on non-2xx, so only a failed deploy asks for one.
+	code := http.StatusOK
+	if delivery.Outcome == domain.WebhookFailed {
+		code = http.StatusInternalServerError
+	}
+
+	h.writer.Write(w, code, &response.Response{
+		Message: string(delivery.Outcome),
+		Data:    delivery,
+	})
+}
+
+// gitWebhookRequest resolves the provider from its event header. Gitea also
+// sends X-GitHub-Event for compatibility, so it is checked first.
+func gitWebhookRequest(r *http.Request) (domain.GitWebhookRequest, bool) {
+	switch {
+	case r.Header.Get("X-Gitea-Event") != "":
+		return domain.GitWebhookRequest{
+			Provider:   domain.GitProviderGitea,
+			Event:      r.Header.Get("X-Gitea-Event"),
+			DeliveryID: r.Header.Get("X-Gitea-Delivery"),
+			Signature:  r.Header.Get("X-Gitea-Signature"),
+		}, true
+	case r.Header.Get("X-Gitlab-Event") != "":
+		return domain.GitWebhookRequest{
+			Provider:   domain.GitProviderGitLab,
+			Event:      r.Header.Get("X-Gitlab-Event"),
+			DeliveryID: r.Header.Get("X-Gitlab-Event-UUID"),
+			Signature:  r.Header.Get("X-Gitlab-Token"),
+		}, true
+	case r.Header.Get("X-GitHub-Event") != "":
+		return domain.GitWebhookRequest{
+			Provider:   domain.GitProviderGitHub,
+			Event:      r.Header.Get("X-GitHub-Event"),
+			DeliveryID: r.Header.Get("X-GitHub-Delivery"),
+			Signature:  r.Header.Get("X-Hub-Signature-256"),
+		}, true
+	default:
+		return domain.GitWebhookRequest{}, false
+	}
+}
+
+func (h *GitWebhookHandler) Show(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	hook, err := h.svc.Get(r.Context(), appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrGitWebhookNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "webhook not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to get webhook",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: hook,
+	})
+}
+
+func (h *GitWebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	var req domain.GitWebhookUpdateRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	if err := h.svc.Update(r.Context(), appID, req); err != nil {
+		if errors.Is(err, domain.ErrGitWebhookNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "webhook not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to update webhook",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "webhook updated successfully",
+	})
+}
+
+func (h *GitWebhookHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	hook, err := h.svc.Rotate(r.Context(), appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrApplicationNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to generate webhook",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "webhook generated, copy the secret now: it will not be shown again",
+		Data:    hook,
+	})
+}
+
+func (h *GitWebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	q := r.URL.Query()
+
+	opts := domain.GitWebhookDeliveryListOptions{
+		ListOptions: domain.ListOptions{
+			Page:       GetInt(q, "page", 1),
+			Limit:      GetInt(q, "limit", 10),
+			IsPaginate: GetBool(q, "paginate"),
+		},
+		ApplicationID: appID,
+	}
+
+	result, err := h.svc.ListDeliveries(r.Context(), opts)
+	if err != nil {
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to list webhook deliveries",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: result.Data,
+		Meta: result.Meta,
+	})
+}
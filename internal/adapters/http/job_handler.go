@@ -174,7 +174,7 @@ func (h *JobHandler) Finish(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	job, err := h.svc.Finish(r.Context(), jobID, req.Status)
+	job, err := h.svc.Finish(r.Context(), jobID, req.Status, req.StartedAt)
 	if err != nil {
 		if errors.Is(err, domain.ErrJobNotFound) {
 			h.writer.Write(w, http.StatusNotFound, &response.Response{
@@ -210,7 +210,7 @@ func (h *JobHandler) Summary(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Retry re-queues a failed or expired job with its original payload so the
+// Retry re-queues a failed, expired or cancelled job with its original payload so the
 // owning agent picks it up again. v0.3.13 Track C: queue recoverability.
 func (h *JobHandler) Retry(w http.ResponseWriter, r *http.Request) {
 	jobID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
@@ -236,7 +236,7 @@ func (h *JobHandler) Retry(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Only terminal failures can be retried.
-	if job.Status != domain.JobFailed && job.Status != domain.JobExpired {
+	if job.Status != domain.JobFailed && job.Status != domain.JobExpired && job.Status != domain.JobCancelled {
 		h.writer.Write(w, http.StatusConflict, &response.Response{
 			Message: "job is not in a retryable state",
 		})
@@ -262,3 +262,55 @@ func (h *JobHandler) Retry(w http.ResponseWriter, r *http.Request) {
 		Data: retried,
 	})
 }
+
+// Cancel stops a queued or running job. A queued job is cancelled at once; a
+// running job is signalled to stop and reported cancelled by its agent, so
+// the response is 202 until the agent confirms.
+func (h *JobHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userCtx, ok := domain.GetUserContext(r.Context())
+	if !ok {
+		h.writer.Write(w, http.StatusUnauthorized, &response.Response{
+			Message: "unauthorized",
+		})
+		return
+	}
+
+	jobID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid job id",
+		})
+		return
+	}
+
+	job, err := h.svc.Cancel(r.Context(), jobID, userCtx.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrJobNotFound):
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "job not found",
+			})
+		case errors.Is(err, domain.ErrInvalidJobState):
+			h.writer.Write(w, http.StatusConflict, &response.Response{
+				Message: "job is not in a cancellable state",
+			})
+		default:
+			h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+				Message: "failed to cancel job",
+			})
+		}
+		return
+	}
+
+	if job.Status == domain.JobRunning {
+		h.writer.Write(w, http.StatusAccepted, &response.Response{
+			Data:    job,
+			Message: "cancellation requested",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: job,
+	})
+}
@@ -21,7 +21,8 @@ import (
 type fakeJobService struct {
 	jobs map[int64]*domain.Job
 
-	retryCalls int
+	retryCalls  int
+	cancelCalls int
 }
 
 func newFakeJobService() *fakeJobService {
@@ -68,10 +69,26 @@ func (f *fakeJobService) Start(ctx context.Context, jobID int64) (*domain.Job, e
 	return nil, nil
 }
 
-func (f *fakeJobService) Finish(ctx context.Context, jobID int64, status domain.JobStatus) (*domain.Job, error) {
+func (f *fakeJobService) Finish(ctx context.Context, jobID int64, status domain.JobStatus, startedAt *time.Time) (*domain.Job, error) {
 	return nil, nil
 }
 
+func (f *fakeJobService) Cancel(ctx context.Context, jobID int64, cancelledBy int64) (*domain.Job, error) {
+	f.cancelCalls++
+	job, ok := f.jobs[jobID]
+	if !ok {
+		return nil, domain.ErrJobNotFound
+	}
+	switch job.Status {
+	case domain.JobQueued:
+		job.Status = domain.JobCancelled
+	case domain.JobRunning:
+	default:
+		return nil, domain.ErrInvalidJobState
+	}
+	return job, nil
+}
+
 func (f *fakeJobService) Summary(ctx context.Context) (*domain.JobStatusCounts, error) {
 	return nil, nil
 }
@@ -161,3 +178,64 @@ func TestJobHandler_Retry_InvalidID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 	assert.Equal(t, 0, svc.retryCalls)
 }
+
+func withUser(req *http.Request) *http.Request {
+	return req.WithContext(domain.SetUserContext(req.Context(), domain.UserContext{ID: 1}))
+}
+
+func TestJobHandler_Cancel_Queued(t *testing.T) {
+	svc := newFakeJobService()
+	svc.jobs[42] = &domain.Job{ID: 42, Type: domain.JobTypeAppDeploy, Status: domain.JobQueued}
+
+	h := newJobTestHandler(svc)
+	req := withUser(httptest.NewRequest(http.MethodPost, "/jobs/42/cancel", nil))
+	req.SetPathValue("id", "42")
+	rec := httptest.NewRecorder()
+
+	h.Cancel(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, svc.cancelCalls)
+	assert.Equal(t, domain.JobCancelled, svc.jobs[42].Status)
+}
+
+func TestJobHandler_Cancel_Running(t *testing.T) {
+	svc := newFakeJobService()
+	svc.jobs[42] = &domain.Job{ID: 42, Type: domain.JobTypeAppDeploy, Status: domain.JobRunning}
+
+	h := newJobTestHandler(svc)
+	req := withUser(httptest.NewRequest(http.MethodPost, "/jobs/42/cancel", nil))
+	req.SetPathValue("id", "42")
+	rec := httptest.NewRecorder()
+
+	h.Cancel(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+}
+
+func TestJobHandler_Cancel_Finished(t *testing.T) {
+	svc := newFakeJobService()
+	svc.jobs[42] = &domain.Job{ID: 42, Type: domain.JobTypeAppDeploy, Status: domain.JobSuccess}
+
+	h := newJobTestHandler(svc)
+	req := withUser(httptest.NewRequest(http.MethodPost, "/jobs/42/cancel", nil))
+	req.SetPathValue("id", "42")
+	rec := httptest.NewRecorder()
+
+	h.Cancel(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestJobHandler_Cancel_NotFound(t *testing.T) {
+	svc := newFakeJobService()
+	h := newJobTestHandler(svc)
+
+	req := withUser(httptest.NewRequest(http.MethodPost, "/jobs/99/cancel", nil))
+	req.SetPathValue("id", "99")
+	rec := httptest.NewRecorder()
+
+	h.Cancel(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
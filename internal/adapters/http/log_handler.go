@@ -1,6 +1,11 @@
 package http
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 
 	"horizonx/internal/adapters/http/request"
@@ -104,3 +109,85 @@ func (h *LogHandler) Store(w http.ResponseWriter, r *http.Request) {
 		Message: "log created successfully",
 	})
 }
+
+// StoreBatch ingests an agent's log batch: NDJSON, one LogEmitRequest per
+// line, gzip-compressed when Content-Encoding says so. The batch is stored in
+// one insert; a 413 tells the agent to send smaller batches.
+func (h *LogHandler) StoreBatch(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	body := io.ReadCloser(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: "invalid gzip body",
+			})
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	// The limit applies to the decompressed body, so a small gzip payload
+	// cannot expand without bound.
+	dec := json.NewDecoder(http.MaxBytesReader(w, body, domain.MaxLogBatchBytes))
+
+	var logs []*domain.Log
+	for {
+		var req domain.LogEmitRequest
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				h.writer.Write(w, http.StatusRequestEntityTooLarge, &response.Response{
+					Message: fmt.Sprintf("log batch exceeds %d bytes", domain.MaxLogBatchBytes),
+				})
+				return
+			}
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: fmt.Sprintf("invalid log on line %d: %s", len(logs)+1, err.Error()),
+			})
+			return
+		}
+
+		if len(logs) == domain.MaxLogBatchSize {
+			h.writer.Write(w, http.StatusRequestEntityTooLarge, &response.Response{
+				Message: fmt.Sprintf("log batch exceeds %d lines", domain.MaxLogBatchSize),
+			})
+			return
+		}
+
+		if errs := h.validator.Validate(&req); len(errs) > 0 {
+			h.writer.WriteValidationError(w, errs)
+			return
+		}
+
+		logs = append(logs, &domain.Log{
+			Timestamp:     req.Timestamp,
+			Level:         req.Level,
+			Source:        req.Source,
+			Action:        req.Action,
+			TraceID:       req.TraceID,
+			JobID:         req.JobID,
+			ServerID:      req.ServerID,
+			ApplicationID: req.ApplicationID,
+			DeploymentID:  req.DeploymentID,
+			Message:       req.Message,
+			Context:       req.Context,
+		})
+	}
+
+	if err := h.svc.CreateBatch(r.Context(), logs); err != nil {
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to create logs",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusCreated, &response.Response{
+		Message: fmt.Sprintf("%d logs created successfully", len(logs)),
+	})
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogService records the batches handed to CreateBatch.
+type fakeLogService struct {
+	domain.LogService
+
+	batches [][]*domain.Log
+}
+
+func (f *fakeLogService) CreateBatch(_ context.Context, logs []*domain.Log) error {
+	f.batches = append(f.batches, logs)
+	return nil
+}
+
+func newLogTestHandler(svc domain.LogService) *LogHandler {
+	return NewLogHandler(
+		svc,
+		request.NewJSONDecoder(),
+		response.NewJSONWriter(stubLogger{}),
+		validator.NewValidator(),
+	)
+}
+
+func gzipNDJSON(t *testing.T, lines ...domain.LogEmitRequest) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+	for _, l := range lines {
+		require.NoError(t, enc.Encode(l))
+	}
+	require.NoError(t, gz.Close())
+	return &buf
+}
+
+func TestLogHandler_StoreBatch_GzipNDJSON(t *testing.T) {
+	svc := &fakeLogService{}
+	h := newLogTestHandler(svc)
+
+	body := gzipNDJSON(t,
+		domain.LogEmitRequest{Level: domain.LogInfo, Source: domain.LogAgent, Message: "npm install"},
+		domain.LogEmitRequest{Level: domain.LogWarn, Source: domain.LogAgent, Message: "npm WARN deprecated"},
+	)
+	req := httptest.NewRequest(http.MethodPost, "/agent/logs/batch", body)
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	h.StoreBatch(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.Len(t, svc.batches, 1)
+	require.Len(t, svc.batches[0], 2)
+	assert.Equal(t, "npm install", svc.batches[0][0].Message)
+	assert.Equal(t, domain.LogWarn, svc.batches[0][1].Level)
+}
+
+func TestLogHandler_StoreBatch_RejectsOversizedBatch(t *testing.T) {
+	svc := &fakeLogService{}
+	h := newLogTestHandler(svc)
+
+	lines := make([]domain.LogEmitRequest, domain.MaxLogBatchSize+1)
+	body := gzipNDJSON(t, lines...)
+	req := httptest.NewRequest(http.MethodPost, "/agent/logs/batch", body)
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	h.StoreBatch(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Empty(t, svc.batches)
+}
+
+func TestLogHandler_StoreBatch_RejectsMalformedLine(t *testing.T) {
+	svc := &fakeLogService{}
+	h := newLogTestHandler(svc)
+
+	req := httptest.NewRequest(http.MethodPost, "/agent/logs/batch", strings.NewReader("{\"message\":\"ok\"}\n{not json}\n"))
+	rec := httptest.NewRecorder()
+
+	h.StoreBatch(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "line 2")
+	assert.Empty(t, svc.batches)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"horizonx/internal/domain"
 
@@ -38,7 +39,10 @@ func (f *fakeJobRepo) Retry(ctx context.Context, jobID int64, j *domain.Job) (*d
 func (f *fakeJobRepo) MarkRunning(ctx context.Context, jobID int64) (*domain.Job, error) {
 	return nil, nil
 }
-func (f *fakeJobRepo) MarkFinished(ctx context.Context, jobID int64, status domain.JobStatus) (*domain.Job, error) {
+func (f *fakeJobRepo) MarkFinished(ctx context.Context, jobID int64, status domain.JobStatus, startedAt *time.Time) (*domain.Job, error) {
+	return nil, nil
+}
+func (f *fakeJobRepo) CancelQueued(ctx context.Context, jobID int64) (*domain.Job, error) {
 	return nil, nil
 }
 func (f *fakeJobRepo) CountsByStatus(ctx context.Context) (*domain.JobStatusCounts, error) {
@@ -70,6 +74,9 @@ func (f *fakeServerRepo) Update(ctx context.Context, s *domain.Server, serverID
 func (f *fakeServerRepo) UpdateOSInfo(ctx context.Context, serverID uuid.UUID, osInfo domain.OSInfo) error {
 	return nil
 }
+func (f *fakeServerRepo) UpdateAgentInfo(ctx context.Context, serverID uuid.UUID, info domain.AgentInfo) error {
+	return nil
+}
 func (f *fakeServerRepo) UpdateStatus(ctx context.Context, serverID uuid.UUID, isOnline bool) error {
 	return nil
 }
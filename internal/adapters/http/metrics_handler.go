@@ -2,7 +2,11 @@ package http
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"horizonx/internal/adapters/http/request"
 	"horizonx/internal/adapters/http/response"
@@ -145,3 +149,138 @@ func (h *MetricsHandler) NetSpeedHistory(w http.ResponseWriter, r *http.Request)
 		Data: data,
 	})
 }
+
+// Rollups returns a server's hourly or daily rollups; from and to are RFC
+// 3339 timestamps.
+func (h *MetricsHandler) Rollups(w http.ResponseWriter, r *http.Request) {
+	serverID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "server not found",
+		})
+		return
+	}
+
+	q := r.URL.Query()
+	opts := domain.MetricsRollupListOptions{
+		Resolution: domain.MetricsResolution(GetString(q, "resolution", string(domain.MetricsHourly))),
+	}
+	if opts.From, opts.To, err = parseTimeRange(q); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	data, err := h.svc.Rollups(r.Context(), serverID, opts)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidMetricsRollupQuery) {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
+
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to get metrics rollups",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: data,
+	})
+}
+
+// Query charts one metric over a time range: from and to are RFC 3339
+// timestamps, step a duration such as 5m.
+func (h *MetricsHandler) Query(w http.ResponseWriter, r *http.Request) {
+	serverID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.writer.Write(w, http.StatusNotFound, &response.Response{
+			Message: "server not found",
+		})
+		return
+	}
+
+	q := r.URL.Query()
+	query := domain.MetricsQuery{
+		Metric: GetString(q, "metric", ""),
+	}
+	if query.From, query.To, err = parseTimeRange(q); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+	if v := q.Get("step"); v != "" {
+		if query.Step, err = time.ParseDuration(v); err != nil {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: "step must be a duration such as 5m",
+			})
+			return
+		}
+	}
+
+	data, err := h.svc.Query(r.Context(), serverID, query)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidMetricsQuery) {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: err.Error(),
+			})
+			return
+		}
+
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to query metrics",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: data,
+	})
+}
+
+// parseTimeRange reads the optional from and to of a history query.
+func parseTimeRange(q url.Values) (from, to time.Time, err error) {
+	for key, dst := range map[string]*time.Time{"from": &from, "to": &to} {
+		v := q.Get(key)
+		if v == "" {
+			continue
+		}
+		if *dst, err = time.Parse(time.RFC3339, v); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp", key)
+		}
+	}
+	return from, to, nil
+}
+
+func (h *MetricsHandler) AppMetrics(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	data, err := h.svc.AppMetrics(r.Context(), appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMetricsNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application metrics not found",
+			})
+			return
+		}
+
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to get application metrics",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: data,
+	})
+}
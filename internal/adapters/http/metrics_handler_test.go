@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMetricsService struct {
+	domain.MetricsService
+	opts  *domain.MetricsRollupListOptions
+	query *domain.MetricsQuery
+}
+
+func (f *fakeMetricsService) Rollups(_ context.Context, _ uuid.UUID, opts domain.MetricsRollupListOptions) ([]domain.MetricsRollup, error) {
+	f.opts = &opts
+	if err := opts.Validate(time.Now()); err != nil {
+		return nil, err
+	}
+	return []domain.MetricsRollup{}, nil
+}
+
+func (f *fakeMetricsService) Query(_ context.Context, _ uuid.UUID, q domain.MetricsQuery) (*domain.MetricsQueryResult, error) {
+	f.query = &q
+	if err := q.Validate(time.Now()); err != nil {
+		return nil, err
+	}
+	return &domain.MetricsQueryResult{Metric: q.Metric}, nil
+}
+
+func TestMetricsHandler_Rollups(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"defaults to hourly", "", http.StatusOK},
+		{"range", "?resolution=day&from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z", http.StatusOK},
+		{"unknown resolution", "?resolution=minute", http.StatusBadRequest},
+		{"bad timestamp", "?from=yesterday", http.StatusBadRequest},
+		{"empty range", "?from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z", http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeMetricsService{}
+			h := NewMetricsHandler(svc, request.NewJSONDecoder(), response.NewJSONWriter(stubLogger{}), validator.NewValidator())
+
+			serverID := uuid.New().String()
+			req := httptest.NewRequest(http.MethodGet, "/servers/"+serverID+"/metrics/rollups"+tc.query, nil)
+			req.SetPathValue("id", serverID)
+			rec := httptest.NewRecorder()
+
+			h.Rollups(rec, req)
+
+			assert.Equal(t, tc.want, rec.Code)
+		})
+	}
+
+	svc := &fakeMetricsService{}
+	h := NewMetricsHandler(svc, request.NewJSONDecoder(), response.NewJSONWriter(stubLogger{}), validator.NewValidator())
+	req := httptest.NewRequest(http.MethodGet, "/servers/x/metrics/rollups", nil)
+	req.SetPathValue("id", uuid.New().String())
+	h.Rollups(httptest.NewRecorder(), req)
+	assert.Equal(t, domain.MetricsHourly, svc.opts.Resolution)
+}
+
+func TestMetricsHandler_Query(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"defaults to the last hour", "?metric=memory.usage_percent", http.StatusOK},
+		{"range and step", "?metric=disk.util_percent&from=2026-01-01T00:00:00Z&to=2026-01-02T00:00:00Z&step=5m", http.StatusOK},
+		{"unknown metric", "?metric=cpu.nope", http.StatusBadRequest},
+		{"bad step", "?metric=cpu.usage_percent&step=often", http.StatusBadRequest},
+		{"bad timestamp", "?metric=cpu.usage_percent&to=now", http.StatusBadRequest},
+		{"too many steps", "?metric=cpu.usage_percent&from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z&step=5s", http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeMetricsService{}
+			h := NewMetricsHandler(svc, request.NewJSONDecoder(), response.NewJSONWriter(stubLogger{}), validator.NewValidator())
+
+			serverID := uuid.New().String()
+			req := httptest.NewRequest(http.MethodGet, "/servers/"+serverID+"/metrics/query"+tc.query, nil)
+			req.SetPathValue("id", serverID)
+			rec := httptest.NewRecorder()
+
+			h.Query(rec, req)
+
+			assert.Equal(t, tc.want, rec.Code)
+		})
+	}
+}
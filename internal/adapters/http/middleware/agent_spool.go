@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+// AgentSpool applies a report replayed from an agent's spool at most once. A
+// record already applied is answered with 208 Already Reported without
+// reaching the handler; a record the handler accepts (2xx) is acked. Requests
+// without spool headers pass through untouched. It must run after Agent.
+func AgentSpool(svc domain.AgentSpoolService, log logger.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rawID := r.Header.Get(domain.AgentSpoolIDHeader)
+			if rawID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			spoolID, err := uuid.Parse(rawID)
+			if err != nil {
+				http.Error(w, "invalid spool id", http.StatusBadRequest)
+				return
+			}
+			seq, err := strconv.ParseInt(r.Header.Get(domain.AgentSpoolSeqHeader), 10, 64)
+			if err != nil || seq <= 0 {
+				http.Error(w, "invalid spool sequence", http.StatusBadRequest)
+				return
+			}
+
+			serverID, ok := GetServerID(r.Context())
+			if !ok {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			applied, err := svc.Applied(r.Context(), serverID, spoolID, seq)
+			if err != nil {
+				log.Error("failed to check agent spool", "server_id", serverID, "error", err)
+				http.Error(w, "failed to check spool", http.StatusInternalServerError)
+				return
+			}
+			if applied {
+				w.WriteHeader(http.StatusAlreadyReported)
+				return
+			}
+
+			rec := &statusRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			if rec.status >= 300 {
+				return
+			}
+			if err := svc.Ack(r.Context(), serverID, spoolID, seq); err != nil {
+				// The agent has its answer; at worst the record is applied
+				// again if it is ever replayed.
+				log.Error("failed to ack agent spool", "server_id", serverID, "seq", seq, "error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type fakeAgentSpoolService struct {
+	spoolID uuid.UUID
+	lastSeq int64
+}
+
+func (f *fakeAgentSpoolService) Applied(_ context.Context, _ uuid.UUID, spoolID uuid.UUID, seq int64) (bool, error) {
+	return spoolID == f.spoolID && seq <= f.lastSeq, nil
+}
+
+func (f *fakeAgentSpoolService) Ack(_ context.Context, _ uuid.UUID, spoolID uuid.UUID, seq int64) error {
+	f.spoolID, f.lastSeq = spoolID, seq
+	return nil
+}
+
+func spooledRequest(spoolID uuid.UUID, seq string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/agent/jobs/1/finish", nil)
+	req.Header.Set(domain.AgentSpoolIDHeader, spoolID.String())
+	req.Header.Set(domain.AgentSpoolSeqHeader, seq)
+	return req.WithContext(context.WithValue(req.Context(), ServerIDKey, uuid.New()))
+}
+
+func TestAgentSpoolAppliesRecordOnce(t *testing.T) {
+	svc := &fakeAgentSpoolService{}
+	calls := 0
+	handler := AgentSpool(svc, &warnCaptureLogger{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	spoolID := uuid.New()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, spooledRequest(spoolID, "3"))
+	if rec.Code != http.StatusOK || svc.lastSeq != 3 {
+		t.Fatalf("first delivery: status %d, acked %d", rec.Code, svc.lastSeq)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, spooledRequest(spoolID, "3"))
+	if rec.Code != http.StatusAlreadyReported || calls != 1 {
+		t.Fatalf("replay: status %d, handler calls %d", rec.Code, calls)
+	}
+
+	// A new spool (agent reinstalled) starts its numbering over.
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, spooledRequest(uuid.New(), "1"))
+	if rec.Code != http.StatusOK || calls != 2 {
+		t.Fatalf("new spool: status %d, handler calls %d", rec.Code, calls)
+	}
+}
+
+func TestAgentSpoolDoesNotAckFailedRecord(t *testing.T) {
+	svc := &fakeAgentSpoolService{}
+	handler := AgentSpool(svc, &warnCaptureLogger{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, spooledRequest(uuid.New(), "1"))
+
+	if svc.lastSeq != 0 {
+		t.Fatalf("failed record was acked")
+	}
+}
+
+func TestAgentSpoolPassesThroughUnspooledRequests(t *testing.T) {
+	handler := AgentSpool(&fakeAgentSpoolService{}, &warnCaptureLogger{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://example.com/agent/metrics", nil))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+}
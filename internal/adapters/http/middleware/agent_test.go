@@ -33,6 +33,9 @@ func (f *fakeServerService) Update(ctx context.Context, req domain.ServerSaveReq
 func (f *fakeServerService) UpdateOSInfo(ctx context.Context, serverID uuid.UUID, osInfo domain.OSInfo) error {
 	return nil
 }
+func (f *fakeServerService) UpdateAgentInfo(ctx context.Context, serverID uuid.UUID, info domain.AgentInfo) error {
+	return nil
+}
 func (f *fakeServerService) UpdateStatus(ctx context.Context, serverID uuid.UUID, status bool) error {
 	return nil
 }
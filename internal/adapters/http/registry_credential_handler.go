@@ -0,0 +1,139 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"horizonx/internal/adapters/http/request"
+	"horizonx/internal/adapters/http/response"
+	"horizonx/internal/adapters/http/validator"
+	"horizonx/internal/domain"
+)
+
+type RegistryCredentialHandler struct {
+	svc domain.RegistryCredentialService
+
+	decoder   request.RequestDecoder
+	writer    response.ResponseWriter
+	validator validator.Validator
+}
+
+func NewRegistryCredentialHandler(
+	svc domain.RegistryCredentialService,
+	d request.RequestDecoder,
+	w response.ResponseWriter,
+	v validator.Validator,
+) *RegistryCredentialHandler {
+	return &RegistryCredentialHandler{
+		svc:       svc,
+		decoder:   d,
+		writer:    w,
+		validator: v,
+	}
+}
+
+func (h *RegistryCredentialHandler) Show(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	creds, err := h.svc.Get(r.Context(), appID)
+	if err != nil {
+		if errors.Is(err, domain.ErrRegistryCredentialsNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "registry credentials not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to get registry credentials",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Data: creds,
+	})
+}
+
+func (h *RegistryCredentialHandler) Update(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	var req domain.RegistryCredentialsRequest
+	if err := h.decoder.Decode(r, &req); err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if errs := h.validator.Validate(&req); len(errs) > 0 {
+		h.writer.WriteValidationError(w, errs)
+		return
+	}
+
+	creds, err := h.svc.Set(r.Context(), appID, req)
+	if err != nil {
+		if errors.Is(err, domain.ErrApplicationNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "application not found",
+			})
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidApplicationSource) {
+			h.writer.Write(w, http.StatusBadRequest, &response.Response{
+				Message: "registry credentials are only used by image applications",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to save registry credentials",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "registry credentials saved successfully",
+		Data:    creds,
+	})
+}
+
+func (h *RegistryCredentialHandler) Destroy(w http.ResponseWriter, r *http.Request) {
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.writer.Write(w, http.StatusBadRequest, &response.Response{
+			Message: "invalid application id",
+		})
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), appID); err != nil {
+		if errors.Is(err, domain.ErrRegistryCredentialsNotFound) {
+			h.writer.Write(w, http.StatusNotFound, &response.Response{
+				Message: "registry credentials not found",
+			})
+			return
+		}
+		h.writer.Write(w, http.StatusInternalServerError, &response.Response{
+			Message: "failed to delete registry credentials",
+		})
+		return
+	}
+
+	h.writer.Write(w, http.StatusOK, &response.Response{
+		Message: "registry credentials deleted successfully",
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"horizonx/internal/adapters/http/middleware"
 	"horizonx/internal/adapters/http/middleware/ratelimit"
 	"horizonx/internal/adapters/ws/agentws"
+	"horizonx/internal/adapters/ws/execws"
 	"horizonx/internal/adapters/ws/userws"
 	"horizonx/internal/config"
 	"horizonx/internal/domain"
@@ -18,6 +19,7 @@ import (
 type RouterDeps struct {
 	WsUser  *userws.Handler
 	WsAgent *agentws.Handler
+	WsExec  *execws.Handler
 
 	Auth        *AuthHandler
 	Account     *AccountHandler
@@ -30,11 +32,20 @@ type RouterDeps struct {
 	Deployment  *DeploymentHandler
 	AuditLog    *AuditLogHandler
 	Settings    *SettingsHandler
+	GitWebhook  *GitWebhookHandler
+	Preview     *PreviewHandler
+
+	GitCredential      *GitCredentialHandler
+	RegistryCredential *RegistryCredentialHandler
+	BuildVariable      *BuildVariableHandler
+	AgentUpgrade       *AgentUpgradeHandler
+	ContainerLogs      *ContainerLogsHandler
 
 	SessionStore domain.SessionStore
 
-	RoleService   domain.RoleService
-	ServerService domain.ServerService
+	RoleService       domain.RoleService
+	ServerService     domain.ServerService
+	AgentSpoolService domain.AgentSpoolService
 
 	MetricsRegistry *metrics.Registry
 	Logger          logger.Logger
@@ -58,6 +69,7 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 
 	agentStack := middleware.New()
 	agentStack.Use(middleware.Agent(deps.ServerService, deps.Logger))
+	agentStack.Use(middleware.AgentSpool(deps.AgentSpoolService, deps.Logger))
 
 	metricsReadStack := userStack.Extend(middleware.Permission(deps.RoleService, domain.PermMetricsRead))
 
@@ -69,6 +81,7 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 
 	appReadStack := userStack.Extend(middleware.Permission(deps.RoleService, domain.PermAppRead))
 	appWriteStack := userStack.Extend(middleware.Permission(deps.RoleService, domain.PermAppWrite))
+	appExecStack := userStack.Extend(middleware.Permission(deps.RoleService, domain.PermAppExec))
 
 	// P1-10: brute-force guard on the public login endpoint — 5 attempts per
 	// IP per minute, then HTTP 429. With TRUST_PROXY the key is the real
@@ -96,6 +109,10 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 	// WEBSOCKET
 	mux.HandleFunc("GET /ws/user", deps.WsUser.Serve)
 	mux.HandleFunc("GET /ws/agent", deps.WsAgent.Serve)
+	mux.Handle("GET /ws/applications/{id}/exec", appExecStack.ThenFunc(deps.WsExec.Serve))
+
+	// GIT WEBHOOKS (public: authenticated by the provider signature)
+	mux.HandleFunc("POST /hooks/git/{app_token}", deps.GitWebhook.Receive)
 
 	// AUTH
 	mux.Handle("GET /auth/user", userStack.ThenFunc(deps.Auth.User))
@@ -104,12 +121,14 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 
 	// AGENT ENDPOINTS
 	mux.Handle("POST /agent/logs", agentStack.ThenFunc(deps.Log.Store))
+	mux.Handle("POST /agent/logs/batch", agentStack.ThenFunc(deps.Log.StoreBatch))
 	mux.Handle("GET /agent/jobs", agentStack.ThenFunc(deps.Job.Pending))
 	mux.Handle("POST /agent/jobs/{id}/start", agentStack.ThenFunc(deps.Job.Start))
 	mux.Handle("POST /agent/jobs/{id}/finish", agentStack.ThenFunc(deps.Job.Finish))
 	mux.Handle("POST /agent/metrics", agentStack.ThenFunc(deps.Metrics.Ingest))
 	mux.Handle("POST /agent/applications/health", agentStack.ThenFunc(deps.Application.ReportHealth))
 	mux.Handle("POST /agent/deployments/{id}/commit-info", agentStack.ThenFunc(deps.Deployment.UpdateCommitInfo))
+	mux.Handle("POST /agent/deployments/{id}/auto-rollback", agentStack.ThenFunc(deps.Deployment.AutoRollback))
 
 	// LOGS
 	mux.Handle("GET /logs", userStack.ThenFunc(deps.Log.Index))
@@ -123,6 +142,7 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 
 	// v0.3.13 Track C: re-queue a failed/expired job with its original payload.
 	mux.Handle("POST /jobs/{id}/retry", userStack.ThenFunc(deps.Job.Retry))
+	mux.Handle("POST /jobs/{id}/cancel", userStack.ThenFunc(deps.Job.Cancel))
 
 	// SERVERS
 	mux.Handle("GET /servers", serverReadStack.ThenFunc(deps.Server.Index))
@@ -130,11 +150,15 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 	mux.Handle("PUT /servers/{id}", serverWriteStack.ThenFunc(deps.Server.Update))
 	mux.Handle("DELETE /servers/{id}", serverWriteStack.ThenFunc(deps.Server.Destroy))
 	mux.Handle("POST /servers/{id}/rotate-secret", serverWriteStack.ThenFunc(deps.Server.RotateSecret))
+	mux.Handle("POST /servers/{id}/agent/upgrade", serverWriteStack.ThenFunc(deps.AgentUpgrade.Upgrade))
+	mux.Handle("POST /servers/agent/rollout", serverWriteStack.ThenFunc(deps.AgentUpgrade.Rollout))
 
 	// SERVER METRICS
 	mux.Handle("GET /servers/{id}/metrics/latest", metricsReadStack.ThenFunc(deps.Metrics.Latest))
 	mux.Handle("GET /servers/{id}/metrics/cpu-usage-history", metricsReadStack.ThenFunc(deps.Metrics.CPUUsageHistory))
 	mux.Handle("GET /servers/{id}/metrics/net-speed-history", metricsReadStack.ThenFunc(deps.Metrics.NetSpeedHistory))
+	mux.Handle("GET /servers/{id}/metrics/rollups", metricsReadStack.ThenFunc(deps.Metrics.Rollups))
+	mux.Handle("GET /servers/{id}/metrics/query", metricsReadStack.ThenFunc(deps.Metrics.Query))
 
 	// ACCOUNT
 	mux.Handle("POST /account/profile", userStack.ThenFunc(deps.Account.Profile))
@@ -161,10 +185,47 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) http.Handler {
 	mux.Handle("POST /applications/{id}/stop", appWriteStack.ThenFunc(deps.Application.Stop))
 	mux.Handle("POST /applications/{id}/restart", appWriteStack.ThenFunc(deps.Application.Restart))
 
+	// SERVICES
+	mux.Handle("GET /applications/{id}/services", appReadStack.ThenFunc(deps.Application.Services))
+	mux.Handle("POST /applications/{id}/services/{service}/start", appWriteStack.ThenFunc(deps.Application.StartService))
+	mux.Handle("POST /applications/{id}/services/{service}/stop", appWriteStack.ThenFunc(deps.Application.StopService))
+	mux.Handle("POST /applications/{id}/services/{service}/restart", appWriteStack.ThenFunc(deps.Application.RestartService))
+	mux.Handle("POST /applications/{id}/services/{service}/scale", appWriteStack.ThenFunc(deps.Application.ScaleService))
+
+	// CONTAINER LOGS
+	mux.Handle("GET /applications/{id}/container-logs", appReadStack.ThenFunc(deps.ContainerLogs.Index))
+
+	// APPLICATION METRICS
+	mux.Handle("GET /applications/{id}/metrics", appReadStack.ThenFunc(deps.Metrics.AppMetrics))
+
 	// DEPLOYMENTS
 	mux.Handle("GET /applications/{id}/deployments", appReadStack.ThenFunc(deps.Deployment.Index))
 	mux.Handle("GET /applications/{id}/deployments/{deployment_id}", appReadStack.ThenFunc(deps.Deployment.Show))
 	mux.Handle("GET /applications/{id}/deployments/{deployment_id}/diff", appReadStack.ThenFunc(deps.Deployment.Diff))
+	mux.Handle("POST /applications/{id}/deployments/{deployment_id}/rollback", appWriteStack.ThenFunc(deps.Application.RollbackTo))
+
+	// PREVIEWS
+	mux.Handle("GET /applications/{id}/previews", appReadStack.ThenFunc(deps.Preview.Index))
+	mux.Handle("DELETE /applications/{id}/previews/{number}", appWriteStack.ThenFunc(deps.Preview.Destroy))
+
+	// GIT WEBHOOK SETTINGS
+	mux.Handle("GET /applications/{id}/git-webhook", appReadStack.ThenFunc(deps.GitWebhook.Show))
+	mux.Handle("PUT /applications/{id}/git-webhook", appWriteStack.ThenFunc(deps.GitWebhook.Update))
+	mux.Handle("POST /applications/{id}/git-webhook/rotate", appWriteStack.ThenFunc(deps.GitWebhook.Rotate))
+	mux.Handle("GET /applications/{id}/git-webhook/deliveries", appReadStack.ThenFunc(deps.GitWebhook.Deliveries))
+	mux.Handle("GET /applications/{id}/git-credentials", appReadStack.ThenFunc(deps.GitCredential.Show))
+	mux.Handle("POST /applications/{id}/git-credentials/deploy-key", appWriteStack.ThenFunc(deps.GitCredential.GenerateDeployKey))
+	mux.Handle("DELETE /applications/{id}/git-credentials/deploy-key", appWriteStack.ThenFunc(deps.GitCredential.DeleteDeployKey))
+	mux.Handle("PUT /applications/{id}/git-credentials/https", appWriteStack.ThenFunc(deps.GitCredential.UpdateHTTPS))
+	mux.Handle("DELETE /applications/{id}/git-credentials/https", appWriteStack.ThenFunc(deps.GitCredential.DeleteHTTPS))
+	mux.Handle("GET /applications/{id}/registry-credentials", appReadStack.ThenFunc(deps.RegistryCredential.Show))
+	mux.Handle("PUT /applications/{id}/registry-credentials", appWriteStack.ThenFunc(deps.RegistryCredential.Update))
+	mux.Handle("DELETE /applications/{id}/registry-credentials", appWriteStack.ThenFunc(deps.RegistryCredential.Destroy))
+
+	// BUILD VARIABLES
+	mux.Handle("GET /applications/{id}/build-variables", appReadStack.ThenFunc(deps.BuildVariable.Index))
+	mux.Handle("PUT /applications/{id}/build-variables/{key}", appWriteStack.ThenFunc(deps.BuildVariable.Update))
+	mux.Handle("DELETE /applications/{id}/build-variables/{key}", appWriteStack.ThenFunc(deps.BuildVariable.Destroy))
 
 	// AUDIT LOG
 	mux.Handle("GET /audit-logs", userStack.ThenFunc(deps.AuditLog.Index))
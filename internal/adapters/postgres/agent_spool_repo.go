@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AgentSpoolRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAgentSpoolRepository(db *pgxpool.Pool) domain.AgentSpoolRepository {
+	return &AgentSpoolRepository{db: db}
+}
+
+func (r *AgentSpoolRepository) LastSeq(ctx context.Context, serverID, spoolID uuid.UUID) (int64, error) {
+	query := `
+		SELECT last_seq
+		FROM agent_spools
+		WHERE server_id = $1 AND spool_id = $2
+	`
+
+	var seq int64
+	err := r.db.QueryRow(ctx, query, serverID, spoolID).Scan(&seq)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get agent spool: %w", err)
+	}
+
+	return seq, nil
+}
+
+// Ack never moves a spool's sequence backwards, so a late duplicate cannot
+// reopen records already applied.
+func (r *AgentSpoolRepository) Ack(ctx context.Context, serverID, spoolID uuid.UUID, seq int64) error {
+	query := `
+		INSERT INTO agent_spools (server_id, spool_id, last_seq)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (server_id) DO UPDATE
+		SET
+			spool_id = EXCLUDED.spool_id,
+			last_seq = EXCLUDED.last_seq,
+			updated_at = NOW()
+		WHERE agent_spools.spool_id <> EXCLUDED.spool_id
+		   OR agent_spools.last_seq < EXCLUDED.last_seq
+	`
+
+	if _, err := r.db.Exec(ctx, query, serverID, spoolID, seq); err != nil {
+		return fmt.Errorf("failed to ack agent spool: %w", err)
+	}
+
+	return nil
+}
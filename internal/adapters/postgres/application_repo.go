@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -38,6 +39,20 @@ func (r *ApplicationRepository) List(ctx context.Context, opts domain.Applicatio
 			site_url,
 			branch,
 			status,
+			source_type,
+			image,
+			compose_definition,
+			auto_rollback,
+			readiness_probes,
+			pre_deploy_commands,
+			post_deploy_commands,
+			compose,
+			services,
+			previews_enabled,
+			preview_ttl_hours,
+			parent_application_id,
+			preview_number,
+			preview_expires_at,
 			last_deployment_at,
 			created_at,
 			updated_at
@@ -63,6 +78,24 @@ func (r *ApplicationRepository) List(ctx context.Context, opts domain.Applicatio
 		argCounter++
 	}
 
+	if opts.ParentApplicationID != nil {
+		conditions = append(conditions, fmt.Sprintf("parent_application_id = $%d", argCounter))
+		args = append(args, *opts.ParentApplicationID)
+		argCounter++
+	}
+
+	if opts.PreviewNumber != nil {
+		conditions = append(conditions, fmt.Sprintf("preview_number = $%d", argCounter))
+		args = append(args, *opts.PreviewNumber)
+		argCounter++
+	}
+
+	if opts.PreviewExpiredAt != nil {
+		conditions = append(conditions, fmt.Sprintf("preview_expires_at <= $%d", argCounter))
+		args = append(args, *opts.PreviewExpiredAt)
+		argCounter++
+	}
+
 	if len(conditions) > 0 {
 		baseQuery += " WHERE " + strings.Join(conditions, " AND ")
 	}
@@ -95,6 +128,7 @@ func (r *ApplicationRepository) List(ctx context.Context, opts domain.Applicatio
 	var applications []*domain.Application
 	for rows.Next() {
 		var a domain.Application
+		var probes, preDeploy, postDeploy, compose, services []byte
 
 		if err := rows.Scan(
 			&a.ID,
@@ -105,12 +139,29 @@ func (r *ApplicationRepository) List(ctx context.Context, opts domain.Applicatio
 			&a.SiteURL,
 			&a.Branch,
 			&a.Status,
+			&a.SourceType,
+			&a.Image,
+			&a.ComposeDefinition,
+			&a.AutoRollback,
+			&probes,
+			&preDeploy,
+			&postDeploy,
+			&compose,
+			&services,
+			&a.PreviewsEnabled,
+			&a.PreviewTTLHours,
+			&a.ParentApplicationID,
+			&a.PreviewNumber,
+			&a.PreviewExpiresAt,
 			&a.LastDeploymentAt,
 			&a.CreatedAt,
 			&a.UpdatedAt,
 		); err != nil {
 			return nil, 0, fmt.Errorf("failed to scan applications: %w", err)
 		}
+		if err := decodeApplicationLists(&a, probes, preDeploy, postDeploy, compose, services); err != nil {
+			return nil, 0, err
+		}
 
 		applications = append(applications, &a)
 	}
@@ -124,12 +175,14 @@ func (r *ApplicationRepository) List(ctx context.Context, opts domain.Applicatio
 
 func (r *ApplicationRepository) GetByID(ctx context.Context, appID int64) (*domain.Application, error) {
 	query := `
-		SELECT id, server_id, name, repo_name, repo_url, site_url, branch, status, last_deployment_at, created_at, updated_at
+		SELECT id, server_id, name, repo_name, repo_url, site_url, branch, status, source_type, image, compose_definition, auto_rollback, readiness_probes, pre_deploy_commands, post_deploy_commands, compose, services,
+			previews_enabled, preview_ttl_hours, parent_application_id, preview_number, preview_expires_at, last_deployment_at, created_at, updated_at
 		FROM applications
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var app domain.Application
+	var probes, preDeploy, postDeploy, compose, services []byte
 	err := r.db.QueryRow(ctx, query, appID).Scan(
 		&app.ID,
 		&app.ServerID,
@@ -139,6 +192,20 @@ func (r *ApplicationRepository) GetByID(ctx context.Context, appID int64) (*doma
 		&app.SiteURL,
 		&app.Branch,
 		&app.Status,
+		&app.SourceType,
+		&app.Image,
+		&app.ComposeDefinition,
+		&app.AutoRollback,
+		&probes,
+		&preDeploy,
+		&postDeploy,
+		&compose,
+		&services,
+		&app.PreviewsEnabled,
+		&app.PreviewTTLHours,
+		&app.ParentApplicationID,
+		&app.PreviewNumber,
+		&app.PreviewExpiresAt,
 		&app.LastDeploymentAt,
 		&app.CreatedAt,
 		&app.UpdatedAt,
@@ -149,19 +216,31 @@ func (r *ApplicationRepository) GetByID(ctx context.Context, appID int64) (*doma
 		}
 		return nil, fmt.Errorf("failed to get application: %w", err)
 	}
+	if err := decodeApplicationLists(&app, probes, preDeploy, postDeploy, compose, services); err != nil {
+		return nil, err
+	}
 
 	return &app, nil
 }
 
 func (r *ApplicationRepository) Create(ctx context.Context, app *domain.Application) (*domain.Application, error) {
 	query := `
-		INSERT INTO applications (server_id, name, repo_name, repo_url, site_url, branch, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO applications (
+			server_id, name, repo_name, repo_url, site_url, branch, status, source_type, image, compose_definition, auto_rollback,
+			readiness_probes, pre_deploy_commands, post_deploy_commands, compose, previews_enabled, preview_ttl_hours,
+			parent_application_id, preview_number, preview_expires_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 		RETURNING id, created_at, updated_at
 	`
 
+	probes, preDeploy, postDeploy, compose, err := encodeApplicationLists(app)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now().UTC()
-	err := r.db.QueryRow(
+	err = r.db.QueryRow(
 		ctx, query,
 		app.ServerID,
 		app.Name,
@@ -170,6 +249,19 @@ func (r *ApplicationRepository) Create(ctx context.Context, app *domain.Applicat
 		app.SiteURL,
 		app.Branch,
 		domain.AppStatusUnknown,
+		app.SourceType,
+		app.Image,
+		app.ComposeDefinition,
+		app.AutoRollback,
+		probes,
+		preDeploy,
+		postDeploy,
+		compose,
+		app.PreviewsEnabled,
+		app.PreviewTTLHours,
+		app.ParentApplicationID,
+		app.PreviewNumber,
+		app.PreviewExpiresAt,
 		now,
 		now,
 	).Scan(&app.ID, &app.CreatedAt, &app.UpdatedAt)
@@ -183,15 +275,31 @@ func (r *ApplicationRepository) Create(ctx context.Context, app *domain.Applicat
 func (r *ApplicationRepository) Update(ctx context.Context, app *domain.Application, appID int64) error {
 	query := `
 		UPDATE applications
-		SET name = $1, site_url = $2, branch = $3, updated_at = $4
-		WHERE id = $5 AND deleted_at IS NULL
+		SET name = $1, site_url = $2, branch = $3, image = $4, compose_definition = $5, auto_rollback = $6,
+			readiness_probes = $7, pre_deploy_commands = $8, post_deploy_commands = $9, compose = $10,
+			previews_enabled = $11, preview_ttl_hours = $12, updated_at = $13
+		WHERE id = $14 AND deleted_at IS NULL
 	`
 
+	probes, preDeploy, postDeploy, compose, err := encodeApplicationLists(app)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now().UTC()
 	ct, err := r.db.Exec(ctx, query,
 		app.Name,
 		app.SiteURL,
 		app.Branch,
+		app.Image,
+		app.ComposeDefinition,
+		app.AutoRollback,
+		probes,
+		preDeploy,
+		postDeploy,
+		compose,
+		app.PreviewsEnabled,
+		app.PreviewTTLHours,
 		now,
 		appID,
 	)
@@ -259,6 +367,25 @@ func (r *ApplicationRepository) UpdateLastDeployment(ctx context.Context, appID
 	return nil
 }
 
+func (r *ApplicationRepository) UpdatePreviewExpiry(ctx context.Context, appID int64, expiresAt time.Time) error {
+	query := `
+		UPDATE applications
+		SET preview_expires_at = $1
+		WHERE id = $2 AND parent_application_id IS NOT NULL AND deleted_at IS NULL
+	`
+
+	ct, err := r.db.Exec(ctx, query, expiresAt.UTC(), appID)
+	if err != nil {
+		return fmt.Errorf("failed to update preview expiry: %w", err)
+	}
+
+	if ct.RowsAffected() == 0 {
+		return domain.ErrApplicationNotFound
+	}
+
+	return nil
+}
+
 func (r *ApplicationRepository) SyncEnvVars(ctx context.Context, appID int64, envVars []domain.EnvironmentVariable) error {
 	if len(envVars) == 0 {
 		return nil
@@ -290,15 +417,17 @@ func (r *ApplicationRepository) SyncEnvVars(ctx context.Context, appID int64, en
 				value,
 				value_encrypted,
 				is_preview,
+				is_secret,
 				created_at,
 				updated_at
 			)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 			ON CONFLICT (application_id, key)
 			DO UPDATE SET
 				value = EXCLUDED.value,
 				value_encrypted = EXCLUDED.value_encrypted,
 				is_preview = EXCLUDED.is_preview,
+				is_secret = EXCLUDED.is_secret,
 				updated_at = EXCLUDED.updated_at
 		`,
 			appID,
@@ -306,6 +435,7 @@ func (r *ApplicationRepository) SyncEnvVars(ctx context.Context, appID int64, en
 			encValue,
 			len(r.encKey) > 0,
 			e.IsPreview,
+			e.IsSecret,
 			now,
 			now,
 		)
@@ -337,6 +467,72 @@ func (r *ApplicationRepository) SyncEnvVars(ctx context.Context, appID int64, en
 	return nil
 }
 
+// marshalJSONList stores a missing list as an empty array so JSONB list
+// columns (readiness probes, release commands) stay arrays.
+func marshalJSONList[T any](list []T, what string) ([]byte, error) {
+	if list == nil {
+		list = []T{}
+	}
+	raw, err := json.Marshal(list)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s: %w", what, err)
+	}
+	return raw, nil
+}
+
+func encodeApplicationLists(app *domain.Application) ([]byte, []byte, []byte, []byte, error) {
+	probes, err := marshalJSONList(app.ReadinessProbes, "readiness probes")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	preDeploy, err := marshalJSONList(app.PreDeployCommands, "pre-deploy commands")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	postDeploy, err := marshalJSONList(app.PostDeployCommands, "post-deploy commands")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	compose, err := json.Marshal(app.Compose)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("marshal compose: %w", err)
+	}
+	return probes, preDeploy, postDeploy, compose, nil
+}
+
+func decodeApplicationLists(app *domain.Application, probes, preDeploy, postDeploy, compose, services []byte) error {
+	var err error
+	if app.ReadinessProbes, err = unmarshalJSONList[domain.ReadinessProbe](probes, "readiness probes"); err != nil {
+		return err
+	}
+	if app.PreDeployCommands, err = unmarshalJSONList[domain.ReleaseCommand](preDeploy, "pre-deploy commands"); err != nil {
+		return err
+	}
+	if app.PostDeployCommands, err = unmarshalJSONList[domain.ReleaseCommand](postDeploy, "post-deploy commands"); err != nil {
+		return err
+	}
+	if app.Services, err = unmarshalJSONList[domain.ServiceHealth](services, "services"); err != nil {
+		return err
+	}
+	if len(compose) > 0 {
+		if err := json.Unmarshal(compose, &app.Compose); err != nil {
+			return fmt.Errorf("unmarshal compose: %w", err)
+		}
+	}
+	return nil
+}
+
+func unmarshalJSONList[T any](raw []byte, what string) ([]T, error) {
+	list := []T{}
+	if len(raw) == 0 {
+		return list, nil
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", what, err)
+	}
+	return list, nil
+}
+
 // encryptEnvValue encrypts a plaintext env var value for storage. In legacy
 // mode (no key configured) it returns the value unchanged.
 func (r *ApplicationRepository) encryptEnvValue(value string) (string, error) {
@@ -356,7 +552,7 @@ func (r *ApplicationRepository) decryptEnvValue(value string, encrypted bool) (s
 
 func (r *ApplicationRepository) ListEnvVars(ctx context.Context, appID int64) ([]domain.EnvironmentVariable, error) {
 	query := `
-		SELECT id, application_id, key, value, value_encrypted, is_preview, created_at, updated_at
+		SELECT id, application_id, key, value, value_encrypted, is_preview, is_secret, created_at, updated_at
 		FROM environment_variables
 		WHERE application_id = $1
 		ORDER BY key ASC
@@ -378,6 +574,7 @@ func (r *ApplicationRepository) ListEnvVars(ctx context.Context, appID int64) ([
 			&env.Value,
 			&env.ValueEncrypted,
 			&env.IsPreview,
+			&env.IsSecret,
 			&env.CreatedAt,
 			&env.UpdatedAt,
 		)
@@ -403,10 +600,10 @@ func (r *ApplicationRepository) CreateEnvVar(ctx context.Context, env *domain.En
 	}
 
 	query := `
-		INSERT INTO environment_variables (application_id, key, value, value_encrypted, is_preview, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO environment_variables (application_id, key, value, value_encrypted, is_preview, is_secret, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		ON CONFLICT (application_id, key) 
-		DO UPDATE SET value = EXCLUDED.value, value_encrypted = EXCLUDED.value_encrypted, is_preview = EXCLUDED.is_preview, updated_at = EXCLUDED.updated_at
+		DO UPDATE SET value = EXCLUDED.value, value_encrypted = EXCLUDED.value_encrypted, is_preview = EXCLUDED.is_preview, is_secret = EXCLUDED.is_secret, updated_at = EXCLUDED.updated_at
 		RETURNING id, created_at, updated_at
 	`
 
@@ -417,6 +614,7 @@ func (r *ApplicationRepository) CreateEnvVar(ctx context.Context, env *domain.En
 		encValue,
 		len(r.encKey) > 0,
 		env.IsPreview,
+		env.IsSecret,
 		now,
 		now,
 	).Scan(&env.ID, &env.CreatedAt, &env.UpdatedAt)
@@ -435,14 +633,15 @@ func (r *ApplicationRepository) UpdateEnvVar(ctx context.Context, env *domain.En
 
 	query := `
 		UPDATE environment_variables
-		SET value = $1, value_encrypted = $2, is_preview = $3, updated_at = $4
-		WHERE application_id = $5 AND key = $6
+		SET value = $1, value_encrypted = $2, is_preview = $3, is_secret = $4, updated_at = $5
+		WHERE application_id = $6 AND key = $7
 	`
 
 	ct, err := r.db.Exec(ctx, query,
 		encValue,
 		len(r.encKey) > 0,
 		env.IsPreview,
+		env.IsSecret,
 		time.Now().UTC(),
 		env.ApplicationID,
 		env.Key,
@@ -479,19 +678,29 @@ func (r *ApplicationRepository) UpdateHealth(ctx context.Context, serverID uuid.
 	}
 
 	valueStrings := make([]string, 0, len(reports))
-	valueArgs := make([]any, 0, len(reports)*2)
+	valueArgs := make([]any, 0, len(reports)*3)
 
 	argPos := 2
 	for _, d := range reports {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d::bigint, $%d::text)", argPos, argPos+1))
-		valueArgs = append(valueArgs, d.ApplicationID, d.Status)
-		argPos += 2
+		// No breakdown (an older agent) keeps the last one: NULL below.
+		var services []byte
+		if d.Services != nil {
+			raw, err := json.Marshal(d.Services)
+			if err != nil {
+				return fmt.Errorf("marshal services: %w", err)
+			}
+			services = raw
+		}
+
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d::bigint, $%d::text, $%d::jsonb)", argPos, argPos+1, argPos+2))
+		valueArgs = append(valueArgs, d.ApplicationID, d.Status, services)
+		argPos += 3
 	}
 
 	query := fmt.Sprintf(`
 		UPDATE applications a
-		SET status = v.status
-		FROM (VALUES %s) AS v(app_id, status)
+		SET status = v.status, services = COALESCE(v.services, a.services)
+		FROM (VALUES %s) AS v(app_id, status, services)
 		WHERE a.id = v.app_id
 		  AND a.server_id = $1
 	`, strings.Join(valueStrings, ","))
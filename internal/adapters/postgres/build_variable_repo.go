@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/security"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BuildVariableRepository struct {
+	db     *pgxpool.Pool
+	encKey []byte
+}
+
+func NewBuildVariableRepository(db *pgxpool.Pool, encKey []byte) domain.BuildVariableRepository {
+	return &BuildVariableRepository{db: db, encKey: encKey}
+}
+
+func (r *BuildVariableRepository) List(ctx context.Context, appID int64) ([]domain.BuildVariable, error) {
+	query := `
+		SELECT id, application_id, key, value, value_encrypted, is_secret, created_at, updated_at
+		FROM application_build_variables
+		WHERE application_id = $1
+		ORDER BY key ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, appID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query build variables: %w", err)
+	}
+	defer rows.Close()
+
+	var vars []domain.BuildVariable
+	for rows.Next() {
+		var v domain.BuildVariable
+		if err := rows.Scan(
+			&v.ID,
+			&v.ApplicationID,
+			&v.Key,
+			&v.Value,
+			&v.ValueEncrypted,
+			&v.IsSecret,
+			&v.CreatedAt,
+			&v.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan build variable: %w", err)
+		}
+
+		if v.Value, err = r.decrypt(v.Value, v.ValueEncrypted); err != nil {
+			return nil, fmt.Errorf("failed to decrypt build variable %q: %w", v.Key, err)
+		}
+
+		vars = append(vars, v)
+	}
+
+	return vars, rows.Err()
+}
+
+func (r *BuildVariableRepository) Save(ctx context.Context, v *domain.BuildVariable) error {
+	encValue, err := r.encrypt(v.Value)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt build variable %q: %w", v.Key, err)
+	}
+
+	query := `
+		INSERT INTO application_build_variables (application_id, key, value, value_encrypted, is_secret, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (application_id, key) DO UPDATE SET
+			value = EXCLUDED.value,
+			value_encrypted = EXCLUDED.value_encrypted,
+			is_secret = EXCLUDED.is_secret,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, value_encrypted, created_at, updated_at
+	`
+
+	err = r.db.QueryRow(ctx, query,
+		v.ApplicationID,
+		v.Key,
+		encValue,
+		len(r.encKey) > 0,
+		v.IsSecret,
+		time.Now().UTC(),
+	).Scan(&v.ID, &v.ValueEncrypted, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save build variable: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BuildVariableRepository) Delete(ctx context.Context, appID int64, key string) error {
+	query := `DELETE FROM application_build_variables WHERE application_id = $1 AND key = $2`
+
+	ct, err := r.db.Exec(ctx, query, appID, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete build variable: %w", err)
+	}
+
+	if ct.RowsAffected() == 0 {
+		return domain.ErrBuildVariableNotFound
+	}
+
+	return nil
+}
+
+// encrypt works like ApplicationRepository.encryptEnvValue: without a key
+// the value is stored as is.
+func (r *BuildVariableRepository) encrypt(value string) (string, error) {
+	if len(r.encKey) == 0 {
+		return value, nil
+	}
+	return security.Encrypt(value, r.encKey)
+}
+
+func (r *BuildVariableRepository) decrypt(value string, encrypted bool) (string, error) {
+	if !encrypted || len(r.encKey) == 0 {
+		return value, nil
+	}
+	return security.Decrypt(value, r.encKey)
+}
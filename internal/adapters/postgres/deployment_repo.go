@@ -29,8 +29,10 @@ func (r *DeploymentRepository) List(ctx context.Context, opts domain.DeploymentL
 			d.id,
 			d.application_id,
 			d.branch,
+			d.ref,
 			d.commit_hash,
 			d.commit_message,
+			d.image_digest,
 			d.status,
 			d.deployed_by,
 			d.triggered_at,
@@ -39,6 +41,7 @@ func (r *DeploymentRepository) List(ctx context.Context, opts domain.DeploymentL
 			d.env_snapshot,
 			d.previous_deployment_id,
 			d.previous_commit_hash,
+			d.rollback_of_deployment_id,
 			u.id,
 			u.name,
 			u.email,
@@ -116,8 +119,10 @@ func (r *DeploymentRepository) List(ctx context.Context, opts domain.DeploymentL
 			&d.ID,
 			&d.ApplicationID,
 			&d.Branch,
+			&d.Ref,
 			&d.CommitHash,
 			&d.CommitMessage,
+			&d.ImageDigest,
 			&d.Status,
 			&d.DeployedBy,
 			&d.TriggeredAt,
@@ -126,6 +131,7 @@ func (r *DeploymentRepository) List(ctx context.Context, opts domain.DeploymentL
 			&d.EnvSnapshot,
 			&d.PreviousDeploymentID,
 			&d.PreviousCommitHash,
+			&d.RollbackOfDeploymentID,
 			&userID,
 			&userName,
 			&userEmail,
@@ -159,8 +165,10 @@ func (r *DeploymentRepository) GetByID(ctx context.Context, deploymentID int64)
 			d.id,
 			d.application_id,
 			d.branch,
+			d.ref,
 			d.commit_hash,
 			d.commit_message,
+			d.image_digest,
 			d.status, 
 			d.deployed_by,
 			d.triggered_at,
@@ -169,6 +177,7 @@ func (r *DeploymentRepository) GetByID(ctx context.Context, deploymentID int64)
 			d.env_snapshot,
 			d.previous_deployment_id,
 			d.previous_commit_hash,
+			d.rollback_of_deployment_id,
 			u.id,
 			u.name,
 			u.email,
@@ -190,8 +199,10 @@ func (r *DeploymentRepository) GetByID(ctx context.Context, deploymentID int64)
 		&d.ID,
 		&d.ApplicationID,
 		&d.Branch,
+		&d.Ref,
 		&d.CommitHash,
 		&d.CommitMessage,
+		&d.ImageDigest,
 		&d.Status,
 		&d.DeployedBy,
 		&d.TriggeredAt,
@@ -200,6 +211,7 @@ func (r *DeploymentRepository) GetByID(ctx context.Context, deploymentID int64)
 		&d.EnvSnapshot,
 		&d.PreviousDeploymentID,
 		&d.PreviousCommitHash,
+		&d.RollbackOfDeploymentID,
 		&uID,
 		&uName,
 		&uEmail,
@@ -228,11 +240,13 @@ func (r *DeploymentRepository) Create(ctx context.Context, d *domain.Deployment)
 		INSERT INTO deployments (
 			application_id,
 			branch,
+			ref,
 			deployed_by,
 			status,
-			triggered_at
+			triggered_at,
+			rollback_of_deployment_id
 		)
-		VALUES ($1, $2, $3, $4, $5)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING
 			id,
 			application_id,
@@ -244,9 +258,11 @@ func (r *DeploymentRepository) Create(ctx context.Context, d *domain.Deployment)
 	if err := r.db.QueryRow(ctx, query,
 		d.ApplicationID,
 		d.Branch,
+		d.Ref,
 		d.DeployedBy,
 		domain.DeploymentPending,
 		now,
+		d.RollbackOfDeploymentID,
 	).Scan(
 		&d.ID,
 		&d.ApplicationID,
@@ -358,6 +374,30 @@ func (r *DeploymentRepository) UpdateCommitInfo(ctx context.Context, deploymentI
 	return &d, nil
 }
 
+func (r *DeploymentRepository) UpdateImageDigest(ctx context.Context, deploymentID int64, imageDigest string) (*domain.Deployment, error) {
+	query := `
+		UPDATE deployments
+		SET image_digest = $1
+		WHERE id = $2
+		RETURNING id, application_id, image_digest
+	`
+
+	var d domain.Deployment
+	err := r.db.QueryRow(ctx, query, imageDigest, deploymentID).Scan(
+		&d.ID,
+		&d.ApplicationID,
+		&d.ImageDigest,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrDeploymentNotFound
+		}
+		return nil, fmt.Errorf("failed to update deployment image digest: %w", err)
+	}
+
+	return &d, nil
+}
+
 func (r *DeploymentRepository) UpdateEnvSnapshot(ctx context.Context, deploymentID int64, snapshot map[string]string) (*domain.Deployment, error) {
 	raw, err := json.Marshal(snapshot)
 	if err != nil {
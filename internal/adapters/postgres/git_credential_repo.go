@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/security"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type GitCredentialRepository struct {
+	db     *pgxpool.Pool
+	encKey []byte
+}
+
+func NewGitCredentialRepository(db *pgxpool.Pool, encKey []byte) domain.GitCredentialRepository {
+	return &GitCredentialRepository{db: db, encKey: encKey}
+}
+
+func (r *GitCredentialRepository) GetByApplicationID(ctx context.Context, appID int64) (*domain.ApplicationGitCredentials, error) {
+	query := `
+		SELECT
+			c.application_id,
+			c.ssh_public_key,
+			c.ssh_private_key,
+			c.https_username,
+			c.https_token,
+			c.created_at,
+			c.updated_at
+		FROM application_git_credentials c
+		JOIN applications a ON a.id = c.application_id AND a.deleted_at IS NULL
+		WHERE c.application_id = $1
+	`
+
+	var (
+		creds      domain.ApplicationGitCredentials
+		privateKey string
+		token      string
+	)
+	err := r.db.QueryRow(ctx, query, appID).Scan(
+		&creds.ApplicationID,
+		&creds.SSHPublicKey,
+		&privateKey,
+		&creds.HTTPSUsername,
+		&token,
+		&creds.CreatedAt,
+		&creds.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrGitCredentialsNotFound
+		}
+		return nil, fmt.Errorf("failed to get git credentials: %w", err)
+	}
+
+	if creds.SSHPrivateKey, err = r.decrypt(privateKey); err != nil {
+		return nil, fmt.Errorf("failed to decrypt deploy key: %w", err)
+	}
+	if creds.HTTPSToken, err = r.decrypt(token); err != nil {
+		return nil, fmt.Errorf("failed to decrypt https token: %w", err)
+	}
+	creds.HasHTTPSToken = creds.HTTPSToken != ""
+
+	return &creds, nil
+}
+
+func (r *GitCredentialRepository) SaveDeployKey(ctx context.Context, appID int64, publicKey, privateKey string) error {
+	encKey, err := r.encrypt(privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt deploy key: %w", err)
+	}
+
+	query := `
+		INSERT INTO application_git_credentials (application_id, ssh_public_key, ssh_private_key, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (application_id) DO UPDATE SET
+			ssh_public_key = EXCLUDED.ssh_public_key,
+			ssh_private_key = EXCLUDED.ssh_private_key,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.Exec(ctx, query, appID, publicKey, encKey, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to save deploy key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *GitCredentialRepository) SaveHTTPSCredentials(ctx context.Context, appID int64, username, token string) error {
+	encToken, err := r.encrypt(token)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt https token: %w", err)
+	}
+
+	query := `
+		INSERT INTO application_git_credentials (application_id, https_username, https_token, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (application_id) DO UPDATE SET
+			https_username = EXCLUDED.https_username,
+			https_token = EXCLUDED.https_token,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.Exec(ctx, query, appID, username, encToken, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to save https credentials: %w", err)
+	}
+
+	return nil
+}
+
+// encrypt leaves empty values empty so a cleared credential stays
+// distinguishable from a set one.
+func (r *GitCredentialRepository) encrypt(value string) (string, error) {
+	if value == "" || len(r.encKey) == 0 {
+		return value, nil
+	}
+	return security.Encrypt(value, r.encKey)
+}
+
+func (r *GitCredentialRepository) decrypt(value string) (string, error) {
+	if value == "" || len(r.encKey) == 0 {
+		return value, nil
+	}
+	return security.Decrypt(value, r.encKey)
+}
@@ -0,0 +1,258 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/security"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type GitWebhookRepository struct {
+	db     *pgxpool.Pool
+	encKey []byte
+}
+
+func NewGitWebhookRepository(db *pgxpool.Pool, encKey []byte) domain.GitWebhookRepository {
+	return &GitWebhookRepository{db: db, encKey: encKey}
+}
+
+func (r *GitWebhookRepository) GetByApplicationID(ctx context.Context, appID int64) (*domain.GitWebhook, error) {
+	query := `
+		SELECT w.application_id, w.token, w.secret, w.auto_deploy, w.created_at, w.updated_at
+		FROM git_webhooks w
+		JOIN applications a ON a.id = w.application_id AND a.deleted_at IS NULL
+		WHERE w.application_id = $1
+	`
+	return r.get(ctx, query, appID)
+}
+
+func (r *GitWebhookRepository) GetByToken(ctx context.Context, token string) (*domain.GitWebhook, error) {
+	query := `
+		SELECT w.application_id, w.token, w.secret, w.auto_deploy, w.created_at, w.updated_at
+		FROM git_webhooks w
+		JOIN applications a ON a.id = w.application_id AND a.deleted_at IS NULL
+		WHERE w.token = $1
+	`
+	return r.get(ctx, query, token)
+}
+
+func (r *GitWebhookRepository) get(ctx context.Context, query string, arg any) (*domain.GitWebhook, error) {
+	var hook domain.GitWebhook
+	var secret string
+	err := r.db.QueryRow(ctx, query, arg).Scan(
+		&hook.ApplicationID,
+		&hook.Token,
+		&secret,
+		&hook.AutoDeploy,
+		&hook.CreatedAt,
+		&hook.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrGitWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get git webhook: %w", err)
+	}
+
+	if hook.Secret, err = r.decryptSecret(secret); err != nil {
+		return nil, fmt.Errorf("failed to decrypt git webhook secret: %w", err)
+	}
+
+	return &hook, nil
+}
+
+func (r *GitWebhookRepository) Save(ctx context.Context, hook *domain.GitWebhook) (*domain.GitWebhook, error) {
+	secret, err := r.encryptSecret(hook.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt git webhook secret: %w", err)
+	}
+
+	query := `
+		INSERT INTO git_webhooks (application_id, token, secret, auto_deploy, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (application_id) DO UPDATE SET
+			token = EXCLUDED.token,
+			secret = EXCLUDED.secret,
+			updated_at = EXCLUDED.updated_at
+		RETURNING auto_deploy, created_at, updated_at
+	`
+
+	now := time.Now().UTC()
+	if err := r.db.QueryRow(ctx, query,
+		hook.ApplicationID,
+		hook.Token,
+		secret,
+		hook.AutoDeploy,
+		now,
+	).Scan(&hook.AutoDeploy, &hook.CreatedAt, &hook.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to save git webhook: %w", err)
+	}
+
+	return hook, nil
+}
+
+func (r *GitWebhookRepository) UpdateAutoDeploy(ctx context.Context, appID int64, autoDeploy bool) error {
+	query := `UPDATE git_webhooks SET auto_deploy = $1, updated_at = $2 WHERE application_id = $3`
+
+	ct, err := r.db.Exec(ctx, query, autoDeploy, time.Now().UTC(), appID)
+	if err != nil {
+		return fmt.Errorf("failed to update git webhook: %w", err)
+	}
+
+	if ct.RowsAffected() == 0 {
+		return domain.ErrGitWebhookNotFound
+	}
+
+	return nil
+}
+
+func (r *GitWebhookRepository) ClaimDelivery(ctx context.Context, d *domain.GitWebhookDelivery) (*domain.GitWebhookDelivery, error) {
+	query := `
+		INSERT INTO git_webhook_deliveries (
+			application_id, provider, delivery_id, dedupe_key, event, branch, commit_hash, pull_request, outcome, message, received_at
+		)
+		VALUES ($1, $2, $3, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (application_id, dedupe_key) DO NOTHING
+		RETURNING id, received_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		d.ApplicationID,
+		d.Provider,
+		d.DeliveryID,
+		d.Event,
+		d.Branch,
+		d.CommitHash,
+		d.PullRequest,
+		d.Outcome,
+		d.Message,
+		time.Now().UTC(),
+	).Scan(&d.ID, &d.ReceivedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDuplicateWebhookDelivery
+		}
+		return nil, fmt.Errorf("failed to claim git webhook delivery: %w", err)
+	}
+
+	return d, nil
+}
+
+func (r *GitWebhookRepository) CreateDelivery(ctx context.Context, d *domain.GitWebhookDelivery) (*domain.GitWebhookDelivery, error) {
+	query := `
+		INSERT INTO git_webhook_deliveries (
+			application_id, provider, delivery_id, event, branch, commit_hash, pull_request, outcome, message, deployment_id, received_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, received_at
+	`
+
+	if err := r.db.QueryRow(ctx, query,
+		d.ApplicationID,
+		d.Provider,
+		d.DeliveryID,
+		d.Event,
+		d.Branch,
+		d.CommitHash,
+		d.PullRequest,
+		d.Outcome,
+		d.Message,
+		d.DeploymentID,
+		time.Now().UTC(),
+	).Scan(&d.ID, &d.ReceivedAt); err != nil {
+		return nil, fmt.Errorf("failed to create git webhook delivery: %w", err)
+	}
+
+	return d, nil
+}
+
+func (r *GitWebhookRepository) FinishDelivery(ctx context.Context, d *domain.GitWebhookDelivery) error {
+	query := `
+		UPDATE git_webhook_deliveries
+		SET outcome = $1,
+		    message = $2,
+		    deployment_id = $3,
+		    dedupe_key = CASE WHEN $1 = 'failed' THEN NULL ELSE dedupe_key END
+		WHERE id = $4
+	`
+
+	if _, err := r.db.Exec(ctx, query, d.Outcome, d.Message, d.DeploymentID, d.ID); err != nil {
+		return fmt.Errorf("failed to finish git webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *GitWebhookRepository) ListDeliveries(ctx context.Context, opts domain.GitWebhookDeliveryListOptions) ([]*domain.GitWebhookDelivery, int64, error) {
+	baseQuery := `FROM git_webhook_deliveries WHERE application_id = $1`
+	args := []any{opts.ApplicationID}
+
+	var total int64
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) "+baseQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count git webhook deliveries: %w", err)
+	}
+
+	baseQuery += " ORDER BY received_at DESC, id DESC"
+
+	if opts.IsPaginate {
+		offset := (opts.Page - 1) * opts.Limit
+		baseQuery += " LIMIT $2 OFFSET $3"
+		args = append(args, opts.Limit, offset)
+	} else {
+		baseQuery += fmt.Sprintf(" LIMIT %d", opts.Limit)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, application_id, provider, delivery_id, event, branch, commit_hash, pull_request, outcome, message, deployment_id, received_at
+		`+baseQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query git webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.GitWebhookDelivery
+	for rows.Next() {
+		var d domain.GitWebhookDelivery
+		if err := rows.Scan(
+			&d.ID,
+			&d.ApplicationID,
+			&d.Provider,
+			&d.DeliveryID,
+			&d.Event,
+			&d.Branch,
+			&d.CommitHash,
+			&d.PullRequest,
+			&d.Outcome,
+			&d.Message,
+			&d.DeploymentID,
+			&d.ReceivedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan git webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, &d)
+	}
+
+	return deliveries, total, rows.Err()
+}
+
+// encryptSecret mirrors the env var encryption: without a key the secret is
+// stored as-is.
+func (r *GitWebhookRepository) encryptSecret(secret string) (string, error) {
+	if len(r.encKey) == 0 {
+		return secret, nil
+	}
+	return security.Encrypt(secret, r.encKey)
+}
+
+func (r *GitWebhookRepository) decryptSecret(secret string) (string, error) {
+	if len(r.encKey) == 0 {
+		return secret, nil
+	}
+	return security.Decrypt(secret, r.encKey)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"horizonx/internal/domain"
 
@@ -31,6 +32,7 @@ func (r *JobRepository) List(ctx context.Context, opts domain.JobListOptions) ([
 			deployment_id,
 			type,
 			payload,
+			payload_version,
 			status,
 			queued_at,
 			started_at,
@@ -117,6 +119,7 @@ func (r *JobRepository) List(ctx context.Context, opts domain.JobListOptions) ([
 			&job.DeploymentID,
 			&job.Type,
 			&job.Payload,
+			&job.PayloadVersion,
 			&job.Status,
 			&job.QueuedAt,
 			&job.StartedAt,
@@ -145,6 +148,7 @@ func (r *JobRepository) GetPending(ctx context.Context, serverID uuid.UUID) ([]*
 			deployment_id,
 			type,
 			payload,
+			payload_version,
 			status,
 			queued_at,
 			expired_at
@@ -171,6 +175,7 @@ func (r *JobRepository) GetPending(ctx context.Context, serverID uuid.UUID) ([]*
 			&j.DeploymentID,
 			&j.Type,
 			&j.Payload,
+			&j.PayloadVersion,
 			&j.Status,
 			&j.QueuedAt,
 			&j.ExpiredAt,
@@ -198,6 +203,7 @@ func (r *JobRepository) GetByID(ctx context.Context, jobID int64) (*domain.Job,
 			deployment_id,
 			type,
 			payload,
+			payload_version,
 			status,
 			queued_at,
 			started_at,
@@ -216,6 +222,7 @@ func (r *JobRepository) GetByID(ctx context.Context, jobID int64) (*domain.Job,
 		&j.DeploymentID,
 		&j.Type,
 		&j.Payload,
+		&j.PayloadVersion,
 		&j.Status,
 		&j.QueuedAt,
 		&j.StartedAt,
@@ -243,10 +250,11 @@ func (r *JobRepository) Create(ctx context.Context, j *domain.Job) (*domain.Job,
 			deployment_id,
 			type,
 			payload,
+			payload_version,
 			expired_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, queued_at
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, status, queued_at
 	`
 
 	err := r.db.QueryRow(ctx, query,
@@ -256,9 +264,11 @@ func (r *JobRepository) Create(ctx context.Context, j *domain.Job) (*domain.Job,
 		j.DeploymentID,
 		j.Type,
 		j.Payload,
+		j.PayloadVersion,
 		j.ExpiredAt,
 	).Scan(
 		&j.ID,
+		&j.Status,
 		&j.QueuedAt,
 	)
 	if err != nil {
@@ -292,6 +302,7 @@ func (r *JobRepository) Retry(ctx context.Context, jobID int64, j *domain.Job) (
 		UPDATE jobs
 		SET
 			payload = $2,
+			payload_version = $6,
 			status = $3,
 			queued_at = $4,
 			started_at = null,
@@ -306,6 +317,7 @@ func (r *JobRepository) Retry(ctx context.Context, jobID int64, j *domain.Job) (
 			deployment_id,
 			type,
 			payload,
+			payload_version,
 			status,
 			queued_at,
 			expired_at
@@ -317,6 +329,7 @@ func (r *JobRepository) Retry(ctx context.Context, jobID int64, j *domain.Job) (
 		j.Status,
 		j.QueuedAt,
 		j.ExpiredAt,
+		j.PayloadVersion,
 	).Scan(
 		&j.ID,
 		&j.TraceID,
@@ -325,6 +338,7 @@ func (r *JobRepository) Retry(ctx context.Context, jobID int64, j *domain.Job) (
 		&j.DeploymentID,
 		&j.Type,
 		&j.Payload,
+		&j.PayloadVersion,
 		&j.Status,
 		&j.QueuedAt,
 		&j.ExpiredAt,
@@ -352,6 +366,7 @@ func (r *JobRepository) MarkRunning(ctx context.Context, jobID int64) (*domain.J
 			deployment_id,
 			type,
 			payload,
+			payload_version,
 			status,
 			queued_at,
 			started_at,
@@ -368,6 +383,7 @@ func (r *JobRepository) MarkRunning(ctx context.Context, jobID int64) (*domain.J
 		&job.DeploymentID,
 		&job.Type,
 		&job.Payload,
+		&job.PayloadVersion,
 		&job.Status,
 		&job.QueuedAt,
 		&job.StartedAt,
@@ -385,18 +401,24 @@ func (r *JobRepository) MarkRunning(ctx context.Context, jobID int64) (*domain.J
 	return &job, nil
 }
 
+// MarkFinished finishes a running job. A job the reaper expired can still be
+// finished by its agent: a report replayed after an outage replaces the
+// expiry with the job's real outcome.
 func (r *JobRepository) MarkFinished(
 	ctx context.Context,
 	jobID int64,
 	status domain.JobStatus,
+	startedAt *time.Time,
 ) (*domain.Job, error) {
 	query := `
 		UPDATE jobs
 		SET
 			status = $1,
-			finished_at = NOW()
+			finished_at = NOW(),
+			expired_at = CASE WHEN $3 THEN NOW() ELSE expired_at END
 		WHERE id = $2
-		  AND status = 'running'
+		  AND (status = 'running' OR (status = 'expired' AND NOT $3))
+		  AND ($4::timestamptz IS NULL OR started_at = $4)
 		RETURNING
 			id,
 			trace_id,
@@ -405,6 +427,7 @@ func (r *JobRepository) MarkFinished(
 			deployment_id,
 			type,
 			payload,
+			payload_version,
 			status,
 			queued_at,
 			started_at,
@@ -413,7 +436,7 @@ func (r *JobRepository) MarkFinished(
 	`
 
 	var job domain.Job
-	err := r.db.QueryRow(ctx, query, status, jobID).Scan(
+	err := r.db.QueryRow(ctx, query, status, jobID, status == domain.JobExpired, startedAt).Scan(
 		&job.ID,
 		&job.TraceID,
 		&job.ServerID,
@@ -421,6 +444,7 @@ func (r *JobRepository) MarkFinished(
 		&job.DeploymentID,
 		&job.Type,
 		&job.Payload,
+		&job.PayloadVersion,
 		&job.Status,
 		&job.QueuedAt,
 		&job.StartedAt,
@@ -438,6 +462,62 @@ func (r *JobRepository) MarkFinished(
 	return &job, nil
 }
 
+// CancelQueued cancels a job that no agent has started yet. The status guard
+// makes it race-safe against MarkRunning: whichever runs first wins.
+func (r *JobRepository) CancelQueued(ctx context.Context, jobID int64) (*domain.Job, error) {
+	query := `
+		UPDATE jobs
+		SET
+			status = 'cancelled',
+			finished_at = NOW()
+		WHERE id = $1
+		  AND status = 'queued'
+		RETURNING
+			id,
+			trace_id,
+			server_id,
+			application_id,
+			deployment_id,
+			type,
+			payload,
+			payload_version,
+			status,
+			queued_at,
+			started_at,
+			finished_at,
+			expired_at
+	`
+
+	var job domain.Job
+	err := r.db.QueryRow(ctx, query, jobID).Scan(
+		&job.ID,
+		&job.TraceID,
+		&job.ServerID,
+		&job.ApplicationID,
+		&job.DeploymentID,
+		&job.Type,
+		&job.Payload,
+		&job.PayloadVersion,
+		&job.Status,
+		&job.QueuedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.ExpiredAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		if _, err := r.GetByID(ctx, jobID); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrInvalidJobState
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
 // CountsByStatus returns the number of jobs in each status.
 // P2-17: queue visibility — feeds the /jobs/summary endpoint and /metrics gauges.
 func (r *JobRepository) CountsByStatus(ctx context.Context) (*domain.JobStatusCounts, error) {
@@ -448,6 +528,7 @@ func (r *JobRepository) CountsByStatus(ctx context.Context) (*domain.JobStatusCo
 			COUNT(*) FILTER (WHERE status = 'success'),
 			COUNT(*) FILTER (WHERE status = 'failed'),
 			COUNT(*) FILTER (WHERE status = 'expired'),
+			COUNT(*) FILTER (WHERE status = 'cancelled'),
 			COUNT(*)
 		FROM jobs
 	`
@@ -459,6 +540,7 @@ func (r *JobRepository) CountsByStatus(ctx context.Context) (*domain.JobStatusCo
 		&counts.Success,
 		&counts.Failed,
 		&counts.Expired,
+		&counts.Cancelled,
 		&counts.Total,
 	)
 	if err != nil {
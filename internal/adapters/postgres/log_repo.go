@@ -8,6 +8,7 @@ import (
 
 	"horizonx/internal/domain"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -249,3 +250,69 @@ func (r *LogRepository) Create(ctx context.Context, l *domain.Log) (*domain.Log,
 
 	return l, nil
 }
+
+// BulkInsert stores a batch of logs with COPY, like MetricsRepository does.
+// COPY returns nothing, so IDs are drawn from the sequence first: published
+// logs carry their ID like the ones Create returns.
+func (r *LogRepository) BulkInsert(ctx context.Context, logs []*domain.Log) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	idRows, err := r.db.Query(ctx, `SELECT nextval(pg_get_serial_sequence('logs', 'id')) FROM generate_series(1, $1)`, len(logs))
+	if err != nil {
+		return fmt.Errorf("failed to reserve log ids: %w", err)
+	}
+	ids, err := pgx.CollectRows(idRows, pgx.RowTo[int64])
+	if err != nil {
+		return fmt.Errorf("failed to reserve log ids: %w", err)
+	}
+
+	now := time.Now().UTC()
+	rows := make([][]any, len(logs))
+	for i, l := range logs {
+		l.ID = ids[i]
+		l.CreatedAt = now
+		rows[i] = []any{
+			l.ID,
+			l.Timestamp,
+			string(l.Level),
+			string(l.Source),
+			string(l.Action),
+			l.TraceID,
+			l.JobID,
+			l.ServerID,
+			l.ApplicationID,
+			l.DeploymentID,
+			l.Message,
+			l.Context,
+			l.CreatedAt,
+		}
+	}
+
+	_, err = r.db.CopyFrom(
+		ctx,
+		pgx.Identifier{"logs"},
+		[]string{
+			"id",
+			"timestamp",
+			"level",
+			"source",
+			"action",
+			"trace_id",
+			"job_id",
+			"server_id",
+			"application_id",
+			"deployment_id",
+			"message",
+			"context",
+			"created_at",
+		},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert logs: %w", err)
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"horizonx/internal/domain"
@@ -60,3 +61,369 @@ func (r *MetricsRepository) Cleanup(ctx context.Context, serverID uuid.UUID, cut
 
 	return nil
 }
+
+// rollupTables maps a resolution to its table; the names are never user
+// input.
+var rollupTables = map[domain.MetricsResolution]string{
+	domain.MetricsHourly: "server_metrics_hourly",
+	domain.MetricsDaily:  "server_metrics_daily",
+}
+
+// rollupSeries are the columns summarised per bucket (each as _min, _avg,
+// _max and _p95), in MetricsRollup field order, the query metric each one
+// backs, and how each is read from a raw sample.
+var rollupSeries = []struct {
+	column string
+	metric string
+	expr   string
+}{
+	{"cpu", "cpu.usage_percent", "cpu_usage_percent"},
+	{"memory", "memory.usage_percent", "memory_usage_percent"},
+	{"disk_read", "disk.total_read_mbps", jsonArraySum("disk", "read_mbps", "sum")},
+	{"disk_write", "disk.total_write_mbps", jsonArraySum("disk", "write_mbps", "sum")},
+	{"net_rx", "network.rx_mbs", "(data->'network'->'rx_speed_mbs'->>'ema')::float8"},
+	{"net_tx", "network.tx_mbs", "(data->'network'->'tx_speed_mbs'->>'ema')::float8"},
+	{"gpu", "gpu.avg_core_usage_percent", jsonArraySum("gpu", "core_usage_percent", "avg")},
+}
+
+// jsonArraySum aggregates a signal's EMA over the elements of one of the
+// sample's arrays.
+func jsonArraySum(array, signal, agg string) string {
+	return fmt.Sprintf(`(SELECT %s((e->'%s'->>'ema')::float8) FROM jsonb_array_elements(%s) e)`,
+		agg, signal, jsonArray("data->'"+array+"'"))
+}
+
+// jsonArray guards a JSON array that is null rather than empty when there
+// are no elements.
+func jsonArray(path string) string {
+	return fmt.Sprintf("CASE jsonb_typeof(%s) WHEN 'array' THEN %s ELSE '[]'::jsonb END", path, path)
+}
+
+func rollupTable(resolution domain.MetricsResolution) (string, error) {
+	table, ok := rollupTables[resolution]
+	if !ok {
+		return "", domain.ErrInvalidMetricsRollupQuery
+	}
+	return table, nil
+}
+
+func (r *MetricsRepository) OldestSample(ctx context.Context, serverID uuid.UUID) (time.Time, bool, error) {
+	var oldest *time.Time
+	if err := r.db.QueryRow(ctx, `SELECT MIN(recorded_at) FROM server_metrics WHERE server_id = $1`, serverID).Scan(&oldest); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get oldest metrics sample: %w", err)
+	}
+	if oldest == nil {
+		return time.Time{}, false, nil
+	}
+	return *oldest, true, nil
+}
+
+func (r *MetricsRepository) StaleRollups(ctx context.Context, resolution domain.MetricsResolution, end time.Time) ([]time.Time, error) {
+	table, err := rollupTable(resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	// Raw samples only ever add to a bucket until the cleanup drops them, so
+	// a bucket holding more than its rollup counted got samples since it was
+	// rolled up, late ones included.
+	query := fmt.Sprintf(`
+		WITH raw AS (
+			SELECT
+				server_id,
+				date_trunc($1, recorded_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS bucket,
+				COUNT(*) AS samples
+			FROM server_metrics
+			WHERE recorded_at < $2
+			GROUP BY 1, 2
+		)
+		SELECT DISTINCT raw.bucket
+		FROM raw
+		LEFT JOIN %s r ON r.server_id = raw.server_id AND r.bucket = raw.bucket
+		WHERE raw.samples > COALESCE(r.samples, 0)
+		ORDER BY raw.bucket
+	`, table)
+
+	rows, err := r.db.Query(ctx, query, string(resolution), end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stale metrics rollups: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []time.Time
+	for rows.Next() {
+		var bucket time.Time
+		if err := rows.Scan(&bucket); err != nil {
+			return nil, fmt.Errorf("failed to scan stale metrics rollup: %w", err)
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, rows.Err()
+}
+
+func (r *MetricsRepository) RollUp(ctx context.Context, resolution domain.MetricsResolution, from, to time.Time) error {
+	table, err := rollupTable(resolution)
+	if err != nil {
+		return err
+	}
+
+	var selects, columns, aggregates, updates []string
+	for _, series := range rollupSeries {
+		c := series.column
+		selects = append(selects, fmt.Sprintf("%s AS %s", series.expr, c))
+		for _, stat := range []string{"min", "avg", "max", "p95"} {
+			columns = append(columns, c+"_"+stat)
+			updates = append(updates, fmt.Sprintf("%s_%s = EXCLUDED.%s_%s", c, stat, c, stat))
+		}
+		aggregates = append(aggregates,
+			fmt.Sprintf("MIN(%s), AVG(%s), MAX(%s), percentile_cont(0.95) WITHIN GROUP (ORDER BY %s)", c, c, c, c))
+	}
+
+	// Buckets are cut in UTC whatever the session's time zone.
+	query := fmt.Sprintf(`
+		WITH samples AS (
+			SELECT
+				server_id,
+				date_trunc($1, recorded_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS bucket,
+				%s
+			FROM server_metrics
+			WHERE recorded_at >= $2 AND recorded_at < $3
+		)
+		INSERT INTO %s (server_id, bucket, samples, %s)
+		SELECT server_id, bucket, COUNT(*), %s
+		FROM samples
+		GROUP BY server_id, bucket
+		ON CONFLICT (server_id, bucket) DO UPDATE SET
+			samples = EXCLUDED.samples,
+			%s
+	`,
+		strings.Join(selects, ",\n\t\t\t\t"),
+		table,
+		strings.Join(columns, ", "),
+		strings.Join(aggregates, ",\n\t\t\t"),
+		strings.Join(updates, ",\n\t\t\t"),
+	)
+
+	if _, err := r.db.Exec(ctx, query, string(resolution), from, to); err != nil {
+		return fmt.Errorf("failed to roll up server metrics: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MetricsRepository) ListRollups(ctx context.Context, serverID uuid.UUID, opts domain.MetricsRollupListOptions) ([]domain.MetricsRollup, error) {
+	table, err := rollupTable(opts.Resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	for _, series := range rollupSeries {
+		for _, stat := range []string{"min", "avg", "max", "p95"} {
+			columns = append(columns, series.column+"_"+stat)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		SELECT bucket, samples, %s
+		FROM %s
+		WHERE server_id = $1
+		AND bucket >= $2
+		AND bucket < $3
+		ORDER BY bucket ASC
+	`, strings.Join(columns, ", "), table)
+
+	rows, err := r.db.Query(ctx, query, serverID, opts.From, opts.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metrics rollups: %w", err)
+	}
+	defer rows.Close()
+
+	rollups := make([]domain.MetricsRollup, 0)
+	for rows.Next() {
+		rollup := domain.MetricsRollup{ServerID: serverID, Resolution: opts.Resolution}
+
+		// Every stat may be NULL (gpu_* without a GPU); scanned as pointers
+		// in rollupSeries order.
+		stats := make([]*float64, len(columns))
+		dest := []any{&rollup.Bucket, &rollup.Samples}
+		for i := range stats {
+			dest = append(dest, &stats[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan metrics rollup: %w", err)
+		}
+
+		targets := []*domain.RollupStat{
+			&rollup.CPUUsagePercent,
+			&rollup.MemoryUsagePercent,
+			&rollup.DiskReadMBps,
+			&rollup.DiskWriteMBps,
+			&rollup.NetRXMBs,
+			&rollup.NetTXMBs,
+		}
+		for i, target := range targets {
+			*target = rollupStat(stats[i*4:])
+		}
+		if gpu := stats[len(targets)*4:]; gpu[0] != nil {
+			stat := rollupStat(gpu)
+			rollup.GPUUsagePercent = &stat
+		}
+
+		rollups = append(rollups, rollup)
+	}
+
+	return rollups, rows.Err()
+}
+
+func rollupStat(values []*float64) domain.RollupStat {
+	get := func(v *float64) float64 {
+		if v == nil {
+			return 0
+		}
+		return *v
+	}
+	return domain.RollupStat{Min: get(values[0]), Avg: get(values[1]), Max: get(values[2]), P95: get(values[3])}
+}
+
+func (r *MetricsRepository) CleanupRollups(ctx context.Context, resolution domain.MetricsResolution, cutoff time.Time) error {
+	table, err := rollupTable(resolution)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.Exec(ctx, `DELETE FROM `+table+` WHERE bucket < $1`, cutoff); err != nil {
+		return fmt.Errorf("failed to cleanup metrics rollups: %w", err)
+	}
+
+	return nil
+}
+
+// queryMetrics read the query metrics the rollups do not keep from a raw
+// sample, as a subquery yielding a (label, value) row per series.
+var queryMetrics = map[string]string{
+	"cpu.core_usage_percent": `SELECT (c.i - 1)::text, (c.v->>'ema')::float8
+		FROM jsonb_array_elements(` + jsonArray("data->'cpu'->'per_core'") + `) WITH ORDINALITY c(v, i)`,
+	"cpu.temperature": scalarMetric("data->'cpu'->'temperature'->>'ema'"),
+	"cpu.frequency":   scalarMetric("data->'cpu'->'frequency'->>'ema'"),
+	"cpu.power_watt":  scalarMetric("data->'cpu'->'power_watt'->>'ema'"),
+
+	"memory.used_gb":      scalarMetric("data->'memory'->>'used_gb'"),
+	"memory.available_gb": scalarMetric("data->'memory'->>'available_gb'"),
+	"memory.swap_used_gb": scalarMetric("data->'memory'->>'swap_used_gb'"),
+	"memory.swap_usage_percent": scalarMetric(
+		"(data->'memory'->>'swap_used_gb')::float8 * 100 / NULLIF((data->'memory'->>'swap_total_gb')::float8, 0)"),
+
+	"disk.read_mbps":    itemMetric("disk", "name", "e->'read_mbps'->>'ema'"),
+	"disk.write_mbps":   itemMetric("disk", "name", "e->'write_mbps'->>'ema'"),
+	"disk.util_percent": itemMetric("disk", "name", "e->'util_pct'->>'ema'"),
+	"disk.temperature":  itemMetric("disk", "name", "e->'temperature'->>'ema'"),
+
+	"filesystem.usage_percent": filesystemMetric("percent"),
+	"filesystem.used_gb":       filesystemMetric("used_gb"),
+	"filesystem.free_gb":       filesystemMetric("free_gb"),
+
+	"gpu.core_usage_percent": itemMetric("gpu", "card", "e->'core_usage_percent'->>'ema'"),
+	"gpu.temperature":        itemMetric("gpu", "card", "e->'temperature'->>'ema'"),
+	"gpu.frequency_mhz":      itemMetric("gpu", "card", "e->'frequency_mhz'->>'ema'"),
+	"gpu.power_watt":         itemMetric("gpu", "card", "e->'power_watt'->>'ema'"),
+	"gpu.vram_used_gb":       itemMetric("gpu", "card", "e->>'vram_used_gb'"),
+	"gpu.vram_percent":       itemMetric("gpu", "card", "e->>'vram_percent'"),
+}
+
+func scalarMetric(value string) string {
+	return fmt.Sprintf("SELECT ''::text, (%s)::float8", value)
+}
+
+// itemMetric reads one series per element of the sample's array, labelled
+// by the element's label field.
+func itemMetric(array, label, value string) string {
+	return fmt.Sprintf("SELECT e->>'%s', (%s)::float8 FROM jsonb_array_elements(%s) e",
+		label, value, jsonArray("data->'"+array+"'"))
+}
+
+// filesystemMetric reads one series per mountpoint, across the disks.
+func filesystemMetric(field string) string {
+	return fmt.Sprintf(`SELECT f->>'mountpoint', (f->>'%s')::float8
+		FROM jsonb_array_elements(%s) d, jsonb_array_elements(%s) f`,
+		field, jsonArray("data->'disk'"), jsonArray("d->'filesystems'"))
+}
+
+// queryMetricSource returns the table and time column a metric is read
+// from at the resolution, and the (label, value) subquery reading it from
+// each row.
+func queryMetricSource(metric string, resolution domain.MetricsResolution) (table, at, expr string, err error) {
+	for _, series := range rollupSeries {
+		if series.metric != metric {
+			continue
+		}
+		if resolution == domain.MetricsRaw {
+			return "server_metrics", "recorded_at", scalarMetric(series.expr), nil
+		}
+		table, err := rollupTable(resolution)
+		return table, "bucket", scalarMetric("t." + series.column + "_avg"), err
+	}
+
+	expr, ok := queryMetrics[metric]
+	if !ok || resolution != domain.MetricsRaw {
+		return "", "", "", fmt.Errorf("%w: %s at %s resolution", domain.ErrInvalidMetricsQuery, metric, resolution)
+	}
+	return "server_metrics", "recorded_at", expr, nil
+}
+
+func (r *MetricsRepository) OldestRollup(ctx context.Context, serverID uuid.UUID, resolution domain.MetricsResolution) (time.Time, bool, error) {
+	table, err := rollupTable(resolution)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	var oldest *time.Time
+	if err := r.db.QueryRow(ctx, `SELECT MIN(bucket) FROM `+table+` WHERE server_id = $1`, serverID).Scan(&oldest); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get oldest metrics rollup: %w", err)
+	}
+	if oldest == nil {
+		return time.Time{}, false, nil
+	}
+	return *oldest, true, nil
+}
+
+func (r *MetricsRepository) QueryMetrics(ctx context.Context, serverID uuid.UUID, resolution domain.MetricsResolution, q domain.MetricsQuery) ([]domain.MetricsQuerySample, error) {
+	table, at, expr, err := queryMetricSource(q.Metric, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT s.label, date_bin(make_interval(secs => $2), t.%[2]s, $3) AS step_at, AVG(s.value)
+		FROM %[1]s t
+		CROSS JOIN LATERAL (%[3]s) AS s(label, value)
+		WHERE t.server_id = $1
+		AND t.%[2]s >= $3
+		AND t.%[2]s < $4
+		AND s.value IS NOT NULL
+		GROUP BY s.label, step_at
+		ORDER BY s.label, step_at
+	`, table, at, expr)
+
+	rows, err := r.db.Query(ctx, query, serverID, q.Step.Seconds(), q.From, q.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query server metrics: %w", err)
+	}
+	defer rows.Close()
+
+	samples := make([]domain.MetricsQuerySample, 0)
+	for rows.Next() {
+		var sample domain.MetricsQuerySample
+		var label *string
+		if err := rows.Scan(&label, &sample.At, &sample.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan metrics sample: %w", err)
+		}
+		if label != nil {
+			sample.Label = *label
+		}
+		samples = append(samples, sample)
+	}
+
+	return samples, rows.Err()
+}
@@ -0,0 +1,3 @@
+-- deployments: the git ref (commit SHA or tag) a deploy was pinned to. NULL
+-- means the tip of the branch; commit_hash still records what was resolved.
+ALTER TABLE deployments ADD COLUMN IF NOT EXISTS ref VARCHAR(255);
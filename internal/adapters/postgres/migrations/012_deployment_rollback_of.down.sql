@@ -0,0 +1,2 @@
+ALTER TABLE deployments DROP CONSTRAINT IF EXISTS fk_deployment_rollback_of;
+ALTER TABLE deployments DROP COLUMN IF EXISTS rollback_of_deployment_id;
@@ -0,0 +1,7 @@
+-- deployments: a rollback records the deployment whose image and env
+-- snapshot it restored.
+ALTER TABLE deployments ADD COLUMN IF NOT EXISTS rollback_of_deployment_id BIGINT;
+
+ALTER TABLE deployments DROP CONSTRAINT IF EXISTS fk_deployment_rollback_of;
+ALTER TABLE deployments ADD CONSTRAINT fk_deployment_rollback_of
+    FOREIGN KEY (rollback_of_deployment_id) REFERENCES deployments(id) ON DELETE SET NULL;
@@ -0,0 +1 @@
+ALTER TABLE applications DROP COLUMN IF EXISTS auto_rollback;
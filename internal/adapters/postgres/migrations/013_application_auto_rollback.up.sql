@@ -0,0 +1,3 @@
+-- applications: opt-in policy to restore the previously running image when a
+-- deploy fails its post-deploy health gate.
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS auto_rollback BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+-- applications: readiness probes (http/tcp/command) the agent runs as part of
+-- the deploy and rollback health gate.
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS readiness_probes JSONB NOT NULL DEFAULT '[]'::jsonb;
@@ -0,0 +1,2 @@
+ALTER TABLE applications DROP COLUMN IF EXISTS post_deploy_commands;
+ALTER TABLE applications DROP COLUMN IF EXISTS pre_deploy_commands;
@@ -0,0 +1,4 @@
+-- applications: release commands run in one-off containers of the new image,
+-- before `compose up` (migrations) and after the app is healthy (warmup).
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS pre_deploy_commands JSONB NOT NULL DEFAULT '[]'::jsonb;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS post_deploy_commands JSONB NOT NULL DEFAULT '[]'::jsonb;
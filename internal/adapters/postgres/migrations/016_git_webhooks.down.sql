@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS git_webhook_deliveries;
+DROP TABLE IF EXISTS git_webhooks;
@@ -0,0 +1,38 @@
+-- git_webhooks: per-application push receiver (POST /hooks/git/{token}).
+-- secret is encrypted at rest like env var values.
+CREATE TABLE IF NOT EXISTS git_webhooks (
+    application_id BIGINT PRIMARY KEY,
+    token VARCHAR(100) NOT NULL,
+    secret TEXT NOT NULL,
+    auto_deploy BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT fk_git_webhook_app FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_git_webhooks_token ON git_webhooks(token);
+
+-- git_webhook_deliveries: every received delivery and its outcome. dedupe_key
+-- is only set on the delivery that claimed it, so redeliveries conflict while
+-- the duplicates themselves are still logged.
+CREATE TABLE IF NOT EXISTS git_webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    application_id BIGINT NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    delivery_id VARCHAR(100) NOT NULL,
+    dedupe_key VARCHAR(100),
+    event VARCHAR(100) NOT NULL DEFAULT '',
+    branch VARCHAR(255) NOT NULL DEFAULT '',
+    commit_hash VARCHAR(64) NOT NULL DEFAULT '',
+    outcome VARCHAR(20) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    deployment_id BIGINT,
+    received_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT fk_git_delivery_app FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    CONSTRAINT fk_git_delivery_deployment FOREIGN KEY (deployment_id) REFERENCES deployments(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_git_deliveries_dedupe ON git_webhook_deliveries(application_id, dedupe_key);
+CREATE INDEX IF NOT EXISTS idx_git_deliveries_app_received ON git_webhook_deliveries(application_id, received_at DESC);
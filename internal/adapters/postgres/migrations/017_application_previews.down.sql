@@ -0,0 +1,10 @@
+ALTER TABLE git_webhook_deliveries DROP COLUMN IF EXISTS pull_request;
+
+DROP INDEX IF EXISTS idx_apps_preview_expires_at;
+DROP INDEX IF EXISTS idx_unique_apps_preview;
+
+ALTER TABLE applications DROP COLUMN IF EXISTS preview_expires_at;
+ALTER TABLE applications DROP COLUMN IF EXISTS preview_number;
+ALTER TABLE applications DROP COLUMN IF EXISTS parent_application_id;
+ALTER TABLE applications DROP COLUMN IF EXISTS preview_ttl_hours;
+ALTER TABLE applications DROP COLUMN IF EXISTS previews_enabled;
@@ -0,0 +1,14 @@
+-- applications: per-pull-request preview environments. A preview is a child
+-- application of the app it previews, keyed by PR number, and is torn down
+-- once preview_expires_at passes. preview_ttl_hours = 0 means the default.
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS previews_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS preview_ttl_hours INT NOT NULL DEFAULT 0;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS parent_application_id BIGINT REFERENCES applications(id) ON DELETE CASCADE;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS preview_number INT;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS preview_expires_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_unique_apps_preview ON applications(parent_application_id, preview_number) WHERE deleted_at IS NULL AND parent_application_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_apps_preview_expires_at ON applications(preview_expires_at) WHERE deleted_at IS NULL AND preview_expires_at IS NOT NULL;
+
+-- git_webhook_deliveries: the PR a pull/merge request delivery was about.
+ALTER TABLE git_webhook_deliveries ADD COLUMN IF NOT EXISTS pull_request INT;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS application_git_credentials;
@@ -0,0 +1,14 @@
+-- application_git_credentials: per-application git auth handed to the agent
+-- on deploy. ssh_private_key and https_token are encrypted at rest like env
+-- var values; the public key is what gets pasted into the forge.
+CREATE TABLE IF NOT EXISTS application_git_credentials (
+    application_id BIGINT PRIMARY KEY,
+    ssh_public_key TEXT NOT NULL DEFAULT '',
+    ssh_private_key TEXT NOT NULL DEFAULT '',
+    https_username VARCHAR(255) NOT NULL DEFAULT '',
+    https_token TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT fk_git_credentials_app FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- applications: the compose stack to run (files, project dir, profiles).
+-- '{}' keeps auto-detection of a single compose file at the repo root.
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS compose JSONB NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS application_registry_credentials;
+ALTER TABLE deployments DROP COLUMN IF EXISTS image_digest;
+ALTER TABLE applications DROP COLUMN IF EXISTS compose_definition;
+ALTER TABLE applications DROP COLUMN IF EXISTS image;
+ALTER TABLE applications DROP COLUMN IF EXISTS source_type;
@@ -0,0 +1,23 @@
+-- applications: where a deploy gets its image. 'git' clones and builds the
+-- repository; 'image' pulls image from a registry and runs it with
+-- compose_definition, or a generated single-service compose file when empty.
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS source_type VARCHAR(20) NOT NULL DEFAULT 'git';
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS image VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS compose_definition TEXT NOT NULL DEFAULT '';
+
+-- deployments: the digest-pinned image an image deploy ran, so a rollback
+-- replays exactly that image.
+ALTER TABLE deployments ADD COLUMN IF NOT EXISTS image_digest VARCHAR(512);
+
+-- application_registry_credentials: the login the agent pulls an image app's
+-- image with. password is encrypted at rest like env var values.
+CREATE TABLE IF NOT EXISTS application_registry_credentials (
+    application_id BIGINT PRIMARY KEY,
+    registry VARCHAR(255) NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    password TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT fk_registry_credentials_app FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE
+);
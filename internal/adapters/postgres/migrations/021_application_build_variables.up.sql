@@ -0,0 +1,16 @@
+-- application_build_variables: values only `compose build` sees. Build args
+-- are passed as --build-arg, secrets (is_secret) are mounted as BuildKit
+-- secret files. value is encrypted at rest like env var values.
+CREATE TABLE IF NOT EXISTS application_build_variables (
+    id BIGSERIAL PRIMARY KEY,
+    application_id BIGINT NOT NULL,
+    key VARCHAR(128) NOT NULL,
+    value TEXT NOT NULL,
+    value_encrypted BOOLEAN NOT NULL DEFAULT FALSE,
+    is_secret BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT fk_build_variables_app FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    CONSTRAINT uq_build_variables_app_key UNIQUE (application_id, key)
+);
@@ -0,0 +1,10 @@
+-- agent_spools: the last record applied from each agent's on-disk spool, so
+-- reports the agent replays after an outage are applied once.
+CREATE TABLE IF NOT EXISTS agent_spools (
+    server_id UUID PRIMARY KEY,
+    spool_id UUID NOT NULL,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT fk_agent_spools_server FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS payload_version;
+ALTER TABLE servers DROP COLUMN IF EXISTS agent_info;
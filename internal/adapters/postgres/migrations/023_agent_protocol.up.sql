@@ -0,0 +1,5 @@
+-- agent_info: the version and job types (with payload versions) the agent
+-- advertised on its last connect. NULL for agents that predate the hello.
+-- payload_version: the schema version each job's payload was written at.
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS agent_info JSONB;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS payload_version INT NOT NULL DEFAULT 1;
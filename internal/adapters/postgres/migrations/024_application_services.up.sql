@@ -0,0 +1,3 @@
+-- services: each compose service of an app (state, health, ports, image) as
+-- the agent's last health check saw it.
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS services JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS server_metrics_daily;
+DROP TABLE IF EXISTS server_metrics_hourly;
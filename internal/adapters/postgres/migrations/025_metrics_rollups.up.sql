@@ -0,0 +1,82 @@
+-- server_metrics_hourly / _daily: min, avg, max and p95 of each server's raw
+-- samples per hour and per UTC day, kept long after the raw rows are deleted.
+-- gpu_* is NULL for servers without a GPU.
+CREATE TABLE IF NOT EXISTS server_metrics_hourly (
+    server_id UUID NOT NULL,
+    bucket TIMESTAMPTZ NOT NULL,
+    samples INTEGER NOT NULL,
+
+    cpu_min DOUBLE PRECISION,
+    cpu_avg DOUBLE PRECISION,
+    cpu_max DOUBLE PRECISION,
+    cpu_p95 DOUBLE PRECISION,
+    memory_min DOUBLE PRECISION,
+    memory_avg DOUBLE PRECISION,
+    memory_max DOUBLE PRECISION,
+    memory_p95 DOUBLE PRECISION,
+    disk_read_min DOUBLE PRECISION,
+    disk_read_avg DOUBLE PRECISION,
+    disk_read_max DOUBLE PRECISION,
+    disk_read_p95 DOUBLE PRECISION,
+    disk_write_min DOUBLE PRECISION,
+    disk_write_avg DOUBLE PRECISION,
+    disk_write_max DOUBLE PRECISION,
+    disk_write_p95 DOUBLE PRECISION,
+    net_rx_min DOUBLE PRECISION,
+    net_rx_avg DOUBLE PRECISION,
+    net_rx_max DOUBLE PRECISION,
+    net_rx_p95 DOUBLE PRECISION,
+    net_tx_min DOUBLE PRECISION,
+    net_tx_avg DOUBLE PRECISION,
+    net_tx_max DOUBLE PRECISION,
+    net_tx_p95 DOUBLE PRECISION,
+    gpu_min DOUBLE PRECISION,
+    gpu_avg DOUBLE PRECISION,
+    gpu_max DOUBLE PRECISION,
+    gpu_p95 DOUBLE PRECISION,
+
+    PRIMARY KEY (server_id, bucket),
+    CONSTRAINT fk_server_metrics_hourly_server FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_metrics_hourly_bucket ON server_metrics_hourly (bucket);
+
+CREATE TABLE IF NOT EXISTS server_metrics_daily (
+    server_id UUID NOT NULL,
+    bucket TIMESTAMPTZ NOT NULL,
+    samples INTEGER NOT NULL,
+
+    cpu_min DOUBLE PRECISION,
+    cpu_avg DOUBLE PRECISION,
+    cpu_max DOUBLE PRECISION,
+    cpu_p95 DOUBLE PRECISION,
+    memory_min DOUBLE PRECISION,
+    memory_avg DOUBLE PRECISION,
+    memory_max DOUBLE PRECISION,
+    memory_p95 DOUBLE PRECISION,
+    disk_read_min DOUBLE PRECISION,
+    disk_read_avg DOUBLE PRECISION,
+    disk_read_max DOUBLE PRECISION,
+    disk_read_p95 DOUBLE PRECISION,
+    disk_write_min DOUBLE PRECISION,
+    disk_write_avg DOUBLE PRECISION,
+    disk_write_max DOUBLE PRECISION,
+    disk_write_p95 DOUBLE PRECISION,
+    net_rx_min DOUBLE PRECISION,
+    net_rx_avg DOUBLE PRECISION,
+    net_rx_max DOUBLE PRECISION,
+    net_rx_p95 DOUBLE PRECISION,
+    net_tx_min DOUBLE PRECISION,
+    net_tx_avg DOUBLE PRECISION,
+    net_tx_max DOUBLE PRECISION,
+    net_tx_p95 DOUBLE PRECISION,
+    gpu_min DOUBLE PRECISION,
+    gpu_avg DOUBLE PRECISION,
+    gpu_max DOUBLE PRECISION,
+    gpu_p95 DOUBLE PRECISION,
+
+    PRIMARY KEY (server_id, bucket),
+    CONSTRAINT fk_server_metrics_daily_server FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_metrics_daily_bucket ON server_metrics_daily (bucket);
@@ -0,0 +1 @@
+ALTER TABLE environment_variables DROP COLUMN IF EXISTS is_secret;
@@ -0,0 +1,3 @@
+-- Secret env vars are masked by the agent in job output; other values are
+-- left readable in deploy logs.
+ALTER TABLE environment_variables ADD COLUMN IF NOT EXISTS is_secret BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/security"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RegistryCredentialRepository struct {
+	db     *pgxpool.Pool
+	encKey []byte
+}
+
+func NewRegistryCredentialRepository(db *pgxpool.Pool, encKey []byte) domain.RegistryCredentialRepository {
+	return &RegistryCredentialRepository{db: db, encKey: encKey}
+}
+
+func (r *RegistryCredentialRepository) GetByApplicationID(ctx context.Context, appID int64) (*domain.ApplicationRegistryCredentials, error) {
+	query := `
+		SELECT
+			c.application_id,
+			c.registry,
+			c.username,
+			c.password,
+			c.created_at,
+			c.updated_at
+		FROM application_registry_credentials c
+		JOIN applications a ON a.id = c.application_id AND a.deleted_at IS NULL
+		WHERE c.application_id = $1
+	`
+
+	var (
+		creds    domain.ApplicationRegistryCredentials
+		password string
+	)
+	err := r.db.QueryRow(ctx, query, appID).Scan(
+		&creds.ApplicationID,
+		&creds.Registry,
+		&creds.Username,
+		&password,
+		&creds.CreatedAt,
+		&creds.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrRegistryCredentialsNotFound
+		}
+		return nil, fmt.Errorf("failed to get registry credentials: %w", err)
+	}
+
+	if creds.Password, err = r.decrypt(password); err != nil {
+		return nil, fmt.Errorf("failed to decrypt registry password: %w", err)
+	}
+
+	return &creds, nil
+}
+
+func (r *RegistryCredentialRepository) Save(ctx context.Context, creds *domain.ApplicationRegistryCredentials) error {
+	encPassword, err := r.encrypt(creds.Password)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt registry password: %w", err)
+	}
+
+	query := `
+		INSERT INTO application_registry_credentials (application_id, registry, username, password, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (application_id) DO UPDATE SET
+			registry = EXCLUDED.registry,
+			username = EXCLUDED.username,
+			password = EXCLUDED.password,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.Exec(ctx, query, creds.ApplicationID, creds.Registry, creds.Username, encPassword, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to save registry credentials: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RegistryCredentialRepository) Delete(ctx context.Context, appID int64) error {
+	query := `DELETE FROM application_registry_credentials WHERE application_id = $1`
+
+	ct, err := r.db.Exec(ctx, query, appID)
+	if err != nil {
+		return fmt.Errorf("failed to delete registry credentials: %w", err)
+	}
+
+	if ct.RowsAffected() == 0 {
+		return domain.ErrRegistryCredentialsNotFound
+	}
+
+	return nil
+}
+
+func (r *RegistryCredentialRepository) encrypt(value string) (string, error) {
+	if len(r.encKey) == 0 {
+		return value, nil
+	}
+	return security.Encrypt(value, r.encKey)
+}
+
+func (r *RegistryCredentialRepository) decrypt(value string) (string, error) {
+	if len(r.encKey) == 0 {
+		return value, nil
+	}
+	return security.Decrypt(value, r.encKey)
+}
@@ -30,6 +30,7 @@ func (r *ServerRepository) List(ctx context.Context, opts domain.ServerListOptio
 			COALESCE(s.ip_address::text, ''),
 			s.is_online,
 			s.os_info,
+			s.agent_info,
 			s.created_at,
 			s.updated_at,
 			(SELECT COUNT(*) FROM applications a WHERE a.server_id = s.id AND a.deleted_at IS NULL) AS application_count
@@ -96,6 +97,7 @@ func (r *ServerRepository) List(ctx context.Context, opts domain.ServerListOptio
 			&s.IPAddress,
 			&s.IsOnline,
 			&s.OSInfo,
+			&s.AgentInfo,
 			&s.CreatedAt,
 			&s.UpdatedAt,
 			&s.ApplicationCount,
@@ -122,6 +124,7 @@ func (r *ServerRepository) GetByID(ctx context.Context, serverID uuid.UUID) (*do
 			api_token,
 			is_online,
 			os_info,
+			agent_info,
 			created_at,
 			updated_at
 		FROM servers
@@ -136,6 +139,7 @@ func (r *ServerRepository) GetByID(ctx context.Context, serverID uuid.UUID) (*do
 		&s.APIToken,
 		&s.IsOnline,
 		&s.OSInfo,
+		&s.AgentInfo,
 		&s.CreatedAt,
 		&s.UpdatedAt,
 	)
@@ -157,6 +161,7 @@ func (r *ServerRepository) GetByToken(ctx context.Context, token string) (*domai
 			COALESCE(ip_address::text, ''),
 			is_online,
 			os_info,
+			agent_info,
 			created_at,
 			updated_at
 		FROM servers
@@ -170,6 +175,7 @@ func (r *ServerRepository) GetByToken(ctx context.Context, token string) (*domai
 		&s.IPAddress,
 		&s.IsOnline,
 		&s.OSInfo,
+		&s.AgentInfo,
 		&s.CreatedAt,
 		&s.UpdatedAt,
 	)
@@ -261,6 +267,26 @@ func (r *ServerRepository) UpdateOSInfo(ctx context.Context, serverID uuid.UUID,
 	return nil
 }
 
+func (r *ServerRepository) UpdateAgentInfo(ctx context.Context, serverID uuid.UUID, info domain.AgentInfo) error {
+	query := `
+		UPDATE servers
+		SET agent_info = $2, updated_at = $3
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	now := time.Now().UTC()
+	info.ReportedAt = now
+	ct, err := r.db.Exec(ctx, query, serverID, info, now)
+	if err != nil {
+		return fmt.Errorf("failed to update server agent info: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("server with ID %s not found or deleted", serverID.String())
+	}
+
+	return nil
+}
+
 func (r *ServerRepository) Delete(ctx context.Context, serverID uuid.UUID) error {
 	query := `UPDATE servers SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
 
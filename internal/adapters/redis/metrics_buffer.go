@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// metricsBufferMaxLen caps the agent's metrics stream.
+const metricsBufferMaxLen = 5000
+
+// MetricsBuffer keeps an agent's samples in a capped Redis stream. It is the
+// opt-in alternative to the agent's file buffer, for hosts that already run
+// Redis.
+type MetricsBuffer struct {
+	registry *Registry
+	stream   string
+}
+
+func NewMetricsBuffer(registry *Registry, serverID uuid.UUID) domain.MetricsBuffer {
+	return &MetricsBuffer{
+		registry: registry,
+		stream:   fmt.Sprintf("metrics:agent:%s:stream", serverID.String()),
+	}
+}
+
+func (b *MetricsBuffer) Append(ctx context.Context, m *domain.Metrics) error {
+	_, err := b.registry.Append(ctx, b.stream, m, metricsBufferMaxLen)
+	return err
+}
+
+func (b *MetricsBuffer) Recent(ctx context.Context, n int) ([]domain.Metrics, error) {
+	msgs, err := b.registry.GetRangeDesc(ctx, b.stream, int64(n))
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, _, err := ParseStreamMessages[domain.Metrics](msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(metrics)
+	return metrics, nil
+}
@@ -88,8 +88,8 @@ func (n *Notifier) run() {
 }
 
 // Handle implements the event bus subscriber signature.
-// It only reacts to terminal deployment states (success/failed) to avoid
-// spamming on every intermediate transition.
+// It only reacts to terminal deployment states (success/failed/rolled_back)
+// to avoid spamming on every intermediate transition.
 func (n *Notifier) Handle(event any) {
 	ws := n.getSettings()
 	if !ws.Enabled || ws.URL == "" {
@@ -102,7 +102,7 @@ func (n *Notifier) Handle(event any) {
 	}
 
 	switch evt.Status {
-	case domain.DeploymentSuccess, domain.DeploymentFailed:
+	case domain.DeploymentSuccess, domain.DeploymentFailed, domain.DeploymentRolledBack:
 	default:
 		return
 	}
@@ -110,9 +110,13 @@ func (n *Notifier) Handle(event any) {
 	appName := n.appName(context.Background(), evt.ApplicationID)
 	emoji := "✅"
 	statusText := "succeeded"
-	if evt.Status == domain.DeploymentFailed {
+	switch evt.Status {
+	case domain.DeploymentFailed:
 		emoji = "❌"
 		statusText = "failed"
+	case domain.DeploymentRolledBack:
+		emoji = "↩️"
+		statusText = "failed its health gate and was automatically rolled back to the previous release"
 	}
 
 	msg := fmt.Sprintf(
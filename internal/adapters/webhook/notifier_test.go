@@ -41,12 +41,15 @@ func (f *fakeAppSvc) UpdateHealth(ctx context.Context, serverID uuid.UUID, repor
 func (f *fakeAppSvc) Delete(ctx context.Context, appID int64) error {
 	return nil
 }
-func (f *fakeAppSvc) Deploy(ctx context.Context, appID int64, deployedBy int64) (*domain.Deployment, error) {
+func (f *fakeAppSvc) Deploy(ctx context.Context, appID int64, req domain.ApplicationDeployRequest, deployedBy int64) (*domain.Deployment, error) {
 	return nil, nil
 }
 func (f *fakeAppSvc) Rollback(ctx context.Context, appID int64, deployedBy int64) (*domain.Deployment, error) {
 	return nil, nil
 }
+func (f *fakeAppSvc) RollbackTo(ctx context.Context, appID int64, deploymentID int64, deployedBy int64) (*domain.Deployment, error) {
+	return nil, nil
+}
 func (f *fakeAppSvc) Start(ctx context.Context, appID int64) error {
 	return nil
 }
@@ -56,6 +59,12 @@ func (f *fakeAppSvc) Stop(ctx context.Context, appID int64) error {
 func (f *fakeAppSvc) Restart(ctx context.Context, appID int64) error {
 	return nil
 }
+func (f *fakeAppSvc) ListServices(ctx context.Context, appID int64) ([]domain.ServiceHealth, error) {
+	return nil, nil
+}
+func (f *fakeAppSvc) ServiceAction(ctx context.Context, appID int64, service string, action domain.ServiceAction, replicas int) error {
+	return nil
+}
 func (f *fakeAppSvc) ListEnvVars(ctx context.Context, appID int64) ([]domain.EnvironmentVariable, error) {
 	return nil, nil
 }
@@ -167,6 +176,28 @@ func TestNotifierPostsOnSuccessDeployment(t *testing.T) {
 	}
 }
 
+func TestNotifierPostsOnAutoRollback(t *testing.T) {
+	var captured captureHandler
+	srv := httptest.NewServer(&captured)
+	defer srv.Close()
+
+	n := New(settings(enabled(srv.URL)), &fakeAppSvc{}, nil)
+	n.Handle(domain.EventDeploymentStatusChanged{
+		DeploymentID:  44,
+		ApplicationID: 5,
+		Status:        domain.DeploymentRolledBack,
+	})
+
+	waitForRequests(t, &captured, 1)
+
+	captured.mu.Lock()
+	defer captured.mu.Unlock()
+	content, _ := captured.body["content"].(string)
+	if !strings.Contains(content, "deployment #44") || !strings.Contains(content, "automatically rolled back") {
+		t.Fatalf("unexpected content: %q", content)
+	}
+}
+
 func TestNotifierSkipsIntermediateStatus(t *testing.T) {
 	var captured captureHandler
 	srv := httptest.NewServer(&captured)
@@ -8,6 +8,7 @@ import (
 
 	"horizonx/internal/domain"
 	"horizonx/internal/logger"
+	"horizonx/internal/version"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
@@ -17,7 +18,7 @@ const (
 	writeWait      = 10 * time.Second
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 8192
+	maxMessageSize = 256 << 10 // container log batches, JSON-escaped, are the largest agent frames
 )
 
 type Client struct {
@@ -28,13 +29,15 @@ type Client struct {
 	conn *websocket.Conn
 	send chan []byte
 
-	log logger.Logger
-	svc domain.ServerService
+	log   logger.Logger
+	svc   domain.ServerService
+	logs  domain.ContainerLogService
+	execs domain.ExecService
 
 	ID uuid.UUID
 }
 
-func NewClient(hub *Router, conn *websocket.Conn, log logger.Logger, svc domain.ServerService, cID uuid.UUID) *Client {
+func NewClient(hub *Router, conn *websocket.Conn, log logger.Logger, svc domain.ServerService, logs domain.ContainerLogService, execs domain.ExecService, cID uuid.UUID) *Client {
 	ctx, cancel := context.WithCancel(hub.ctx)
 
 	return &Client{
@@ -45,8 +48,10 @@ func NewClient(hub *Router, conn *websocket.Conn, log logger.Logger, svc domain.
 		conn: conn,
 		send: make(chan []byte, 256),
 
-		log: log,
-		svc: svc,
+		log:   log,
+		svc:   svc,
+		logs:  logs,
+		execs: execs,
 
 		ID: cID,
 	}
@@ -104,6 +109,53 @@ func (a *Client) readPump() {
 					break
 				}
 
+			case "agent_hello":
+				var info domain.AgentInfo
+				if err := json.Unmarshal(msg.Payload, &info); err != nil {
+					a.log.Error("ws: failed to unmarshal agent hello payload", "error", err)
+					break
+				}
+
+				if err := a.svc.UpdateAgentInfo(context.Background(), a.ID, info); err != nil {
+					a.log.Error("ws: failed to update server agent info", "error", err)
+					break
+				}
+
+				if info.Outdated(version.Version) {
+					a.log.Warn("ws: agent is outdated",
+						"server_id", a.ID,
+						"agent_version", info.Version,
+						"server_version", version.Version,
+					)
+				}
+
+			case "container_logs":
+				var batch domain.ContainerLogsBatch
+				if err := json.Unmarshal(msg.Payload, &batch); err != nil {
+					a.log.Error("ws: failed to unmarshal container logs payload", "error", err)
+					break
+				}
+
+				a.logs.HandleBatch(a.ID, batch)
+
+			case "exec_output":
+				var output domain.ExecOutput
+				if err := json.Unmarshal(msg.Payload, &output); err != nil {
+					a.log.Error("ws: failed to unmarshal exec output payload", "error", err)
+					break
+				}
+
+				a.execs.HandleOutput(a.ID, output)
+
+			case "exec_exit":
+				var exit domain.ExecExit
+				if err := json.Unmarshal(msg.Payload, &exit); err != nil {
+					a.log.Error("ws: failed to unmarshal exec exit payload", "error", err)
+					break
+				}
+
+				a.execs.HandleExit(a.ID, exit)
+
 			default:
 				a.log.Debug("ws: unknown agent message event", "event", msg.Event)
 			}
@@ -15,9 +15,11 @@ type Handler struct {
 	upgrader websocket.Upgrader
 	log      logger.Logger
 	svc      domain.ServerService
+	logs     domain.ContainerLogService
+	execs    domain.ExecService
 }
 
-func NewHandler(router *Router, log logger.Logger, svc domain.ServerService) *Handler {
+func NewHandler(router *Router, log logger.Logger, svc domain.ServerService, logs domain.ContainerLogService, execs domain.ExecService) *Handler {
 	upgrader := websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
 			return true
@@ -29,6 +31,8 @@ func NewHandler(router *Router, log logger.Logger, svc domain.ServerService) *Ha
 		upgrader: upgrader,
 		log:      log,
 		svc:      svc,
+		logs:     logs,
+		execs:    execs,
 	}
 }
 
@@ -69,7 +73,7 @@ func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	a := NewClient(h.router, conn, h.log, h.svc, serverID)
+	a := NewClient(h.router, conn, h.log, h.svc, h.logs, h.execs, serverID)
 	a.hub.register <- a
 
 	go a.writePump()
@@ -2,7 +2,9 @@ package agentws
 
 import (
 	"context"
+	"encoding/json"
 
+	"horizonx/internal/domain"
 	"horizonx/internal/logger"
 
 	"github.com/google/uuid"
@@ -16,10 +18,19 @@ type Router struct {
 
 	register   chan *Client
 	unregister chan *Client
+	outbox     chan *outboundMessage
 
 	log logger.Logger
 }
 
+// outboundMessage is a pre-encoded server → agent frame waiting for Run to
+// hand it to the target agent's send buffer.
+type outboundMessage struct {
+	serverID uuid.UUID
+	event    string
+	data     []byte
+}
+
 func NewRouter(parent context.Context, log logger.Logger) *Router {
 	ctx, cancel := context.WithCancel(parent)
 
@@ -29,6 +40,7 @@ func NewRouter(parent context.Context, log logger.Logger) *Router {
 		agents:     make(map[uuid.UUID]*Client),
 		register:   make(chan *Client, 64),
 		unregister: make(chan *Client, 64),
+		outbox:     make(chan *outboundMessage, 256),
 		log:        log,
 	}
 }
@@ -44,18 +56,37 @@ func (r *Router) Run() {
 			return
 
 		case a := <-r.register:
+			// A reconnecting agent can register before its previous
+			// connection unregisters; close the stale one so it stops
+			// receiving frames meant for the live socket.
+			if prev, ok := r.agents[a.ID]; ok && prev != a {
+				close(prev.send)
+			}
 			r.agents[a.ID] = a
 			a.log.Info("ws: agent registered", "id", a.ID)
 
 		case a := <-r.unregister:
 			agent, ok := r.agents[a.ID]
-			if !ok {
+			if !ok || agent != a {
 				continue
 			}
 
 			delete(r.agents, a.ID)
 			close(agent.send)
 			r.log.Info("ws: agent unregistered", "id", a.ID)
+
+		case msg := <-r.outbox:
+			agent, ok := r.agents[msg.serverID]
+			if !ok {
+				r.log.Debug("ws: agent not connected, message left for polling", "server_id", msg.serverID, "event", msg.event)
+				continue
+			}
+
+			select {
+			case agent.send <- msg.data:
+			default:
+				r.log.Warn("ws: agent send buffer full, dropping message", "server_id", msg.serverID, "event", msg.event)
+			}
 		}
 	}
 }
@@ -63,3 +94,83 @@ func (r *Router) Run() {
 func (r *Router) Stop() {
 	r.cancel()
 }
+
+// Send queues an event for the agent connected as serverID. It never blocks:
+// when the agent is offline or the router is backed up the message is
+// dropped, so callers must only push work that the agent can also recover
+// through its HTTP endpoints.
+func (r *Router) Send(serverID uuid.UUID, event string, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&domain.WsServerMessage{
+		TargetServerID: serverID,
+		Event:          event,
+		Payload:        raw,
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case r.outbox <- &outboundMessage{serverID: serverID, event: event, data: data}:
+	case <-r.ctx.Done():
+	default:
+		r.log.Warn("ws: agent outbox full, dropping message", "server_id", serverID, "event", event)
+	}
+
+	return nil
+}
+
+// Dispatch pushes a queued job to its agent so it starts right away instead
+// of waiting for the next poll. Implements domain.JobDispatcher.
+func (r *Router) Dispatch(job *domain.Job) {
+	if err := r.Send(job.ServerID, "job_dispatch", job); err != nil {
+		r.log.Error("ws: failed to encode job dispatch", "job_id", job.ID, "error", err)
+	}
+}
+
+// CancelJob tells the agent running the job to stop it. Implements
+// domain.JobDispatcher.
+func (r *Router) CancelJob(job *domain.Job) {
+	if err := r.Send(job.ServerID, "job_cancel", domain.JobCancelSignal{JobID: job.ID}); err != nil {
+		r.log.Error("ws: failed to encode job cancel", "job_id", job.ID, "error", err)
+	}
+}
+
+// StartContainerLogs asks the agent to stream an app's container logs.
+// Implements domain.ContainerLogsTransport.
+func (r *Router) StartContainerLogs(serverID uuid.UUID, req domain.ContainerLogsRequest) error {
+	return r.Send(serverID, "container_logs_start", req)
+}
+
+// StopContainerLogs ends a stream started by StartContainerLogs. Implements
+// domain.ContainerLogsTransport.
+func (r *Router) StopContainerLogs(serverID uuid.UUID, id string) error {
+	return r.Send(serverID, "container_logs_stop", domain.ContainerLogsStop{ID: id})
+}
+
+// StartExec asks the agent to open a shell in an app container. Implements
+// domain.ExecTransport.
+func (r *Router) StartExec(serverID uuid.UUID, req domain.ExecStart) error {
+	return r.Send(serverID, "exec_start", req)
+}
+
+// SendExecInput types into a shell opened by StartExec. Implements
+// domain.ExecTransport.
+func (r *Router) SendExecInput(serverID uuid.UUID, input domain.ExecInput) error {
+	return r.Send(serverID, "exec_input", input)
+}
+
+// ResizeExec resizes a shell's terminal. Implements domain.ExecTransport.
+func (r *Router) ResizeExec(serverID uuid.UUID, resize domain.ExecResize) error {
+	return r.Send(serverID, "exec_resize", resize)
+}
+
+// CloseExec kills a shell opened by StartExec. Implements
+// domain.ExecTransport.
+func (r *Router) CloseExec(serverID uuid.UUID, id string) error {
+	return r.Send(serverID, "exec_close", domain.ExecClose{ID: id})
+}
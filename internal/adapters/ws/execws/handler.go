@@ -0,0 +1,204 @@
+// Package execws serves interactive shells into app containers. Each socket
+// is one session: binary frames carry the terminal's input and output, text
+// frames carry control messages.
+package execws
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = (pongWait * 9) / 10
+	maxMessageSize = 64 << 10 // a pasted block of input
+)
+
+// control is a text frame. The client sends {"type":"resize","cols","rows"};
+// the server ends the session with {"type":"exit","exit_code","reason"}.
+type control struct {
+	Type string `json:"type"`
+	Cols uint16 `json:"cols,omitempty"`
+	Rows uint16 `json:"rows,omitempty"`
+
+	domain.ExecResult
+}
+
+type Handler struct {
+	svc      domain.ExecService
+	upgrader websocket.Upgrader
+	log      logger.Logger
+}
+
+func NewHandler(svc domain.ExecService, log logger.Logger, allowedOrigins []string) *Handler {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+
+			allowed := slices.Contains(allowedOrigins, origin)
+			if !allowed {
+				log.Warn("ws exec: origin rejected", "origin", origin)
+			}
+
+			return allowed
+		},
+	}
+
+	return &Handler{
+		svc:      svc,
+		upgrader: upgrader,
+		log:      log,
+	}
+}
+
+// Serve opens a shell in the app's container:
+// GET /ws/applications/{id}/exec?service=web&shell=sh&cols=80&rows=24.
+func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
+	user, ok := domain.GetUserContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	appID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid application id", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := domain.ExecOptions{
+		Service: query.Get("service"),
+		Shell:   query.Get("shell"),
+		Cols:    parseSize(query.Get("cols")),
+		Rows:    parseSize(query.Get("rows")),
+	}
+	if err := opts.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.log.Error("ws exec: upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Browsers cannot read the status of a failed handshake, so errors from
+	// here on end the socket with an exit message instead.
+	sess, err := h.svc.Open(r.Context(), user.ID, appID, opts)
+	if err != nil {
+		h.log.Warn("ws exec: failed to open session", "application_id", appID, "service", opts.Service, "error", err)
+		h.finish(conn, domain.ExecResult{Reason: openError(err)})
+		return
+	}
+
+	go h.readPump(conn, sess)
+	h.writePump(conn, sess)
+}
+
+// readPump relays the client's input and resizes until the socket closes,
+// which ends the session.
+func (h *Handler) readPump(conn *websocket.Conn, sess domain.ExecSession) {
+	defer sess.Close("client disconnected")
+
+	conn.SetReadLimit(maxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		switch kind {
+		case websocket.BinaryMessage:
+			if err := sess.Write(data); err != nil {
+				return
+			}
+
+		case websocket.TextMessage:
+			var msg control
+			if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "resize" {
+				h.log.Debug("ws exec: unknown control message", "session_id", sess.ID())
+				continue
+			}
+			if err := sess.Resize(msg.Cols, msg.Rows); errors.Is(err, domain.ErrExecSessionClosed) {
+				return
+			}
+		}
+	}
+}
+
+// writePump relays the terminal's output until the session ends, then tells
+// the client how.
+func (h *Handler) writePump(conn *websocket.Conn, sess domain.ExecSession) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case data, ok := <-sess.Output():
+			if !ok {
+				h.finish(conn, sess.Result())
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+				sess.Close("client disconnected")
+				return
+			}
+
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				sess.Close("client disconnected")
+				return
+			}
+		}
+	}
+}
+
+func (h *Handler) finish(conn *websocket.Conn, result domain.ExecResult) {
+	_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+	_ = conn.WriteJSON(control{Type: "exit", ExecResult: result})
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+// openError is what the client is told when the session could not start.
+func openError(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrApplicationNotFound):
+		return "application not found"
+	case errors.Is(err, domain.ErrServerOffline):
+		return "server is offline"
+	default:
+		return "failed to open session"
+	}
+}
+
+func parseSize(raw string) uint16 {
+	n, err := strconv.ParseUint(raw, 10, 16)
+	if err != nil {
+		return 0
+	}
+	return uint16(n)
+}
@@ -0,0 +1,156 @@
+package execws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/gorilla/websocket"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Debug(string, ...any) {}
+func (noopLogger) Info(string, ...any)  {}
+func (noopLogger) Warn(string, ...any)  {}
+func (noopLogger) Error(string, ...any) {}
+
+// echoSession prints back what is typed and exits once "exit" is.
+type echoSession struct {
+	output  chan []byte
+	resizes chan [2]uint16
+	closed  chan string
+	result  domain.ExecResult
+}
+
+func (e *echoSession) ID() string                { return "e1" }
+func (e *echoSession) Output() <-chan []byte     { return e.output }
+func (e *echoSession) Result() domain.ExecResult { return e.result }
+func (e *echoSession) Close(reason string)       { e.closed <- reason }
+func (e *echoSession) Resize(cols, rows uint16) error {
+	e.resizes <- [2]uint16{cols, rows}
+	return nil
+}
+
+func (e *echoSession) Write(data []byte) error {
+	e.output <- data
+	if string(data) == "exit" {
+		code := 0
+		e.result = domain.ExecResult{ExitCode: &code, Reason: "shell exited"}
+		close(e.output)
+	}
+	return nil
+}
+
+type fakeExecService struct {
+	domain.ExecService
+	sess *echoSession
+	opts chan domain.ExecOptions
+}
+
+func (f *fakeExecService) Open(_ context.Context, userID int64, appID int64, opts domain.ExecOptions) (domain.ExecSession, error) {
+	if appID != 7 {
+		return nil, domain.ErrApplicationNotFound
+	}
+	f.opts <- opts
+	return f.sess, nil
+}
+
+func newTestServer(t *testing.T, svc domain.ExecService) *httptest.Server {
+	h := NewHandler(svc, noopLogger{}, []string{"https://dash.example"})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws/applications/{id}/exec", func(w http.ResponseWriter, r *http.Request) {
+		ctx := domain.SetUserContext(r.Context(), domain.UserContext{ID: 5})
+		h.Serve(w, r.WithContext(ctx))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dial(t *testing.T, srv *httptest.Server, path string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+path, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestServeRelaysTerminalUntilExit(t *testing.T) {
+	sess := &echoSession{output: make(chan []byte, 8), resizes: make(chan [2]uint16, 1), closed: make(chan string, 2)}
+	svc := &fakeExecService{sess: sess, opts: make(chan domain.ExecOptions, 1)}
+	conn := dial(t, newTestServer(t, svc), "/ws/applications/7/exec?service=web&shell=bash&cols=100&rows=30")
+
+	if opts := <-svc.opts; opts.Service != "web" || opts.Shell != "bash" || opts.Cols != 100 || opts.Rows != 30 {
+		t.Fatalf("opts = %+v", opts)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","cols":120,"rows":40}`)); err != nil {
+		t.Fatal(err)
+	}
+	if size := <-sess.resizes; size != [2]uint16{120, 40} {
+		t.Fatalf("resized to %v", size)
+	}
+
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte("ls")); err != nil {
+		t.Fatal(err)
+	}
+	kind, data, err := conn.ReadMessage()
+	if err != nil || kind != websocket.BinaryMessage || string(data) != "ls" {
+		t.Fatalf("read %d %q %v, want the echoed input", kind, data, err)
+	}
+
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte("exit")); err != nil {
+		t.Fatal(err)
+	}
+	if _, data, _ = conn.ReadMessage(); string(data) != "exit" {
+		t.Fatalf("read %q, want the echoed input", data)
+	}
+
+	var exit control
+	if err := conn.ReadJSON(&exit); err != nil {
+		t.Fatal(err)
+	}
+	if exit.Type != "exit" || exit.ExitCode == nil || *exit.ExitCode != 0 {
+		t.Fatalf("exit = %+v, want exit code 0", exit)
+	}
+}
+
+func TestServeReportsOpenFailureOverTheSocket(t *testing.T) {
+	svc := &fakeExecService{opts: make(chan domain.ExecOptions, 1)}
+	conn := dial(t, newTestServer(t, svc), "/ws/applications/99/exec?service=web")
+
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exit control
+	if err := json.Unmarshal(data, &exit); err != nil || exit.Type != "exit" || exit.Reason != "application not found" {
+		t.Fatalf("read %s, want an exit for the missing app", data)
+	}
+}
+
+func TestServeRejectsBadRequestsBeforeUpgrading(t *testing.T) {
+	srv := newTestServer(t, &fakeExecService{})
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"/ws/applications/7/exec?service=web&shell=python", nil); err == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("bad shell: err = %v, want a 400", err)
+	}
+
+	header := http.Header{"Origin": {"https://evil.example"}}
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"/ws/applications/7/exec?service=web", header); err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("foreign origin: err = %v, want a 403", err)
+	}
+}
@@ -27,13 +27,14 @@ type Client struct {
 	conn *websocket.Conn
 	send chan []byte
 
-	log logger.Logger
+	log   logger.Logger
+	roles domain.RoleService
 
 	ID string
 }
 
-func NewClient(hub *Hub, conn *websocket.Conn, log logger.Logger, cID string) *Client {
-	ctx, cancel := context.WithCancel(hub.ctx)
+func NewClient(hub *Hub, conn *websocket.Conn, log logger.Logger, roles domain.RoleService, user domain.UserContext, cID string) *Client {
+	ctx, cancel := context.WithCancel(domain.SetUserContext(hub.ctx, user))
 
 	return &Client{
 		ctx:    ctx,
@@ -43,7 +44,8 @@ func NewClient(hub *Hub, conn *websocket.Conn, log logger.Logger, cID string) *C
 		conn: conn,
 		send: make(chan []byte, 256),
 
-		log: log,
+		log:   log,
+		roles: roles,
 
 		ID: cID,
 	}
@@ -82,26 +84,63 @@ func (c *Client) readPump() {
 				continue
 			}
 
-			switch msg.Type {
-			case "subscribe":
-				c.hub.subscribe <- &Subscription{
-					client:  c,
-					channel: msg.Channel,
-				}
-			case "unsubscribe":
-				c.hub.unsubscribe <- &Subscription{
-					client:  c,
-					channel: msg.Channel,
-				}
-			case "ping":
-				// Application-level heartbeat: the browser JS cannot see the
-				// WS protocol pings the server sends, so the dashboard pings
-				// us on its own cadence. Reply so it can prove liveness.
-				c.sendPong()
-			default:
-				c.log.Debug("ws: unknown client message type", "type", msg.Type)
-			}
+			c.handleMessage(msg)
+		}
+	}
+}
+
+func (c *Client) handleMessage(msg domain.WsClientMessage) {
+	switch msg.Type {
+	case "subscribe":
+		if err := c.authorize(msg.Channel); err != nil {
+			c.log.Warn("ws: subscription rejected", "client", c.ID, "channel", msg.Channel, "error", err)
+			c.sendSubscribeError(msg.Channel, err)
+			return
+		}
+		c.hub.subscribe <- &Subscription{
+			client:  c,
+			channel: msg.Channel,
 		}
+	case "unsubscribe":
+		c.hub.unsubscribe <- &Subscription{
+			client:  c,
+			channel: msg.Channel,
+		}
+	case "ping":
+		// Application-level heartbeat: the browser JS cannot see the
+		// WS protocol pings the server sends, so the dashboard pings
+		// us on its own cadence. Reply so it can prove liveness.
+		c.sendPong()
+	default:
+		c.log.Debug("ws: unknown client message type", "type", msg.Type)
+	}
+}
+
+// authorize checks the client may subscribe to channel. Streaming an app's
+// container logs needs app_read, the same as GET
+// /applications/{id}/container-logs; the other channels only need a
+// signed-in user.
+func (c *Client) authorize(channel string) error {
+	if _, _, ok := domain.ParseContainerLogsChannel(channel); ok {
+		return c.roles.HasPermission(c.ctx, domain.PermAppRead)
+	}
+	return nil
+}
+
+func (c *Client) sendSubscribeError(channel string, err error) {
+	msg, merr := json.Marshal(&domain.WsServerEvent{
+		Channel: channel,
+		Event:   "subscribe_error",
+		Payload: map[string]any{"message": err.Error()},
+	})
+	if merr != nil {
+		return
+	}
+
+	select {
+	case c.send <- msg:
+	default:
+		c.log.Debug("ws: subscribe error dropped (send buffer full)")
 	}
 }
 
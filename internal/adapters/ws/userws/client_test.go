@@ -0,0 +1,82 @@
+package userws
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"horizonx/internal/domain"
+)
+
+type fakeRoles struct {
+	domain.RoleService
+	allowed map[domain.PermissionConst]bool
+	checked []domain.PermissionConst
+}
+
+func (f *fakeRoles) HasPermission(ctx context.Context, perm domain.PermissionConst) error {
+	f.checked = append(f.checked, perm)
+	if !f.allowed[perm] {
+		return domain.ErrYouDontHavePermission
+	}
+	return nil
+}
+
+func TestSubscribeContainerLogsRequiresAppRead(t *testing.T) {
+	h := newTestHub(t)
+	roles := &fakeRoles{}
+	c := NewClient(h, nil, noopLogger{}, roles, domain.UserContext{ID: 7, Role: domain.RoleViewer}, "7")
+
+	c.handleMessage(domain.WsClientMessage{Type: "subscribe", Channel: "app:3:container-logs:web"})
+
+	select {
+	case sub := <-h.subscribe:
+		t.Fatalf("subscription to %q queued without app_read", sub.channel)
+	default:
+	}
+	if len(roles.checked) != 1 || roles.checked[0] != domain.PermAppRead {
+		t.Fatalf("checked permissions = %v, want [%s]", roles.checked, domain.PermAppRead)
+	}
+
+	select {
+	case raw := <-c.send:
+		var ev domain.WsServerEvent
+		if err := json.Unmarshal(raw, &ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Event != "subscribe_error" || ev.Channel != "app:3:container-logs:web" {
+			t.Fatalf("got event %q on %q, want subscribe_error", ev.Event, ev.Channel)
+		}
+	default:
+		t.Fatal("rejected subscription sent no error to the client")
+	}
+
+	roles.allowed = map[domain.PermissionConst]bool{domain.PermAppRead: true}
+	c.handleMessage(domain.WsClientMessage{Type: "subscribe", Channel: "app:3:container-logs:web"})
+
+	select {
+	case sub := <-h.subscribe:
+		if sub.channel != "app:3:container-logs:web" {
+			t.Fatalf("subscribed to %q", sub.channel)
+		}
+	default:
+		t.Fatal("subscription with app_read was not queued")
+	}
+}
+
+func TestSubscribeOtherChannelsNeedNoPermission(t *testing.T) {
+	h := newTestHub(t)
+	roles := &fakeRoles{}
+	c := NewClient(h, nil, noopLogger{}, roles, domain.UserContext{ID: 7}, "7")
+
+	c.handleMessage(domain.WsClientMessage{Type: "subscribe", Channel: "server:1:metrics"})
+
+	select {
+	case <-h.subscribe:
+	default:
+		t.Fatal("subscription was not queued")
+	}
+	if len(roles.checked) != 0 {
+		t.Fatalf("checked permissions %v for a channel that needs none", roles.checked)
+	}
+}
@@ -15,12 +15,13 @@ type Handler struct {
 	hub      *Hub
 	upgrader websocket.Upgrader
 	log      logger.Logger
+	roles    domain.RoleService
 
 	secret         string
 	allowedOrigins []string
 }
 
-func NewHandler(hub *Hub, log logger.Logger, secret string, allowedOrigins []string) *Handler {
+func NewHandler(hub *Hub, log logger.Logger, roles domain.RoleService, secret string, allowedOrigins []string) *Handler {
 	upgrader := websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
 			origin := r.Header.Get("Origin")
@@ -42,6 +43,7 @@ func NewHandler(hub *Hub, log logger.Logger, secret string, allowedOrigins []str
 		hub:      hub,
 		upgrader: upgrader,
 		log:      log,
+		roles:    roles,
 
 		secret:         secret,
 		allowedOrigins: allowedOrigins,
@@ -50,6 +52,7 @@ func NewHandler(hub *Hub, log logger.Logger, secret string, allowedOrigins []str
 
 func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
 	var clientID string
+	var user domain.UserContext
 
 	cookie, err := r.Cookie("horizonx_access_token")
 	if err == nil {
@@ -57,6 +60,7 @@ func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
 		claims, err := domain.ValidateToken(tokenString, h.secret)
 		if err == nil {
 			clientID = fmt.Sprintf("%v", claims.UserID)
+			user = domain.UserContext{ID: claims.UserID, Role: claims.Role, SessionID: claims.SessionID}
 		}
 	}
 
@@ -72,7 +76,7 @@ func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c := NewClient(h.hub, conn, h.log, clientID)
+	c := NewClient(h.hub, conn, h.log, h.roles, user, clientID)
 	c.hub.register <- c
 
 	go c.writePump()
@@ -25,6 +25,10 @@ type Hub struct {
 	// (we never kill a slow-but-healthy connection).
 	dropped uint64
 
+	// onChannel is told when a channel gets its first subscriber or loses
+	// its last one; see OnChannel.
+	onChannel func(channel string, active bool)
+
 	log logger.Logger
 }
 
@@ -68,38 +72,13 @@ func (h *Hub) Run() {
 			c.log.Info("ws: user registered", "id", c.ID)
 
 		case c := <-h.unregister:
-			if !h.clients[c] {
-				continue
-			}
-
-			delete(h.clients, c)
-			close(c.send)
-			h.log.Info("ws: user unregistered", "id", c.ID)
-
-			for chID, subs := range h.channels {
-				if _, subsribed := subs[c]; subsribed {
-					delete(subs, c)
-					if len(subs) == 0 {
-						delete(h.channels, chID)
-					}
-				}
-			}
+			h.handleUnregister(c)
 
 		case sub := <-h.subscribe:
-			if h.channels[sub.channel] == nil {
-				h.channels[sub.channel] = make(map[*Client]bool)
-			}
-			h.channels[sub.channel][sub.client] = true
+			h.handleSubscribe(sub)
 
 		case sub := <-h.unsubscribe:
-			if subs, ok := h.channels[sub.channel]; ok {
-				if _, subscribed := subs[sub.client]; subscribed {
-					delete(subs, sub.client)
-					if len(subs) == 0 {
-						delete(h.channels, sub.channel)
-					}
-				}
-			}
+			h.handleUnsubscribe(sub)
 
 		case ev := <-h.events:
 			h.handleEvent(ev)
@@ -107,10 +86,69 @@ func (h *Hub) Run() {
 	}
 }
 
+func (h *Hub) handleUnregister(c *Client) {
+	if !h.clients[c] {
+		return
+	}
+
+	delete(h.clients, c)
+	close(c.send)
+	h.log.Info("ws: user unregistered", "id", c.ID)
+
+	for chID, subs := range h.channels {
+		if _, subsribed := subs[c]; subsribed {
+			delete(subs, c)
+			if len(subs) == 0 {
+				delete(h.channels, chID)
+				h.channelActive(chID, false)
+			}
+		}
+	}
+}
+
+func (h *Hub) handleSubscribe(sub *Subscription) {
+	// readPump cancels the client before it unregisters: a late
+	// subscription must not outlive the unregister.
+	if sub.client.ctx.Err() != nil {
+		return
+	}
+	if h.channels[sub.channel] == nil {
+		h.channels[sub.channel] = make(map[*Client]bool)
+		h.channelActive(sub.channel, true)
+	}
+	h.channels[sub.channel][sub.client] = true
+}
+
+func (h *Hub) handleUnsubscribe(sub *Subscription) {
+	if subs, ok := h.channels[sub.channel]; ok {
+		if _, subscribed := subs[sub.client]; subscribed {
+			delete(subs, sub.client)
+			if len(subs) == 0 {
+				delete(h.channels, sub.channel)
+				h.channelActive(sub.channel, false)
+			}
+		}
+	}
+}
+
 func (h *Hub) Stop() {
 	h.cancel()
 }
 
+// OnChannel registers fn to be told when a channel gets its first subscriber
+// (active) or loses its last one, so server-side producers such as a
+// container logs stream only run while someone watches. fn is called from
+// the hub loop and must not block. Call before Run.
+func (h *Hub) OnChannel(fn func(channel string, active bool)) {
+	h.onChannel = fn
+}
+
+func (h *Hub) channelActive(channel string, active bool) {
+	if h.onChannel != nil {
+		h.onChannel(channel, active)
+	}
+}
+
 func (h *Hub) Broadcast(ev *domain.WsServerEvent) {
 	select {
 	case h.events <- ev:
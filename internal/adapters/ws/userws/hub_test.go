@@ -2,6 +2,8 @@ package userws
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"testing"
 
 	"horizonx/internal/domain"
@@ -70,8 +72,8 @@ func TestHandleEventDoesNotKillSlowClient(t *testing.T) {
 func TestHandleEventChannelScoping(t *testing.T) {
 	h := newTestHub(t)
 
-	sub := NewClient(h, nil, noopLogger{}, "sub")
-	other := NewClient(h, nil, noopLogger{}, "other")
+	sub := NewClient(h, nil, noopLogger{}, nil, domain.UserContext{}, "sub")
+	other := NewClient(h, nil, noopLogger{}, nil, domain.UserContext{}, "other")
 	h.clients[sub] = true
 	h.clients[other] = true
 	h.channels["deployment:7"] = map[*Client]bool{sub: true}
@@ -94,3 +96,38 @@ func TestHandleEventChannelScoping(t *testing.T) {
 	default:
 	}
 }
+
+// TestOnChannelReportsFirstAndLastSubscriber verifies a channel turns active
+// with its first subscriber and inactive when the last one unsubscribes or
+// disconnects.
+func TestOnChannelReportsFirstAndLastSubscriber(t *testing.T) {
+	h := newTestHub(t)
+
+	var changes []string
+	h.OnChannel(func(channel string, active bool) {
+		changes = append(changes, fmt.Sprintf("%s:%v", channel, active))
+	})
+
+	a := NewClient(h, nil, noopLogger{}, nil, domain.UserContext{}, "a")
+	b := NewClient(h, nil, noopLogger{}, nil, domain.UserContext{}, "b")
+	h.clients[a] = true
+	h.clients[b] = true
+
+	const channel = "app:1:container-logs"
+	h.handleSubscribe(&Subscription{client: a, channel: channel})
+	h.handleSubscribe(&Subscription{client: b, channel: channel})
+	h.handleUnsubscribe(&Subscription{client: a, channel: channel})
+	h.handleUnregister(b)
+
+	// A subscription that arrives after its client left is dropped.
+	b.cancel()
+	h.handleSubscribe(&Subscription{client: b, channel: channel})
+
+	want := []string{channel + ":true", channel + ":false"}
+	if !slices.Equal(changes, want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	if len(h.channels) != 0 {
+		t.Fatalf("channels = %v, want none left", h.channels)
+	}
+}
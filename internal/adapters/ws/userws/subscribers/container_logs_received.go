@@ -0,0 +1,27 @@
+package subscribers
+
+import (
+	"horizonx/internal/adapters/ws/userws"
+	"horizonx/internal/domain"
+)
+
+type ContainerLogsReceived struct {
+	hub *userws.Hub
+}
+
+func NewContainerLogsReceived(hub *userws.Hub) *ContainerLogsReceived {
+	return &ContainerLogsReceived{hub: hub}
+}
+
+func (s *ContainerLogsReceived) Handle(event any) {
+	evt, ok := event.(domain.EventContainerLogs)
+	if !ok {
+		return
+	}
+
+	s.hub.Broadcast(&domain.WsServerEvent{
+		Channel: evt.Channel,
+		Event:   "container_logs_received",
+		Payload: evt,
+	})
+}
@@ -31,6 +31,10 @@ func Register(bus EventBus, hub *userws.Hub) {
 	bus.Subscribe("application_created", applicationCreated.Handle)
 	bus.Subscribe("application_status_changed", applicationStatusChanged.Handle)
 
+	// Container Log Events
+	containerLogsReceived := NewContainerLogsReceived(hub)
+	bus.Subscribe("container_logs_received", containerLogsReceived.Handle)
+
 	// Deployment Events
 	deploymentCreated := NewDeploymentCreated(hub)
 	deploymentStarted := NewDeploymentStarted(hub)
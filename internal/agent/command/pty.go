@@ -0,0 +1,70 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// PTY is a command running on a pseudo-terminal, for interactive sessions.
+// Reads return what the terminal shows, writes are typed into it; the output
+// is neither captured nor redacted.
+type PTY struct {
+	*os.File // the terminal's master side
+
+	cmd *exec.Cmd
+}
+
+// StartPTY starts the command on a new pseudo-terminal of rows x cols. It
+// leads its own session, so cancelling ctx kills it and whatever it spawned.
+func (c *Command) StartPTY(ctx context.Context, rows, cols uint16) (*PTY, error) {
+	master, tty, err := openPTY()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pty: %w", err)
+	}
+	defer tty.Close()
+
+	if err := setPTYSize(master, rows, cols); err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to size pty: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, c.name, c.args...)
+	cmd.Dir = c.workDir
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+	cmd.Env = append(cmd.Env, c.env...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = killWaitDelay
+
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	return &PTY{File: master, cmd: cmd}, nil
+}
+
+// Resize tells the terminal, and through SIGWINCH the program in it, its new
+// size.
+func (p *PTY) Resize(rows, cols uint16) error {
+	return setPTYSize(p.File, rows, cols)
+}
+
+// Wait waits for the command to exit and returns its exit code. The
+// terminal stays open; Close it once its output was read.
+func (p *PTY) Wait() (int, error) {
+	err := p.cmd.Wait()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
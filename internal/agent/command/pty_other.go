@@ -0,0 +1,18 @@
+//go:build !linux
+
+package command
+
+import (
+	"errors"
+	"os"
+)
+
+// The agent only runs on Linux; elsewhere the package builds for tests and
+// tooling, without terminals.
+func openPTY() (master, tty *os.File, err error) {
+	return nil, nil, errors.ErrUnsupported
+}
+
+func setPTYSize(*os.File, uint16, uint16) error {
+	return errors.ErrUnsupported
+}
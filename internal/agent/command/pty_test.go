@@ -0,0 +1,52 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestStartPTYRelaysInputOutputAndExitCode(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-terminals are only supported on linux")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	p, err := NewCommand(t.TempDir(), "sh", "-c", `[ -t 0 ] && echo tty; stty size; read x; echo "got:$x"; exit 3`).StartPTY(ctx, 40, 120)
+	if err != nil {
+		t.Fatalf("StartPTY: %v", err)
+	}
+	defer p.Close()
+
+	if _, err := p.Write([]byte("hello\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		// The master reads EIO once the shell exits and closes its side.
+		_, _ = io.Copy(&out, p)
+		close(done)
+	}()
+
+	code, err := p.Wait()
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	<-done
+
+	if code != 3 {
+		t.Fatalf("exit code = %d, want 3", code)
+	}
+	for _, want := range []string{"tty", "40 120", "got:hello"} {
+		if !bytes.Contains(out.Bytes(), []byte(want)) {
+			t.Fatalf("output %q lacks %q", out.String(), want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	// MinRedactedLength keeps short values ("1", "on", "20") from being
+	// blanked out of every line that happens to contain them.
+	MinRedactedLength = 4
+
+	// Redacted replaces a secret in command output.
+	Redacted = "********"
+)
+
+type redactorKey struct{}
+
+// Redactor masks known secret values in command output. Besides each value
+// as is, it masks the forms a value is commonly echoed in: base64 and
+// URL-encoded (credentials in clone URLs). Basic auth headers and docker
+// config auths encode "user:password" as one, so callers pass that pair as a
+// secret of its own for it to be masked.
+type Redactor struct {
+	needles []string
+}
+
+// NewRedactor returns a redactor for secrets. Multi-line secrets (private
+// keys) are masked line by line, since output is streamed a line at a time.
+// Values shorter than MinRedactedLength are ignored.
+func NewRedactor(secrets ...string) *Redactor {
+	seen := make(map[string]bool)
+	var needles []string
+
+	add := func(s string) {
+		if len(s) < MinRedactedLength || seen[s] {
+			return
+		}
+		seen[s] = true
+		needles = append(needles, s)
+	}
+
+	for _, secret := range secrets {
+		for _, part := range strings.Split(strings.ReplaceAll(secret, "\r\n", "\n"), "\n") {
+			part = strings.TrimSpace(part)
+			if len(part) < MinRedactedLength {
+				continue
+			}
+			add(part)
+			add(base64.StdEncoding.EncodeToString([]byte(part)))
+			add(base64.RawStdEncoding.EncodeToString([]byte(part)))
+			add(base64.URLEncoding.EncodeToString([]byte(part)))
+			add(base64.RawURLEncoding.EncodeToString([]byte(part)))
+			add(url.QueryEscape(part))
+			add(url.PathEscape(part))
+		}
+	}
+
+	// Longest first, so a value containing another is not half-masked.
+	sort.Slice(needles, func(i, j int) bool { return len(needles[i]) > len(needles[j]) })
+
+	return &Redactor{needles: needles}
+}
+
+// Redact returns line with every known secret replaced by Redacted. A nil
+// redactor returns line unchanged.
+func (r *Redactor) Redact(line string) string {
+	if r == nil {
+		return line
+	}
+	for _, n := range r.needles {
+		line = strings.ReplaceAll(line, n, Redacted)
+	}
+	return line
+}
+
+// WithRedactor returns a context under which every Command masks r's secrets
+// in its output, before any line reaches a handler or the returned output.
+func WithRedactor(ctx context.Context, r *Redactor) context.Context {
+	return context.WithValue(ctx, redactorKey{}, r)
+}
+
+func redactorFrom(ctx context.Context) *Redactor {
+	r, _ := ctx.Value(redactorKey{}).(*Redactor)
+	return r
+}
@@ -7,9 +7,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"horizonx/internal/domain"
 )
@@ -17,6 +20,10 @@ import (
 const (
 	initialScannerBufferSize = 4096
 	maxScannerBufferSize     = 10 * 1024 * 1024
+
+	// killWaitDelay bounds how long Wait blocks on output pipes held open by
+	// grandchildren after the process group was killed.
+	killWaitDelay = 5 * time.Second
 )
 
 type StreamHandler = func(line string, stream domain.LogStream, level domain.LogLevel)
@@ -25,6 +32,7 @@ type Command struct {
 	workDir string
 	name    string
 	args    []string
+	env     []string
 }
 
 func NewCommand(workDir, name string, args ...string) *Command {
@@ -35,13 +43,33 @@ func NewCommand(workDir, name string, args ...string) *Command {
 	}
 }
 
-func (c *Command) Run(ctx context.Context, handlers ...StreamHandler) (string, error) {
-	var buf bytes.Buffer
+// WithEnv adds KEY=value entries to the command's environment, on top of the
+// agent's own.
+func (c *Command) WithEnv(env ...string) *Command {
+	c.env = append(c.env, env...)
+	return c
+}
 
-	err := c.execute(ctx, func(line string, stream domain.LogStream, level domain.LogLevel) {
+// Run runs the command and returns its output. Handlers get each line with
+// the context's secrets masked (see WithRedactor); the returned output is
+// left as is, since callers parse it (digests, compose JSON) on the box.
+func (c *Command) Run(ctx context.Context, handlers ...StreamHandler) (string, error) {
+	var (
+		buf bytes.Buffer
+		mu  sync.Mutex
+	)
+
+	// stdout and stderr are streamed from their own goroutines.
+	capture := func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
 		buf.WriteString(line)
 		buf.WriteString("\n")
+	}
 
+	err := c.execute(ctx, capture, func(line string, stream domain.LogStream, level domain.LogLevel) {
+		mu.Lock()
+		defer mu.Unlock()
 		for _, h := range handlers {
 			if h != nil {
 				h(line, stream, level)
@@ -52,9 +80,28 @@ func (c *Command) Run(ctx context.Context, handlers ...StreamHandler) (string, e
 	return buf.String(), err
 }
 
-func (c *Command) execute(ctx context.Context, handler StreamHandler) error {
+// Stream runs the command like Run but keeps none of its output, for
+// commands that print until they are cancelled (`compose logs -f`).
+// Handlers are called from the stdout and stderr goroutines at once.
+func (c *Command) Stream(ctx context.Context, handler StreamHandler) error {
+	return c.execute(ctx, nil, handler)
+}
+
+func (c *Command) execute(ctx context.Context, capture func(string), handler StreamHandler) error {
 	cmd := exec.CommandContext(ctx, c.name, c.args...)
 	cmd.Dir = c.workDir
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
+
+	// Run the command in its own process group and kill the whole group on
+	// cancel, so a cancelled job also stops whatever it spawned (compose,
+	// buildx, hook scripts) instead of leaving them orphaned.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = killWaitDelay
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -70,6 +117,10 @@ func (c *Command) execute(ctx context.Context, handler StreamHandler) error {
 		return fmt.Errorf("failed to start command: %w", err)
 	}
 
+	// Secrets are masked in streamOutput, before a line reaches any handler
+	// and from there the server's logs.
+	redactor := redactorFrom(ctx)
+
 	errChan := make(chan error, 2)
 
 	var wg sync.WaitGroup
@@ -77,14 +128,14 @@ func (c *Command) execute(ctx context.Context, handler StreamHandler) error {
 
 	go func() {
 		defer wg.Done()
-		if err := c.streamOutput(stdout, handler, domain.StreamStdout); err != nil {
+		if err := c.streamOutput(stdout, capture, handler, redactor, domain.StreamStdout); err != nil {
 			errChan <- fmt.Errorf("stdout stream error: %w", err)
 		}
 	}()
 
 	go func() {
 		defer wg.Done()
-		if err := c.streamOutput(stderr, handler, domain.StreamStderr); err != nil {
+		if err := c.streamOutput(stderr, capture, handler, redactor, domain.StreamStderr); err != nil {
 			errChan <- fmt.Errorf("stderr stream error: %w", err)
 		}
 	}()
@@ -110,24 +161,32 @@ func (c *Command) execute(ctx context.Context, handler StreamHandler) error {
 	return nil
 }
 
-func (c *Command) streamOutput(r io.Reader, handler StreamHandler, stream domain.LogStream) error {
+func (c *Command) streamOutput(r io.Reader, capture func(string), handler StreamHandler, redactor *Redactor, stream domain.LogStream) error {
 	if handler == nil {
 		handler = func(string, domain.LogStream, domain.LogLevel) {}
 	}
+	if capture == nil {
+		capture = func(string) {}
+	}
 
 	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, initialScannerBufferSize), maxScannerBufferSize)
 
 	for scanner.Scan() {
+		// The scanner hands over whole lines however far they run past its
+		// initial buffer, so a secret is never split across two Redact calls.
 		text := scanner.Text()
 		lines := c.normalizeAndSplitLines(text)
 
 		for _, line := range lines {
 			line = strings.TrimSpace(line)
-			level := c.classifyLine(line)
-			if line != "" {
-				handler(line, stream, level)
+			if line == "" {
+				continue
 			}
+			capture(line)
+
+			line = redactor.Redact(line)
+			handler(line, stream, c.classifyLine(line))
 		}
 	}
 
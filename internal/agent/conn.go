@@ -9,10 +9,12 @@ import (
 	"net/http"
 	"time"
 
+	"horizonx/internal/agent/executor"
 	"horizonx/internal/config"
 	"horizonx/internal/domain"
 	"horizonx/internal/logger"
 	"horizonx/internal/system"
+	"horizonx/internal/version"
 
 	"github.com/gorilla/websocket"
 	"golang.org/x/sync/errgroup"
@@ -22,24 +24,41 @@ const (
 	writeWait      = 10 * time.Second
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 8192
+	maxMessageSize = 1 << 20 // job dispatches carry full payloads (env vars included)
 )
 
 type Agent struct {
-	conn *websocket.Conn
-	send chan []byte
-	cfg  *config.Config
-	log  logger.Logger
+	conn   *websocket.Conn
+	send   chan []byte
+	cfg    *config.Config
+	log    logger.Logger
+	worker *JobWorker
+
+	// containerLogs runs the `compose logs` streams the server relays to
+	// the dashboard, execs the shells users open in app containers.
+	containerLogs *containerLogStreams
+	execs         *execSessions
 }
 
 var ErrUnauthorized = errors.New("connection failed: unauthorized")
 
-func NewAgent(cfg *config.Config, log logger.Logger) *Agent {
-	return &Agent{
-		send: make(chan []byte, 256),
-		cfg:  cfg,
-		log:  log,
+func NewAgent(cfg *config.Config, log logger.Logger, worker *JobWorker, exec *executor.Executor) *Agent {
+	a := &Agent{
+		send:   make(chan []byte, 256),
+		cfg:    cfg,
+		log:    log,
+		worker: worker,
 	}
+	a.containerLogs = newContainerLogStreams(cfg.AgentServerID, exec.ContainerLogs, a.queue, log)
+	a.execs = newExecSessions(cfg.AgentServerID, func(ctx context.Context, req domain.ExecStart) (terminal, error) {
+		pty, err := exec.Exec(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return pty, nil
+	}, a.queue, log)
+
+	return a
 }
 
 func (a *Agent) Start(ctx context.Context) error {
@@ -86,8 +105,21 @@ func (a *Agent) dialUp(ctx context.Context) error {
 	a.conn = conn
 	a.log.Info("connected to server", "url", a.cfg.AgentTargetWsURL)
 
+	// The hello goes first so the server knows what this agent can run
+	// before it queues anything for it.
+	a.sendAgentHello()
 	a.sendServerOSInfo()
 
+	// Jobs are pushed over the socket from here on; the worker polls once
+	// now to catch anything queued while we were disconnected.
+	a.worker.SetConnected(true)
+	defer a.worker.SetConnected(false)
+
+	// Log streams die with the connection; the server asks for them again
+	// once we are back. Shells are not resumed.
+	defer a.containerLogs.StopAll()
+	defer a.execs.CloseAll()
+
 	g, gctx := errgroup.WithContext(ctx)
 
 	g.Go(func() error { return a.readPump(gctx) })
@@ -149,12 +181,92 @@ func (a *Agent) readPump(ctx context.Context) error {
 			case <-ctx.Done():
 				return nil
 			default:
-				a.log.Debug("incoming server message", "payload", serverMessage.Payload)
+				a.handleServerMessage(ctx, serverMessage)
 			}
 		}
 	}
 }
 
+func (a *Agent) handleServerMessage(ctx context.Context, msg domain.WsServerMessage) {
+	switch msg.Event {
+	case "job_dispatch":
+		var job domain.Job
+		if err := json.Unmarshal(msg.Payload, &job); err != nil {
+			a.log.Error("invalid job dispatch payload", "error", err)
+			return
+		}
+
+		a.log.Debug("job pushed by server", "job_id", job.ID, "type", job.Type)
+		a.worker.Dispatch(job)
+
+	case "job_cancel":
+		var signal domain.JobCancelSignal
+		if err := json.Unmarshal(msg.Payload, &signal); err != nil {
+			a.log.Error("invalid job cancel payload", "error", err)
+			return
+		}
+
+		a.worker.CancelJob(signal.JobID)
+
+	case "container_logs_start":
+		var req domain.ContainerLogsRequest
+		if err := json.Unmarshal(msg.Payload, &req); err != nil || req.ID == "" {
+			a.log.Error("invalid container logs request payload", "error", err)
+			return
+		}
+
+		a.containerLogs.Start(ctx, req)
+
+	case "container_logs_stop":
+		var stop domain.ContainerLogsStop
+		if err := json.Unmarshal(msg.Payload, &stop); err != nil {
+			a.log.Error("invalid container logs stop payload", "error", err)
+			return
+		}
+
+		a.containerLogs.Stop(stop.ID)
+
+	case "exec_start":
+		var req domain.ExecStart
+		if err := json.Unmarshal(msg.Payload, &req); err != nil || req.ID == "" {
+			a.log.Error("invalid exec start payload", "error", err)
+			return
+		}
+
+		a.execs.Start(ctx, req)
+
+	case "exec_input":
+		var input domain.ExecInput
+		if err := json.Unmarshal(msg.Payload, &input); err != nil {
+			a.log.Error("invalid exec input payload", "error", err)
+			return
+		}
+
+		a.execs.Input(input.ID, input.Data)
+
+	case "exec_resize":
+		var resize domain.ExecResize
+		if err := json.Unmarshal(msg.Payload, &resize); err != nil {
+			a.log.Error("invalid exec resize payload", "error", err)
+			return
+		}
+
+		a.execs.Resize(resize.ID, resize.Rows, resize.Cols)
+
+	case "exec_close":
+		var closeMsg domain.ExecClose
+		if err := json.Unmarshal(msg.Payload, &closeMsg); err != nil {
+			a.log.Error("invalid exec close payload", "error", err)
+			return
+		}
+
+		a.execs.Close(closeMsg.ID)
+
+	default:
+		a.log.Debug("unknown server message event", "event", msg.Event)
+	}
+}
+
 func (a *Agent) writePump(ctx context.Context) error {
 	ticker := time.NewTicker(pingPeriod)
 	defer ticker.Stop()
@@ -193,6 +305,17 @@ func (a *Agent) writePump(ctx context.Context) error {
 	}
 }
 
+// queue hands message to the write pump, waiting for room in its buffer
+// until ctx ends.
+func (a *Agent) queue(ctx context.Context, message []byte) error {
+	select {
+	case a.send <- message:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (a *Agent) sendServerOSInfo() {
 	system := system.NewReader(a.log)
 
@@ -226,3 +349,34 @@ func (a *Agent) sendServerOSInfo() {
 		a.log.Warn("send channel full, OS info dropped")
 	}
 }
+
+// sendAgentHello advertises this build's version and the job types (with
+// their payload versions) it can run. The server refuses jobs this agent
+// would not understand instead of sending them.
+func (a *Agent) sendAgentHello() {
+	info, err := json.Marshal(&domain.AgentInfo{
+		Version:  version.Version,
+		JobTypes: domain.JobPayloadVersions,
+	})
+	if err != nil {
+		a.log.Error("failed to marshal agent hello payload", "error", err.Error())
+		return
+	}
+
+	message, err := json.Marshal(&domain.WsAgentMessage{
+		ServerID: a.cfg.AgentServerID,
+		Event:    "agent_hello",
+		Payload:  info,
+	})
+	if err != nil {
+		a.log.Error("failed to marshal agent message", "error", err.Error())
+		return
+	}
+
+	select {
+	case a.send <- message:
+		a.log.Debug("agent hello sent", "version", version.Version)
+	default:
+		a.log.Warn("send channel full, agent hello dropped")
+	}
+}
@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Lines are relayed in batches: every containerLogsFlushInterval, or
+	// sooner once a batch holds containerLogsBatchLines lines or about
+	// containerLogsBatchBytes of text. The byte cap keeps a batch well under
+	// the server's read limit for agent frames.
+	containerLogsFlushInterval = 200 * time.Millisecond
+	containerLogsBatchLines    = 100
+	containerLogsBatchBytes    = 32 << 10
+)
+
+// containerLogsRunner runs `docker compose logs` for req; see
+// executor.Executor.ContainerLogs.
+type containerLogsRunner func(ctx context.Context, req domain.ContainerLogsRequest, emit func(domain.ContainerLogLine)) error
+
+// containerLogStreams runs the container log streams the server asked for
+// over the socket, keyed by stream ID. Streams live as long as the
+// connection: the server starts them again when the agent reconnects.
+type containerLogStreams struct {
+	serverID uuid.UUID
+	run      containerLogsRunner
+	// send queues a message for the write pump, blocking until there is
+	// room: a slow socket slows compose down instead of losing lines.
+	send func(ctx context.Context, message []byte) error
+	log  logger.Logger
+
+	mu      sync.Mutex
+	streams map[string]context.CancelFunc
+}
+
+func newContainerLogStreams(serverID uuid.UUID, run containerLogsRunner, send func(context.Context, []byte) error, log logger.Logger) *containerLogStreams {
+	return &containerLogStreams{
+		serverID: serverID,
+		run:      run,
+		send:     send,
+		log:      log,
+		streams:  make(map[string]context.CancelFunc),
+	}
+}
+
+// Start runs req until compose exits, Stop is called for it or ctx ends. A
+// request for a stream that is already running is ignored.
+func (s *containerLogStreams) Start(ctx context.Context, req domain.ContainerLogsRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.streams[req.ID]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.streams[req.ID] = cancel
+
+	go s.stream(ctx, req)
+}
+
+func (s *containerLogStreams) Stop(id string) {
+	s.mu.Lock()
+	cancel, ok := s.streams[id]
+	delete(s.streams, id)
+	s.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+func (s *containerLogStreams) StopAll() {
+	s.mu.Lock()
+	streams := s.streams
+	s.streams = make(map[string]context.CancelFunc)
+	s.mu.Unlock()
+
+	for _, cancel := range streams {
+		cancel()
+	}
+}
+
+func (s *containerLogStreams) stream(ctx context.Context, req domain.ContainerLogsRequest) {
+	defer s.Stop(req.ID)
+
+	s.log.Debug("container logs stream started", "id", req.ID, "app_key", req.AppKey, "service", req.Service, "follow", req.Follow)
+
+	lines := make(chan domain.ContainerLogLine, containerLogsBatchLines)
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- s.run(ctx, req, func(line domain.ContainerLogLine) {
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+			}
+		})
+		close(lines)
+	}()
+
+	ticker := time.NewTicker(containerLogsFlushInterval)
+	defer ticker.Stop()
+
+	var (
+		batch []domain.ContainerLogLine
+		size  int
+	)
+
+	flush := func(done bool, runErr error) bool {
+		msg := domain.ContainerLogsBatch{ID: req.ID, Lines: batch, Done: done}
+		if runErr != nil {
+			msg.Error = runErr.Error()
+		}
+		batch, size = nil, 0
+
+		if err := s.sendBatch(ctx, msg); err != nil {
+			s.log.Debug("container logs stream stopped", "id", req.ID, "error", err)
+			return false
+		}
+		return true
+	}
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				runErr := <-errc
+				// A stopped stream was forgotten by the server already.
+				if ctx.Err() == nil {
+					flush(true, runErr)
+				}
+				return
+			}
+
+			batch = append(batch, line)
+			size += len(line.Line) + len(line.Container)
+			if len(batch) >= containerLogsBatchLines || size >= containerLogsBatchBytes {
+				if !flush(false, nil) {
+					return
+				}
+			}
+
+		case <-ticker.C:
+			if len(batch) > 0 && !flush(false, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *containerLogStreams) sendBatch(ctx context.Context, batch domain.ContainerLogsBatch) error {
+	payload, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	message, err := json.Marshal(&domain.WsAgentMessage{
+		ServerID: s.serverID,
+		Event:    "container_logs",
+		Payload:  payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, message)
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// batchSink collects the batches a stream sends.
+type batchSink chan domain.ContainerLogsBatch
+
+func (b batchSink) send(_ context.Context, message []byte) error {
+	var msg domain.WsAgentMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return err
+	}
+	var batch domain.ContainerLogsBatch
+	if err := json.Unmarshal(msg.Payload, &batch); err != nil {
+		return err
+	}
+	b <- batch
+	return nil
+}
+
+func (b batchSink) next(t *testing.T) domain.ContainerLogsBatch {
+	t.Helper()
+	select {
+	case batch := <-b:
+		return batch
+	case <-time.After(5 * time.Second):
+		t.Fatal("no batch sent")
+		return domain.ContainerLogsBatch{}
+	}
+}
+
+func TestContainerLogStreamBatchesLinesAndEndsWithDone(t *testing.T) {
+	sink := make(batchSink, 16)
+	run := func(_ context.Context, _ domain.ContainerLogsRequest, emit func(domain.ContainerLogLine)) error {
+		for i := range containerLogsBatchLines + 5 {
+			emit(domain.ContainerLogLine{Line: fmt.Sprintf("line %d", i)})
+		}
+		return errors.New("exit status 1")
+	}
+	s := newContainerLogStreams(uuid.New(), run, sink.send, nopLogger{})
+
+	s.Start(context.Background(), domain.ContainerLogsRequest{ID: "s1"})
+
+	full := sink.next(t)
+	if full.ID != "s1" || len(full.Lines) != containerLogsBatchLines || full.Done {
+		t.Fatalf("first batch = %d lines done=%v, want a full batch", len(full.Lines), full.Done)
+	}
+
+	last := sink.next(t)
+	if len(last.Lines) != 5 || !last.Done || last.Error != "exit status 1" {
+		t.Fatalf("last batch = %+v, want the 5 remaining lines, done, with compose's error", last)
+	}
+}
+
+func TestContainerLogStreamStopsQuietlyAndIgnoresDuplicates(t *testing.T) {
+	sink := make(batchSink, 16)
+	started := make(chan struct{}, 2)
+	run := func(ctx context.Context, _ domain.ContainerLogsRequest, _ func(domain.ContainerLogLine)) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	s := newContainerLogStreams(uuid.New(), run, sink.send, nopLogger{})
+
+	req := domain.ContainerLogsRequest{ID: "s1", Follow: true}
+	s.Start(context.Background(), req)
+	s.Start(context.Background(), req)
+
+	<-started
+	s.Stop("s1")
+
+	select {
+	case <-started:
+		t.Fatal("a duplicate start request ran a second stream")
+	case batch := <-sink:
+		t.Fatalf("stopped stream sent %+v, want nothing", batch)
+	case <-time.After(2 * containerLogsFlushInterval):
+	}
+}
@@ -4,6 +4,7 @@ package docker
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -21,6 +22,8 @@ type Container struct {
 	Name     string `json:"Name"`
 	Ports    string `json:"Ports"`
 	Project  string `json:"Project"`
+	Service  string `json:"Service"`
+	Image    string `json:"Image"`
 	State    string `json:"State"`
 	Health   string `json:"Health"`
 	ExitCode int    `json:"ExitCode"`
@@ -35,6 +38,17 @@ func (m *Manager) Cmd(ctx context.Context, workDir string, args []string, handle
 	return cmd.Run(ctx, handlers...)
 }
 
+// Stream runs a long-lived docker command, handing each line to handler
+// without buffering the output.
+func (m *Manager) Stream(ctx context.Context, workDir string, args []string, handler command.StreamHandler) error {
+	return command.NewCommand(workDir, "docker", args...).Stream(ctx, handler)
+}
+
+// PTY starts an interactive docker command on a pseudo-terminal.
+func (m *Manager) PTY(ctx context.Context, workDir string, args []string, rows, cols uint16) (*command.PTY, error) {
+	return command.NewCommand(workDir, "docker", args...).StartPTY(ctx, rows, cols)
+}
+
 func (m *Manager) GetDockerComposeFile(workDir string) (string, error) {
 	// Production compose is preferred when present — repos keep a dev
 	// docker-compose.yml for local work and ship docker-compose.prod.yml
@@ -98,6 +112,39 @@ func (m *Manager) WriteEnvFile(workDir string, envVars map[string]string) error
 	return os.WriteFile(envPath, buf.Bytes(), 0o600)
 }
 
+// ReadEnvFile parses the .env written by WriteEnvFile. A missing file is not
+// an error: the app has simply never been deployed on this host.
+func (m *Manager) ReadEnvFile(workDir string) (map[string]string, error) {
+	envVars := make(map[string]string)
+
+	data, err := os.ReadFile(filepath.Join(workDir, ".env"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return envVars, nil
+		}
+		return nil, err
+	}
+
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		if len(v) >= 2 && strings.HasPrefix(v, "\"") && strings.HasSuffix(v, "\"") {
+			v = strings.ReplaceAll(v[1:len(v)-1], "\\n", "\n")
+		}
+		envVars[strings.TrimSpace(k)] = v
+	}
+
+	return envVars, nil
+}
+
 func (m *Manager) IsDockerInstalled() bool {
 	return exec.Command("docker", "--version").Run() == nil
 }
@@ -1,9 +1,13 @@
 package docker
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"horizonx/internal/domain"
 )
 
 func TestGetDockerComposeFilePrefersProd(t *testing.T) {
@@ -41,3 +45,84 @@ func TestGetDockerComposeFileMissing(t *testing.T) {
 		t.Fatal("expected error when no compose file exists")
 	}
 }
+
+func TestReadEnvFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	m := &Manager{}
+
+	want := map[string]string{
+		"APP_IMAGE": "demo-1:abc123",
+		"MULTILINE": "first\nsecond",
+		"EMPTY":     "",
+	}
+	if err := m.WriteEnvFile(dir, want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := m.ReadEnvFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestReadEnvFileMissing(t *testing.T) {
+	m := &Manager{}
+	got, err := m.ReadEnvFile(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected empty env, got %v", got)
+	}
+}
+
+func TestWriteRegistryAuth(t *testing.T) {
+	dir, err := writeRegistryAuth(&domain.RegistryCredentials{
+		Registry: "docker.io",
+		Username: "ci",
+		Password: "s3cret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	info, err := os.Stat(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("config.json mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	// Docker Hub logins live under the index URL, not the host name.
+	auth, ok := config.Auths["https://index.docker.io/v1/"]
+	if !ok {
+		t.Fatalf("docker hub auth missing: %s", raw)
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(auth.Auth)
+	if string(decoded) != "ci:s3cret" {
+		t.Fatalf("auth = %q, want ci:s3cret", decoded)
+	}
+}
@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"horizonx/internal/agent/command"
+	"horizonx/internal/domain"
+)
+
+// dockerHubAuthKey is the key docker stores Docker Hub logins under.
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// Pull pulls image, logged in with creds when set. The login goes into a
+// throwaway DOCKER_CONFIG removed before returning, so it never lands in the
+// agent user's ~/.docker/config.json or on the command line.
+func (m *Manager) Pull(ctx context.Context, workDir, image string, creds *domain.RegistryCredentials, handlers ...command.StreamHandler) (string, error) {
+	cmd := command.NewCommand(workDir, "docker", "pull", image)
+
+	if creds != nil {
+		dir, err := writeRegistryAuth(creds)
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(dir)
+
+		cmd = cmd.WithEnv("DOCKER_CONFIG=" + dir)
+	}
+
+	return cmd.Run(ctx, handlers...)
+}
+
+// writeRegistryAuth writes a docker config.json holding only creds to a new
+// private temp dir and returns the dir.
+func writeRegistryAuth(creds *domain.RegistryCredentials) (string, error) {
+	key := creds.Registry
+	if key == "docker.io" || key == "index.docker.io" || key == "registry-1.docker.io" {
+		key = dockerHubAuthKey
+	}
+
+	config, err := json.Marshal(map[string]any{
+		"auths": map[string]any{
+			key: map[string]string{
+				"auth": base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password)),
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "horizonx-registry-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create registry auth dir: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), config, 0o600); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to write registry auth: %w", err)
+	}
+
+	return dir, nil
+}
@@ -0,0 +1,228 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// execOutputChunk caps one exec_output message; the terminal is read in
+	// whatever it has ready, up to this.
+	execOutputChunk = 16 << 10
+	// execInputBuffer is how many input messages may wait for a shell that
+	// is not reading; more are dropped rather than stall the socket.
+	execInputBuffer = 64
+	// execDrainWait is how long output is still read after the shell
+	// exited, for what it printed last.
+	execDrainWait = time.Second
+)
+
+// terminal is a shell on a pseudo-terminal; see command.PTY.
+type terminal interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+	Resize(rows, cols uint16) error
+	Wait() (int, error)
+}
+
+// execRunner opens the shell for req; see executor.Executor.Exec.
+type execRunner func(ctx context.Context, req domain.ExecStart) (terminal, error)
+
+type execSession struct {
+	cancel context.CancelFunc
+	input  chan []byte
+
+	// term is set once the shell started.
+	term terminal
+}
+
+// execSessions runs the interactive shells the server opens over the
+// socket, keyed by session ID. Like log streams they end with the
+// connection.
+type execSessions struct {
+	serverID uuid.UUID
+	run      execRunner
+	send     func(ctx context.Context, message []byte) error
+	log      logger.Logger
+
+	mu       sync.Mutex
+	sessions map[string]*execSession
+}
+
+func newExecSessions(serverID uuid.UUID, run execRunner, send func(context.Context, []byte) error, log logger.Logger) *execSessions {
+	return &execSessions{
+		serverID: serverID,
+		run:      run,
+		send:     send,
+		log:      log,
+		sessions: make(map[string]*execSession),
+	}
+}
+
+// Start opens the shell for req. A request for a session that is already
+// open is ignored.
+func (s *execSessions) Start(ctx context.Context, req domain.ExecStart) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[req.ID]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	sess := &execSession{cancel: cancel, input: make(chan []byte, execInputBuffer)}
+	s.sessions[req.ID] = sess
+
+	go s.session(ctx, req, sess)
+}
+
+func (s *execSessions) Input(id string, data []byte) {
+	s.mu.Lock()
+	sess, ok := s.sessions[id]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	select {
+	case sess.input <- data:
+	default:
+		s.log.Warn("exec session is not reading its input, dropped", "id", id)
+	}
+}
+
+func (s *execSessions) Resize(id string, rows, cols uint16) {
+	s.mu.Lock()
+	var term terminal
+	if sess, ok := s.sessions[id]; ok {
+		term = sess.term
+	}
+	s.mu.Unlock()
+	if term == nil {
+		return
+	}
+
+	if err := term.Resize(rows, cols); err != nil {
+		s.log.Debug("failed to resize exec session", "id", id, "error", err)
+	}
+}
+
+// Close kills the session's shell.
+func (s *execSessions) Close(id string) {
+	s.mu.Lock()
+	sess, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.mu.Unlock()
+
+	if ok {
+		sess.cancel()
+	}
+}
+
+func (s *execSessions) CloseAll() {
+	s.mu.Lock()
+	sessions := s.sessions
+	s.sessions = make(map[string]*execSession)
+	s.mu.Unlock()
+
+	for _, sess := range sessions {
+		sess.cancel()
+	}
+}
+
+func (s *execSessions) session(ctx context.Context, req domain.ExecStart, sess *execSession) {
+	defer s.Close(req.ID)
+
+	term, err := s.run(ctx, req)
+	if err != nil {
+		s.log.Warn("failed to open exec session", "id", req.ID, "app_key", req.AppKey, "service", req.Service, "error", err)
+		s.sendMessage(ctx, "exec_exit", domain.ExecExit{ID: req.ID, ExitCode: -1, Error: err.Error()})
+		return
+	}
+	defer term.Close()
+
+	s.mu.Lock()
+	sess.term = term
+	s.mu.Unlock()
+
+	s.log.Info("exec session opened", "id", req.ID, "app_key", req.AppKey, "service", req.Service)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case data := <-sess.input:
+				if _, err := term.Write(data); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	output := make(chan struct{})
+	go func() {
+		defer close(output)
+
+		buf := make([]byte, execOutputChunk)
+		for {
+			n, err := term.Read(buf)
+			if n > 0 {
+				data := make([]byte, n)
+				copy(data, buf[:n])
+				if s.sendMessage(ctx, "exec_output", domain.ExecOutput{ID: req.ID, Data: data}) != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	code, err := term.Wait()
+
+	// Whatever the shell printed last may still be buffered in the
+	// terminal; processes it left behind must not hold the session open.
+	_ = term.SetReadDeadline(time.Now().Add(execDrainWait))
+	<-output
+
+	// A session closed by the server needs no report.
+	if ctx.Err() != nil {
+		return
+	}
+
+	exit := domain.ExecExit{ID: req.ID, ExitCode: code}
+	if err != nil {
+		exit.Error = err.Error()
+	}
+	s.sendMessage(ctx, "exec_exit", exit)
+
+	s.log.Info("exec session ended", "id", req.ID, "exit_code", code)
+}
+
+func (s *execSessions) sendMessage(ctx context.Context, event string, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	message, err := json.Marshal(&domain.WsAgentMessage{
+		ServerID: s.serverID,
+		Event:    event,
+		Payload:  raw,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, message)
+}
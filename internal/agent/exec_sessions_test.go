@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeTerminal echoes its input as output and exits with code once "exit"
+// is typed.
+type fakeTerminal struct {
+	out    *io.PipeReader
+	outW   *io.PipeWriter
+	exited chan struct{}
+	code   int
+	sizes  chan [2]uint16
+}
+
+func newFakeTerminal(code int) *fakeTerminal {
+	r, w := io.Pipe()
+	return &fakeTerminal{out: r, outW: w, exited: make(chan struct{}), code: code, sizes: make(chan [2]uint16, 4)}
+}
+
+func (f *fakeTerminal) Read(p []byte) (int, error) { return f.out.Read(p) }
+
+func (f *fakeTerminal) Write(p []byte) (int, error) {
+	if _, err := f.outW.Write(p); err != nil {
+		return 0, err
+	}
+	if string(p) == "exit" {
+		f.outW.Close()
+		close(f.exited)
+	}
+	return len(p), nil
+}
+
+func (f *fakeTerminal) Close() error                    { return f.out.Close() }
+func (f *fakeTerminal) SetReadDeadline(time.Time) error { return nil }
+
+func (f *fakeTerminal) Resize(rows, cols uint16) error {
+	f.sizes <- [2]uint16{rows, cols}
+	return nil
+}
+
+func (f *fakeTerminal) Wait() (int, error) {
+	<-f.exited
+	return f.code, nil
+}
+
+// execSink collects the messages sessions send.
+type execSink chan domain.WsAgentMessage
+
+func (e execSink) send(_ context.Context, message []byte) error {
+	var msg domain.WsAgentMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return err
+	}
+	e <- msg
+	return nil
+}
+
+func (e execSink) next(t *testing.T, event string, v any) {
+	t.Helper()
+	select {
+	case msg := <-e:
+		if msg.Event != event {
+			t.Fatalf("sent %s %s, want %s", msg.Event, msg.Payload, event)
+		}
+		if err := json.Unmarshal(msg.Payload, v); err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no %s sent", event)
+	}
+}
+
+func TestExecSessionRelaysTerminalAndReportsExit(t *testing.T) {
+	sink := make(execSink, 16)
+	term := newFakeTerminal(3)
+	run := func(context.Context, domain.ExecStart) (terminal, error) { return term, nil }
+	s := newExecSessions(uuid.New(), run, sink.send, nopLogger{})
+
+	s.Start(context.Background(), domain.ExecStart{ID: "e1"})
+
+	// Input sent before the shell started waits for it.
+	s.Input("e1", []byte("ls"))
+	var out domain.ExecOutput
+	sink.next(t, "exec_output", &out)
+	if out.ID != "e1" || string(out.Data) != "ls" {
+		t.Fatalf("output = %+v, want the echoed input", out)
+	}
+
+	s.Resize("e1", 40, 120)
+	if size := <-term.sizes; size != [2]uint16{40, 120} {
+		t.Fatalf("resized to %v, want 40x120", size)
+	}
+
+	s.Input("e1", []byte("exit"))
+	sink.next(t, "exec_output", &out)
+
+	var exit domain.ExecExit
+	sink.next(t, "exec_exit", &exit)
+	if exit.ID != "e1" || exit.ExitCode != 3 || exit.Error != "" {
+		t.Fatalf("exit = %+v, want code 3", exit)
+	}
+}
+
+func TestExecSessionReportsStartFailure(t *testing.T) {
+	sink := make(execSink, 16)
+	run := func(context.Context, domain.ExecStart) (terminal, error) {
+		return nil, errors.New("service \"web\" is not running")
+	}
+	s := newExecSessions(uuid.New(), run, sink.send, nopLogger{})
+
+	s.Start(context.Background(), domain.ExecStart{ID: "e1", Service: "web"})
+
+	var exit domain.ExecExit
+	sink.next(t, "exec_exit", &exit)
+	if exit.ID != "e1" || exit.ExitCode != -1 || exit.Error == "" {
+		t.Fatalf("exit = %+v, want the start error", exit)
+	}
+}
+
+func TestExecSessionClosedByServerSendsNoExit(t *testing.T) {
+	sink := make(execSink, 16)
+	term := newFakeTerminal(137)
+	started := make(chan struct{})
+	run := func(ctx context.Context, _ domain.ExecStart) (terminal, error) {
+		go func() {
+			<-ctx.Done()
+			term.Write([]byte("exit"))
+		}()
+		close(started)
+		return term, nil
+	}
+	s := newExecSessions(uuid.New(), run, sink.send, nopLogger{})
+
+	s.Start(context.Background(), domain.ExecStart{ID: "e1"})
+	<-started
+	s.Close("e1")
+
+	// The kill echoes "exit" but nothing is reported once closed.
+	timeout := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case msg := <-sink:
+			if msg.Event == "exec_exit" {
+				t.Fatalf("closed session sent %s", msg.Payload)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
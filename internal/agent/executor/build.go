@@ -0,0 +1,111 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"horizonx/internal/domain"
+)
+
+// buildSecretsOverride is the compose file, written next to the secret files,
+// that mounts the build secrets into every service that builds.
+const buildSecretsOverride = "compose.build-secrets.yml"
+
+// composeBuild runs `compose build` for a deploy. Build args are passed as
+// --build-arg; build secrets are written to a private temp dir, removed once
+// the build is done, and mounted through a compose override so a Dockerfile
+// reads them with `RUN --mount=type=secret,id=<key>`. Neither lands in the
+// app's .env; both are masked in the streamed build log with the job's other
+// secrets (see jobSecrets).
+func (e *Executor) composeBuild(ctx context.Context, stack composeStack, payload domain.AppDeployPayload, emit EmitHandler, action domain.LogAction) error {
+	args := []string{"build"}
+	for _, key := range sortedKeys(payload.BuildArgs) {
+		args = append(args, "--build-arg", key+"="+payload.BuildArgs[key])
+	}
+
+	if len(payload.BuildSecrets) > 0 {
+		dir, err := os.MkdirTemp("", "horizonx-build-")
+		if err != nil {
+			e.logFatalHandler(fmt.Sprintf("failed to prepare build secrets, %s", err.Error()), emit, action, domain.StepDockerBuild)
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		override, err := e.writeBuildSecrets(ctx, stack, dir, payload.BuildSecrets)
+		if err != nil {
+			e.logFatalHandler(fmt.Sprintf("failed to prepare build secrets, %s", err.Error()), emit, action, domain.StepDockerBuild)
+			return err
+		}
+		stack.overrides = append(stack.overrides, override)
+	}
+
+	_, err := e.composeCmd(ctx, stack, args, e.logStreamHandler(emit, action, domain.StepDockerBuild))
+	return err
+}
+
+// writeBuildSecrets writes each secret to its own file in dir and returns the
+// path of a compose override that declares them and hands all of them to
+// every service with a build section. Services are read from the resolved
+// config, so the override never adds a build to an image-only service.
+func (e *Executor) writeBuildSecrets(ctx context.Context, stack composeStack, dir string, secrets map[string]string) (string, error) {
+	out, err := e.composeCmd(ctx, stack, []string{"config", "--format", "json"})
+	if err != nil {
+		return "", fmt.Errorf("failed to read compose config: %w", err)
+	}
+
+	var config struct {
+		Services map[string]struct {
+			Build json.RawMessage `json:"build"`
+		} `json:"services"`
+	}
+	if err := json.Unmarshal([]byte(out), &config); err != nil {
+		return "", fmt.Errorf("failed to parse compose config: %w", err)
+	}
+
+	keys := sortedKeys(secrets)
+	declared := make(map[string]any, len(keys))
+	for _, key := range keys {
+		path := filepath.Join(dir, key)
+		if err := os.WriteFile(path, []byte(secrets[key]), 0o600); err != nil {
+			return "", fmt.Errorf("failed to write build secret %s: %w", key, err)
+		}
+		declared[key] = map[string]string{"file": path}
+	}
+
+	services := make(map[string]any)
+	for name, svc := range config.Services {
+		if len(svc.Build) == 0 || string(svc.Build) == "null" {
+			continue
+		}
+		services[name] = map[string]any{"build": map[string]any{"secrets": keys}}
+	}
+
+	// JSON is valid YAML, so the override needs no YAML encoder.
+	override, err := json.MarshalIndent(map[string]any{
+		"services": services,
+		"secrets":  declared,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, buildSecretsOverride)
+	if err := os.WriteFile(path, override, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write build secrets override: %w", err)
+	}
+
+	return path, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
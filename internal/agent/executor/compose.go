@@ -0,0 +1,138 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"horizonx/internal/agent/command"
+	"horizonx/internal/domain"
+)
+
+// composeStack is one app's compose stack on disk: the directory compose
+// runs in (where the app's .env lives) and the files and profiles that make
+// it up. Every job resolves it from the app's ComposeSpec, so a deploy, its
+// health gate and later start/stop/health-check jobs address the same stack.
+type composeStack struct {
+	// project is the compose project name, pinned to the app key so the
+	// stack's containers, networks and volumes are the app's alone: a preview
+	// shares its parent's compose spec and apps may share a ProjectDir
+	// basename, and moving ProjectDir must not orphan the running stack.
+	project string
+	dir     string
+	spec    domain.ComposeSpec
+
+	// overrides are extra compose files layered over the stack's for one
+	// command, such as the build secrets override. They live outside dir.
+	overrides []string
+}
+
+// appStack resolves the stack of the app checked out under appKey.
+func (e *Executor) appStack(appKey string, spec domain.ComposeSpec) (composeStack, error) {
+	if err := spec.Validate(); err != nil {
+		return composeStack{}, err
+	}
+
+	return composeStack{
+		project: composeProjectName(appKey),
+		dir:     filepath.Join(e.getAppWorkDir(appKey), spec.ProjectDir),
+		spec:    spec,
+	}, nil
+}
+
+// composeProjectName normalizes appKey the way compose normalizes a
+// directory name into a project name, so stacks started before the name was
+// pinned (named after their work dir, which is the app key) keep theirs.
+func composeProjectName(appKey string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(appKey))
+	return strings.TrimLeft(name, "-_")
+}
+
+// composeFiles returns the -f arguments for the stack. Without configured
+// files the single compose file is auto-detected (prod compose preferred, see
+// Manager.GetDockerComposeFile); configured files must all exist.
+func (e *Executor) composeFiles(stack composeStack) ([]string, error) {
+	if len(stack.spec.Files) == 0 {
+		file, err := e.docker.GetDockerComposeFile(stack.dir)
+		if err != nil {
+			return nil, err
+		}
+		return []string{file}, nil
+	}
+
+	for _, f := range stack.spec.Files {
+		if _, err := os.Stat(filepath.Join(stack.dir, f)); err != nil {
+			return nil, fmt.Errorf("compose file %s not found in %s", f, stack.dir)
+		}
+	}
+	return stack.spec.Files, nil
+}
+
+// composeCmd runs a `docker compose` subcommand against the stack. Files are
+// passed explicitly via -f so the right ones are used even when the repo
+// ships both dev and prod composes. Configured files may live in
+// subdirectories, so the project directory is pinned to the stack dir: that
+// is where relative paths resolve and where the agent's .env is read from.
+func (e *Executor) composeCmd(ctx context.Context, stack composeStack, args []string, handlers ...command.StreamHandler) (string, error) {
+	fullArgs, err := e.composeArgs(stack, args)
+	if err != nil {
+		return "", err
+	}
+
+	return e.docker.Cmd(ctx, stack.dir, fullArgs, handlers...)
+}
+
+// composeStream is composeCmd for subcommands that run until cancelled; their
+// output only goes to handler.
+func (e *Executor) composeStream(ctx context.Context, stack composeStack, args []string, handler command.StreamHandler) error {
+	fullArgs, err := e.composeArgs(stack, args)
+	if err != nil {
+		return err
+	}
+
+	return e.docker.Stream(ctx, stack.dir, fullArgs, handler)
+}
+
+// composePTY runs an interactive subcommand against the stack on a
+// pseudo-terminal.
+func (e *Executor) composePTY(ctx context.Context, stack composeStack, args []string, rows, cols uint16) (*command.PTY, error) {
+	fullArgs, err := e.composeArgs(stack, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.docker.PTY(ctx, stack.dir, fullArgs, rows, cols)
+}
+
+func (e *Executor) composeArgs(stack composeStack, args []string) ([]string, error) {
+	files, err := e.composeFiles(stack)
+	if err != nil {
+		return nil, err
+	}
+
+	fullArgs := []string{"compose"}
+	if stack.project != "" {
+		fullArgs = append(fullArgs, "-p", stack.project)
+	}
+	for _, f := range files {
+		fullArgs = append(fullArgs, "-f", f)
+	}
+	for _, f := range stack.overrides {
+		fullArgs = append(fullArgs, "-f", f)
+	}
+	if len(stack.spec.Files) > 0 {
+		fullArgs = append(fullArgs, "--project-directory", stack.dir)
+	}
+	for _, p := range stack.spec.Profiles {
+		fullArgs = append(fullArgs, "--profile", p)
+	}
+
+	return append(fullArgs, args...), nil
+}
@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"horizonx/internal/domain"
+)
+
+// maxContainerLogLine caps one line of app output relayed to the server, so a
+// single runaway line (a minified JSON dump) can't outgrow a WS frame.
+const maxContainerLogLine = 16 << 10
+
+// ContainerLogs runs `docker compose logs` on the app's stack and hands each
+// line to emit until compose exits or ctx is cancelled. It is not a job: it
+// takes no app lock and leaves nothing in the server's job logs.
+func (e *Executor) ContainerLogs(ctx context.Context, req domain.ContainerLogsRequest, emit func(domain.ContainerLogLine)) error {
+	if !filepath.IsLocal(req.AppKey) {
+		return fmt.Errorf("invalid app key %q", req.AppKey)
+	}
+	if err := domain.ValidateComposeService(req.Service); err != nil {
+		return err
+	}
+
+	stack, err := e.appStack(req.AppKey, req.Compose)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(stack.dir); err != nil {
+		return fmt.Errorf("app is not deployed on this server")
+	}
+
+	args := []string{"logs", "--no-color", "--timestamps"}
+	if req.Follow {
+		args = append(args, "--follow")
+	}
+	if req.Tail > 0 {
+		args = append(args, "--tail", strconv.Itoa(req.Tail))
+	}
+	// Values are joined to their flag so compose never reads one as a flag
+	// of its own.
+	if req.Since != "" {
+		args = append(args, "--since="+req.Since)
+	}
+	if req.Until != "" {
+		args = append(args, "--until="+req.Until)
+	}
+	if req.Service != "" {
+		args = append(args, req.Service)
+	}
+
+	return e.composeStream(ctx, stack, args, func(line string, _ domain.LogStream, level domain.LogLevel) {
+		if len(line) > maxContainerLogLine {
+			line = line[:maxContainerLogLine] + " [truncated]"
+		}
+		emit(domain.ParseContainerLogLine(line, level))
+	})
+}
@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"horizonx/internal/domain"
+)
+
+func TestContainerLogsRunsComposeLogsAndParsesLines(t *testing.T) {
+	workDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workDir, "shop-1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	docker := &fakeDocker{streamOut: []string{
+		"web-1  | 2026-10-16T08:00:00.123456789Z listening on :8080",
+		"no prefix here",
+	}}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, workDir, noopLogger(), nil)
+
+	var lines []domain.ContainerLogLine
+	err := ex.ContainerLogs(context.Background(), domain.ContainerLogsRequest{
+		ID:      "s1",
+		AppInfo: domain.AppInfo{ApplicationID: 1, AppKey: "shop-1"},
+		Service: "web",
+		Tail:    100,
+		Follow:  true,
+		Since:   "15m",
+	}, func(line domain.ContainerLogLine) { lines = append(lines, line) })
+	if err != nil {
+		t.Fatalf("ContainerLogs: %v", err)
+	}
+
+	want := "compose -p shop-1 -f compose.yml logs --no-color --timestamps --follow --tail 100 --since=15m web"
+	if len(docker.cmdCalls) != 1 || docker.cmdCalls[0] != want {
+		t.Fatalf("calls = %q, want %q", docker.cmdCalls, want)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if l := lines[0]; l.Container != "web-1" || l.Line != "listening on :8080" || l.Timestamp == nil {
+		t.Fatalf("line = %+v, want web-1's timestamped line", l)
+	}
+	if l := lines[1]; l.Container != "" || l.Line != "no prefix here" || l.Timestamp != nil {
+		t.Fatalf("line = %+v, want the raw line", l)
+	}
+}
+
+func TestContainerLogsRejectsUndeployedAppAndBadService(t *testing.T) {
+	docker := &fakeDocker{}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, t.TempDir(), noopLogger(), nil)
+	emit := func(domain.ContainerLogLine) {}
+
+	if err := ex.ContainerLogs(context.Background(), domain.ContainerLogsRequest{AppInfo: domain.AppInfo{AppKey: "missing-1"}}, emit); err == nil {
+		t.Fatal("logs of an app that was never deployed must fail")
+	}
+	if err := ex.ContainerLogs(context.Background(), domain.ContainerLogsRequest{AppInfo: domain.AppInfo{AppKey: "../etc"}}, emit); err == nil {
+		t.Fatal("app key outside the work dir must be rejected")
+	}
+	if err := ex.ContainerLogs(context.Background(), domain.ContainerLogsRequest{AppInfo: domain.AppInfo{AppKey: "shop-1"}, Service: "--help"}, emit); err == nil {
+		t.Fatal("service name that looks like a flag must be rejected")
+	}
+	if len(docker.cmdCalls) != 0 {
+		t.Fatalf("compose ran for a rejected request: %q", docker.cmdCalls)
+	}
+}
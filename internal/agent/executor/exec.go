@@ -0,0 +1,36 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"horizonx/internal/agent/command"
+	"horizonx/internal/domain"
+)
+
+// Exec opens a shell in one service's running container of the app, on a
+// pseudo-terminal the caller relays. Like ContainerLogs it is not a job.
+func (e *Executor) Exec(ctx context.Context, req domain.ExecStart) (*command.PTY, error) {
+	if !filepath.IsLocal(req.AppKey) {
+		return nil, fmt.Errorf("invalid app key %q", req.AppKey)
+	}
+
+	opts := domain.ExecOptions{Service: req.Service, Shell: req.Shell, Cols: req.Cols, Rows: req.Rows}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	stack, err := e.appStack(req.AppKey, req.Compose)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(stack.dir); err != nil {
+		return nil, fmt.Errorf("app is not deployed on this server")
+	}
+
+	// compose exec is interactive with a TTY by default once its own stdin
+	// is a terminal.
+	return e.composePTY(ctx, stack, []string{"exec", opts.Service, opts.Shell}, opts.Rows, opts.Cols)
+}
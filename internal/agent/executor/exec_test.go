@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"horizonx/internal/domain"
+)
+
+func TestExecRunsComposeExecOnATerminal(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-terminals are only supported on linux")
+	}
+
+	workDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workDir, "shop-1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	docker := &fakeDocker{ptyCmd: []string{"sh", "-c", "stty size; exit 2"}}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, workDir, noopLogger(), nil)
+
+	pty, err := ex.Exec(context.Background(), domain.ExecStart{
+		ID:      "e1",
+		AppInfo: domain.AppInfo{ApplicationID: 1, AppKey: "shop-1"},
+		Service: "web",
+		Shell:   "bash",
+		Cols:    100,
+		Rows:    30,
+	})
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	defer pty.Close()
+
+	want := "compose -p shop-1 -f compose.yml exec web bash"
+	if len(docker.cmdCalls) != 1 || docker.cmdCalls[0] != want {
+		t.Fatalf("calls = %q, want %q", docker.cmdCalls, want)
+	}
+
+	out, _ := io.ReadAll(pty)
+	if !strings.Contains(string(out), "30 100") {
+		t.Fatalf("output = %q, want the requested terminal size", out)
+	}
+	if code, _ := pty.Wait(); code != 2 {
+		t.Fatalf("exit code = %d, want 2", code)
+	}
+}
+
+func TestExecRejectsUndeployedAppAndBadOptions(t *testing.T) {
+	docker := &fakeDocker{}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, t.TempDir(), noopLogger(), nil)
+	ctx := context.Background()
+
+	if _, err := ex.Exec(ctx, domain.ExecStart{AppInfo: domain.AppInfo{AppKey: "missing-1"}, Service: "web"}); err == nil {
+		t.Fatal("exec into an app that was never deployed must fail")
+	}
+	if _, err := ex.Exec(ctx, domain.ExecStart{AppInfo: domain.AppInfo{AppKey: "../etc"}, Service: "web"}); err == nil {
+		t.Fatal("app key outside the work dir must be rejected")
+	}
+	if _, err := ex.Exec(ctx, domain.ExecStart{AppInfo: domain.AppInfo{AppKey: "shop-1"}, Service: "web", Shell: "python"}); err == nil {
+		t.Fatal("a shell outside the allowed list must be rejected")
+	}
+	if len(docker.cmdCalls) != 0 {
+		t.Fatalf("compose ran for a rejected request: %q", docker.cmdCalls)
+	}
+}
@@ -28,16 +28,20 @@ const healthGateTimeout = 60 * time.Second
 // an interface so the deploy pipeline can be unit-tested with a fake.
 type DockerRunner interface {
 	Cmd(ctx context.Context, workDir string, args []string, handlers ...command.StreamHandler) (string, error)
+	Stream(ctx context.Context, workDir string, args []string, handler command.StreamHandler) error
+	PTY(ctx context.Context, workDir string, args []string, rows, cols uint16) (*command.PTY, error)
+	Pull(ctx context.Context, workDir, image string, creds *domain.RegistryCredentials, handlers ...command.StreamHandler) (string, error)
 	GetDockerComposeFile(workDir string) (string, error)
 	GetDockerfile(workDir string) (string, error)
 	WriteEnvFile(workDir string, envVars map[string]string) error
+	ReadEnvFile(workDir string) (map[string]string, error)
 	IsDockerInstalled() bool
 	IsDockerComposeAvailable() bool
 }
 
 // GitRunner is the subset of git.Manager the executor needs.
 type GitRunner interface {
-	CloneOrPull(ctx context.Context, workDir, remoteURL, branch string, handlers ...command.StreamHandler) (string, error)
+	CloneOrPull(ctx context.Context, workDir, remoteURL, branch, ref string, creds *domain.GitCredentials, handlers ...command.StreamHandler) (string, error)
 	GetCurrentCommit(ctx context.Context, workDir string, handlers ...command.StreamHandler) (string, error)
 	GetCommitMessage(ctx context.Context, workDir string, handlers ...command.StreamHandler) (string, error)
 	IsGitInstalled() bool
@@ -89,6 +93,18 @@ func (e *Executor) Init() error {
 func (e *Executor) Execute(ctx context.Context, job *domain.Job, emit EmitHandler) error {
 	e.log.Debug("executing job", "job_id", job.ID)
 
+	// Every command the job runs masks the payload's secrets in its output
+	// before a line is emitted to the server.
+	ctx = command.WithRedactor(ctx, command.NewRedactor(jobSecrets(job)...))
+
+	// A payload newer than this build would be half-understood: fields it
+	// doesn't know are silently dropped. The server checks this too, from
+	// the agent's hello; this catches jobs queued before the hello arrived.
+	if supported, ok := domain.JobPayloadVersions[job.Type]; ok && job.PayloadVersion > supported {
+		return fmt.Errorf("%s payload version %d is newer than this agent supports (%d); upgrade the agent",
+			job.Type, job.PayloadVersion, supported)
+	}
+
 	switch job.Type {
 	case domain.JobTypeMetricsCollect:
 		emit(e.metrics())
@@ -107,26 +123,58 @@ func (e *Executor) Execute(ctx context.Context, job *domain.Job, emit EmitHandle
 		return e.rollbackApp(ctx, job, emit)
 	case domain.JobTypeAppDestroy:
 		return e.destroyApp(ctx, job, emit)
+	case domain.JobTypeAppService:
+		return e.appService(ctx, job, emit)
+	case domain.JobTypeAgentUpgrade:
+		return e.upgradeAgent(ctx, job, emit)
 	default:
 		return fmt.Errorf("unknown job type: %s", job.Type)
 	}
 }
 
-func (e *Executor) getAppWorkDir(dirName string) string {
-	return filepath.Join(e.workDir, dirName)
-}
+// jobSecrets returns the values in a job's payload that must not appear in
+// its logs: secret env var values, build secrets and credentials. Payload
+// types share these keys, so one decode covers all of them.
+func jobSecrets(job *domain.Job) []string {
+	var payload struct {
+		EnvVars             map[string]string           `json:"env_vars"`
+		SecretEnvKeys       []string                    `json:"secret_env_keys"`
+		BuildSecrets        map[string]string           `json:"build_secrets"`
+		GitCredentials      *domain.GitCredentials      `json:"git_credentials"`
+		RegistryCredentials *domain.RegistryCredentials `json:"registry_credentials"`
+	}
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil
+	}
 
-// composeCmd runs a `docker compose` subcommand against the repo's compose
-// file. The file is resolved per-call (prod compose preferred, see
-// Manager.GetDockerComposeFile) and passed explicitly via -f so the right
-// file is used even when the repo ships both dev + prod composes.
-func (e *Executor) composeCmd(ctx context.Context, workDir string, args []string, handlers ...command.StreamHandler) (string, error) {
-	composeFile, err := e.docker.GetDockerComposeFile(workDir)
-	if err != nil {
-		return "", err
+	// Only values marked secret: masking every env value would blank out
+	// ordinary words and numbers ("true", "3000") wherever they appear.
+	var secrets []string
+	for _, key := range payload.SecretEnvKeys {
+		secrets = append(secrets, payload.EnvVars[key])
+	}
+	for _, v := range payload.BuildSecrets {
+		secrets = append(secrets, v)
+	}
+	// Basic auth and docker config auths encode user and password together,
+	// so the pair is a secret of its own.
+	if c := payload.GitCredentials; c != nil {
+		secrets = append(secrets, c.SSHPrivateKey, c.HTTPSToken)
+		if c.HTTPSUsername != "" && c.HTTPSToken != "" {
+			secrets = append(secrets, c.HTTPSUsername+":"+c.HTTPSToken)
+		}
+	}
+	if c := payload.RegistryCredentials; c != nil {
+		secrets = append(secrets, c.Password)
+		if c.Username != "" && c.Password != "" {
+			secrets = append(secrets, c.Username+":"+c.Password)
+		}
 	}
-	fullArgs := append([]string{"compose", "-f", composeFile}, args...)
-	return e.docker.Cmd(ctx, workDir, fullArgs, handlers...)
+	return secrets
+}
+
+func (e *Executor) getAppWorkDir(dirName string) string {
+	return filepath.Join(e.workDir, dirName)
 }
 
 func (e *Executor) createWorkDir() error {
@@ -186,9 +234,16 @@ func (e *Executor) checkAppHealths(ctx context.Context, job *domain.Job, emit Em
 	reports := make([]domain.ApplicationHealth, 0, len(payload.Applications))
 
 	for _, app := range payload.Applications {
-		workDir := e.getAppWorkDir(app.AppKey)
+		stack, err := e.appStack(app.AppKey, app.Compose)
+		if err != nil {
+			reports = append(reports, domain.ApplicationHealth{
+				ApplicationID: app.ApplicationID,
+				Status:        domain.AppStatusFailed,
+			})
+			continue
+		}
 
-		output, err := e.composeCmd(ctx, workDir, []string{"ps", "--format", "json"})
+		output, err := e.composeCmd(ctx, stack, []string{"ps", "--format", "json"})
 		if err != nil {
 			// TODO: implement application docker container status
 			e.log.Debug("failed to run docker compose ps",
@@ -209,6 +264,7 @@ func (e *Executor) checkAppHealths(ctx context.Context, job *domain.Job, emit Em
 			reports = append(reports, domain.ApplicationHealth{
 				ApplicationID: app.ApplicationID,
 				Status:        domain.AppStatusUnknown,
+				Services:      e.serviceHealths(ctx, stack, nil),
 			})
 			continue
 		}
@@ -241,6 +297,7 @@ func (e *Executor) checkAppHealths(ctx context.Context, job *domain.Job, emit Em
 		reports = append(reports, domain.ApplicationHealth{
 			ApplicationID: app.ApplicationID,
 			Status:        aggregateContainerHealth(containers),
+			Services:      e.serviceHealths(ctx, stack, containers),
 		})
 	}
 
@@ -339,7 +396,7 @@ func aggregateContainerHealth(containers []docker.Container) domain.ApplicationS
 // deadline passes with the app still starting.
 func (e *Executor) waitForAppRunning(
 	ctx context.Context,
-	workDir string,
+	stack composeStack,
 	appKey string,
 	emit EmitHandler,
 	action domain.LogAction,
@@ -351,7 +408,7 @@ func (e *Executor) waitForAppRunning(
 	defer ticker.Stop()
 
 	for {
-		output, err := e.composeCmd(ctx, workDir, []string{"ps", "--format", "json"})
+		output, err := e.composeCmd(ctx, stack, []string{"ps", "--format", "json"})
 		if err != nil {
 			// compose ps can fail transiently while containers are created.
 			if time.Now().After(deadline) {
@@ -406,60 +463,40 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 	workDir := e.getAppWorkDir(payload.AppKey)
 	action := domain.ActionAppDeploy
 
-	// Create app work directory
-	if err := os.MkdirAll(workDir, 0o755); err != nil {
-		return err
-	}
-
-	// Git clone or pull
-	if _, err := e.git.CloneOrPull(ctx, workDir, payload.RepoURL, payload.Branch, e.logStreamHandler(
-		emit,
-		action,
-		domain.StepGitClone,
-	),
-	); err != nil {
-		e.logFatalHandler(
-			fmt.Sprintf("failed to clone or pull repository, %s", err.Error()),
-			emit,
-			action,
-			domain.StepGitClone,
-		)
+	stack, err := e.appStack(payload.AppKey, payload.Compose)
+	if err != nil {
+		e.logFatalHandler(err.Error(), emit, action, domain.StepBuildPrepare)
 		return err
 	}
 
-	// Get git commit info
-	commitHash, err := e.git.GetCurrentCommit(ctx, workDir)
-	if err != nil {
-		e.logFatalHandler(
-			fmt.Sprintf("failed to get commit hash, %s", err.Error()),
-			emit,
-			action,
-			domain.StepBuildPrepare,
-		)
+	// Create app work directory
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
 		return err
 	}
 
-	// Get git commit message
-	commitMessage, err := e.git.GetCommitMessage(ctx, workDir)
-	if err != nil {
-		e.logFatalHandler(
-			fmt.Sprintf("failed to get commit message, %s", err.Error()),
-			emit,
-			action,
-			domain.StepBuildPrepare,
-		)
+	// Image apps pull a prebuilt image; git apps clone and build their own,
+	// tagged with the commit.
+	var appImage string
+	if payload.SourceType == domain.SourceImage {
+		appImage, err = e.pullImage(ctx, stack, payload, emit, action)
+		if err != nil {
+			return err
+		}
 
-		return err
+		// Report the pinned reference: a rollback replays exactly it.
+		emit(domain.EventCommitInfoEmitted{
+			DeploymentID: *job.DeploymentID,
+			ImageDigest:  appImage,
+		})
+	} else {
+		appImage, err = e.checkout(ctx, workDir, payload, *job.DeploymentID, emit, action)
+		if err != nil {
+			return err
+		}
 	}
 
-	emit(domain.EventCommitInfoEmitted{
-		DeploymentID: *job.DeploymentID,
-		Hash:         commitHash[:8],
-		Message:      commitMessage,
-	})
-
-	// Get docker compose file
-	if _, err := e.docker.GetDockerComposeFile(workDir); err != nil {
+	// Get docker compose files
+	if _, err := e.composeFiles(stack); err != nil {
 		e.logFatalHandler(
 			fmt.Sprintf("failed to get docker compose file, %s", err.Error()),
 			emit,
@@ -469,10 +506,20 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 		return err
 	}
 
+	// Keep the env the running stack was started with. The new one, with the
+	// new APP_IMAGE, must be on disk for the build and the pre-deploy
+	// commands, so the old one is put back if either fails: a later restart
+	// must not boot a release that never went live. An automatic rollback
+	// restores it too if the new release fails its health gate.
+	prevEnv, err := e.docker.ReadEnvFile(stack.dir)
+	if err != nil {
+		e.log.Warn("failed to read previous env, it cannot be restored", "app", payload.AppKey, "error", err)
+	}
+
 	// Write user env
 	userEnvVars := payload.EnvVars
 	if len(userEnvVars) > 0 {
-		if err := e.docker.WriteEnvFile(workDir, userEnvVars); err != nil {
+		if err := e.docker.WriteEnvFile(stack.dir, userEnvVars); err != nil {
 			e.logFatalHandler(
 				fmt.Sprintf("failed to write user env, %s", err.Error()),
 				emit,
@@ -483,8 +530,6 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 		}
 	}
 
-	// Define docker's image and container name
-	appImage := fmt.Sprintf("%s:%s", payload.AppKey, commitHash)
 	appContainerName := payload.AppKey
 
 	// Write user and build env (APP_IMAGE + APP_CONTAINER_NAME must exist BEFORE
@@ -497,7 +542,7 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 	envVars["APP_IMAGE"] = appImage
 	envVars["APP_CONTAINER_NAME"] = appContainerName
 
-	if err := e.docker.WriteEnvFile(workDir, envVars); err != nil {
+	if err := e.docker.WriteEnvFile(stack.dir, envVars); err != nil {
 		e.logFatalHandler(
 			fmt.Sprintf("failed to write user and build env, %s", err.Error()),
 			emit,
@@ -512,17 +557,27 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 	// the compose file pick its own build.target — a multi-stage Dockerfile
 	// whose last stage is e.g. nginx no longer produces the wrong runtime
 	// image. Compose tags each service per its image: field, so the primary
-	// app image lands on APP_IMAGE (the rollback tag).
-	if _, err := e.composeCmd(ctx, workDir, []string{"build"}, e.logStreamHandler(
-		emit,
-		action,
-		domain.StepDockerBuild,
-	)); err != nil {
-		// CRITICAL (P0-1): a failed build must abort the deploy. Falling
-		// through used to run `compose down` on the RUNNING app and then
-		// `compose up` a broken/absent image — a bad build took the old,
-		// working app down. Return the error so the job is marked failed
-		// and the running stack is left untouched.
+	// app image lands on APP_IMAGE (the rollback tag). Build args and secrets
+	// reach only this step (see composeBuild). Image apps were pulled ready
+	// to run.
+	if payload.SourceType != domain.SourceImage {
+		if err := e.composeBuild(ctx, stack, payload, emit, action); err != nil {
+			// CRITICAL (P0-1): a failed build must abort the deploy. Falling
+			// through used to run `compose down` on the RUNNING app and then
+			// `compose up` a broken/absent image — a bad build took the old,
+			// working app down. Return the error so the job is marked failed
+			// and the running stack is left untouched.
+			e.restoreEnv(stack, prevEnv, emit, action, domain.StepDockerBuild)
+			return err
+		}
+	}
+
+	// Release commands (migrations) run against the freshly built image
+	// before traffic switches. Like a failed build, a failed command aborts
+	// the deploy with the running stack untouched.
+	if err := e.runReleaseCommands(ctx, stack, payload.PreDeployCommands, emit, action, domain.StepPreDeploy); err != nil {
+		e.logFatalHandler(err.Error(), emit, action, domain.StepPreDeploy)
+		e.restoreEnv(stack, prevEnv, emit, action, domain.StepPreDeploy)
 		return err
 	}
 
@@ -531,7 +586,7 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 	// actually come up before the job reports success — a build that
 	// recreates cleanly but crashes on boot must flip the app to failed,
 	// not optimistically claim "running".
-	if _, err := e.composeCmd(ctx, workDir, []string{"up", "-d", "--force-recreate"}, e.logStreamHandler(
+	if _, err := e.composeCmd(ctx, stack, []string{"up", "-d", "--force-recreate"}, e.logStreamHandler(
 		emit,
 		action,
 		domain.StepDockerStart,
@@ -546,23 +601,218 @@ func (e *Executor) deployApp(ctx context.Context, job *domain.Job, emit EmitHand
 	}
 
 	// P1-9: post-deploy health gate — only report success once the app is
-	// actually running, not merely "compose up returned 0".
-	if err := e.waitForAppRunning(ctx, workDir, payload.AppKey, emit, action, domain.StepDockerHealthCheck, healthGateTimeout); err != nil {
+	// actually running and its readiness probes pass, not merely "compose up
+	// returned 0".
+	if err := e.healthGate(ctx, stack, payload.AppKey, payload.ReadinessProbes, emit, action); err != nil {
+		if payload.AutoRollback && ctx.Err() == nil {
+			e.autoRollback(ctx, stack, payload, appImage, prevEnv, err, emit)
+		}
 		return err
 	}
 
+	// Post-deploy commands (cache warmup) need the new release up and healthy.
+	// Traffic has already moved to it, so a failure is a warning: failing the
+	// deploy would report a failed release that is in fact live.
+	if err := e.runReleaseCommands(ctx, stack, payload.PostDeployCommands, emit, action, domain.StepPostDeploy); err != nil {
+		e.logStreamHandler(emit, action, domain.StepPostDeploy)(
+			fmt.Sprintf("%s; the new release stays live", err.Error()),
+			domain.StreamStderr,
+			domain.LogWarn,
+		)
+	}
+
 	return nil
 }
 
+// restoreEnv puts back the env the running stack was started with after a
+// deploy is abandoned before traffic switched. A first deploy has none.
+func (e *Executor) restoreEnv(stack composeStack, prevEnv map[string]string, emit EmitHandler, action domain.LogAction, step domain.LogStep) {
+	if len(prevEnv) == 0 {
+		return
+	}
+	if err := e.docker.WriteEnvFile(stack.dir, prevEnv); err != nil {
+		e.logStreamHandler(emit, action, step)(
+			fmt.Sprintf("failed to restore the previous env, %s", err.Error()),
+			domain.StreamStderr,
+			domain.LogWarn,
+		)
+	}
+}
+
+// checkout brings the app's repository to the commit being deployed, reports
+// it, and returns the image tag the build produces.
+func (e *Executor) checkout(
+	ctx context.Context,
+	workDir string,
+	payload domain.AppDeployPayload,
+	deploymentID int64,
+	emit EmitHandler,
+	action domain.LogAction,
+) (string, error) {
+	// Git clone or pull
+	if _, err := e.git.CloneOrPull(ctx, workDir, payload.RepoURL, payload.Branch, payload.Ref, payload.GitCredentials, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepGitClone,
+	),
+	); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to clone or pull repository, %s", err.Error()),
+			emit,
+			action,
+			domain.StepGitClone,
+		)
+		return "", err
+	}
+
+	// Get git commit info
+	commitHash, err := e.git.GetCurrentCommit(ctx, workDir)
+	if err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to get commit hash, %s", err.Error()),
+			emit,
+			action,
+			domain.StepBuildPrepare,
+		)
+		return "", err
+	}
+
+	// Get git commit message
+	commitMessage, err := e.git.GetCommitMessage(ctx, workDir)
+	if err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to get commit message, %s", err.Error()),
+			emit,
+			action,
+			domain.StepBuildPrepare,
+		)
+
+		return "", err
+	}
+
+	// Report the full hash: it is the image tag suffix, and a rollback
+	// rebuilds the tag from the recorded hash.
+	emit(domain.EventCommitInfoEmitted{
+		DeploymentID: deploymentID,
+		Hash:         commitHash,
+		Message:      commitMessage,
+	})
+
+	return fmt.Sprintf("%s:%s", payload.AppKey, commitHash), nil
+}
+
+// runReleaseCommands runs each command in a one-off `compose run --rm`
+// container of its service, in order, stopping at the first failure. The
+// caller reports the failure: how bad it is depends on the step.
+func (e *Executor) runReleaseCommands(
+	ctx context.Context,
+	stack composeStack,
+	cmds []domain.ReleaseCommand,
+	emit EmitHandler,
+	action domain.LogAction,
+	step domain.LogStep,
+) error {
+	for _, c := range cmds {
+		e.logStreamHandler(emit, action, step)(
+			fmt.Sprintf("running %q in %s", c.Command, c.Service),
+			domain.StreamStdout,
+			domain.LogInfo,
+		)
+
+		if _, err := e.composeCmd(ctx, stack, []string{"run", "--rm", c.Service, "sh", "-c", c.Command}, e.logStreamHandler(
+			emit,
+			action,
+			step,
+		)); err != nil {
+			return fmt.Errorf("release command %q in %s failed, %w", c.Command, c.Service, err)
+		}
+	}
+
+	return nil
+}
+
+// autoRollback puts the previously running image back after a deploy failed
+// its health gate: the old .env (and with it APP_IMAGE) is restored and the
+// stack recreated in place. Both outcomes are reported; the deploy itself
+// still fails.
+func (e *Executor) autoRollback(
+	ctx context.Context,
+	stack composeStack,
+	payload domain.AppDeployPayload,
+	failedImage string,
+	prevEnv map[string]string,
+	gateErr error,
+	emit EmitHandler,
+) {
+	action := domain.ActionAppDeploy
+	step := domain.StepDockerStart
+
+	prevImage := prevEnv["APP_IMAGE"]
+	if prevImage == "" || prevImage == failedImage {
+		e.logStreamHandler(emit, action, step)(
+			"no previous release to roll back to, leaving the failed release in place",
+			domain.StreamStderr,
+			domain.LogWarn,
+		)
+		return
+	}
+
+	e.logStreamHandler(emit, action, step)(
+		fmt.Sprintf("health gate failed, rolling back to %s", prevImage),
+		domain.StreamStdout,
+		domain.LogWarn,
+	)
+
+	report := domain.EventAutoRollbackEmitted{
+		DeploymentID:  payload.DeploymentID,
+		FailedImage:   failedImage,
+		RestoredImage: prevImage,
+		Reason:        gateErr.Error(),
+	}
+
+	if err := e.docker.WriteEnvFile(stack.dir, prevEnv); err != nil {
+		e.logFatalHandler(fmt.Sprintf("auto rollback failed to restore env, %s", err.Error()), emit, action, step)
+		emit(report)
+		return
+	}
+
+	if _, err := e.composeCmd(ctx, stack, []string{"up", "-d", "--force-recreate"}, e.logStreamHandler(
+		emit,
+		action,
+		step,
+	)); err != nil {
+		e.logFatalHandler(fmt.Sprintf("auto rollback failed to run docker compose up, %s", err.Error()), emit, action, step)
+		emit(report)
+		return
+	}
+
+	if err := e.healthGate(ctx, stack, payload.AppKey, payload.ReadinessProbes, emit, action); err != nil {
+		emit(report)
+		return
+	}
+
+	e.logStreamHandler(emit, action, domain.StepDockerHealthCheck)(
+		fmt.Sprintf("rolled back to %s, app is running", prevImage),
+		domain.StreamStdout,
+		domain.LogInfo,
+	)
+
+	report.Restored = true
+	emit(report)
+}
+
 func (e *Executor) startApp(ctx context.Context, job *domain.Job, emit EmitHandler) error {
 	var payload domain.AppStartPayload
 	if err := json.Unmarshal(job.Payload, &payload); err != nil {
 		return err
 	}
 
-	workDir := e.getAppWorkDir(payload.AppKey)
+	stack, err := e.appStack(payload.AppKey, payload.Compose)
+	if err != nil {
+		return err
+	}
 
-	if _, err := e.composeCmd(ctx, workDir, []string{"start"}, e.logStreamHandler(
+	if _, err := e.composeCmd(ctx, stack, []string{"start"}, e.logStreamHandler(
 		emit,
 		domain.ActionAppStart,
 		domain.StepDockerStart,
@@ -585,9 +835,12 @@ func (e *Executor) stopApp(ctx context.Context, job *domain.Job, emit EmitHandle
 		return err
 	}
 
-	workDir := e.getAppWorkDir(payload.AppKey)
+	stack, err := e.appStack(payload.AppKey, payload.Compose)
+	if err != nil {
+		return err
+	}
 
-	if _, err := e.composeCmd(ctx, workDir, []string{"stop"}, e.logStreamHandler(
+	if _, err := e.composeCmd(ctx, stack, []string{"stop"}, e.logStreamHandler(
 		emit,
 		domain.ActionAppStop,
 		domain.StepDockerStop,
@@ -610,9 +863,12 @@ func (e *Executor) restartApp(ctx context.Context, job *domain.Job, emit EmitHan
 		return err
 	}
 
-	workDir := e.getAppWorkDir(payload.AppKey)
+	stack, err := e.appStack(payload.AppKey, payload.Compose)
+	if err != nil {
+		return err
+	}
 
-	if _, err := e.composeCmd(ctx, workDir, []string{"up", "-d", "--force-recreate"}, e.logStreamHandler(
+	if _, err := e.composeCmd(ctx, stack, []string{"up", "-d", "--force-recreate"}, e.logStreamHandler(
 		emit,
 		domain.ActionAppRestart,
 		domain.StepDockerRestart,
@@ -631,24 +887,50 @@ func (e *Executor) restartApp(ctx context.Context, job *domain.Job, emit EmitHan
 
 // rollbackApp re-deploys the stack using a previously built image tag (P0-4).
 // The tag was recorded from the last successful deploy, so the image exists in
-// the local docker daemon. We rewrite the env file so APP_IMAGE points at the
-// old tag, then recreate in place — same zero-downtime path as a normal deploy.
+// the local docker daemon; image apps re-pull their pinned digest if not. We
+// rewrite the env file so APP_IMAGE points at the old tag, then recreate in
+// place — same zero-downtime path as a normal deploy.
 func (e *Executor) rollbackApp(ctx context.Context, job *domain.Job, emit EmitHandler) error {
 	var payload domain.AppRollbackPayload
 	if err := json.Unmarshal(job.Payload, &payload); err != nil {
 		return err
 	}
 
-	workDir := e.getAppWorkDir(payload.AppKey)
 	action := domain.ActionAppDeploy
 	step := domain.StepDockerStart
 
+	stack, err := e.appStack(payload.AppKey, payload.Compose)
+	if err != nil {
+		e.logFatalHandler(err.Error(), emit, action, step)
+		return err
+	}
+
 	if payload.ImageTag == "" {
 		err := fmt.Errorf("rollback image tag is empty")
 		e.logFatalHandler(err.Error(), emit, action, step)
 		return err
 	}
 
+	// Make sure the image is still on this host before touching the running
+	// stack — it may have been pruned since that release. A pinned registry
+	// image can simply be pulled again; a built one is gone for good.
+	imageTag := payload.ImageTag
+	if payload.SourceType == domain.SourceImage {
+		err = e.ensureImage(ctx, stack.dir, payload.ImageTag, payload.RegistryCredentials, emit, action)
+	} else {
+		imageTag, err = e.resolveImageTag(ctx, stack.dir, payload.ImageTag)
+	}
+	if err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("rollback image %s is not available on this server, %s", payload.ImageTag, err.Error()),
+			emit,
+			action,
+			domain.StepBuildPrepare,
+		)
+		return err
+	}
+	payload.ImageTag = imageTag
+
 	// Point the stack at the previous image and reuse the app's current env.
 	envVars := make(map[string]string)
 	if payload.EnvVars != nil {
@@ -659,7 +941,7 @@ func (e *Executor) rollbackApp(ctx context.Context, job *domain.Job, emit EmitHa
 	envVars["APP_IMAGE"] = payload.ImageTag
 	envVars["APP_CONTAINER_NAME"] = payload.AppKey
 
-	if err := e.docker.WriteEnvFile(workDir, envVars); err != nil {
+	if err := e.docker.WriteEnvFile(stack.dir, envVars); err != nil {
 		e.logFatalHandler(
 			fmt.Sprintf("failed to write rollback env, %s", err.Error()),
 			emit,
@@ -671,7 +953,7 @@ func (e *Executor) rollbackApp(ctx context.Context, job *domain.Job, emit EmitHa
 
 	// Recreate with the previous image. Health-gated like a deploy (P1-9):
 	// the rollback only succeeds once the stack is actually running again.
-	if _, err := e.composeCmd(ctx, workDir, []string{"up", "-d", "--force-recreate"}, e.logStreamHandler(
+	if _, err := e.composeCmd(ctx, stack, []string{"up", "-d", "--force-recreate"}, e.logStreamHandler(
 		emit,
 		action,
 		step,
@@ -685,28 +967,64 @@ func (e *Executor) rollbackApp(ctx context.Context, job *domain.Job, emit EmitHa
 		return err
 	}
 
-	if err := e.waitForAppRunning(ctx, workDir, payload.AppKey, emit, action, domain.StepDockerHealthCheck, healthGateTimeout); err != nil {
+	if err := e.healthGate(ctx, stack, payload.AppKey, payload.ReadinessProbes, emit, action); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// resolveImageTag checks that imageTag exists in the local docker daemon.
+// Deployments recorded before full commit hashes were reported carry an
+// 8-character hash, while images were always tagged with the full one; such
+// tags are matched by prefix against the repository's local tags.
+func (e *Executor) resolveImageTag(ctx context.Context, workDir, imageTag string) (string, error) {
+	if _, err := e.docker.Cmd(ctx, workDir, []string{"image", "inspect", "--format", "{{.Id}}", imageTag}); err == nil {
+		return imageTag, nil
+	}
+
+	repo, tag, ok := strings.Cut(imageTag, ":")
+	if !ok || tag == "" {
+		return "", fmt.Errorf("image %s not found", imageTag)
+	}
+
+	out, err := e.docker.Cmd(ctx, workDir, []string{"image", "ls", repo, "--format", "{{.Tag}}"})
+	if err != nil {
+		return "", fmt.Errorf("failed to list images: %w", err)
+	}
+
+	for _, local := range strings.Fields(out) {
+		if strings.HasPrefix(local, tag) {
+			return repo + ":" + local, nil
+		}
+	}
+
+	return "", fmt.Errorf("image %s not found", imageTag)
+}
+
 func (e *Executor) destroyApp(ctx context.Context, job *domain.Job, emit EmitHandler) error {
 	var payload domain.AppDestroyPayload
 	if err := json.Unmarshal(job.Payload, &payload); err != nil {
 		return err
 	}
 
+	if payload.Preview {
+		return e.destroyPreview(ctx, payload, emit)
+	}
+
 	workDir := e.getAppWorkDir(payload.AppKey)
 	imageName := payload.AppKey
 
 	// Stopping container
-	if _, err := e.docker.Cmd(ctx, workDir, []string{"stop", imageName}, e.logStreamHandler(
+	if out, err := e.docker.Cmd(ctx, workDir, []string{"stop", imageName}, e.logStreamHandler(
 		emit,
 		domain.ActionAppDestroy,
 		domain.StepDockerStop,
 	)); err != nil {
+		// Nothing to stop, back up or remove: the app never ran.
+		if isNoSuchContainer(out) {
+			return nil
+		}
 		e.logFatalHandler(
 			fmt.Sprintf("failed to stopping container, %s", err.Error()),
 			emit,
@@ -734,11 +1052,11 @@ func (e *Executor) destroyApp(ctx context.Context, job *domain.Job, emit EmitHan
 	}
 
 	// Remove container
-	if _, err := e.docker.Cmd(ctx, workDir, []string{"rm", imageName}, e.logStreamHandler(
+	if out, err := e.docker.Cmd(ctx, workDir, []string{"rm", imageName}, e.logStreamHandler(
 		emit,
 		domain.ActionAppDestroy,
 		domain.StepDockerRemove,
-	)); err != nil {
+	)); err != nil && !isNoSuchContainer(out) {
 		e.logFatalHandler(
 			fmt.Sprintf("failed to removing container, %s", err.Error()),
 			emit,
@@ -750,3 +1068,48 @@ func (e *Executor) destroyApp(ctx context.Context, job *domain.Job, emit EmitHan
 
 	return nil
 }
+
+// destroyPreview takes a preview's whole compose stack down, with its
+// networks and volumes, and removes its checkout. No backup is kept: a
+// preview is rebuilt from its branch whenever it is needed again.
+func (e *Executor) destroyPreview(ctx context.Context, payload domain.AppDestroyPayload, emit EmitHandler) error {
+	stack, err := e.appStack(payload.AppKey, payload.Compose)
+	if err != nil {
+		return err
+	}
+
+	// A preview whose checkout never happened has no stack to take down.
+	if _, err := os.Stat(stack.dir); err == nil {
+		if _, err := e.composeCmd(ctx, stack, []string{"down", "-v", "--remove-orphans"}, e.logStreamHandler(
+			emit,
+			domain.ActionAppDestroy,
+			domain.StepDockerRemove,
+		)); err != nil {
+			e.logFatalHandler(
+				fmt.Sprintf("failed to take the preview stack down, %s", err.Error()),
+				emit,
+				domain.ActionAppDestroy,
+				domain.StepDockerRemove,
+			)
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(e.getAppWorkDir(payload.AppKey)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to remove the preview work dir, %s", err.Error()),
+			emit,
+			domain.ActionAppDestroy,
+			domain.StepDockerRemove,
+		)
+		return err
+	}
+
+	return nil
+}
+
+// isNoSuchContainer tells a docker command that failed because its container
+// does not exist, from its output.
+func isNoSuchContainer(out string) bool {
+	return strings.Contains(strings.ToLower(out), "no such container")
+}
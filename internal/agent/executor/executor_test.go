@@ -2,8 +2,12 @@ package executor
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -20,20 +24,37 @@ import (
 type fakeDocker struct {
 	cmdCalls  []string // "build", "compose up", etc.
 	cmdErrs   map[string]error
+	cmdOuts   map[string]string
 	psOutput  string
 	psErr     error
+	psQueue   []string // consumed before psOutput, one per `compose ps`
 	envWrites []map[string]string
+	envDirs   []string
+	envFile   map[string]string
+	pulls     []string
+	pullCreds []*domain.RegistryCredentials
+	onCmd     func(args []string)
+	streamOut []string // lines every Stream call prints
+	ptyCmd    []string // what PTY runs instead of docker
 }
 
 func (f *fakeDocker) Cmd(_ context.Context, _ string, args []string, _ ...command.StreamHandler) (string, error) {
 	key := strings.Join(args, " ")
 	f.cmdCalls = append(f.cmdCalls, key)
+	if f.onCmd != nil {
+		f.onCmd(args)
+	}
 	if f.cmdErrs != nil {
 		if err := f.cmdErrs[key]; err != nil {
-			return "", err
+			return f.cmdOuts[key], err
 		}
 	}
 	if containsSub(args, []string{"compose", "ps"}) {
+		if len(f.psQueue) > 0 {
+			out := f.psQueue[0]
+			f.psQueue = f.psQueue[1:]
+			return out, nil
+		}
 		if f.psOutput != "" || f.psErr != nil {
 			return f.psOutput, f.psErr
 		}
@@ -41,6 +62,9 @@ func (f *fakeDocker) Cmd(_ context.Context, _ string, args []string, _ ...comman
 		// gate (P1-9) passes.
 		return `[{"ID":"a","Name":"demo-app-1","State":"running","Health":"","ExitCode":0}]`, nil
 	}
+	if out, ok := f.cmdOuts[key]; ok {
+		return out, nil
+	}
 	return "ok", nil
 }
 
@@ -58,22 +82,48 @@ func containsSub(args, needles []string) bool {
 	return false
 }
 
+func (f *fakeDocker) Stream(_ context.Context, _ string, args []string, handler command.StreamHandler) error {
+	f.cmdCalls = append(f.cmdCalls, strings.Join(args, " "))
+	for _, line := range f.streamOut {
+		handler(line, domain.StreamStdout, domain.LogInfo)
+	}
+	return nil
+}
+
+func (f *fakeDocker) PTY(ctx context.Context, workDir string, args []string, rows, cols uint16) (*command.PTY, error) {
+	f.cmdCalls = append(f.cmdCalls, strings.Join(args, " "))
+	return command.NewCommand(workDir, f.ptyCmd[0], f.ptyCmd[1:]...).StartPTY(ctx, rows, cols)
+}
+
+func (f *fakeDocker) Pull(_ context.Context, _ string, image string, creds *domain.RegistryCredentials, _ ...command.StreamHandler) (string, error) {
+	f.pulls = append(f.pulls, image)
+	f.pullCreds = append(f.pullCreds, creds)
+	return "pulled", nil
+}
+
 func (f *fakeDocker) GetDockerComposeFile(string) (string, error)   { return "compose.yml", nil }
 func (f *fakeDocker) GetDockerfile(string) (string, error)          { return "Dockerfile", nil }
 func (f *fakeDocker) IsDockerInstalled() bool                       { return true }
 func (f *fakeDocker) IsDockerComposeAvailable() bool                { return true }
 
-func (f *fakeDocker) WriteEnvFile(_ string, env map[string]string) error {
+func (f *fakeDocker) WriteEnvFile(dir string, env map[string]string) error {
 	f.envWrites = append(f.envWrites, env)
+	f.envDirs = append(f.envDirs, dir)
 	return nil
 }
 
+func (f *fakeDocker) ReadEnvFile(string) (map[string]string, error) {
+	return f.envFile, nil
+}
+
 type fakeGit struct {
 	commit string
 	msg    string
+	ref    string
 }
 
-func (g *fakeGit) CloneOrPull(context.Context, string, string, string, ...command.StreamHandler) (string, error) {
+func (g *fakeGit) CloneOrPull(_ context.Context, _, _, _, ref string, _ *domain.GitCredentials, _ ...command.StreamHandler) (string, error) {
+	g.ref = ref
 	return "cloned", nil
 }
 
@@ -124,7 +174,7 @@ func emitNoop(any) {}
 
 func TestDeployBuildFailureAborts(t *testing.T) {
 	docker := &fakeDocker{cmdErrs: map[string]error{
-		"compose -f compose.yml build": errors.New("build exploded"),
+		"compose -p demo-app-1 -f compose.yml build": errors.New("build exploded"),
 	}}
 	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
 
@@ -142,6 +192,152 @@ func TestDeployBuildFailureAborts(t *testing.T) {
 	}
 }
 
+// A payload newer than this build is refused before anything runs, rather
+// than deployed with the fields the agent doesn't know dropped.
+func TestDeployRefusesNewerPayloadVersion(t *testing.T) {
+	docker := &fakeDocker{}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	job := deployJob(t)
+	job.PayloadVersion = domain.JobPayloadVersions[domain.JobTypeAppDeploy] + 1
+
+	if err := ex.Execute(context.Background(), job, emitNoop); err == nil {
+		t.Fatal("expected a newer payload version to be refused")
+	}
+	if len(docker.cmdCalls) != 0 {
+		t.Fatalf("commands ran for a refused job: %v", docker.cmdCalls)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Release commands: pre-deploy runs between build and up, post-deploy after
+// the health gate; a failed pre-deploy command aborts like a failed build.
+// ---------------------------------------------------------------------------
+
+func releaseJob(t *testing.T, pre, post []domain.ReleaseCommand) *domain.Job {
+	t.Helper()
+	job := deployJob(t)
+	var payload domain.AppDeployPayload
+	_ = json.Unmarshal(job.Payload, &payload)
+	payload.PreDeployCommands = pre
+	payload.PostDeployCommands = post
+	job.Payload, _ = json.Marshal(payload)
+	return job
+}
+
+func indexOfCall(calls []string, sub string) int {
+	for i, c := range calls {
+		if strings.Contains(c, sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestDeployRunsReleaseCommandsInOrder(t *testing.T) {
+	docker := &fakeDocker{}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	job := releaseJob(t,
+		[]domain.ReleaseCommand{{Service: "app", Command: "php artisan migrate --force"}},
+		[]domain.ReleaseCommand{{Service: "app", Command: "php artisan config:cache"}},
+	)
+	if err := ex.Execute(context.Background(), job, emitNoop); err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+
+	build := indexOfCall(docker.cmdCalls, "compose -p demo-app-1 -f compose.yml build")
+	migrate := indexOfCall(docker.cmdCalls, "run --rm app sh -c php artisan migrate --force")
+	up := indexOfCall(docker.cmdCalls, "up -d --force-recreate")
+	warm := indexOfCall(docker.cmdCalls, "run --rm app sh -c php artisan config:cache")
+
+	if build < 0 || migrate < build || up < migrate || warm < up {
+		t.Fatalf("unexpected command order: %q", docker.cmdCalls)
+	}
+}
+
+func TestDeployPreDeployFailureAborts(t *testing.T) {
+	docker := &fakeDocker{cmdErrs: map[string]error{
+		"compose -p demo-app-1 -f compose.yml run --rm app sh -c rails db:migrate": errors.New("migration failed"),
+	}}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	var failedStep domain.LogStep
+	job := releaseJob(t, []domain.ReleaseCommand{{Service: "app", Command: "rails db:migrate"}}, nil)
+	err := ex.Execute(context.Background(), job, func(e any) {
+		if l, ok := e.(domain.EventLogEmitted); ok && l.Level == domain.LogFatal {
+			failedStep = l.Context.Step
+		}
+	})
+	if err == nil {
+		t.Fatal("expected deploy to fail when a pre-deploy command fails")
+	}
+	if failedStep != domain.StepPreDeploy {
+		t.Fatalf("expected failure logged at %s, got %s", domain.StepPreDeploy, failedStep)
+	}
+
+	if i := indexOfCall(docker.cmdCalls, "up -d"); i >= 0 {
+		t.Fatalf("compose up executed after a failed release command: %q", docker.cmdCalls[i])
+	}
+}
+
+// The new env has to be on disk for the migration to run the new image; a
+// failed migration puts the running release's env back so a later restart
+// boots it, not the unmigrated one.
+func TestDeployPreDeployFailureRestoresEnv(t *testing.T) {
+	docker := &fakeDocker{
+		cmdErrs: map[string]error{
+			"compose -p demo-app-1 -f compose.yml run --rm app sh -c rails db:migrate": errors.New("migration failed"),
+		},
+		envFile: map[string]string{"FOO": "bar", "APP_IMAGE": "demo-app-1:aaaa"},
+	}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	job := releaseJob(t, []domain.ReleaseCommand{{Service: "app", Command: "rails db:migrate"}}, nil)
+	if err := ex.Execute(context.Background(), job, emitNoop); err == nil {
+		t.Fatal("expected deploy to fail when a pre-deploy command fails")
+	}
+
+	last := docker.envWrites[len(docker.envWrites)-1]
+	if last["APP_IMAGE"] != "demo-app-1:aaaa" {
+		t.Fatalf("env left at APP_IMAGE=%q, want the running release's demo-app-1:aaaa", last["APP_IMAGE"])
+	}
+}
+
+// Post-deploy commands run once traffic has moved: their failure is a
+// warning, not a failed deploy of a release that is live.
+func TestDeployPostDeployFailureWarns(t *testing.T) {
+	docker := &fakeDocker{cmdErrs: map[string]error{
+		"compose -p demo-app-1 -f compose.yml run --rm app sh -c php artisan config:cache": errors.New("cache failed"),
+	}}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	var warned, fatal bool
+	job := releaseJob(t, nil, []domain.ReleaseCommand{{Service: "app", Command: "php artisan config:cache"}})
+	err := ex.Execute(context.Background(), job, func(e any) {
+		if l, ok := e.(domain.EventLogEmitted); ok && l.Context.Step == domain.StepPostDeploy {
+			warned = warned || l.Level == domain.LogWarn
+			fatal = fatal || l.Level == domain.LogFatal
+		}
+	})
+	if err != nil {
+		t.Fatalf("deploy failed on a post-deploy command: %v", err)
+	}
+	if !warned || fatal {
+		t.Fatalf("warned = %v, fatal = %v; want a warning only", warned, fatal)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // P0-3: deploy must NOT run `compose down` (zero-downtime in-place recreate)
 // ---------------------------------------------------------------------------
@@ -180,6 +376,32 @@ func TestDeployUsesInPlaceRecreateWithoutDown(t *testing.T) {
 	}
 }
 
+// A deploy pinned to a ref hands it to git; the image is still tagged by the
+// resolved commit.
+func TestDeployChecksOutRequestedRef(t *testing.T) {
+	docker := &fakeDocker{}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+
+	job := deployJob(t)
+	var payload domain.AppDeployPayload
+	_ = json.Unmarshal(job.Payload, &payload)
+	payload.Ref = "v1.2.0"
+	job.Payload, _ = json.Marshal(payload)
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	if err := ex.Execute(context.Background(), job, emitNoop); err != nil {
+		t.Fatalf("unexpected deploy error: %v", err)
+	}
+
+	if git.ref != "v1.2.0" {
+		t.Fatalf("expected ref v1.2.0 to be checked out, got %q", git.ref)
+	}
+	if docker.envWrites[len(docker.envWrites)-1]["APP_IMAGE"] != "demo-app-1:"+git.commit {
+		t.Fatalf("expected image tagged by resolved commit, got %v", docker.envWrites)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // P0-5: health check parses multi-service JSON array
 // ---------------------------------------------------------------------------
@@ -334,6 +556,69 @@ func TestRollbackUsesPreviousImageTag(t *testing.T) {
 	}
 }
 
+// A pruned image must fail the rollback before the running stack is touched.
+func TestRollbackFailsWhenImageMissing(t *testing.T) {
+	docker := &fakeDocker{cmdErrs: map[string]error{
+		"image inspect --format {{.Id}} demo-app-1:deadbeef": errors.New("no such image"),
+	}}
+	git := &fakeGit{}
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	payload, _ := json.Marshal(domain.AppRollbackPayload{
+		ApplicationID: 1,
+		DeploymentID:  9,
+		AppKey:        "demo-app-1",
+		ImageTag:      "demo-app-1:deadbeef",
+	})
+	job := &domain.Job{Type: domain.JobTypeAppRollback, Payload: payload}
+
+	if err := ex.Execute(context.Background(), job, emitNoop); err == nil {
+		t.Fatal("expected rollback to fail when the image is gone")
+	}
+	if len(docker.envWrites) != 0 {
+		t.Fatalf("rollback rewrote env for a missing image: %v", docker.envWrites)
+	}
+	for _, call := range docker.cmdCalls {
+		if strings.Contains(call, "up -d") {
+			t.Fatalf("compose up ran for a missing image: %v", docker.cmdCalls)
+		}
+	}
+}
+
+// Deployments recorded with an 8-char hash still find the full-hash tag.
+func TestRollbackResolvesShortHashTag(t *testing.T) {
+	full := "deadbeef0123456789abcdef0123456789abcdef"
+	docker := &fakeDocker{
+		cmdErrs: map[string]error{
+			"image inspect --format {{.Id}} demo-app-1:deadbeef": errors.New("no such image"),
+		},
+		cmdOuts: map[string]string{
+			"image ls demo-app-1 --format {{.Tag}}": "cafebabe0123456789abcdef0123456789abcdef\n" + full + "\n",
+		},
+	}
+	git := &fakeGit{}
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	payload, _ := json.Marshal(domain.AppRollbackPayload{
+		ApplicationID: 1,
+		DeploymentID:  9,
+		AppKey:        "demo-app-1",
+		ImageTag:      "demo-app-1:deadbeef",
+	})
+	job := &domain.Job{Type: domain.JobTypeAppRollback, Payload: payload}
+
+	if err := ex.Execute(context.Background(), job, emitNoop); err != nil {
+		t.Fatalf("unexpected rollback error: %v", err)
+	}
+
+	lastEnv := docker.envWrites[len(docker.envWrites)-1]
+	if lastEnv["APP_IMAGE"] != "demo-app-1:"+full {
+		t.Fatalf("expected APP_IMAGE to resolve to the full tag, got %q", lastEnv["APP_IMAGE"])
+	}
+}
+
 func TestRollbackRejectsEmptyImageTag(t *testing.T) {
 	docker := &fakeDocker{}
 	git := &fakeGit{commit: "abc123", msg: "test"}
@@ -374,6 +659,112 @@ func TestDeployFailsWhenAppCrashesOnBoot(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Auto rollback: a release that fails its health gate is replaced by the
+// previously running image when the app opted in.
+// ---------------------------------------------------------------------------
+
+const (
+	crashedPs = `[{"ID":"a","Name":"demo-app-1","State":"exited","Health":"","ExitCode":1}]`
+	runningPs = `[{"ID":"a","Name":"demo-app-1","State":"running","Health":"","ExitCode":0}]`
+)
+
+func autoRollbackJob(t *testing.T) *domain.Job {
+	t.Helper()
+	job := deployJob(t)
+	var payload domain.AppDeployPayload
+	_ = json.Unmarshal(job.Payload, &payload)
+	payload.AutoRollback = true
+	job.Payload, _ = json.Marshal(payload)
+	return job
+}
+
+func TestDeployAutoRollbackRestoresPreviousImage(t *testing.T) {
+	prevEnv := map[string]string{"FOO": "old", "APP_IMAGE": "demo-app-1:aaaa", "APP_CONTAINER_NAME": "demo-app-1"}
+	docker := &fakeDocker{psQueue: []string{crashedPs, runningPs}, envFile: prevEnv}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	var reports []domain.EventAutoRollbackEmitted
+	err := ex.Execute(context.Background(), autoRollbackJob(t), func(e any) {
+		if r, ok := e.(domain.EventAutoRollbackEmitted); ok {
+			reports = append(reports, r)
+		}
+	})
+	if err == nil {
+		t.Fatal("expected the deploy itself to fail")
+	}
+
+	last := docker.envWrites[len(docker.envWrites)-1]
+	if last["APP_IMAGE"] != "demo-app-1:aaaa" || last["FOO"] != "old" {
+		t.Fatalf("expected previous env restored, got %v", last)
+	}
+
+	ups := 0
+	for _, call := range docker.cmdCalls {
+		if strings.Contains(call, "up -d --force-recreate") {
+			ups++
+		}
+	}
+	if ups != 2 {
+		t.Fatalf("expected deploy and rollback recreates, got %d", ups)
+	}
+
+	if len(reports) != 1 {
+		t.Fatalf("expected one rollback report, got %d", len(reports))
+	}
+	r := reports[0]
+	if !r.Restored || r.DeploymentID != 7 || r.RestoredImage != "demo-app-1:aaaa" ||
+		r.FailedImage != "demo-app-1:0123456789abcdef0123456789abcdef01234567" {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+}
+
+func TestDeployAutoRollbackReportsFailedRestore(t *testing.T) {
+	docker := &fakeDocker{
+		psOutput: crashedPs,
+		envFile:  map[string]string{"APP_IMAGE": "demo-app-1:aaaa"},
+	}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	var reports []domain.EventAutoRollbackEmitted
+	_ = ex.Execute(context.Background(), autoRollbackJob(t), func(e any) {
+		if r, ok := e.(domain.EventAutoRollbackEmitted); ok {
+			reports = append(reports, r)
+		}
+	})
+
+	if len(reports) != 1 || reports[0].Restored {
+		t.Fatalf("expected one unsuccessful rollback report, got %+v", reports)
+	}
+}
+
+func TestDeployWithoutAutoRollbackLeavesFailedRelease(t *testing.T) {
+	docker := &fakeDocker{psOutput: crashedPs, envFile: map[string]string{"APP_IMAGE": "demo-app-1:aaaa"}}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+
+	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
+
+	emitted := false
+	_ = ex.Execute(context.Background(), deployJob(t), func(e any) {
+		if _, ok := e.(domain.EventAutoRollbackEmitted); ok {
+			emitted = true
+		}
+	})
+
+	if emitted {
+		t.Fatal("auto rollback ran for an app that did not opt in")
+	}
+	for _, env := range docker.envWrites {
+		if env["APP_IMAGE"] == "demo-app-1:aaaa" {
+			t.Fatal("previous image restored without auto rollback")
+		}
+	}
+}
+
 func TestDeployHealthGateTimesOutOnUnknownState(t *testing.T) {
 	// Container stays "created" (not running, not failed) — the gate must
 	// time out and fail the deploy rather than hang forever.
@@ -382,8 +773,8 @@ func TestDeployHealthGateTimesOutOnUnknownState(t *testing.T) {
 
 	ex := NewExecutorWithDeps(docker, git, "/tmp/apps", noopLogger(), nil)
 
-	workDir := ex.getAppWorkDir("demo-app-1")
-	err := ex.waitForAppRunning(context.Background(), workDir, "demo-app-1", emitNoop, domain.ActionAppDeploy, domain.StepDockerHealthCheck, 200*time.Millisecond)
+	stack, _ := ex.appStack("demo-app-1", domain.ComposeSpec{})
+	err := ex.waitForAppRunning(context.Background(), stack, "demo-app-1", emitNoop, domain.ActionAppDeploy, domain.StepDockerHealthCheck, 200*time.Millisecond)
 	if err == nil {
 		t.Fatal("expected health gate to time out for app stuck in 'created' state")
 	}
@@ -391,3 +782,498 @@ func TestDeployHealthGateTimesOutOnUnknownState(t *testing.T) {
 		t.Fatalf("expected timeout error, got: %v", err)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Compose spec: configured files, project dir and profiles reach every
+// compose call, and the env is written where compose reads it.
+// ---------------------------------------------------------------------------
+
+func TestDeployUsesConfiguredComposeStack(t *testing.T) {
+	appsDir := t.TempDir()
+	stackDir := filepath.Join(appsDir, "demo-app-1", "deploy", "api")
+	if err := os.MkdirAll(stackDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"compose.yml", "compose.prod.yml"} {
+		if err := os.WriteFile(filepath.Join(stackDir, f), []byte("services: {}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	docker := &fakeDocker{}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+	ex := NewExecutorWithDeps(docker, git, appsDir, noopLogger(), nil)
+
+	job := deployJob(t)
+	var payload domain.AppDeployPayload
+	_ = json.Unmarshal(job.Payload, &payload)
+	payload.Compose = domain.ComposeSpec{
+		ProjectDir: "deploy/api",
+		Files:      []string{"compose.yml", "compose.prod.yml"},
+		Profiles:   []string{"web"},
+	}
+	job.Payload, _ = json.Marshal(payload)
+
+	if err := ex.Execute(context.Background(), job, emitNoop); err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+
+	want := "compose -p demo-app-1 -f compose.yml -f compose.prod.yml --project-directory " + stackDir + " --profile web build"
+	if indexOfCall(docker.cmdCalls, want) < 0 {
+		t.Fatalf("expected %q, got calls: %v", want, docker.cmdCalls)
+	}
+	for _, dir := range docker.envDirs {
+		if dir != stackDir {
+			t.Fatalf("env written to %s, want the project dir %s", dir, stackDir)
+		}
+	}
+}
+
+// A preview runs its parent's compose spec from the same ProjectDir; the
+// project name comes from the app key, so neither touches the other's
+// containers.
+func TestDeployPinsComposeProjectToAppKey(t *testing.T) {
+	appsDir := t.TempDir()
+	docker := &fakeDocker{}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+	ex := NewExecutorWithDeps(docker, git, appsDir, noopLogger(), nil)
+
+	for _, appKey := range []string{"Shop-1", "Shop-2"} {
+		stackDir := filepath.Join(appsDir, appKey, "deploy", "api")
+		if err := os.MkdirAll(stackDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(stackDir, "compose.yml"), []byte("services: {}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		job := deployJob(t)
+		var payload domain.AppDeployPayload
+		_ = json.Unmarshal(job.Payload, &payload)
+		payload.AppKey = appKey
+		payload.Compose = domain.ComposeSpec{ProjectDir: "deploy/api", Files: []string{"compose.yml"}}
+		job.Payload, _ = json.Marshal(payload)
+
+		if err := ex.Execute(context.Background(), job, emitNoop); err != nil {
+			t.Fatalf("deploy %s failed: %v", appKey, err)
+		}
+	}
+
+	var parent, preview int
+	for _, call := range docker.cmdCalls {
+		switch {
+		case strings.HasPrefix(call, "compose -p shop-1 "):
+			parent++
+		case strings.HasPrefix(call, "compose -p shop-2 "):
+			preview++
+		default:
+			t.Fatalf("compose call without the app's project name: %q", call)
+		}
+	}
+	if parent == 0 || preview == 0 {
+		t.Fatalf("calls = %v, want both apps' stacks addressed", docker.cmdCalls)
+	}
+}
+
+// Build args go on the build command line; build secrets are mounted from
+// files through an override that only touches services that build, and both
+// stay out of the app's .env.
+func TestDeployPassesBuildArgsAndSecrets(t *testing.T) {
+	docker := &fakeDocker{cmdOuts: map[string]string{
+		"compose -p demo-app-1 -f compose.yml config --format json": `{"services":{"app":{"build":{"context":"."}},"db":{"image":"postgres:16"}}}`,
+	}}
+
+	var (
+		override   string
+		secretFile string
+	)
+	docker.onCmd = func(args []string) {
+		if !containsSub(args, []string{"build"}) {
+			return
+		}
+		for i, a := range args {
+			if a == "-f" && strings.HasSuffix(args[i+1], buildSecretsOverride) {
+				raw, _ := os.ReadFile(args[i+1])
+				override = string(raw)
+				secret, _ := os.ReadFile(filepath.Join(filepath.Dir(args[i+1]), "NPM_TOKEN"))
+				secretFile = string(secret)
+			}
+		}
+	}
+
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+	ex := NewExecutorWithDeps(docker, git, t.TempDir(), noopLogger(), nil)
+
+	job := deployJob(t)
+	var payload domain.AppDeployPayload
+	_ = json.Unmarshal(job.Payload, &payload)
+	payload.BuildArgs = map[string]string{"NODE_VERSION": "20"}
+	payload.BuildSecrets = map[string]string{"NPM_TOKEN": "npm_s3cret"}
+	job.Payload, _ = json.Marshal(payload)
+
+	if err := ex.Execute(context.Background(), job, emitNoop); err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+
+	build := docker.cmdCalls[indexOfCall(docker.cmdCalls, "build --build-arg")]
+	if !strings.HasSuffix(build, "build --build-arg NODE_VERSION=20") {
+		t.Fatalf("unexpected build call %q", build)
+	}
+	if secretFile != "npm_s3cret" {
+		t.Fatalf("secret file = %q", secretFile)
+	}
+
+	var parsed struct {
+		Services map[string]struct {
+			Build struct {
+				Secrets []string `json:"secrets"`
+			} `json:"build"`
+		} `json:"services"`
+		Secrets map[string]struct {
+			File string `json:"file"`
+		} `json:"secrets"`
+	}
+	if err := json.Unmarshal([]byte(override), &parsed); err != nil {
+		t.Fatalf("override is not valid: %v\n%s", err, override)
+	}
+	if _, ok := parsed.Services["db"]; ok {
+		t.Fatal("override added a build to an image-only service")
+	}
+	if got := parsed.Services["app"].Build.Secrets; len(got) != 1 || got[0] != "NPM_TOKEN" {
+		t.Fatalf("app build secrets = %v", got)
+	}
+
+	secretsDir := filepath.Dir(parsed.Secrets["NPM_TOKEN"].File)
+	if _, err := os.Stat(secretsDir); !os.IsNotExist(err) {
+		t.Fatalf("build secrets dir %s not removed after the build", secretsDir)
+	}
+	for _, env := range docker.envWrites {
+		if _, ok := env["NPM_TOKEN"]; ok {
+			t.Fatal("build secret written to the runtime .env")
+		}
+		if _, ok := env["NODE_VERSION"]; ok {
+			t.Fatal("build arg written to the runtime .env")
+		}
+	}
+}
+
+func TestDeployFailsOnMissingComposeFile(t *testing.T) {
+	docker := &fakeDocker{}
+	git := &fakeGit{commit: "0123456789abcdef0123456789abcdef01234567", msg: "test"}
+	ex := NewExecutorWithDeps(docker, git, t.TempDir(), noopLogger(), nil)
+
+	job := deployJob(t)
+	var payload domain.AppDeployPayload
+	_ = json.Unmarshal(job.Payload, &payload)
+	payload.Compose = domain.ComposeSpec{Files: []string{"compose.override.yml"}}
+	job.Payload, _ = json.Marshal(payload)
+
+	if err := ex.Execute(context.Background(), job, emitNoop); err == nil {
+		t.Fatal("expected deploy to fail when a configured compose file is missing")
+	}
+	if len(docker.cmdCalls) != 0 {
+		t.Fatalf("docker ran without the compose stack: %v", docker.cmdCalls)
+	}
+}
+
+func TestStopUsesComposeProfiles(t *testing.T) {
+	docker := &fakeDocker{}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+
+	payload, _ := json.Marshal(domain.AppStopPayload{
+		ApplicationID: 1,
+		AppKey:        "demo-app-1",
+		Compose:       domain.ComposeSpec{Profiles: []string{"worker"}},
+	})
+	if err := ex.Execute(context.Background(), &domain.Job{Type: domain.JobTypeAppStop, Payload: payload}, emitNoop); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	if got := docker.cmdCalls[len(docker.cmdCalls)-1]; got != "compose -p demo-app-1 -f compose.yml --profile worker stop" {
+		t.Fatalf("unexpected command: %q", got)
+	}
+}
+
+func TestComposeStackStaysInsideCheckout(t *testing.T) {
+	ex := NewExecutorWithDeps(&fakeDocker{}, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+
+	for _, spec := range []domain.ComposeSpec{
+		{ProjectDir: "../other-app"},
+		{ProjectDir: "/etc"},
+		{Files: []string{"../../compose.yml"}},
+		{Profiles: []string{"--all"}},
+	} {
+		if _, err := ex.appStack("demo-app-1", spec); !errors.Is(err, domain.ErrInvalidDockerCompose) {
+			t.Fatalf("spec %+v: expected ErrInvalidDockerCompose, got %v", spec, err)
+		}
+	}
+}
+
+func imageDeployJob(t *testing.T, definition string) *domain.Job {
+	t.Helper()
+	payload, _ := json.Marshal(domain.AppDeployPayload{
+		ApplicationID:     1,
+		DeploymentID:      7,
+		AppKey:            "demo-app-1",
+		EnvVars:           map[string]string{"FOO": "bar"},
+		SourceType:        domain.SourceImage,
+		Image:             "registry.example.com:5000/api:1.4.2",
+		ComposeDefinition: definition,
+		RegistryCredentials: &domain.RegistryCredentials{
+			Registry: "registry.example.com:5000",
+			Username: "ci",
+			Password: "s3cret",
+		},
+	})
+	deploymentID := int64(7)
+	return &domain.Job{
+		Type:         domain.JobTypeAppDeploy,
+		DeploymentID: &deploymentID,
+		Payload:      payload,
+	}
+}
+
+// An image deploy pulls instead of cloning and building, runs the image
+// pinned to the pulled digest and reports that digest for rollbacks.
+func TestDeployImagePullsWithoutBuild(t *testing.T) {
+	appsDir := t.TempDir()
+	pinned := "registry.example.com:5000/api@sha256:0123456789abcdef"
+
+	docker := &fakeDocker{cmdOuts: map[string]string{
+		"image inspect --format {{json .RepoDigests}} registry.example.com:5000/api:1.4.2": `["other/api@sha256:ffff","` + pinned + `"]`,
+	}}
+	git := &fakeGit{}
+	ex := NewExecutorWithDeps(docker, git, appsDir, noopLogger(), nil)
+
+	var reported []domain.EventCommitInfoEmitted
+	emit := func(evt any) {
+		if info, ok := evt.(domain.EventCommitInfoEmitted); ok {
+			reported = append(reported, info)
+		}
+	}
+
+	if err := ex.Execute(context.Background(), imageDeployJob(t, ""), emit); err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+
+	if len(docker.pulls) != 1 || docker.pulls[0] != "registry.example.com:5000/api:1.4.2" {
+		t.Fatalf("expected one pull of the image, got %v", docker.pulls)
+	}
+	if docker.pullCreds[0] == nil || docker.pullCreds[0].Password != "s3cret" {
+		t.Fatalf("pull did not use the registry credentials: %+v", docker.pullCreds[0])
+	}
+	for _, call := range docker.cmdCalls {
+		if strings.HasSuffix(call, " build") {
+			t.Fatalf("image deploy must not build, calls: %v", docker.cmdCalls)
+		}
+	}
+	if git.ref != "" {
+		t.Fatal("image deploy must not touch git")
+	}
+
+	lastEnv := docker.envWrites[len(docker.envWrites)-1]
+	if lastEnv["APP_IMAGE"] != pinned {
+		t.Fatalf("APP_IMAGE = %q, want %q", lastEnv["APP_IMAGE"], pinned)
+	}
+	if len(reported) != 1 || reported[0].ImageDigest != pinned || reported[0].Hash != "" {
+		t.Fatalf("expected the pinned image to be reported, got %+v", reported)
+	}
+
+	compose, err := os.ReadFile(filepath.Join(appsDir, "demo-app-1", "docker-compose.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(compose), "image: ${APP_IMAGE}") {
+		t.Fatalf("generated compose does not run APP_IMAGE:\n%s", compose)
+	}
+}
+
+func TestDeployImageUsesComposeDefinition(t *testing.T) {
+	appsDir := t.TempDir()
+	definition := "services:\n  api:\n    image: ${APP_IMAGE}\n    ports: [\"8080:8080\"]\n"
+
+	docker := &fakeDocker{cmdOuts: map[string]string{
+		"image inspect --format {{json .RepoDigests}} registry.example.com:5000/api:1.4.2": `["registry.example.com:5000/api@sha256:abcd"]`,
+	}}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, appsDir, noopLogger(), nil)
+
+	if err := ex.Execute(context.Background(), imageDeployJob(t, definition), emitNoop); err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+
+	compose, err := os.ReadFile(filepath.Join(appsDir, "demo-app-1", "docker-compose.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(compose) != definition {
+		t.Fatalf("compose definition not written as given:\n%s", compose)
+	}
+}
+
+// Rolling an image app back to a digest that was pruned pulls it again
+// instead of failing like a pruned build would.
+func TestRollbackImageRepullsPrunedDigest(t *testing.T) {
+	pinned := "registry.example.com:5000/api@sha256:abcd"
+	docker := &fakeDocker{cmdErrs: map[string]error{
+		"image inspect --format {{.Id}} " + pinned: errors.New("no such image"),
+	}}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+
+	payload, _ := json.Marshal(domain.AppRollbackPayload{
+		ApplicationID: 1,
+		DeploymentID:  9,
+		AppKey:        "demo-app-1",
+		ImageTag:      pinned,
+		SourceType:    domain.SourceImage,
+	})
+	job := &domain.Job{Type: domain.JobTypeAppRollback, Payload: payload}
+
+	if err := ex.Execute(context.Background(), job, emitNoop); err != nil {
+		t.Fatalf("unexpected rollback error: %v", err)
+	}
+
+	if len(docker.pulls) != 1 || docker.pulls[0] != pinned {
+		t.Fatalf("expected the pinned image to be pulled, got %v", docker.pulls)
+	}
+	lastEnv := docker.envWrites[len(docker.envWrites)-1]
+	if lastEnv["APP_IMAGE"] != pinned {
+		t.Fatalf("APP_IMAGE = %q, want %q", lastEnv["APP_IMAGE"], pinned)
+	}
+}
+
+func TestPinnedImage(t *testing.T) {
+	cases := []struct {
+		image   string
+		digests []string
+		want    string
+	}{
+		{"registry.example.com:5000/api:1.4.2", []string{"registry.example.com:5000/api@sha256:aa"}, "registry.example.com:5000/api@sha256:aa"},
+		{"registry.example.com:5000/api", []string{"mirror/api@sha256:bb", "registry.example.com:5000/api@sha256:aa"}, "registry.example.com:5000/api@sha256:aa"},
+		{"nginx:1.27", []string{"nginx@sha256:cc"}, "nginx@sha256:cc"},
+		{"api@sha256:dd", []string{"api@sha256:dd"}, "api@sha256:dd"},
+	}
+	for _, c := range cases {
+		got, err := pinnedImage(c.image, c.digests)
+		if err != nil {
+			t.Fatalf("%s: %v", c.image, err)
+		}
+		if got != c.want {
+			t.Fatalf("%s: got %s, want %s", c.image, got, c.want)
+		}
+	}
+
+	if _, err := pinnedImage("api:1", nil); err == nil {
+		t.Fatal("expected an error for an image without digests")
+	}
+}
+
+func TestJobSecretsCollectsPayloadSecrets(t *testing.T) {
+	payload, _ := json.Marshal(domain.AppDeployPayload{
+		EnvVars:             map[string]string{"DB_PASSWORD": "db-s3cret", "PORT": "3000", "DEBUG": "true"},
+		SecretEnvKeys:       []string{"DB_PASSWORD"},
+		BuildArgs:           map[string]string{"NODE_ENV": "production"},
+		BuildSecrets:        map[string]string{"NPM_TOKEN": "npm-s3cret"},
+		GitCredentials:      &domain.GitCredentials{SSHPrivateKey: "ssh-s3cret", HTTPSUsername: "ci", HTTPSToken: "git-s3cret"},
+		RegistryCredentials: &domain.RegistryCredentials{Username: "ci", Password: "registry-s3cret"},
+	})
+
+	got := jobSecrets(&domain.Job{Payload: payload})
+	slices.Sort(got)
+	want := []string{"ci:git-s3cret", "ci:registry-s3cret", "db-s3cret", "git-s3cret", "npm-s3cret", "registry-s3cret", "ssh-s3cret"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("jobSecrets = %q, want %q", got, want)
+	}
+}
+
+// A docker login echoes the registry auth as base64("user:password"); it is
+// masked although neither half alone encodes to it.
+func TestJobSecretsMaskRegistryAuth(t *testing.T) {
+	payload, _ := json.Marshal(domain.AppDeployPayload{
+		RegistryCredentials: &domain.RegistryCredentials{Username: "deployer", Password: "registry-s3cret"},
+	})
+	r := command.NewRedactor(jobSecrets(&domain.Job{Payload: payload})...)
+
+	auth := base64.StdEncoding.EncodeToString([]byte("deployer:registry-s3cret"))
+	if got := r.Redact(`{"auths":{"ghcr.io":{"auth":"` + auth + `"}}}`); strings.Contains(got, auth) {
+		t.Fatalf("registry auth not masked: %s", got)
+	}
+	if got := r.Redact("listening on 3000, debug=true"); got != "listening on 3000, debug=true" {
+		t.Fatalf("plain line changed: %s", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Destroy: a preview's whole stack goes, an app that never ran is gone
+// already.
+// ---------------------------------------------------------------------------
+
+func destroyJob(t *testing.T, payload domain.AppDestroyPayload) *domain.Job {
+	t.Helper()
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &domain.Job{Type: domain.JobTypeAppDestroy, Payload: raw}
+}
+
+func TestDestroyPreviewTakesTheStackDown(t *testing.T) {
+	appsDir := t.TempDir()
+	workDir := filepath.Join(appsDir, "shop-2")
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "compose.yml"), []byte("services: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	docker := &fakeDocker{}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, appsDir, noopLogger(), nil)
+
+	job := destroyJob(t, domain.AppDestroyPayload{
+		AppInfo: domain.AppInfo{ApplicationID: 2, AppKey: "shop-2"},
+		Preview: true,
+	})
+	if err := ex.Execute(context.Background(), job, emitNoop); err != nil {
+		t.Fatalf("destroy failed: %v", err)
+	}
+
+	want := []string{"compose -p shop-2 -f compose.yml down -v --remove-orphans"}
+	if !slices.Equal(docker.cmdCalls, want) {
+		t.Fatalf("calls = %q, want %q", docker.cmdCalls, want)
+	}
+	if _, err := os.Stat(workDir); !os.IsNotExist(err) {
+		t.Fatalf("preview work dir left behind: %v", err)
+	}
+}
+
+func TestDestroyPreviewThatNeverRan(t *testing.T) {
+	docker := &fakeDocker{}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, t.TempDir(), noopLogger(), nil)
+
+	job := destroyJob(t, domain.AppDestroyPayload{
+		AppInfo: domain.AppInfo{ApplicationID: 2, AppKey: "shop-2"},
+		Preview: true,
+	})
+	if err := ex.Execute(context.Background(), job, emitNoop); err != nil {
+		t.Fatalf("destroy failed: %v", err)
+	}
+	if len(docker.cmdCalls) != 0 {
+		t.Fatalf("calls = %q, want none", docker.cmdCalls)
+	}
+}
+
+func TestDestroyAppWithoutContainer(t *testing.T) {
+	docker := &fakeDocker{
+		cmdErrs: map[string]error{"stop shop-1": errors.New("exit status 1")},
+		cmdOuts: map[string]string{"stop shop-1": "Error response from daemon: No such container: shop-1\n"},
+	}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, t.TempDir(), noopLogger(), nil)
+
+	job := destroyJob(t, domain.AppDestroyPayload{AppInfo: domain.AppInfo{ApplicationID: 1, AppKey: "shop-1"}})
+	if err := ex.Execute(context.Background(), job, emitNoop); err != nil {
+		t.Fatalf("destroy failed: %v", err)
+	}
+	if indexOfCall(docker.cmdCalls, "commit") >= 0 {
+		t.Fatalf("backed up a container that does not exist: %q", docker.cmdCalls)
+	}
+}
@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"horizonx/internal/domain"
+)
+
+// imageComposeFile is where an image app's compose definition is written.
+// There is no checkout, so it is the only compose file in the app dir.
+const imageComposeFile = "docker-compose.yml"
+
+// defaultImageCompose runs an image app that has no compose definition of its
+// own: one service on the pulled image, with the app's env.
+const defaultImageCompose = `services:
+  app:
+    image: ${APP_IMAGE}
+    container_name: ${APP_CONTAINER_NAME}
+    env_file: .env
+    restart: unless-stopped
+`
+
+// writeImageCompose writes the app's compose definition, or the default one,
+// into the stack dir.
+func writeImageCompose(stack composeStack, definition string) error {
+	if strings.TrimSpace(definition) == "" {
+		definition = defaultImageCompose
+	}
+	return os.WriteFile(filepath.Join(stack.dir, imageComposeFile), []byte(definition), 0o644)
+}
+
+// pullImage pulls an image app's image and returns it pinned to the digest
+// that was pulled, so the release and any rollback to it run exactly that
+// image even if the tag moves.
+func (e *Executor) pullImage(
+	ctx context.Context,
+	stack composeStack,
+	payload domain.AppDeployPayload,
+	emit EmitHandler,
+	action domain.LogAction,
+) (string, error) {
+	if err := writeImageCompose(stack, payload.ComposeDefinition); err != nil {
+		e.logFatalHandler(fmt.Sprintf("failed to write compose definition, %s", err.Error()), emit, action, domain.StepBuildPrepare)
+		return "", err
+	}
+
+	if _, err := e.docker.Pull(ctx, stack.dir, payload.Image, payload.RegistryCredentials, e.logStreamHandler(
+		emit,
+		action,
+		domain.StepDockerPull,
+	)); err != nil {
+		e.logFatalHandler(fmt.Sprintf("failed to pull image %s, %s", payload.Image, err.Error()), emit, action, domain.StepDockerPull)
+		return "", err
+	}
+
+	pinned, err := e.imageDigest(ctx, stack.dir, payload.Image)
+	if err != nil {
+		e.logFatalHandler(fmt.Sprintf("failed to resolve digest of %s, %s", payload.Image, err.Error()), emit, action, domain.StepDockerPull)
+		return "", err
+	}
+
+	return pinned, nil
+}
+
+// imageDigest returns the repo@sha256 reference of a pulled image.
+func (e *Executor) imageDigest(ctx context.Context, workDir, image string) (string, error) {
+	out, err := e.docker.Cmd(ctx, workDir, []string{"image", "inspect", "--format", "{{json .RepoDigests}}", image})
+	if err != nil {
+		return "", err
+	}
+
+	var digests []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &digests); err != nil {
+		return "", fmt.Errorf("failed to parse image digests: %w", err)
+	}
+
+	return pinnedImage(image, digests)
+}
+
+// pinnedImage picks the digest of image's own repository out of the image's
+// RepoDigests; the same image may also be known under other repositories.
+func pinnedImage(image string, repoDigests []string) (string, error) {
+	repo := imageRepository(image)
+	for _, d := range repoDigests {
+		if name, _, ok := strings.Cut(d, "@"); ok && name == repo {
+			return d, nil
+		}
+	}
+
+	// Docker normalises Docker Hub names (nginx -> docker.io/library/nginx
+	// in some versions), so fall back to a lone digest.
+	if len(repoDigests) == 1 && strings.Contains(repoDigests[0], "@") {
+		return repoDigests[0], nil
+	}
+
+	return "", fmt.Errorf("no digest recorded for %s", image)
+}
+
+// imageRepository strips the tag or digest from an image reference. A colon
+// before the last slash belongs to the registry host's port, not a tag.
+func imageRepository(image string) string {
+	if name, _, ok := strings.Cut(image, "@"); ok {
+		return name
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
+
+// ensureImage makes sure a digest-pinned image is on this host, pulling it
+// again if it was pruned since it was deployed.
+func (e *Executor) ensureImage(
+	ctx context.Context,
+	workDir, image string,
+	creds *domain.RegistryCredentials,
+	emit EmitHandler,
+	action domain.LogAction,
+) error {
+	if _, err := e.docker.Cmd(ctx, workDir, []string{"image", "inspect", "--format", "{{.Id}}", image}); err == nil {
+		return nil
+	}
+
+	_, err := e.docker.Pull(ctx, workDir, image, creds, e.logStreamHandler(emit, action, domain.StepDockerPull))
+	return err
+}
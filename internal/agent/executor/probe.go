@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"horizonx/internal/domain"
+)
+
+const (
+	defaultProbeTimeout = 60 * time.Second
+
+	// probeHost is where HTTP and TCP probes dial: the app's ports are
+	// published on the server the agent runs on.
+	probeHost = "127.0.0.1"
+
+	// probeBodyLimit caps how much of an HTTP response is searched for
+	// BodyContains.
+	probeBodyLimit = 1 << 20
+)
+
+var probeHTTPClient = &http.Client{}
+
+// healthGate is the full post-`compose up` gate for deploys and rollbacks:
+// the stack must reach a running state (P1-9), then every readiness probe the
+// app defines must pass.
+func (e *Executor) healthGate(
+	ctx context.Context,
+	stack composeStack,
+	appKey string,
+	probes []domain.ReadinessProbe,
+	emit EmitHandler,
+	action domain.LogAction,
+) error {
+	if err := e.waitForAppRunning(ctx, stack, appKey, emit, action, domain.StepDockerHealthCheck, healthGateTimeout); err != nil {
+		return err
+	}
+
+	for _, probe := range probes {
+		if err := e.runProbe(ctx, stack, probe, emit, action); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runProbe retries a probe every interval until it has passed
+// SuccessThreshold times in a row, or fails once its timeout is spent. Each
+// attempt gets its own AttemptTimeout and is streamed as a
+// docker_health_check log line.
+func (e *Executor) runProbe(
+	ctx context.Context,
+	stack composeStack,
+	probe domain.ReadinessProbe,
+	emit EmitHandler,
+	action domain.LogAction,
+) error {
+	step := domain.StepDockerHealthCheck
+	logLine := e.logStreamHandler(emit, action, step)
+
+	timeout := probeDuration(probe.TimeoutSeconds, defaultProbeTimeout)
+	interval := probe.Interval()
+	attemptTimeout := min(probe.AttemptTimeout(), interval)
+	threshold := max(probe.SuccessThreshold, 1)
+	name := describeProbe(probe)
+
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	passed := 0
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+		err := e.probeOnce(attemptCtx, stack, probe)
+		cancel()
+
+		if err == nil {
+			passed++
+			logLine(
+				fmt.Sprintf("probe %s: attempt %d passed (%d/%d)", name, attempt, passed, threshold),
+				domain.StreamStdout,
+				domain.LogInfo,
+			)
+			if passed >= threshold {
+				return nil
+			}
+		} else {
+			passed = 0
+			logLine(
+				fmt.Sprintf("probe %s: attempt %d failed: %v", name, attempt, err),
+				domain.StreamStderr,
+				domain.LogWarn,
+			)
+		}
+
+		if time.Now().After(deadline) {
+			msg := fmt.Sprintf("readiness probe %s did not pass within %s", name, timeout)
+			e.logFatalHandler(msg, emit, action, step)
+			return errors.New(msg)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Executor) probeOnce(ctx context.Context, stack composeStack, probe domain.ReadinessProbe) error {
+	switch probe.Type {
+	case domain.ProbeHTTP:
+		return probeHTTP(ctx, probe)
+	case domain.ProbeTCP:
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(probeHost, strconv.Itoa(probe.Port)))
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case domain.ProbeCommand:
+		_, err := e.composeCmd(ctx, stack, []string{"exec", "-T", probe.Service, "sh", "-c", probe.Command})
+		return err
+	default:
+		return fmt.Errorf("unknown probe type: %s", probe.Type)
+	}
+}
+
+// probeHTTP expects ExpectedStatus, or any 2xx when unset, and a body
+// containing BodyContains when set.
+func probeHTTP(ctx context.Context, probe domain.ReadinessProbe) error {
+	path := probe.Path
+	if path == "" {
+		path = "/"
+	}
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(probeHost, strconv.Itoa(probe.Port)), path)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := probeHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if probe.ExpectedStatus != 0 {
+		if resp.StatusCode != probe.ExpectedStatus {
+			return fmt.Errorf("status %d, want %d", resp.StatusCode, probe.ExpectedStatus)
+		}
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d, want 2xx", resp.StatusCode)
+	}
+
+	if probe.BodyContains != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, probeBodyLimit))
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(body), probe.BodyContains) {
+			return fmt.Errorf("body does not contain %q", probe.BodyContains)
+		}
+	}
+
+	return nil
+}
+
+func describeProbe(probe domain.ReadinessProbe) string {
+	switch probe.Type {
+	case domain.ProbeHTTP:
+		return fmt.Sprintf("http :%d%s", probe.Port, probe.Path)
+	case domain.ProbeTCP:
+		return fmt.Sprintf("tcp :%d", probe.Port)
+	case domain.ProbeCommand:
+		return fmt.Sprintf("command in %s", probe.Service)
+	default:
+		return string(probe.Type)
+	}
+}
+
+func probeDuration(seconds int, fallback time.Duration) time.Duration {
+	if seconds <= 0 {
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
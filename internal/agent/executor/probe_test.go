@@ -0,0 +1,173 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+)
+
+func serverPort(t *testing.T, addr string) int {
+	t.Helper()
+	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tcpAddr.Port
+}
+
+func probeDeployJob(t *testing.T, probes ...domain.ReadinessProbe) *domain.Job {
+	t.Helper()
+	job := deployJob(t)
+	var payload domain.AppDeployPayload
+	_ = json.Unmarshal(job.Payload, &payload)
+	payload.ReadinessProbes = probes
+	job.Payload, _ = json.Marshal(payload)
+	return job
+}
+
+// A running container is not enough: the deploy waits until the HTTP probe
+// has passed SuccessThreshold times and streams every attempt.
+func TestDeployRunsHTTPProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	ex := NewExecutorWithDeps(&fakeDocker{}, &fakeGit{commit: "abc", msg: "test"}, "/tmp/apps", noopLogger(), nil)
+
+	var lines []string
+	err := ex.Execute(context.Background(), probeDeployJob(t, domain.ReadinessProbe{
+		Type:             domain.ProbeHTTP,
+		Port:             serverPort(t, srv.Listener.Addr().String()),
+		Path:             "/healthz",
+		ExpectedStatus:   http.StatusOK,
+		BodyContains:     `"ok"`,
+		IntervalSeconds:  1,
+		SuccessThreshold: 2,
+	}), func(e any) {
+		if l, ok := e.(domain.EventLogEmitted); ok && l.Context.Step == domain.StepDockerHealthCheck {
+			lines = append(lines, l.Message)
+		}
+	})
+	if err != nil {
+		t.Fatalf("deploy failed: %v", err)
+	}
+
+	if len(lines) != 2 || !strings.Contains(lines[1], "passed (2/2)") {
+		t.Fatalf("unexpected probe log lines: %q", lines)
+	}
+}
+
+func TestProbeFailsOnUnexpectedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	ex := NewExecutorWithDeps(&fakeDocker{}, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+
+	var failures int
+	err := ex.runProbe(context.Background(), composeStack{dir: "/tmp/apps/demo-app-1"}, domain.ReadinessProbe{
+		Type:            domain.ProbeHTTP,
+		Port:            serverPort(t, srv.Listener.Addr().String()),
+		TimeoutSeconds:  1,
+		IntervalSeconds: 1,
+	}, func(e any) {
+		if l, ok := e.(domain.EventLogEmitted); ok && strings.Contains(l.Message, "status 502") {
+			failures++
+		}
+	}, domain.ActionAppDeploy)
+	if err == nil || !strings.Contains(err.Error(), "did not pass") {
+		t.Fatalf("expected probe timeout, got %v", err)
+	}
+	if failures == 0 {
+		t.Fatal("expected failed attempts to be logged")
+	}
+}
+
+// An endpoint slower than the default attempt timeout passes once the
+// probe gives each attempt long enough.
+func TestProbeAttemptTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(1500 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ex := NewExecutorWithDeps(&fakeDocker{}, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+	probe := domain.ReadinessProbe{
+		Type:                  domain.ProbeHTTP,
+		Port:                  serverPort(t, srv.Listener.Addr().String()),
+		TimeoutSeconds:        1,
+		IntervalSeconds:       2,
+		AttemptTimeoutSeconds: 1,
+	}
+
+	if err := ex.runProbe(context.Background(), composeStack{dir: "/tmp/apps/demo-app-1"}, probe, emitNoop, domain.ActionAppDeploy); err == nil {
+		t.Fatal("expected a 1s attempt to time out against a 1.5s endpoint")
+	}
+
+	probe.AttemptTimeoutSeconds = 2
+	if err := ex.runProbe(context.Background(), composeStack{dir: "/tmp/apps/demo-app-1"}, probe, emitNoop, domain.ActionAppDeploy); err != nil {
+		t.Fatalf("probe failed with a 2s attempt timeout: %v", err)
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	ex := NewExecutorWithDeps(&fakeDocker{}, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+
+	err = ex.probeOnce(context.Background(), composeStack{dir: "/tmp/apps/demo-app-1"}, domain.ReadinessProbe{
+		Type: domain.ProbeTCP,
+		Port: serverPort(t, ln.Addr().String()),
+	})
+	if err != nil {
+		t.Fatalf("tcp probe failed: %v", err)
+	}
+}
+
+func TestProbeCommandRunsInService(t *testing.T) {
+	docker := &fakeDocker{cmdErrs: map[string]error{
+		"compose -f compose.yml exec -T worker sh -c false": errors.New("exit status 1"),
+	}}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+
+	ok := domain.ReadinessProbe{Type: domain.ProbeCommand, Service: "web", Command: "php artisan health"}
+	if err := ex.probeOnce(context.Background(), composeStack{dir: "/tmp/apps/demo-app-1"}, ok); err != nil {
+		t.Fatalf("command probe failed: %v", err)
+	}
+	if last := docker.cmdCalls[len(docker.cmdCalls)-1]; last != "compose -f compose.yml exec -T web sh -c php artisan health" {
+		t.Fatalf("unexpected command: %q", last)
+	}
+
+	failing := domain.ReadinessProbe{Type: domain.ProbeCommand, Service: "worker", Command: "false"}
+	if err := ex.probeOnce(context.Background(), composeStack{dir: "/tmp/apps/demo-app-1"}, failing); err == nil {
+		t.Fatal("expected failing command probe to fail")
+	}
+}
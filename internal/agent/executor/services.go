@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"horizonx/internal/agent/docker"
+	"horizonx/internal/domain"
+)
+
+// serviceHealths breaks an app's containers down per compose service.
+// `compose ps` only lists running containers, so the services the compose
+// files declare are listed too: one without containers is stopped. Nil means
+// the breakdown could not be built and the last one should stand.
+func (e *Executor) serviceHealths(ctx context.Context, stack composeStack, containers []docker.Container) []domain.ServiceHealth {
+	var names []string
+	if output, err := e.composeCmd(ctx, stack, []string{"config", "--services"}); err == nil {
+		for _, line := range strings.Split(output, "\n") {
+			if name := strings.TrimSpace(line); name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	} else {
+		e.log.Debug("failed to run docker compose config --services", "err", err.Error())
+	}
+
+	byService := make(map[string][]docker.Container)
+	for _, c := range containers {
+		if c.Service == "" {
+			continue
+		}
+		if !slices.Contains(names, c.Service) {
+			names = append(names, c.Service)
+		}
+		byService[c.Service] = append(byService[c.Service], c)
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	services := make([]domain.ServiceHealth, 0, len(names))
+	for _, name := range names {
+		svc := domain.ServiceHealth{
+			Name:       name,
+			Status:     domain.AppStatusStopped,
+			Ports:      []string{},
+			Containers: []domain.ServiceContainer{},
+		}
+
+		if group := byService[name]; len(group) > 0 {
+			svc.Status = aggregateContainerHealth(group)
+			svc.Image = group[0].Image
+			for _, c := range group {
+				svc.Containers = append(svc.Containers, domain.ServiceContainer{
+					Name:     c.Name,
+					State:    c.State,
+					Health:   c.Health,
+					ExitCode: c.ExitCode,
+				})
+				for _, port := range strings.Split(c.Ports, ", ") {
+					if port != "" && !slices.Contains(svc.Ports, port) {
+						svc.Ports = append(svc.Ports, port)
+					}
+				}
+			}
+		}
+
+		services = append(services, svc)
+	}
+
+	return services
+}
+
+// appService starts, stops, restarts or scales one service of the stack,
+// then reports the app's health so the dashboard shows the result without
+// waiting for the next health check.
+func (e *Executor) appService(ctx context.Context, job *domain.Job, emit EmitHandler) error {
+	var payload domain.AppServicePayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+
+	if err := domain.ValidateServiceAction(payload.Service, payload.Action, payload.Replicas); err != nil {
+		return err
+	}
+
+	stack, err := e.appStack(payload.AppKey, payload.Compose)
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	step := domain.StepDockerScale
+	switch payload.Action {
+	case domain.ServiceStart:
+		args, step = []string{"start", payload.Service}, domain.StepDockerStart
+	case domain.ServiceStop:
+		args, step = []string{"stop", payload.Service}, domain.StepDockerStop
+	case domain.ServiceRestart:
+		args, step = []string{"restart", payload.Service}, domain.StepDockerRestart
+	case domain.ServiceScale:
+		// --no-deps leaves the other services as they are.
+		args = []string{"up", "-d", "--no-deps", "--scale", payload.Service + "=" + strconv.Itoa(payload.Replicas), payload.Service}
+	}
+
+	action := domain.ActionAppService
+	if _, err := e.composeCmd(ctx, stack, args, e.logStreamHandler(emit, action, step)); err != nil {
+		e.logFatalHandler(
+			fmt.Sprintf("failed to run docker compose %s %s, %s", args[0], payload.Service, err.Error()),
+			emit,
+			action,
+			step,
+		)
+		return err
+	}
+
+	output, err := e.composeCmd(ctx, stack, []string{"ps", "--format", "json"})
+	if err != nil {
+		// The next health check reports it instead.
+		return nil
+	}
+
+	var containers []docker.Container
+	if output != "" {
+		if containers, err = parseComposePs(output); err != nil {
+			return nil
+		}
+	}
+
+	emit([]domain.ApplicationHealth{{
+		ApplicationID: payload.ApplicationID,
+		Status:        aggregateContainerHealth(containers),
+		Services:      e.serviceHealths(ctx, stack, containers),
+	}})
+
+	return nil
+}
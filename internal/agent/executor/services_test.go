@@ -0,0 +1,145 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"horizonx/internal/domain"
+)
+
+func TestHealthCheckBreaksDownServices(t *testing.T) {
+	docker := &fakeDocker{
+		psOutput: `[
+			{"Name":"shop-7-web-1","Service":"web","Image":"shop:abc","State":"running","Ports":"0.0.0.0:8080->80/tcp, :::8080->80/tcp"},
+			{"Name":"shop-7-web-2","Service":"web","Image":"shop:abc","State":"running","Health":"starting","Ports":"0.0.0.0:8080->80/tcp"},
+			{"Name":"shop-7-db-1","Service":"db","Image":"postgres:16","State":"running","Health":"unhealthy"}
+		]`,
+		cmdOuts: map[string]string{"compose -p shop-7 -f compose.yml config --services": "db\nweb\nworker\n"},
+	}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+
+	payload, _ := json.Marshal(domain.AppHealthCheckPayload{
+		Applications: []domain.AppInfo{{ApplicationID: 7, AppKey: "shop-7"}},
+	})
+
+	var reports []domain.ApplicationHealth
+	ex.Execute(context.Background(), &domain.Job{Type: domain.JobTypeAppHealthCheck, Payload: payload}, func(evt any) {
+		if r, ok := evt.([]domain.ApplicationHealth); ok {
+			reports = r
+		}
+	})
+
+	if len(reports) != 1 || reports[0].Status != domain.AppStatusFailed {
+		t.Fatalf("reports = %+v, want the app failed by its unhealthy db", reports)
+	}
+
+	services := reports[0].Services
+	if len(services) != 3 {
+		t.Fatalf("services = %+v, want db, web and worker", services)
+	}
+
+	db, web, worker := services[0], services[1], services[2]
+	if db.Name != "db" || db.Status != domain.AppStatusFailed || db.Image != "postgres:16" || len(db.Ports) != 0 {
+		t.Fatalf("db = %+v", db)
+	}
+	if web.Name != "web" || web.Status != domain.AppStatusStarting || len(web.Containers) != 2 ||
+		!slices.Equal(web.Ports, []string{"0.0.0.0:8080->80/tcp", ":::8080->80/tcp"}) {
+		t.Fatalf("web = %+v", web)
+	}
+	if worker.Name != "worker" || worker.Status != domain.AppStatusStopped || len(worker.Containers) != 0 {
+		t.Fatalf("worker = %+v, want it stopped without containers", worker)
+	}
+}
+
+func TestHealthCheckServicesWithoutComposeConfig(t *testing.T) {
+	docker := &fakeDocker{
+		psOutput: `{"Name":"shop-7-web-1","Service":"web","State":"running"}`,
+		cmdErrs:  map[string]error{"compose -p shop-7 -f compose.yml config --services": errors.New("boom")},
+	}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+
+	payload, _ := json.Marshal(domain.AppHealthCheckPayload{
+		Applications: []domain.AppInfo{{ApplicationID: 7, AppKey: "shop-7"}},
+	})
+
+	var reports []domain.ApplicationHealth
+	ex.Execute(context.Background(), &domain.Job{Type: domain.JobTypeAppHealthCheck, Payload: payload}, func(evt any) {
+		if r, ok := evt.([]domain.ApplicationHealth); ok {
+			reports = r
+		}
+	})
+
+	if len(reports) != 1 || len(reports[0].Services) != 1 || reports[0].Services[0].Status != domain.AppStatusRunning {
+		t.Fatalf("reports = %+v, want web from its container alone", reports)
+	}
+}
+
+func TestAppServiceActions(t *testing.T) {
+	cases := []struct {
+		action   domain.ServiceAction
+		replicas int
+		want     string
+	}{
+		{domain.ServiceStart, 0, "compose -p shop-7 -f compose.yml start worker"},
+		{domain.ServiceStop, 0, "compose -p shop-7 -f compose.yml stop worker"},
+		{domain.ServiceRestart, 0, "compose -p shop-7 -f compose.yml restart worker"},
+		{domain.ServiceScale, 3, "compose -p shop-7 -f compose.yml up -d --no-deps --scale worker=3 worker"},
+		{domain.ServiceScale, 0, "compose -p shop-7 -f compose.yml up -d --no-deps --scale worker=0 worker"},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.action), func(t *testing.T) {
+			docker := &fakeDocker{
+				psOutput: `{"Name":"shop-7-worker-1","Service":"worker","State":"running"}`,
+				cmdOuts:  map[string]string{"compose -p shop-7 -f compose.yml config --services": "worker"},
+			}
+			ex := NewExecutorWithDeps(docker, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+
+			payload, _ := json.Marshal(domain.AppServicePayload{
+				AppInfo:  domain.AppInfo{ApplicationID: 7, AppKey: "shop-7"},
+				Service:  "worker",
+				Action:   tc.action,
+				Replicas: tc.replicas,
+			})
+
+			var reports []domain.ApplicationHealth
+			err := ex.Execute(context.Background(), &domain.Job{Type: domain.JobTypeAppService, Payload: payload}, func(evt any) {
+				if r, ok := evt.([]domain.ApplicationHealth); ok {
+					reports = r
+				}
+			})
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+
+			if docker.cmdCalls[0] != tc.want {
+				t.Fatalf("ran %q, want %q", docker.cmdCalls[0], tc.want)
+			}
+			if len(reports) != 1 || reports[0].ApplicationID != 7 || len(reports[0].Services) != 1 {
+				t.Fatalf("reports = %+v, want the app's health right after", reports)
+			}
+		})
+	}
+}
+
+func TestAppServiceRejectsBadPayload(t *testing.T) {
+	docker := &fakeDocker{}
+	ex := NewExecutorWithDeps(docker, &fakeGit{}, "/tmp/apps", noopLogger(), nil)
+
+	for _, p := range []domain.AppServicePayload{
+		{AppInfo: domain.AppInfo{AppKey: "shop-7"}, Service: "--all", Action: domain.ServiceStop},
+		{AppInfo: domain.AppInfo{AppKey: "shop-7"}, Service: "web", Action: "down"},
+		{AppInfo: domain.AppInfo{AppKey: "shop-7"}, Service: "web", Action: domain.ServiceScale, Replicas: domain.MaxServiceReplicas + 1},
+	} {
+		payload, _ := json.Marshal(p)
+		if err := ex.Execute(context.Background(), &domain.Job{Type: domain.JobTypeAppService, Payload: payload}, emitNoop); !errors.Is(err, domain.ErrInvalidServiceAction) {
+			t.Fatalf("%+v: err = %v, want ErrInvalidServiceAction", p, err)
+		}
+	}
+	if len(docker.cmdCalls) != 0 {
+		t.Fatalf("ran %v", docker.cmdCalls)
+	}
+}
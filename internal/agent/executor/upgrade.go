@@ -0,0 +1,206 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"horizonx/internal/domain"
+)
+
+// The agent runs unprivileged under ProtectSystem=strict, so it can neither
+// replace /usr/local/bin/horizonx nor restart its own unit. An agent_upgrade
+// job instead drops an UpgradeRequest into its work dir; a root systemd path
+// unit (horizonx-agent-upgrade.path, installed by `install agent`) runs
+// `horizonx upgrade --agent-request` on it, which swaps the binary and
+// restarts the agent. The helper only writes an UpgradeResult when there is
+// nothing to restart into: the upgrade failed, or the agent is already on
+// the requested release.
+const (
+	UpgradeDir         = ".horizonx-upgrade"
+	UpgradeRequestFile = "request"
+	UpgradeResultFile  = "result"
+)
+
+// UpgradeHelperUnit is the path unit that watches for upgrade requests.
+// Package var so tests can point it at a temp file.
+var UpgradeHelperUnit = "/etc/systemd/system/horizonx-agent-upgrade.path"
+
+// upgradePollInterval is how often the job checks for the helper's result.
+var upgradePollInterval = time.Second
+
+// ErrAgentRestarting is returned by an agent_upgrade job when the agent is
+// stopped while it waits for the helper, i.e. it is being restarted into the
+// new release. The job is left running: the server completes it when the
+// agent reconnects on the new version.
+var ErrAgentRestarting = errors.New("agent is restarting into the upgraded release")
+
+type UpgradeRequest struct {
+	JobID   int64  `json:"job_id"`
+	Version string `json:"version,omitempty"`
+}
+
+type UpgradeResult struct {
+	JobID   int64  `json:"job_id"`
+	Version string `json:"version"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (e *Executor) upgradeAgent(ctx context.Context, job *domain.Job, emit EmitHandler) error {
+	var payload domain.AgentUpgradePayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return err
+	}
+
+	action := domain.ActionAgentUpgrade
+	step := domain.StepAgentUpgrade
+	logInfo := func(message string) {
+		e.logStreamHandler(emit, action, step)(message, domain.StreamStdout, domain.LogInfo)
+	}
+
+	if _, err := os.Stat(UpgradeHelperUnit); err != nil {
+		err = fmt.Errorf("upgrade helper %s is not installed; re-run `horizonx install agent` on this host once", UpgradeHelperUnit)
+		e.logFatalHandler(err.Error(), emit, action, step)
+		return err
+	}
+
+	dir := filepath.Join(e.workDir, UpgradeDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create upgrade directory: %w", err)
+	}
+
+	resultPath := filepath.Join(dir, UpgradeResultFile)
+	if err := os.Remove(resultPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to clear previous upgrade result: %w", err)
+	}
+
+	target := payload.Version
+	if target == "" {
+		target = "the latest release"
+	}
+
+	req, err := json.Marshal(UpgradeRequest{JobID: job.ID, Version: payload.Version})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, UpgradeRequestFile), req); err != nil {
+		return fmt.Errorf("failed to write upgrade request: %w", err)
+	}
+
+	logInfo(fmt.Sprintf("upgrade to %s requested, waiting for the upgrade helper", target))
+
+	ticker := time.NewTicker(upgradePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err := errors.New("upgrade helper did not upgrade the agent in time; check `journalctl -u horizonx-agent-upgrade`")
+				e.logFatalHandler(err.Error(), emit, action, step)
+				return err
+			}
+			logInfo("agent stopping to restart into the new release")
+			return ErrAgentRestarting
+		case <-ticker.C:
+		}
+
+		res, ok := readUpgradeResult(resultPath, job.ID)
+		if !ok {
+			continue
+		}
+		if res.Error != "" {
+			e.logFatalHandler(fmt.Sprintf("agent upgrade failed, %s", res.Error), emit, action, step)
+			return errors.New(res.Error)
+		}
+
+		logInfo(fmt.Sprintf("agent is already on %s", res.Version))
+		return nil
+	}
+}
+
+// readUpgradeResult returns the helper's result for jobID, if it wrote one.
+func readUpgradeResult(path string, jobID int64) (UpgradeResult, bool) {
+	var res UpgradeResult
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return res, false
+	}
+	if err := json.Unmarshal(data, &res); err != nil || res.JobID != jobID {
+		return res, false
+	}
+
+	return res, true
+}
+
+// writeFileAtomic replaces path in one rename: the path unit never fires on
+// a half-written request, nor does the job read a half-written result.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// maxUpgradeRequestSize bounds what the root helper reads from the agent's
+// (unprivileged) work dir.
+const maxUpgradeRequestSize = 4 << 10
+
+// ReadUpgradeRequest reads the request an agent_upgrade job left at path.
+// It runs as root on a file the agent user controls, so only a small
+// regular file holding a release tag is accepted.
+func ReadUpgradeRequest(path string) (UpgradeRequest, error) {
+	var req UpgradeRequest
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return req, err
+	}
+	if !info.Mode().IsRegular() || info.Size() > maxUpgradeRequestSize {
+		return req, fmt.Errorf("%s is not an upgrade request", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return req, err
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return req, fmt.Errorf("invalid upgrade request: %w", err)
+	}
+	if err := domain.ValidateAgentVersion(req.Version); err != nil {
+		return req, err
+	}
+
+	return req, nil
+}
+
+// WriteUpgradeResult leaves res next to the request for the waiting job.
+// The file is renamed into place rather than opened by name, so a link
+// planted in the agent's dir is replaced, never followed.
+func WriteUpgradeResult(dir string, res UpgradeResult) error {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, UpgradeResultFile), data)
+}
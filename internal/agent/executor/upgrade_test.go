@@ -0,0 +1,139 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+)
+
+func upgradeJob(t *testing.T, version string) *domain.Job {
+	t.Helper()
+	payload, err := json.Marshal(domain.AgentUpgradePayload{Version: version, FromVersion: "v1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &domain.Job{ID: 7, Type: domain.JobTypeAgentUpgrade, Payload: payload}
+}
+
+// withUpgradeHelper points the executor at a fake helper unit and makes the
+// result poll fast.
+func withUpgradeHelper(t *testing.T) {
+	t.Helper()
+	unit := filepath.Join(t.TempDir(), "horizonx-agent-upgrade.path")
+	if err := os.WriteFile(unit, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldUnit, oldPoll := UpgradeHelperUnit, upgradePollInterval
+	UpgradeHelperUnit, upgradePollInterval = unit, 10*time.Millisecond
+	t.Cleanup(func() { UpgradeHelperUnit, upgradePollInterval = oldUnit, oldPoll })
+}
+
+// fakeHelper answers the first request it sees like `upgrade --agent-request`.
+func fakeHelper(t *testing.T, workDir string, upgradeErr string) {
+	t.Helper()
+	dir := filepath.Join(workDir, UpgradeDir)
+	go func() {
+		for range 200 {
+			req, err := ReadUpgradeRequest(filepath.Join(dir, UpgradeRequestFile))
+			if err == nil {
+				_ = WriteUpgradeResult(dir, UpgradeResult{JobID: req.JobID, Version: "v1.1.0", Error: upgradeErr})
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+}
+
+func TestUpgradeAgentReportsHelperFailure(t *testing.T) {
+	withUpgradeHelper(t)
+	workDir := t.TempDir()
+	ex := NewExecutorWithDeps(&fakeDocker{}, &fakeGit{}, workDir, noopLogger(), nil)
+
+	fakeHelper(t, workDir, "checksum mismatch")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := ex.Execute(ctx, upgradeJob(t, "v1.1.0"), emitNoop)
+	if err == nil || err.Error() != "checksum mismatch" {
+		t.Fatalf("err = %v, want the helper's error", err)
+	}
+
+	req, err := ReadUpgradeRequest(filepath.Join(workDir, UpgradeDir, UpgradeRequestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.JobID != 7 || req.Version != "v1.1.0" {
+		t.Fatalf("request = %+v, want job 7 pinned to v1.1.0", req)
+	}
+}
+
+func TestUpgradeAgentAlreadyCurrentSucceeds(t *testing.T) {
+	withUpgradeHelper(t)
+	workDir := t.TempDir()
+	ex := NewExecutorWithDeps(&fakeDocker{}, &fakeGit{}, workDir, noopLogger(), nil)
+
+	fakeHelper(t, workDir, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := ex.Execute(ctx, upgradeJob(t, ""), emitNoop); err != nil {
+		t.Fatalf("err = %v, want success", err)
+	}
+}
+
+// The helper restarts the agent instead of answering: the job must not be
+// reported, the server completes it on reconnect.
+func TestUpgradeAgentShutdownLeavesJobToServer(t *testing.T) {
+	withUpgradeHelper(t)
+	ex := NewExecutorWithDeps(&fakeDocker{}, &fakeGit{}, t.TempDir(), noopLogger(), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	if err := ex.Execute(ctx, upgradeJob(t, ""), emitNoop); !errors.Is(err, ErrAgentRestarting) {
+		t.Fatalf("err = %v, want ErrAgentRestarting", err)
+	}
+}
+
+func TestUpgradeAgentWithoutHelperFails(t *testing.T) {
+	withUpgradeHelper(t)
+	UpgradeHelperUnit = filepath.Join(t.TempDir(), "missing.path")
+	ex := NewExecutorWithDeps(&fakeDocker{}, &fakeGit{}, t.TempDir(), noopLogger(), nil)
+
+	if err := ex.Execute(context.Background(), upgradeJob(t, ""), emitNoop); err == nil {
+		t.Fatal("upgrade without the helper unit must fail")
+	}
+}
+
+func TestReadUpgradeRequestRejectsSymlinkAndBadVersion(t *testing.T) {
+	dir := t.TempDir()
+
+	target := filepath.Join(dir, "target")
+	if err := os.WriteFile(target, []byte(`{"job_id":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadUpgradeRequest(link); err == nil {
+		t.Fatal("symlinked request must be rejected")
+	}
+
+	bad := filepath.Join(dir, "bad")
+	if err := os.WriteFile(bad, []byte(`{"job_id":1,"version":"../../evil"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadUpgradeRequest(bad); !errors.Is(err, domain.ErrInvalidAgentVersion) {
+		t.Fatalf("err = %v, want ErrInvalidAgentVersion", err)
+	}
+}
@@ -0,0 +1,66 @@
+package git
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"horizonx/internal/domain"
+)
+
+// auth is the environment that makes git use one deploy's credentials
+// instead of the agent user's own SSH key. Nothing lands in the repository's
+// config: the deploy key lives in a private temp dir until close, and the
+// HTTPS token is passed as an extra header through GIT_CONFIG_* variables so
+// it never shows up in the remote URL or the process list.
+type auth struct {
+	env    []string
+	keyDir string
+}
+
+func newAuth(creds *domain.GitCredentials) (*auth, error) {
+	a := &auth{}
+	if creds == nil {
+		return a, nil
+	}
+
+	if creds.SSHPrivateKey != "" {
+		dir, err := os.MkdirTemp("", "horizonx-git-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create deploy key dir: %w", err)
+		}
+		a.keyDir = dir
+
+		keyFile := filepath.Join(dir, "id_deploy")
+		if err := os.WriteFile(keyFile, []byte(creds.SSHPrivateKey), 0o600); err != nil {
+			a.close()
+			return nil, fmt.Errorf("failed to write deploy key: %w", err)
+		}
+
+		// IdentitiesOnly keeps ssh from offering the agent user's key first;
+		// host key checks still use the agent's known_hosts.
+		a.env = append(a.env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i '%s' -o IdentitiesOnly=yes", keyFile))
+	}
+
+	if creds.HTTPSToken != "" {
+		basic := base64.StdEncoding.EncodeToString([]byte(creds.HTTPSUsername + ":" + creds.HTTPSToken))
+		a.env = append(a.env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+basic,
+		)
+	}
+
+	// A rejected credential must fail the job, not wait on a prompt.
+	a.env = append(a.env, "GIT_TERMINAL_PROMPT=0")
+
+	return a, nil
+}
+
+// close removes the deploy key from disk.
+func (a *auth) close() {
+	if a.keyDir != "" {
+		_ = os.RemoveAll(a.keyDir)
+	}
+}
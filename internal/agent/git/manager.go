@@ -3,12 +3,14 @@ package git
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"horizonx/internal/agent/command"
+	"horizonx/internal/domain"
 )
 
 type Manager struct{}
@@ -17,22 +19,38 @@ func NewManager() *Manager {
 	return &Manager{}
 }
 
-func (m *Manager) CloneOrPull(ctx context.Context, workDir string, remoteURL, branch string, handlers ...command.StreamHandler) (string, error) {
+// CloneOrPull brings workDir to the tip of branch, or to ref (a commit SHA or
+// tag) when one is given. The branch is always synced first so the checkout
+// keeps a local branch to return to on the next branch deploy. creds, when
+// set, authenticate every fetch and are removed from disk before returning.
+func (m *Manager) CloneOrPull(ctx context.Context, workDir string, remoteURL, branch, ref string, creds *domain.GitCredentials, handlers ...command.StreamHandler) (string, error) {
+	auth, err := newAuth(creds)
+	if err != nil {
+		return "", err
+	}
+	defer auth.close()
+
+	var output string
 	if yes := m.IsGitRepo(workDir); yes {
-		return m.Pull(ctx, workDir, branch, handlers...)
+		output, err = m.Pull(ctx, workDir, branch, auth.env, handlers...)
+	} else {
+		output, err = m.Clone(ctx, workDir, remoteURL, branch, auth.env, handlers...)
+	}
+	if err != nil || ref == "" {
+		return output, err
 	}
 
-	return m.Clone(ctx, workDir, remoteURL, branch, handlers...)
+	return m.CheckoutRef(ctx, workDir, ref, auth.env, handlers...)
 }
 
-func (m *Manager) Clone(ctx context.Context, workDir string, remoteURL, branch string, handlers ...command.StreamHandler) (string, error) {
+func (m *Manager) Clone(ctx context.Context, workDir string, remoteURL, branch string, env []string, handlers ...command.StreamHandler) (string, error) {
 	args := []string{"clone", "--branch", branch, "--depth", "1", remoteURL, workDir}
 
-	cmd := command.NewCommand(workDir, "git", args...)
+	cmd := command.NewCommand(workDir, "git", args...).WithEnv(env...)
 	return cmd.Run(ctx, handlers...)
 }
 
-func (m *Manager) Pull(ctx context.Context, workDir string, branch string, handlers ...command.StreamHandler) (string, error) {
+func (m *Manager) Pull(ctx context.Context, workDir string, branch string, env []string, handlers ...command.StreamHandler) (string, error) {
 	// P1-8: clean pull — force-checkout the branch and hard-reset to the
 	// remote tip. A dirty tree or a divergent local branch must never block a
 	// deploy or leave local junk in the build dir. Deployments are fully
@@ -43,7 +61,7 @@ func (m *Manager) Pull(ctx context.Context, workDir string, branch string, handl
 	}
 
 	// Ensure the remote-tracking ref is fresh before resetting onto it.
-	fetch := command.NewCommand(workDir, "git", "fetch", "origin", branch, "--depth", "1")
+	fetch := command.NewCommand(workDir, "git", "fetch", "origin", branch, "--depth", "1").WithEnv(env...)
 	if output, err := fetch.Run(ctx, handlers...); err != nil {
 		return output, err
 	}
@@ -52,6 +70,41 @@ func (m *Manager) Pull(ctx context.Context, workDir string, branch string, handl
 	return reset.Run(ctx, handlers...)
 }
 
+// CheckoutRef fetches ref from origin and force-checks it out as a detached
+// HEAD. A shallow fetch of the exact object works for tags and full SHAs;
+// abbreviated SHAs can't be fetched by name, so on failure the history is
+// unshallowed and the ref resolved locally.
+func (m *Manager) CheckoutRef(ctx context.Context, workDir string, ref string, env []string, handlers ...command.StreamHandler) (string, error) {
+	fetch := command.NewCommand(workDir, "git", "fetch", "--depth", "1", "origin", ref).WithEnv(env...)
+	if _, err := fetch.Run(ctx, handlers...); err == nil {
+		checkout := command.NewCommand(workDir, "git", "checkout", "-f", "--detach", "FETCH_HEAD")
+		return checkout.Run(ctx, handlers...)
+	}
+
+	unshallow := []string{"fetch", "--tags", "origin"}
+	if m.isShallow(workDir) {
+		unshallow = append(unshallow, "--unshallow")
+	}
+	fetchAll := command.NewCommand(workDir, "git", unshallow...).WithEnv(env...)
+	if output, err := fetchAll.Run(ctx, handlers...); err != nil {
+		return output, err
+	}
+
+	resolve := command.NewCommand(workDir, "git", "rev-parse", "--verify", ref+"^{commit}")
+	out, err := resolve.Run(ctx, handlers...)
+	if err != nil {
+		return out, fmt.Errorf("ref %q not found in remote: %w", ref, err)
+	}
+
+	checkout := command.NewCommand(workDir, "git", "checkout", "-f", "--detach", strings.TrimSpace(out))
+	return checkout.Run(ctx, handlers...)
+}
+
+func (m *Manager) isShallow(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git", "shallow"))
+	return err == nil
+}
+
 func (m *Manager) GetCurrentCommit(ctx context.Context, workDir string, handlers ...command.StreamHandler) (string, error) {
 	cmd := command.NewCommand(workDir, "git", "rev-parse", "HEAD")
 
@@ -2,15 +2,19 @@ package agent
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"horizonx/internal/config"
 	"horizonx/internal/version"
 	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
 )
 
 type HttpClient struct {
@@ -29,6 +33,59 @@ func (c *HttpClient) setAuthHeaders(req *http.Request) {
 	req.Header.Set("Authorization", "Bearer "+c.cfg.AgentServerID.String()+"."+c.cfg.AgentServerAPIToken)
 	// P2-18: agent version pinning — server warns on mismatch.
 	req.Header.Set(version.AgentVersionHeader, version.Version)
+
+	if rec, ok := req.Context().Value(spoolRecordKey{}).(spoolRecordRef); ok {
+		req.Header.Set(domain.AgentSpoolIDHeader, rec.id.String())
+		req.Header.Set(domain.AgentSpoolSeqHeader, strconv.FormatInt(rec.seq, 10))
+	}
+}
+
+type spoolRecordKey struct{}
+
+type spoolRecordRef struct {
+	id  uuid.UUID
+	seq int64
+}
+
+// withSpoolRecord marks requests made under ctx as the replay of spool record
+// seq, so the server applies the record once however often it is sent.
+func withSpoolRecord(ctx context.Context, id uuid.UUID, seq int64) context.Context {
+	return context.WithValue(ctx, spoolRecordKey{}, spoolRecordRef{id: id, seq: seq})
+}
+
+// withoutSpoolRecord clears the mark of withSpoolRecord, for requests that
+// each carry only part of a record.
+func withoutSpoolRecord(ctx context.Context) context.Context {
+	return context.WithValue(ctx, spoolRecordKey{}, nil)
+}
+
+// statusError is an unexpected response status.
+type statusError struct {
+	what   string
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("failed to %s, status: %d", e.what, e.status)
+}
+
+// permanent reports whether sending the same request again cannot succeed.
+// Auth failures are not permanent: they clear once the token is fixed.
+func (e *statusError) permanent() bool {
+	switch e.status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return e.status >= 400 && e.status < 500
+}
+
+// checkStatus accepts want, and 208 Already Reported: the server's answer to
+// a spooled record it has applied before.
+func checkStatus(resp *http.Response, want int, what string) error {
+	if resp.StatusCode == want || resp.StatusCode == http.StatusAlreadyReported {
+		return nil
+	}
+	return &statusError{what: what, status: resp.StatusCode}
 }
 
 func (c *HttpClient) UpdateServerOSInfo(ctx context.Context, req domain.OSInfo) error {
@@ -91,34 +148,46 @@ func (c *HttpClient) GetPendingJobs(ctx context.Context) ([]domain.Job, error) {
 	return response.Data, nil
 }
 
-func (c *HttpClient) StartJob(ctx context.Context, jobID int64) error {
+// StartJob marks the job as running and returns it as the server sees it.
+// The returned status is not running when the job was cancelled before the
+// agent got to it.
+func (c *HttpClient) StartJob(ctx context.Context, jobID int64) (*domain.Job, error) {
 	url := fmt.Sprintf("%s/agent/jobs/%d/start", c.cfg.AgentTargetAPIURL, jobID)
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	c.setAuthHeaders(req)
 
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to start job, status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to start job, status: %d", resp.StatusCode)
 	}
 
-	return nil
+	var response struct {
+		Data domain.Job `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
 }
 
-func (c *HttpClient) FinishJob(ctx context.Context, jobID int64, status domain.JobStatus) error {
+func (c *HttpClient) FinishJob(ctx context.Context, jobID int64, status domain.JobStatus, startedAt *time.Time) error {
 	url := fmt.Sprintf("%s/agent/jobs/%d/finish", c.cfg.AgentTargetAPIURL, jobID)
 
 	payload := &domain.JobFinishRequest{
-		Status: status,
+		Status:    status,
+		StartedAt: startedAt,
 	}
 
 	body, err := json.Marshal(payload)
@@ -140,11 +209,7 @@ func (c *HttpClient) FinishJob(ctx context.Context, jobID int64, status domain.J
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to mark job finished, status: %d", resp.StatusCode)
-	}
-
-	return nil
+	return checkStatus(resp, http.StatusOK, "mark job finished")
 }
 
 func (c *HttpClient) SendAppHealthReports(ctx context.Context, req []domain.ApplicationHealth) error {
@@ -198,27 +263,33 @@ func (c *HttpClient) SendMetrics(ctx context.Context, req *domain.Metrics) error
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to send metrics, status: %d", resp.StatusCode)
-	}
-
-	return nil
+	return checkStatus(resp, http.StatusCreated, "send metrics")
 }
 
-func (c *HttpClient) SendLog(ctx context.Context, req *domain.LogEmitRequest) error {
-	url := fmt.Sprintf("%s/agent/logs", c.cfg.AgentTargetAPIURL)
+// SendLogs ships a batch of log lines as gzip-compressed NDJSON, one request
+// for the whole batch.
+func (c *HttpClient) SendLogs(ctx context.Context, reqs []*domain.LogEmitRequest) error {
+	url := fmt.Sprintf("%s/agent/logs/batch", c.cfg.AgentTargetAPIURL)
 
-	body, err := json.Marshal(&req)
-	if err != nil {
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	enc := json.NewEncoder(gz)
+	for _, req := range reqs {
+		if err := enc.Encode(req); err != nil {
+			return err
+		}
+	}
+	if err := gz.Close(); err != nil {
 		return err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, &body)
 	if err != nil {
 		return err
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Content-Type", "application/x-ndjson")
+	httpReq.Header.Set("Content-Encoding", "gzip")
 	c.setAuthHeaders(httpReq)
 
 	resp, err := c.http.Do(httpReq)
@@ -227,19 +298,16 @@ func (c *HttpClient) SendLog(ctx context.Context, req *domain.LogEmitRequest) er
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to send log, status: %d", resp.StatusCode)
-	}
-
-	return nil
+	return checkStatus(resp, http.StatusCreated, "send logs")
 }
 
-func (c *HttpClient) SendCommitInfo(ctx context.Context, deploymentID int64, commitHash string, commitMessage string) error {
+func (c *HttpClient) SendCommitInfo(ctx context.Context, deploymentID int64, commitHash string, commitMessage string, imageDigest string) error {
 	url := fmt.Sprintf("%s/agent/deployments/%d/commit-info", c.cfg.AgentTargetAPIURL, deploymentID)
 
 	payload := &domain.DeploymentCommitInfoRequest{
 		CommitHash:    commitHash,
 		CommitMessage: commitMessage,
+		ImageDigest:   imageDigest,
 	}
 
 	body, err := json.Marshal(payload)
@@ -261,9 +329,30 @@ func (c *HttpClient) SendCommitInfo(ctx context.Context, deploymentID int64, com
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to send deployment commit info, status: %d", resp.StatusCode)
+	return checkStatus(resp, http.StatusOK, "send deployment commit info")
+}
+
+func (c *HttpClient) SendAutoRollback(ctx context.Context, deploymentID int64, payload *domain.DeploymentAutoRollbackRequest) error {
+	url := fmt.Sprintf("%s/agent/deployments/%d/auto-rollback", c.cfg.AgentTargetAPIURL, deploymentID)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuthHeaders(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(resp, http.StatusOK, "send deployment auto rollback")
 }
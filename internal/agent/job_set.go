@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// finishedJobTTL is how long a finished job ID is remembered. A job can reach
+// the agent twice — pushed over the socket and returned by a poll that raced
+// the push — and the late copy must not run again once the first finishes.
+// A retry re-queues the same ID with a new queued_at and is a new run.
+const finishedJobTTL = 2 * time.Minute
+
+// errJobCancelled is the cancel cause of a job stopped on the server's request.
+var errJobCancelled = errors.New("job cancelled")
+
+// jobSet tracks which jobs this agent has already taken, so the same job is
+// never executed twice regardless of how it was delivered.
+type jobSet struct {
+	mu       sync.Mutex
+	active   map[int64]*activeJob
+	finished map[int64]finishedJob
+
+	now func() time.Time
+}
+
+// finishedJob is the run of a job that finished: when it was queued, and
+// when it finished.
+type finishedJob struct {
+	queuedAt time.Time
+	at       time.Time
+}
+
+// activeJob holds the cancel func of a running job. A cancel that arrives
+// before the job registered its func is remembered and applied on SetCancel.
+type activeJob struct {
+	cancel    context.CancelCauseFunc
+	cancelled bool
+}
+
+func newJobSet() *jobSet {
+	return &jobSet{
+		active:   make(map[int64]*activeJob),
+		finished: make(map[int64]finishedJob),
+
+		now: time.Now,
+	}
+}
+
+// Claim marks the run of the job queued at queuedAt as taken. It returns
+// false when the job is already running, or that same run finished recently.
+func (s *jobSet) Claim(jobID int64, queuedAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.active[jobID]; ok {
+		return false
+	}
+	if done, ok := s.finished[jobID]; ok {
+		if done.queuedAt.Equal(queuedAt) && s.now().Sub(done.at) <= finishedJobTTL {
+			return false
+		}
+		delete(s.finished, jobID)
+	}
+
+	s.active[jobID] = &activeJob{}
+	return true
+}
+
+// SetCancel registers the func that stops a claimed job.
+func (s *jobSet) SetCancel(jobID int64, cancel context.CancelCauseFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.active[jobID]
+	if !ok {
+		return
+	}
+
+	j.cancel = cancel
+	if j.cancelled {
+		cancel(errJobCancelled)
+	}
+}
+
+// Cancel stops a claimed job. It returns false when the job is not held by
+// this agent.
+func (s *jobSet) Cancel(jobID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.active[jobID]
+	if !ok {
+		return false
+	}
+
+	j.cancelled = true
+	if j.cancel != nil {
+		j.cancel(errJobCancelled)
+	}
+	return true
+}
+
+// Finish releases the claim and remembers the run as done.
+func (s *jobSet) Finish(jobID int64, queuedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.active, jobID)
+
+	now := s.now()
+	s.finished[jobID] = finishedJob{queuedAt: queuedAt, at: now}
+
+	for id, done := range s.finished {
+		if now.Sub(done.at) > finishedJobTTL {
+			delete(s.finished, id)
+		}
+	}
+}
+
+// Forget drops the claim without marking the job as done, so the next poll
+// can deliver it again (used when the job never started).
+func (s *jobSet) Forget(jobID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.active, jobID)
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var queued = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// A job delivered by both the socket push and a poll must only run once.
+func TestJobSetClaimsOnce(t *testing.T) {
+	s := newJobSet()
+
+	if !s.Claim(1, queued) {
+		t.Fatal("first claim must succeed")
+	}
+	if s.Claim(1, queued) {
+		t.Fatal("second claim of a running job must fail")
+	}
+
+	s.Finish(1, queued)
+	if s.Claim(1, queued) {
+		t.Fatal("a recently finished job must not be claimed again")
+	}
+}
+
+// A retry re-queues the job under the same ID: that new run must be claimed
+// even right after the previous one finished.
+func TestJobSetClaimsRetriedRun(t *testing.T) {
+	s := newJobSet()
+
+	s.Claim(1, queued)
+	s.Finish(1, queued)
+
+	if !s.Claim(1, queued.Add(time.Minute)) {
+		t.Fatal("a retried run must be claimable")
+	}
+}
+
+func TestJobSetForgetsFinishedRunsAfterTTL(t *testing.T) {
+	s := newJobSet()
+	now := queued
+	s.now = func() time.Time { return now }
+
+	s.Claim(1, queued)
+	s.Finish(1, queued)
+
+	now = now.Add(finishedJobTTL + time.Second)
+	if !s.Claim(1, queued) {
+		t.Fatal("a run finished longer than finishedJobTTL ago must be claimable")
+	}
+}
+
+func TestJobSetForgetAllowsRedelivery(t *testing.T) {
+	s := newJobSet()
+
+	s.Claim(1, queued)
+	s.Forget(1)
+
+	if !s.Claim(1, queued) {
+		t.Fatal("a forgotten job must be claimable again")
+	}
+}
+
+// A cancel that lands while the job is still queued on the agent must stop it
+// as soon as it registers its cancel func.
+func TestJobSetCancelBeforeStart(t *testing.T) {
+	s := newJobSet()
+	s.Claim(1, queued)
+
+	if !s.Cancel(1) {
+		t.Fatal("cancel of a claimed job must succeed")
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s.SetCancel(1, cancel)
+
+	if !errors.Is(context.Cause(ctx), errJobCancelled) {
+		t.Fatalf("expected errJobCancelled cause, got %v", context.Cause(ctx))
+	}
+}
+
+func TestJobSetCancelUnknownJob(t *testing.T) {
+	s := newJobSet()
+
+	if s.Cancel(1) {
+		t.Fatal("cancel of a job this agent does not hold must fail")
+	}
+}
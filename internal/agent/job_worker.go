@@ -3,8 +3,11 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"horizonx/internal/agent/executor"
@@ -12,6 +15,24 @@ import (
 	"horizonx/internal/domain"
 	"horizonx/internal/event"
 	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// pollInterval is how often the agent asks for queued jobs while the
+	// socket is down. Once connected, jobs are pushed over the socket and the
+	// poll only runs every connectedPollInterval as a safety net.
+	pollInterval          = 5 * time.Second
+	connectedPollInterval = time.Minute
+
+	jobQueueSize = 256
+
+	// jobFinishWait is how long a worker holds a finished job's app lock
+	// waiting for the server to take its result, so the app's next job does
+	// not start before this one is recorded as finished. Past it the result
+	// is left to the spool.
+	jobFinishWait = 30 * time.Second
 )
 
 type JobWorker struct {
@@ -21,43 +42,110 @@ type JobWorker struct {
 	httpClient *HttpClient
 	executor   *executor.Executor
 
+	// spool carries every report that must survive a server outage: job
+	// results, logs, metrics and deployment reports.
+	spool *Spool
+
 	// appLocks serializes jobs that touch the same application workdir
 	// (P1-7). Two deploys — or a deploy and a stop — for the same app must
 	// never run concurrently: they share a directory and docker project.
 	appLocks *keyedMutex
+
+	// queue feeds the worker pool from both delivery paths (socket push and
+	// HTTP poll); jobs dedupes them so a job is executed once.
+	queue chan domain.Job
+	jobs  *jobSet
+
+	connected atomic.Bool
+	wake      chan struct{}
 }
 
-func NewJobWorker(cfg *config.Config, log logger.Logger, httpClient HttpClient, executor executor.Executor) *JobWorker {
+func NewJobWorker(cfg *config.Config, log logger.Logger, httpClient HttpClient, executor executor.Executor, spool *Spool) *JobWorker {
 	return &JobWorker{
 		cfg: cfg,
 		log: log,
 
 		httpClient: &httpClient,
 		executor:   &executor,
+		spool:      spool,
 		appLocks:   newKeyedMutex(),
+
+		queue: make(chan domain.Job, jobQueueSize),
+		jobs:  newJobSet(),
+		wake:  make(chan struct{}, 1),
 	}
 }
 
 func (w *JobWorker) Start(ctx context.Context) error {
-	ticker := time.NewTicker(5 * time.Second)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for range w.cfg.AgentJobWorkerCount {
+		wg.Go(func() { w.run(ctx) })
+	}
+	wg.Go(func() { w.spool.Run(ctx, w.deliver) })
+
+	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
-	w.log.Info("job worker started, polling for jobs...")
+	w.log.Info("job worker started, waiting for jobs...")
+
+	var lastPoll time.Time
 
 	for {
 		select {
 		case <-ctx.Done():
 			w.log.Info("job worker stopped")
 			return nil
+		case <-w.wake:
 		case <-ticker.C:
-			if err := w.pollAndExecuteJobs(ctx); err != nil {
-				w.log.Warn("failed to poll and execute jobs", "error", err)
+			if w.connected.Load() && time.Since(lastPoll) < connectedPollInterval {
+				continue
 			}
 		}
+
+		lastPoll = time.Now()
+		if err := w.pollJobs(ctx); err != nil {
+			w.log.Warn("failed to poll jobs", "error", err)
+		}
 	}
 }
 
-func (w *JobWorker) pollAndExecuteJobs(ctx context.Context) error {
+// SetConnected is called by the agent connection when the socket goes up or
+// down. A (re)connect triggers an immediate poll to pick up anything queued
+// while the agent was unreachable.
+func (w *JobWorker) SetConnected(connected bool) {
+	w.connected.Store(connected)
+
+	if connected {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Dispatch queues a job for execution. It is safe to deliver the same job
+// more than once: duplicates are ignored. Returns false when the job was not
+// queued.
+func (w *JobWorker) Dispatch(job domain.Job) bool {
+	if !w.jobs.Claim(job.ID, queuedAt(job)) {
+		w.log.Debug("job already taken, skipping", "job_id", job.ID)
+		return false
+	}
+
+	select {
+	case w.queue <- job:
+		return true
+	default:
+		// Leave it queued on the server; the next poll delivers it again.
+		w.jobs.Forget(job.ID)
+		w.log.Warn("job queue full, deferring job to next poll", "job_id", job.ID)
+		return false
+	}
+}
+
+func (w *JobWorker) pollJobs(ctx context.Context) error {
 	jobs, err := w.httpClient.GetPendingJobs(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to fetch jobs: %w", err)
@@ -68,38 +156,24 @@ func (w *JobWorker) pollAndExecuteJobs(ctx context.Context) error {
 
 	w.log.Debug("received jobs", "count", len(jobs))
 
-	jobCh := make(chan domain.Job, len(jobs))
 	for _, job := range jobs {
-		jobCh <- job
+		w.Dispatch(job)
 	}
-	close(jobCh)
-
-	errCh := make(chan error, len(jobs))
 
-	var wg sync.WaitGroup
+	return nil
+}
 
-	for range w.cfg.AgentJobWorkerCount {
-		wg.Go(func() {
-			for job := range jobCh {
-				select {
-				case <-ctx.Done():
-					return
-				case errCh <- w.processJob(ctx, job):
-				}
+func (w *JobWorker) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-w.queue:
+			if err := w.processJob(ctx, job); err != nil {
+				w.log.Error("failed to process job", "job_id", job.ID, "error", err)
 			}
-		})
-	}
-
-	wg.Wait()
-	close(errCh)
-
-	for jobErr := range errCh {
-		if jobErr != nil {
-			w.log.Error("failed to process job", "error", jobErr)
 		}
 	}
-
-	return nil
 }
 
 func (w *JobWorker) processJob(ctx context.Context, job domain.Job) error {
@@ -112,29 +186,72 @@ func (w *JobWorker) processJob(ctx context.Context, job domain.Job) error {
 		defer unlock()
 	}
 
-	if err := w.httpClient.StartJob(ctx, job.ID); err != nil {
+	started, err := w.httpClient.StartJob(ctx, job.ID)
+	if err != nil {
+		w.jobs.Forget(job.ID)
 		w.log.Error("failed to mark job as running", "job_id", job.ID, "error", err)
 		return err
 	}
+	defer w.jobs.Finish(job.ID, queuedAt(job))
+
+	// The server hands back the job unchanged when it is no longer queued,
+	// e.g. cancelled while it waited for the app lock. Never run those.
+	if started.Status != domain.JobRunning {
+		w.log.Info("job no longer runnable, skipping", "job_id", job.ID, "status", started.Status)
+		return nil
+	}
 
 	execErr := w.execute(ctx, job)
 
+	// The agent is being restarted into a new release mid-upgrade. The job
+	// stays running; the server completes it when the agent reconnects.
+	if errors.Is(execErr, executor.ErrAgentRestarting) {
+		w.log.Info("agent restarting for upgrade, leaving job to the server", "job_id", job.ID)
+		return nil
+	}
+
 	status := domain.JobSuccess
-	if execErr != nil {
+	switch {
+	case errors.Is(execErr, errJobCancelled):
+		status = domain.JobCancelled
+		w.log.Info("job cancelled", "job_id", job.ID)
+	case execErr != nil:
 		status = domain.JobFailed
 		w.log.Error("job execution failed", "job_id", job.ID, "error", execErr)
-	} else {
+	default:
 		w.log.Debug("job executed successfully", "job_id", job.ID)
 	}
 
-	if err := w.httpClient.FinishJob(ctx, job.ID, status); err != nil {
+	seq, err := w.spool.Append(spoolJobFinish, spoolJobFinishRecord{JobID: job.ID, Status: status, StartedAt: started.StartedAt})
+	if err != nil {
 		w.log.Error("failed to mark job as finished", "job_id", job.ID, "error", err)
 		return err
 	}
 
+	waitCtx, cancel := context.WithTimeout(ctx, jobFinishWait)
+	defer cancel()
+	if err := w.spool.WaitAcked(waitCtx, seq); err != nil {
+		w.log.Warn("server has not taken job result yet, leaving it spooled", "job_id", job.ID, "status", status)
+	}
+
+	if status == domain.JobCancelled {
+		return nil
+	}
+
 	return execErr
 }
 
+// CancelJob stops a job this agent is running or has queued. A job it does
+// not hold is ignored: it either finished already or was never delivered.
+func (w *JobWorker) CancelJob(jobID int64) {
+	if !w.jobs.Cancel(jobID) {
+		w.log.Debug("cancel for unknown job ignored", "job_id", jobID)
+		return
+	}
+
+	w.log.Info("cancelling job", "job_id", jobID)
+}
+
 var jobTimeouts = map[domain.JobType]time.Duration{
 	// P0-2: deploy must be LONG — a real build (composer/npm/Go multi-stage)
 	// takes minutes, not seconds. The old 30s budget killed real builds.
@@ -143,9 +260,13 @@ var jobTimeouts = map[domain.JobType]time.Duration{
 	domain.JobTypeAppStart:       2 * time.Minute,
 	domain.JobTypeAppStop:        2 * time.Minute,
 	domain.JobTypeAppRestart:     2 * time.Minute,
+	domain.JobTypeAppService:     5 * time.Minute,
 	domain.JobTypeAppHealthCheck: 1 * time.Minute,
 	domain.JobTypeAppDestroy:     5 * time.Minute,
 	domain.JobTypeMetricsCollect: 1 * time.Minute,
+	// Download, checksum and swap by the root helper; the restart itself
+	// ends the job early.
+	domain.JobTypeAgentUpgrade: 10 * time.Minute,
 }
 
 const defaultJobTimeout = 5 * time.Minute
@@ -157,52 +278,27 @@ func jobTimeout(t domain.JobType) time.Duration {
 	return defaultJobTimeout
 }
 
+// queuedAt tells runs of the same job apart: a retry re-queues it under
+// the same ID.
+func queuedAt(job domain.Job) time.Time {
+	if job.QueuedAt == nil {
+		return time.Time{}
+	}
+	return *job.QueuedAt
+}
+
 func (w *JobWorker) execute(ctx context.Context, job domain.Job) error {
+	ctx, cancelJob := context.WithCancelCause(ctx)
+	defer cancelJob(nil)
+	w.jobs.SetCancel(job.ID, cancelJob)
+
 	ctx, cancel := context.WithTimeout(ctx, jobTimeout(job.Type))
 	defer cancel()
 
-	logCh := make(chan domain.EventLogEmitted, 500)
-	commitCh := make(chan domain.EventCommitInfoEmitted, 1)
-
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	sendCtx := context.WithoutCancel(ctx)
-	go func() {
-		defer wg.Done()
-		for evt := range logCh {
-			err := w.httpClient.SendLog(sendCtx, &domain.LogEmitRequest{
-				Timestamp:     evt.Timestamp,
-				Level:         evt.Level,
-				Source:        evt.Source,
-				Action:        evt.Action,
-				TraceID:       job.TraceID,
-				JobID:         &job.ID,
-				ServerID:      &job.ServerID,
-				ApplicationID: job.ApplicationID,
-				DeploymentID:  job.DeploymentID,
-				Message:       evt.Message,
-				Context:       evt.Context,
-			})
-			if err != nil {
-				w.log.Error("failed to send log", "error", err)
-			}
-		}
-	}()
-
-	go func() {
-		defer wg.Done()
-		for evt := range commitCh {
-			if err := w.httpClient.SendCommitInfo(
-				sendCtx,
-				evt.DeploymentID,
-				evt.Hash,
-				evt.Message,
-			); err != nil {
-				w.log.Error("failed to send commit info", "error", err)
-			}
-		}
-	}()
+	logs := newLogShipper(context.WithoutCancel(ctx), func(_ context.Context, batch []*domain.LogEmitRequest) error {
+		_, err := w.spool.Append(spoolLogs, batch)
+		return err
+	}, w.log)
 
 	bus := event.New()
 
@@ -212,7 +308,7 @@ func (w *JobWorker) execute(ctx context.Context, job domain.Job) error {
 			return
 		}
 
-		if err := w.httpClient.SendMetrics(ctx, metrics); err != nil {
+		if _, err := w.spool.Append(spoolMetrics, metrics); err != nil {
 			w.log.Error("failed to send metrics", "error", err)
 		}
 	})
@@ -234,11 +330,19 @@ func (w *JobWorker) execute(ctx context.Context, job domain.Job) error {
 			return
 		}
 
-		select {
-		case logCh <- evt:
-		default:
-			w.log.Warn("log channel full, dropping event")
-		}
+		logs.Ship(&domain.LogEmitRequest{
+			Timestamp:     evt.Timestamp,
+			Level:         evt.Level,
+			Source:        evt.Source,
+			Action:        evt.Action,
+			TraceID:       job.TraceID,
+			JobID:         &job.ID,
+			ServerID:      &job.ServerID,
+			ApplicationID: job.ApplicationID,
+			DeploymentID:  job.DeploymentID,
+			Message:       evt.Message,
+			Context:       evt.Context,
+		})
 	})
 
 	bus.Subscribe("commit_info", func(event any) {
@@ -247,10 +351,34 @@ func (w *JobWorker) execute(ctx context.Context, job domain.Job) error {
 			return
 		}
 
-		select {
-		case commitCh <- evt:
-		default:
-			w.log.Warn("commit channel full, dropping event")
+		if _, err := w.spool.Append(spoolCommitInfo, spoolCommitInfoRecord{
+			DeploymentID: evt.DeploymentID,
+			Hash:         evt.Hash,
+			Message:      evt.Message,
+			ImageDigest:  evt.ImageDigest,
+		}); err != nil {
+			w.log.Error("failed to send commit info", "error", err)
+		}
+	})
+
+	// Spooled ahead of the job's result, so the server records the rollback
+	// before the job is finished and the deployment marked failed.
+	bus.Subscribe("auto_rollback", func(event any) {
+		evt, ok := event.(domain.EventAutoRollbackEmitted)
+		if !ok {
+			return
+		}
+
+		if _, err := w.spool.Append(spoolAutoRollback, spoolAutoRollbackRecord{
+			DeploymentID: evt.DeploymentID,
+			Request: domain.DeploymentAutoRollbackRequest{
+				FailedImage:   evt.FailedImage,
+				RestoredImage: evt.RestoredImage,
+				Restored:      evt.Restored,
+				Reason:        evt.Reason,
+			},
+		}); err != nil {
+			w.log.Error("failed to send auto rollback", "error", err)
 		}
 	})
 
@@ -264,15 +392,81 @@ func (w *JobWorker) execute(ctx context.Context, job domain.Job) error {
 			bus.Publish("log", event)
 		case domain.EventCommitInfoEmitted:
 			bus.Publish("commit_info", event)
+		case domain.EventAutoRollbackEmitted:
+			bus.Publish("auto_rollback", event)
 		}
 	}
 
 	err := w.executor.Execute(ctx, &job, onEmit)
 
-	close(logCh)
-	close(commitCh)
+	if errors.Is(context.Cause(ctx), errJobCancelled) {
+		onEmit(domain.EventLogEmitted{
+			Timestamp: time.Now().UTC(),
+			Level:     domain.LogWarn,
+			Source:    domain.LogAgent,
+			Action:    domain.ActionJobCancel,
+			Message:   "job cancelled, running processes stopped",
+			Context: &domain.LogContext{
+				Status: string(domain.JobCancelled),
+			},
+		})
+		err = errJobCancelled
+	}
 
-	wg.Wait()
+	logs.Close()
 
 	return err
 }
+
+// deliver sends one spooled record to the server. A record the server
+// refuses outright (bad request, unknown job) is rejected so it does not hold
+// up the records behind it.
+func (w *JobWorker) deliver(ctx context.Context, id uuid.UUID, rec spoolRecord) error {
+	ctx, cancel := context.WithTimeout(withSpoolRecord(ctx, id, rec.Seq), spoolSendTimeout)
+	defer cancel()
+
+	var err error
+	switch rec.Kind {
+	case spoolLogs:
+		var batch []*domain.LogEmitRequest
+		if err = json.Unmarshal(rec.Data, &batch); err == nil {
+			err = w.httpClient.SendLogs(ctx, batch)
+		}
+		// The server would take the first part acked under the record for
+		// the whole of it and skip the rest as replays, so the parts go
+		// unmarked.
+		if len(batch) > 1 && tooLarge(err) {
+			err = splitLogBatch(withoutSpoolRecord(ctx), batch, w.httpClient.SendLogs)
+		}
+	case spoolMetrics:
+		var metrics domain.Metrics
+		if err = json.Unmarshal(rec.Data, &metrics); err == nil {
+			err = w.httpClient.SendMetrics(ctx, &metrics)
+		}
+	case spoolJobFinish:
+		var r spoolJobFinishRecord
+		if err = json.Unmarshal(rec.Data, &r); err == nil {
+			err = w.httpClient.FinishJob(ctx, r.JobID, r.Status, r.StartedAt)
+		}
+	case spoolCommitInfo:
+		var r spoolCommitInfoRecord
+		if err = json.Unmarshal(rec.Data, &r); err == nil {
+			err = w.httpClient.SendCommitInfo(ctx, r.DeploymentID, r.Hash, r.Message, r.ImageDigest)
+		}
+	case spoolAutoRollback:
+		var r spoolAutoRollbackRecord
+		if err = json.Unmarshal(rec.Data, &r); err == nil {
+			err = w.httpClient.SendAutoRollback(ctx, r.DeploymentID, &r.Request)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", errSpoolReject, rec.Kind)
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var statusErr *statusError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || (errors.As(err, &statusErr) && statusErr.permanent()) {
+		return fmt.Errorf("%w: %w", errSpoolReject, err)
+	}
+	return err
+}
@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+)
+
+const (
+	// A batch is sent once it holds logBatchMaxLines lines or
+	// logBatchMaxBytes of messages, or logFlushInterval after its first line,
+	// whichever comes first. Both caps stay well under the server's
+	// MaxLogBatchSize and MaxLogBatchBytes.
+	logBatchMaxLines = 500
+	logBatchMaxBytes = 1 << 20
+	logFlushInterval = 250 * time.Millisecond
+
+	// logQueueSize is how many lines may wait while a batch is in flight.
+	// Past it Ship blocks, which slows the command producing the output
+	// instead of dropping lines.
+	logQueueSize = 2000
+
+	// A batch is tried logSendAttempts times, logRetryBackoff apart and
+	// doubling, each attempt bounded by logSendTimeout, before it is given
+	// up on.
+	logSendAttempts = 4
+	logRetryBackoff = 500 * time.Millisecond
+	logSendTimeout  = 30 * time.Second
+)
+
+type logSendFunc func(ctx context.Context, batch []*domain.LogEmitRequest) error
+
+// logShipper batches a job's log lines and sends them from one goroutine, so
+// the server receives them in order and a verbose build costs a request per
+// batch, not per line.
+type logShipper struct {
+	send logSendFunc
+	log  logger.Logger
+
+	queue chan *domain.LogEmitRequest
+	done  chan struct{}
+}
+
+// newLogShipper starts a shipper. ctx bounds sends and retries; it should
+// outlive the job so the lines logged as the job ends are still delivered.
+func newLogShipper(ctx context.Context, send logSendFunc, log logger.Logger) *logShipper {
+	s := &logShipper{
+		send:  send,
+		log:   log,
+		queue: make(chan *domain.LogEmitRequest, logQueueSize),
+		done:  make(chan struct{}),
+	}
+	go s.run(ctx)
+	return s
+}
+
+// Ship queues one line, blocking while the queue is full.
+func (s *logShipper) Ship(req *domain.LogEmitRequest) {
+	s.queue <- req
+}
+
+// Close flushes the queued lines and waits for the last batch to be sent.
+// Ship must not be called after Close.
+func (s *logShipper) Close() {
+	close(s.queue)
+	<-s.done
+}
+
+func (s *logShipper) run(ctx context.Context) {
+	defer close(s.done)
+
+	var (
+		batch []*domain.LogEmitRequest
+		size  int
+		timer *time.Timer
+		flush <-chan time.Time
+	)
+
+	sendBatch := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, flush = nil, nil
+		}
+		if len(batch) == 0 {
+			return
+		}
+		s.deliver(ctx, batch)
+		batch, size = nil, 0
+	}
+
+	for {
+		select {
+		case req, ok := <-s.queue:
+			if !ok {
+				sendBatch()
+				return
+			}
+
+			batch = append(batch, req)
+			size += len(req.Message)
+			if timer == nil {
+				timer = time.NewTimer(logFlushInterval)
+				flush = timer.C
+			}
+			if len(batch) >= logBatchMaxLines || size >= logBatchMaxBytes {
+				sendBatch()
+			}
+		case <-flush:
+			timer, flush = nil, nil
+			sendBatch()
+		}
+	}
+}
+
+// deliver sends one batch, retrying with backoff. While it retries the queue
+// fills and Ship blocks; a batch still failing after the last attempt is
+// dropped with an error so the job is not held up indefinitely. A batch the
+// server refuses outright is dropped at once, as sending it again cannot
+// succeed; one it finds too large is sent again in halves.
+func (s *logShipper) deliver(ctx context.Context, batch []*domain.LogEmitRequest) {
+	backoff := logRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, logSendTimeout)
+		err := sendLogBatch(sendCtx, batch, s.send)
+		cancel()
+		if err == nil {
+			return
+		}
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.permanent() {
+			s.log.Error("server refused logs, dropping batch", "lines", len(batch), "error", err)
+			return
+		}
+		if attempt == logSendAttempts || ctx.Err() != nil {
+			s.log.Error("failed to send logs, dropping batch", "lines", len(batch), "attempts", attempt, "error", err)
+			return
+		}
+
+		s.log.Warn("failed to send logs, retrying", "lines", len(batch), "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// sendLogBatch sends batch, splitting it in halves for as long as the server
+// answers 413 Request Entity Too Large.
+func sendLogBatch(ctx context.Context, batch []*domain.LogEmitRequest, send logSendFunc) error {
+	err := send(ctx, batch)
+	if len(batch) > 1 && tooLarge(err) {
+		return splitLogBatch(ctx, batch, send)
+	}
+	return err
+}
+
+// splitLogBatch sends batch as two halves, in order, each split further if
+// it is still too large.
+func splitLogBatch(ctx context.Context, batch []*domain.LogEmitRequest, send logSendFunc) error {
+	half := len(batch) / 2
+	if err := sendLogBatch(ctx, batch[:half], send); err != nil {
+		return err
+	}
+	return sendLogBatch(ctx, batch[half:], send)
+}
+
+func tooLarge(err error) bool {
+	var statusErr *statusError
+	return errors.As(err, &statusErr) && statusErr.status == http.StatusRequestEntityTooLarge
+}
@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+type recordingSender struct {
+	mu      sync.Mutex
+	batches [][]*domain.LogEmitRequest
+	fail    int // fail the first n sends
+	refuse  int // answer every send with this status
+	max     int // answer 413 to batches of more lines
+	sends   int
+	block   chan struct{}
+}
+
+func (r *recordingSender) send(_ context.Context, batch []*domain.LogEmitRequest) error {
+	if r.block != nil {
+		<-r.block
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sends++
+	if r.refuse != 0 {
+		return &statusError{what: "send logs", status: r.refuse}
+	}
+	if r.max > 0 && len(batch) > r.max {
+		return &statusError{what: "send logs", status: http.StatusRequestEntityTooLarge}
+	}
+	if r.fail > 0 {
+		r.fail--
+		return errors.New("server unavailable")
+	}
+	r.batches = append(r.batches, batch)
+	return nil
+}
+
+func (r *recordingSender) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for _, b := range r.batches {
+		for _, l := range b {
+			out = append(out, l.Message)
+		}
+	}
+	return out
+}
+
+func logLine(i int) *domain.LogEmitRequest {
+	return &domain.LogEmitRequest{Message: fmt.Sprintf("line %d", i)}
+}
+
+// A verbose command is shipped in a handful of batches, in order, with every
+// line delivered.
+func TestLogShipperBatchesBySize(t *testing.T) {
+	sender := &recordingSender{}
+	s := newLogShipper(context.Background(), sender.send, nopLogger{})
+
+	const n = 2*logBatchMaxLines + 10
+	for i := range n {
+		s.Ship(logLine(i))
+	}
+	s.Close()
+
+	lines := sender.lines()
+	if len(lines) != n {
+		t.Fatalf("delivered %d lines, want %d", len(lines), n)
+	}
+	for i, l := range lines {
+		if l != fmt.Sprintf("line %d", i) {
+			t.Fatalf("line %d out of order: %q", i, l)
+		}
+	}
+	if len(sender.batches) > 3 {
+		t.Fatalf("sent %d batches for %d lines", len(sender.batches), n)
+	}
+}
+
+// A quiet job still sees its lines within the flush interval, without
+// waiting for the batch to fill or the job to end.
+func TestLogShipperFlushesOnInterval(t *testing.T) {
+	sender := &recordingSender{}
+	s := newLogShipper(context.Background(), sender.send, nopLogger{})
+	defer s.Close()
+
+	s.Ship(logLine(0))
+
+	deadline := time.Now().Add(10 * logFlushInterval)
+	for len(sender.lines()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("line not flushed within the flush interval")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// While a batch is stuck in flight the queue fills and Ship blocks instead of
+// dropping lines.
+func TestLogShipperAppliesBackPressure(t *testing.T) {
+	sender := &recordingSender{block: make(chan struct{})}
+	s := newLogShipper(context.Background(), sender.send, nopLogger{})
+
+	shipped := make(chan struct{})
+	go func() {
+		defer close(shipped)
+		for i := range logBatchMaxLines + logQueueSize + 1 {
+			s.Ship(logLine(i))
+		}
+	}()
+
+	select {
+	case <-shipped:
+		t.Fatal("Ship never blocked while the sender was stuck")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(sender.block)
+	<-shipped
+	s.Close()
+
+	if got, want := len(sender.lines()), logBatchMaxLines+logQueueSize+1; got != want {
+		t.Fatalf("delivered %d lines, want %d", got, want)
+	}
+}
+
+func TestLogShipperRetriesFailedBatch(t *testing.T) {
+	sender := &recordingSender{fail: 1}
+	s := newLogShipper(context.Background(), sender.send, nopLogger{})
+
+	s.Ship(logLine(0))
+	s.Close()
+
+	if got := sender.lines(); len(got) != 1 {
+		t.Fatalf("expected the batch to be retried, got %v", got)
+	}
+}
+
+// A batch the server finds too large is sent again in halves until the parts
+// fit, every line still delivered in order.
+func TestLogShipperSplitsBatchTooLarge(t *testing.T) {
+	sender := &recordingSender{max: 30}
+	s := newLogShipper(context.Background(), sender.send, nopLogger{})
+
+	const n = 100
+	for i := range n {
+		s.Ship(logLine(i))
+	}
+	s.Close()
+
+	lines := sender.lines()
+	if len(lines) != n {
+		t.Fatalf("delivered %d lines, want %d", len(lines), n)
+	}
+	for i, l := range lines {
+		if l != fmt.Sprintf("line %d", i) {
+			t.Fatalf("line %d out of order: %q", i, l)
+		}
+	}
+}
+
+// A batch the server refuses is dropped after one attempt: sending it again
+// cannot succeed and would only hold up the lines behind it.
+func TestLogShipperDropsRefusedBatch(t *testing.T) {
+	sender := &recordingSender{refuse: http.StatusBadRequest}
+	s := newLogShipper(context.Background(), sender.send, nopLogger{})
+
+	s.Ship(logLine(0))
+	s.Close()
+
+	if sender.sends != 1 {
+		t.Fatalf("sent the refused batch %d times, want once", sender.sends)
+	}
+}
@@ -0,0 +1,437 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Record kinds. Each maps to one agent endpoint (see JobWorker.deliver).
+	spoolLogs         = "logs"
+	spoolMetrics      = "metrics"
+	spoolJobFinish    = "job_finish"
+	spoolCommitInfo   = "commit_info"
+	spoolAutoRollback = "auto_rollback"
+
+	// spoolMaxBytes caps the journal during a long outage. Past it, logs and
+	// metrics are dropped; job results and deployment reports are always
+	// kept, since losing them is what the spool exists to prevent.
+	spoolMaxBytes = 256 << 20
+
+	// A failed delivery is retried spoolRetryBackoff later, doubling up to
+	// spoolMaxBackoff, for as long as it takes. Each attempt is bounded by
+	// spoolSendTimeout.
+	spoolRetryBackoff = time.Second
+	spoolMaxBackoff   = time.Minute
+	spoolSendTimeout  = time.Minute
+
+	spoolIDFile      = "spool.id"
+	spoolJournalFile = "journal.ndjson"
+	spoolAckedFile   = "acked"
+)
+
+var errSpoolFull = errors.New("spool is full")
+
+type spoolRecord struct {
+	Seq  int64           `json:"seq"`
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+type spoolJobFinishRecord struct {
+	JobID     int64            `json:"job_id"`
+	Status    domain.JobStatus `json:"status"`
+	StartedAt *time.Time       `json:"started_at,omitempty"`
+}
+
+type spoolCommitInfoRecord struct {
+	DeploymentID int64  `json:"deployment_id"`
+	Hash         string `json:"hash"`
+	Message      string `json:"message"`
+	ImageDigest  string `json:"image_digest"`
+}
+
+type spoolAutoRollbackRecord struct {
+	DeploymentID int64                                `json:"deployment_id"`
+	Request      domain.DeploymentAutoRollbackRequest `json:"request"`
+}
+
+// spoolDeliverFunc sends one record to the server. An error wrapping
+// errSpoolReject drops the record; any other error is retried.
+type spoolDeliverFunc func(ctx context.Context, id uuid.UUID, rec spoolRecord) error
+
+var errSpoolReject = errors.New("record rejected")
+
+// Spool is an append-only, fsynced journal of the reports the agent owes the
+// server. Every report is appended first and sent by a single replayer in
+// journal order, so nothing is lost or reordered while the server is down,
+// and nothing changes while it is up but a write to disk. Records are
+// numbered; the replayer persists the last one the server accepted, and the
+// server ignores a number it has already applied, so a record is applied once
+// even if the agent dies between sending it and saving the ack.
+type Spool struct {
+	dir string
+	id  uuid.UUID
+	log logger.Logger
+
+	mu      sync.Mutex
+	journal *os.File
+	size    int64 // journal length
+	readOff int64 // offset of the first record not yet acked
+	seq     int64 // last sequence number appended
+	acked   int64 // last sequence number accepted by the server
+
+	// notify wakes the replayer after an append; ackedCh is closed and
+	// replaced on every ack to wake WaitAcked.
+	notify  chan struct{}
+	ackedCh chan struct{}
+}
+
+// OpenSpool opens the spool in dir, creating it on first use. A record torn
+// by a crash mid-append is cut off; every complete record is kept.
+func OpenSpool(dir string, log logger.Logger) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	s := &Spool{
+		dir:     dir,
+		log:     log,
+		notify:  make(chan struct{}, 1),
+		ackedCh: make(chan struct{}),
+	}
+
+	id, err := s.loadID()
+	if err != nil {
+		return nil, err
+	}
+	s.id = id
+
+	acked, ackOK := s.loadAcked()
+	s.acked, s.seq = acked, acked
+
+	journal, err := os.OpenFile(filepath.Join(dir, spoolJournalFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool journal: %w", err)
+	}
+	s.journal = journal
+
+	if err := s.recover(); err != nil {
+		journal.Close()
+		return nil, err
+	}
+
+	// Without the ack and with an empty journal, numbering would restart
+	// below what the server has applied and it would drop the new records as
+	// replays: carry on as a new spool instead.
+	if !ackOK && s.seq == 0 {
+		if s.id, err = s.newID(); err != nil {
+			journal.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Spool) loadID() (uuid.UUID, error) {
+	path := filepath.Join(s.dir, spoolIDFile)
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return uuid.Parse(strings.TrimSpace(string(data)))
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return uuid.Nil, fmt.Errorf("failed to read spool id: %w", err)
+	}
+
+	return s.newID()
+}
+
+func (s *Spool) newID() (uuid.UUID, error) {
+	id := uuid.New()
+	if err := writeFileAtomic(filepath.Join(s.dir, spoolIDFile), []byte(id.String())); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to write spool id: %w", err)
+	}
+	return id, nil
+}
+
+// loadAcked returns the last acked sequence number. An unreadable ack file
+// is not fatal: the journal is replayed from its start and the server skips
+// the records it already applied. ok is false then.
+func (s *Spool) loadAcked() (acked int64, ok bool) {
+	data, err := os.ReadFile(filepath.Join(s.dir, spoolAckedFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, true
+	}
+	if err == nil {
+		acked, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	}
+	if err != nil {
+		s.log.Warn("spool ack unreadable, replaying the whole journal", "error", err)
+		return 0, false
+	}
+	return acked, true
+}
+
+// recover scans the journal for the last sequence number and truncates a
+// trailing partial record.
+func (s *Spool) recover() error {
+	r := bufio.NewReader(io.NewSectionReader(s.journal, 0, 1<<62))
+
+	var off int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read spool journal: %w", err)
+		}
+
+		var rec spoolRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			break
+		}
+		off += int64(len(line))
+		s.seq = max(s.seq, rec.Seq)
+	}
+
+	if err := s.journal.Truncate(off); err != nil {
+		return fmt.Errorf("failed to truncate spool journal: %w", err)
+	}
+	s.size = off
+
+	if pending := s.seq - s.acked; pending > 0 {
+		s.log.Info("spool has reports to replay", "records", pending)
+	}
+	return nil
+}
+
+// Append adds a record and returns its sequence number once it is on disk.
+func (s *Spool) Append(kind string, v any) (int64, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if (kind == spoolLogs || kind == spoolMetrics) && s.size >= spoolMaxBytes {
+		return 0, errSpoolFull
+	}
+
+	line, err := json.Marshal(spoolRecord{Seq: s.seq + 1, Kind: kind, Data: data})
+	if err != nil {
+		return 0, err
+	}
+	line = append(line, '\n')
+
+	_, err = s.journal.Write(line)
+	if err == nil {
+		err = s.journal.Sync()
+	}
+	if err != nil {
+		// Drop whatever part of the line made it, so the next record does
+		// not land on a torn one.
+		_ = s.journal.Truncate(s.size)
+		return 0, fmt.Errorf("failed to append to spool: %w", err)
+	}
+
+	s.seq++
+	s.size += int64(len(line))
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	return s.seq, nil
+}
+
+// WaitAcked blocks until the server has accepted record seq or ctx is done.
+func (s *Spool) WaitAcked(ctx context.Context, seq int64) error {
+	for {
+		s.mu.Lock()
+		acked, ch := s.acked, s.ackedCh
+		s.mu.Unlock()
+
+		if acked >= seq {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+}
+
+// Run replays the journal in order until ctx is done. A record is retried
+// until deliver succeeds or rejects it; nothing behind it is sent meanwhile.
+func (s *Spool) Run(ctx context.Context, deliver spoolDeliverFunc) {
+	backoff := spoolRetryBackoff
+
+	for {
+		rec, next, err := s.peek()
+		if err != nil {
+			s.log.Error("failed to read spool", "error", err)
+		}
+
+		if rec == nil {
+			s.compact()
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.notify:
+			}
+			continue
+		}
+
+		err = deliver(ctx, s.id, *rec)
+		switch {
+		case err == nil:
+			backoff = spoolRetryBackoff
+		case errors.Is(err, errSpoolReject):
+			s.log.Error("server rejected spooled report, dropping it", "kind", rec.Kind, "seq", rec.Seq, "error", err)
+		default:
+			s.log.Warn("failed to deliver spooled report, retrying", "kind", rec.Kind, "seq", rec.Seq, "retry_in", backoff.String(), "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, spoolMaxBackoff)
+			continue
+		}
+
+		if err := s.ack(rec.Seq, next); err != nil {
+			s.log.Error("failed to save spool ack", "seq", rec.Seq, "error", err)
+		}
+	}
+}
+
+// peek returns the first record not yet acked and the offset just past it,
+// or nil when the replayer has caught up.
+func (s *Spool) peek() (*spoolRecord, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := bufio.NewReader(io.NewSectionReader(s.journal, s.readOff, s.size-s.readOff))
+	off := s.readOff
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			s.readOff = off
+			return nil, off, nil
+		}
+		if err != nil {
+			return nil, off, err
+		}
+		off += int64(len(line))
+
+		var rec spoolRecord
+		if err := json.Unmarshal(bytes.TrimSpace(line), &rec); err != nil {
+			s.log.Error("skipping unreadable spool record", "offset", s.readOff, "error", err)
+			s.readOff = off
+			continue
+		}
+		// Records acked before a restart are still in the journal until the
+		// next compaction.
+		if rec.Seq <= s.acked {
+			s.readOff = off
+			continue
+		}
+		return &rec, off, nil
+	}
+}
+
+func (s *Spool) ack(seq, next int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.acked = seq
+	s.readOff = next
+	close(s.ackedCh)
+	s.ackedCh = make(chan struct{})
+
+	return writeFileAtomic(filepath.Join(s.dir, spoolAckedFile), []byte(strconv.FormatInt(seq, 10)))
+}
+
+// compact empties the journal once every record in it is acked. Sequence
+// numbers carry on from the saved ack.
+func (s *Spool) compact() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size == 0 || s.readOff < s.size || s.acked < s.seq {
+		return
+	}
+	if err := s.journal.Truncate(0); err != nil {
+		s.log.Error("failed to compact spool", "error", err)
+		return
+	}
+	s.size, s.readOff = 0, 0
+}
+
+// Close closes the journal. Records not yet delivered are replayed by the
+// next agent run.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.journal.Close()
+}
+
+// writeFileAtomic replaces path with data so that a crash leaves either the
+// old or the new contents: the temp file is synced before the rename and the
+// directory after it.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
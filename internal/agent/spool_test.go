@@ -0,0 +1,327 @@
+package agent
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"horizonx/internal/config"
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type recordingDeliverer struct {
+	mu   sync.Mutex
+	seqs []int64
+	fail int // fail the first n deliveries
+}
+
+func (r *recordingDeliverer) deliver(_ context.Context, _ uuid.UUID, rec spoolRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail > 0 {
+		r.fail--
+		return errors.New("server unavailable")
+	}
+	r.seqs = append(r.seqs, rec.Seq)
+	return nil
+}
+
+func (r *recordingDeliverer) delivered() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.seqs...)
+}
+
+func appendN(t *testing.T, s *Spool, n int) int64 {
+	t.Helper()
+	var seq int64
+	for i := range n {
+		var err error
+		if seq, err = s.Append(spoolJobFinish, spoolJobFinishRecord{JobID: int64(i + 1), Status: domain.JobSuccess}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return seq
+}
+
+func runSpool(t *testing.T, s *Spool, d spoolDeliverFunc) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx, d)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func waitAcked(t *testing.T, s *Spool, seq int64) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.WaitAcked(ctx, seq); err != nil {
+		t.Fatalf("record %d never acked", seq)
+	}
+}
+
+func TestSpoolReplaysInOrderAfterOutage(t *testing.T) {
+	s, err := OpenSpool(t.TempDir(), nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	last := appendN(t, s, 3)
+
+	d := &recordingDeliverer{fail: 1}
+	runSpool(t, s, d.deliver)
+	waitAcked(t, s, last)
+
+	if got := fmt.Sprint(d.delivered()); got != "[1 2 3]" {
+		t.Fatalf("delivered %s, want [1 2 3]", got)
+	}
+}
+
+// Records acked before a restart are not sent again; the rest are, and new
+// records keep counting from where the spool left off.
+func TestSpoolResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := OpenSpool(dir, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendN(t, s, 2)
+	rec, next, err := s.peek()
+	if err != nil || rec == nil {
+		t.Fatalf("peek = %v, %v", rec, err)
+	}
+	if err := s.ack(rec.Seq, next); err != nil {
+		t.Fatal(err)
+	}
+	appendN(t, s, 1)
+	s.Close()
+
+	// A crash mid-append leaves a torn record behind.
+	f, err := os.OpenFile(filepath.Join(dir, spoolJournalFile), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":4,"kind":"job_fin`)
+	f.Close()
+
+	s, err = OpenSpool(dir, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	last := appendN(t, s, 1)
+	if last != 4 {
+		t.Fatalf("next seq = %d, want 4", last)
+	}
+
+	d := &recordingDeliverer{}
+	runSpool(t, s, d.deliver)
+	waitAcked(t, s, last)
+
+	if got := fmt.Sprint(d.delivered()); got != "[2 3 4]" {
+		t.Fatalf("delivered %s, want [2 3 4]", got)
+	}
+}
+
+// A crash can leave the ack file empty: the agent still starts and replays
+// the journal, leaving the server to skip what it already applied.
+func TestSpoolReplaysJournalWhenAckUnreadable(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := OpenSpool(dir, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := s.id
+	appendN(t, s, 2)
+	s.Close()
+
+	if err := os.WriteFile(filepath.Join(dir, spoolAckedFile), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenSpool(dir, nopLogger{})
+	if err != nil {
+		t.Fatalf("spool did not open with an empty ack file: %v", err)
+	}
+	defer s.Close()
+	if s.id != id {
+		t.Fatal("spool id changed although the journal carries the numbering on")
+	}
+
+	d := &recordingDeliverer{}
+	runSpool(t, s, d.deliver)
+	waitAcked(t, s, 2)
+
+	if got := fmt.Sprint(d.delivered()); got != "[1 2]" {
+		t.Fatalf("delivered %s, want [1 2]", got)
+	}
+}
+
+// With the journal compacted nothing says where numbering left off, so the
+// spool carries on under a new id rather than reuse sequence numbers the
+// server has already seen.
+func TestSpoolStartsOverWhenAckAndJournalAreGone(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := OpenSpool(dir, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := s.id
+	s.Close()
+
+	if err := os.WriteFile(filepath.Join(dir, spoolAckedFile), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenSpool(dir, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.id == id {
+		t.Fatal("spool kept its id with its numbering lost")
+	}
+}
+
+func TestSpoolDropsRejectedRecord(t *testing.T) {
+	s, err := OpenSpool(t.TempDir(), nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	last := appendN(t, s, 2)
+
+	var (
+		mu   sync.Mutex
+		seqs []int64
+	)
+	runSpool(t, s, func(_ context.Context, _ uuid.UUID, rec spoolRecord) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seqs = append(seqs, rec.Seq)
+		if rec.Seq == 1 {
+			return fmt.Errorf("%w: job not found", errSpoolReject)
+		}
+		return nil
+	})
+	waitAcked(t, s, last)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(seqs) != "[1 2]" {
+		t.Fatalf("delivered %v, want [1 2]", seqs)
+	}
+}
+
+func TestDeliverTagsRequestWithSpoolRecord(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusAlreadyReported)
+	}))
+	defer srv.Close()
+
+	w := &JobWorker{httpClient: NewHttpClient(&config.Config{AgentTargetAPIURL: srv.URL})}
+
+	id := uuid.New()
+	data, _ := json.Marshal(spoolJobFinishRecord{JobID: 7, Status: domain.JobSuccess})
+	if err := w.deliver(context.Background(), id, spoolRecord{Seq: 12, Kind: spoolJobFinish, Data: data}); err != nil {
+		t.Fatalf("a duplicate must count as delivered: %v", err)
+	}
+
+	if got.Get(domain.AgentSpoolIDHeader) != id.String() || got.Get(domain.AgentSpoolSeqHeader) != "12" {
+		t.Fatalf("spool headers = %q, %q", got.Get(domain.AgentSpoolIDHeader), got.Get(domain.AgentSpoolSeqHeader))
+	}
+}
+
+// A spooled batch the server finds too large goes in parts. None carries the
+// record: the server would skip every part after the first as a replay.
+func TestDeliverSplitsLogBatchTooLarge(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var batch []string
+		dec := json.NewDecoder(gz)
+		for dec.More() {
+			var req domain.LogEmitRequest
+			if err := dec.Decode(&req); err != nil {
+				t.Error(err)
+				return
+			}
+			batch = append(batch, req.Message)
+		}
+		if len(batch) > 2 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if r.Header.Get(domain.AgentSpoolSeqHeader) != "" {
+			t.Errorf("part %v sent under the spool record", batch)
+		}
+
+		mu.Lock()
+		lines = append(lines, batch...)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	w := &JobWorker{httpClient: NewHttpClient(&config.Config{AgentTargetAPIURL: srv.URL})}
+
+	batch := []*domain.LogEmitRequest{logLine(0), logLine(1), logLine(2), logLine(3), logLine(4)}
+	data, _ := json.Marshal(batch)
+	if err := w.deliver(context.Background(), uuid.New(), spoolRecord{Seq: 3, Kind: spoolLogs, Data: data}); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := fmt.Sprint(lines); got != "[line 0 line 1 line 2 line 3 line 4]" {
+		t.Fatalf("delivered %s", got)
+	}
+}
+
+func TestDeliverRejectsRecordTheServerRefuses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	w := &JobWorker{httpClient: NewHttpClient(&config.Config{AgentTargetAPIURL: srv.URL})}
+
+	data, _ := json.Marshal(spoolJobFinishRecord{JobID: 7, Status: domain.JobSuccess})
+	err := w.deliver(context.Background(), uuid.New(), spoolRecord{Seq: 1, Kind: spoolJobFinish, Data: data})
+	if !errors.Is(err, errSpoolReject) {
+		t.Fatalf("err = %v, want a rejection", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"golang.org/x/sync/errgroup"
@@ -16,6 +17,7 @@ import (
 	"horizonx/internal/agent"
 	"horizonx/internal/agent/executor"
 	"horizonx/internal/config"
+	"horizonx/internal/domain"
 	"horizonx/internal/logger"
 	"horizonx/internal/metrics"
 	"horizonx/internal/version"
@@ -40,19 +42,6 @@ func RunAgent() error {
 	runtimeCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	redisClient, err := redis.Init(ctx, &redis.ClientOptions{
-		Address:  cfg.RedisAddress,
-		Username: cfg.RedisUsername,
-		Password: cfg.RedisPassword,
-		DB:       cfg.RedisDB,
-	})
-	if err != nil {
-		appLog.Error("failed to init redis", "error", err)
-	} else {
-		appLog.Info("redis connected")
-	}
-	defer redisClient.Close()
-
 	// Apps work dir is ALWAYS the agent's current working directory (Maul,
 	// 2026-08-04): no hardcoded /var/lib/horizonx/apps, no AppEnv condition.
 	// In production the systemd unit sets WorkingDirectory=/var/lib/horizonx/apps,
@@ -64,12 +53,46 @@ func RunAgent() error {
 	}
 	appsWorkDir := cwd
 
-	registry := redis.NewRegistry(redisClient)
+	// Reports the server could not take yet wait here, next to the apps,
+	// and are replayed on the next start if the agent stops first.
+	spool, err := agent.OpenSpool(filepath.Join(appsWorkDir, ".horizonx-spool"), appLog)
+	if err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	defer spool.Close()
+
+	// Recent metrics live in a file next to the apps unless the host opts
+	// into Redis: an app host needs nothing but docker and git.
+	var metricsBuffer domain.MetricsBuffer
+	switch cfg.AgentMetricsBuffer {
+	case "redis":
+		redisClient, err := redis.Init(ctx, &redis.ClientOptions{
+			Address:  cfg.RedisAddress,
+			Username: cfg.RedisUsername,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		if err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+		defer redisClient.Close()
+		appLog.Info("redis connected")
+
+		metricsBuffer = redis.NewMetricsBuffer(redis.NewRegistry(redisClient), cfg.AgentServerID)
+	case "file":
+		metricsBuffer, err = metrics.NewFileBuffer(filepath.Join(appsWorkDir, ".horizonx-metrics", "samples.ndjson"), metrics.DefaultFileBufferSize)
+		if err != nil {
+			return fmt.Errorf("metrics buffer: %w", err)
+		}
+	default:
+		return fmt.Errorf("AGENT_METRICS_BUFFER must be file or redis, got %q", cfg.AgentMetricsBuffer)
+	}
+
 	httpClient := agent.NewHttpClient(cfg)
-	collector := metrics.NewCollector(cfg, appLog, registry)
+	collector := metrics.NewCollector(cfg, appLog, metricsBuffer, appsWorkDir)
 	exec := executor.NewExecutor(appsWorkDir, appLog, collector.Latest)
-	worker := agent.NewJobWorker(cfg, appLog, *httpClient, *exec)
-	conn := agent.NewAgent(cfg, appLog)
+	worker := agent.NewJobWorker(cfg, appLog, *httpClient, *exec, spool)
+	conn := agent.NewAgent(cfg, appLog, worker, exec)
 
 	if err := exec.Init(); err != nil {
 		return fmt.Errorf("executor init: %w", err)
@@ -4,10 +4,11 @@ import (
 	"embed"
 )
 
-//go:embed templates/*.service
+//go:embed templates/*.service templates/*.path
 var systemdFS embed.FS
 
-// systemdUnit returns the named unit template (e.g. "horizonx-server.service").
+// systemdUnit returns the named unit template (e.g. "horizonx-server.service"
+// or "horizonx-agent-upgrade.path").
 func systemdUnit(name string) ([]byte, error) {
 	return systemdFS.ReadFile("templates/" + name)
 }
@@ -22,7 +22,6 @@ type AgentProvision struct {
 	WSURL       string // HORIZONX_WS_URL
 	ServerID    string
 	ServerToken string
-	RedisAddr   string
 
 	// User + paths (defaults: horizonx / /var/lib/horizonx / /etc/horizonx).
 	UserName  string
@@ -329,8 +328,7 @@ func (p *AgentProvision) writeEnvFile() error {
 HORIZONX_WS_URL=%s
 HORIZONX_SERVER_ID=%s
 HORIZONX_SERVER_API_TOKEN=%s
-REDIS_ADDR=%s
-`, p.APIURL, p.WSURL, p.ServerID, p.ServerToken, p.RedisAddr)
+`, p.APIURL, p.WSURL, p.ServerID, p.ServerToken)
 	tmp := filepath.Join(os.TempDir(), "horizonx-agent.env")
 	if err := os.WriteFile(tmp, []byte(env), 0o600); err != nil {
 		return err
@@ -344,25 +342,38 @@ REDIS_ADDR=%s
 	return sudo("chmod", "600", dest)
 }
 
+// agentUnits are the units `install agent` installs: the agent itself and
+// the root helper that upgrades it on request (agent_upgrade jobs).
+var agentUnits = []string{
+	"horizonx-agent.service",
+	"horizonx-agent-upgrade.service",
+	"horizonx-agent-upgrade.path",
+}
+
 func (p *AgentProvision) installSystemdUnit() error {
-	data, err := systemdUnit("horizonx-agent.service")
-	if err != nil {
-		return err
-	}
-	tmp := filepath.Join(os.TempDir(), "horizonx-agent.service")
-	if err := os.WriteFile(tmp, data, 0o644); err != nil {
-		return err
-	}
-	dest := filepath.Join("/etc/systemd/system", "horizonx-agent.service")
-	if err := sudo("cp", tmp, dest); err != nil {
-		return err
+	for _, name := range agentUnits {
+		data, err := systemdUnit(name)
+		if err != nil {
+			return err
+		}
+		tmp := filepath.Join(os.TempDir(), name)
+		if err := os.WriteFile(tmp, data, 0o644); err != nil {
+			return err
+		}
+		dest := filepath.Join("/etc/systemd/system", name)
+		if err := sudo("cp", tmp, dest); err != nil {
+			return err
+		}
+		_ = os.Remove(tmp)
 	}
-	_ = os.Remove(tmp)
 	return nil
 }
 
 func (p *AgentProvision) enableService() error {
 	_ = sudo("systemctl", "daemon-reload")
+	if err := sudo("systemctl", "enable", "--now", "horizonx-agent-upgrade.path"); err != nil {
+		return err
+	}
 	return sudo("systemctl", "enable", "--now", "horizonx-agent")
 }
 
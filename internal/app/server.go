@@ -21,16 +21,26 @@ import (
 	"horizonx/internal/adapters/redis"
 	"horizonx/internal/adapters/webhook"
 	"horizonx/internal/adapters/ws/agentws"
+	"horizonx/internal/adapters/ws/execws"
 	"horizonx/internal/adapters/ws/userws"
 	"horizonx/internal/adapters/ws/userws/subscribers"
 	"horizonx/internal/application/account"
+	"horizonx/internal/application/agentspool"
+	"horizonx/internal/application/agentupgrade"
 	"horizonx/internal/application/application"
 	"horizonx/internal/application/auditlog"
 	"horizonx/internal/application/auth"
+	"horizonx/internal/application/buildvariable"
+	"horizonx/internal/application/containerlogs"
 	"horizonx/internal/application/deployment"
+	"horizonx/internal/application/execsession"
+	"horizonx/internal/application/gitcredential"
+	"horizonx/internal/application/gitwebhook"
 	"horizonx/internal/application/job"
 	logSvc "horizonx/internal/application/log"
 	"horizonx/internal/application/metrics"
+	"horizonx/internal/application/preview"
+	"horizonx/internal/application/registrycredential"
 	"horizonx/internal/application/role"
 	"horizonx/internal/application/server"
 	"horizonx/internal/application/user"
@@ -108,6 +118,7 @@ func RunServer() error {
 	// Repositories
 	logRepo := postgres.NewLogRepository(dbPool)
 	serverRepo := postgres.NewServerRepository(dbPool)
+	agentSpoolRepo := postgres.NewAgentSpoolRepository(dbPool)
 	roleRepo := postgres.NewRoleRepository(dbPool)
 	userRepo := postgres.NewUserRepository(dbPool)
 	jobRepo := postgres.NewJobRepository(dbPool)
@@ -118,20 +129,42 @@ func RunServer() error {
 	deploymentRepo := postgres.NewDeploymentRepository(dbPool)
 	auditLogRepo := postgres.NewAuditLogRepository(dbPool)
 	settingsRepo := postgres.NewSettingsRepository(dbPool)
+	gitWebhookRepo := postgres.NewGitWebhookRepository(dbPool, security.KeyFromSecret(cfg.JWTSecret))
+	gitCredentialRepo := postgres.NewGitCredentialRepository(dbPool, security.KeyFromSecret(cfg.JWTSecret))
+	registryCredentialRepo := postgres.NewRegistryCredentialRepository(dbPool, security.KeyFromSecret(cfg.JWTSecret))
+	buildVariableRepo := postgres.NewBuildVariableRepository(dbPool, security.KeyFromSecret(cfg.JWTSecret))
+
+	// The agent router is created ahead of the services: JobService pushes
+	// new jobs to connected agents through it.
+	wsAgentRouter := agentws.NewRouter(runtimeCtx, log)
 
 	// Services
 	logService := logSvc.NewService(logRepo, bus)
 	serverService := server.NewService(serverRepo, bus)
+	agentSpoolService := agentspool.NewService(agentSpoolRepo)
 	sessionStore := redis.NewSessionStore(redisClient)
 	authService := auth.NewService(userRepo, sessionStore, cfg.JWTSecret, cfg.JWTExpiry)
 	roleService := role.NewService(roleRepo)
 	accountService := account.NewService(userRepo, sessionStore)
 	userService := user.NewService(userRepo)
-	jobService := job.NewService(jobRepo, logService, bus)
-	metricsService := metrics.NewService(metricsRepo, redisRegistry, bus, log)
+	jobService := job.NewService(jobRepo, serverRepo, logService, wsAgentRouter, bus, security.KeyFromSecret(cfg.JWTSecret))
+	metricsRetention := domain.MetricsRetention{
+		RawDays:    cfg.MetricsRawRetentionDays,
+		HourlyDays: cfg.MetricsHourlyRetentionDays,
+		DailyDays:  cfg.MetricsDailyRetentionDays,
+	}
+	metricsService := metrics.NewService(metricsRepo, applicationRepo, redisRegistry, bus, log, metricsRetention)
 	deploymentService := deployment.NewService(deploymentRepo, logService, bus)
-	applicationService := application.NewService(applicationRepo, serverService, jobService, deploymentService, bus)
+	applicationService := application.NewService(applicationRepo, serverService, jobService, deploymentService, gitCredentialRepo, registryCredentialRepo, buildVariableRepo, bus)
 	auditLogService := auditlog.NewService(auditLogRepo)
+	previewService := preview.NewService(applicationRepo, applicationService)
+	gitWebhookService := gitwebhook.NewService(gitWebhookRepo, applicationService, previewService)
+	gitCredentialService := gitcredential.NewService(gitCredentialRepo, applicationService)
+	registryCredentialService := registrycredential.NewService(registryCredentialRepo, applicationService)
+	buildVariableService := buildvariable.NewService(buildVariableRepo, applicationService)
+	agentUpgradeService := agentupgrade.NewService(serverService, jobService)
+	containerLogService := containerlogs.NewService(applicationService, serverService, wsAgentRouter, bus, log)
+	execService := execsession.NewService(applicationService, serverService, wsAgentRouter, bus, log, cfg.ExecIdleTimeout)
 
 	// Auto-seed the admin user (Laravel-style seeding, like auto-migrate).
 	// The .env (ADMIN_EMAIL / ADMIN_PASSWORD) seeds the admin on FIRST boot.
@@ -167,12 +200,21 @@ func RunServer() error {
 	}
 
 	// Event Listeners
-	applicationListener := application.NewListener(applicationService, log)
+	applicationListener := application.NewListener(applicationService, deploymentService, log)
 	applicationListener.Register(bus)
 
 	deploymentListener := deployment.NewListener(deploymentService, log)
 	deploymentListener.Register(bus)
 
+	agentUpgradeListener := agentupgrade.NewListener(agentUpgradeService, log)
+	agentUpgradeListener.Register(bus)
+
+	containerLogsListener := containerlogs.NewListener(containerLogService, log)
+	containerLogsListener.Register(bus)
+
+	execListener := execsession.NewListener(execService, log)
+	execListener.Register(bus)
+
 	// P2-14: Prometheus registry (request counters + job queue gauges).
 	metricsRegistry := httpmetrics.NewRegistry(jobRepo, serverRepo, log)
 
@@ -203,6 +245,8 @@ func RunServer() error {
 
 	logHandler := http.NewLogHandler(logService, jsonDecoder, jsonWriter, validator)
 	serverHandler := http.NewServerHandler(serverService, jsonDecoder, jsonWriter, validator)
+	agentUpgradeHandler := http.NewAgentUpgradeHandler(agentUpgradeService, jsonDecoder, jsonWriter, validator)
+	containerLogsHandler := http.NewContainerLogsHandler(containerLogService, jsonDecoder, jsonWriter, validator)
 	authHandler := http.NewAuthHandler(authService, cfg, jsonDecoder, jsonWriter, validator)
 	accountHandler := http.NewAccountHandler(accountService, jsonDecoder, jsonWriter, validator)
 	userHandler := http.NewUserHandler(userService, authService, jsonDecoder, jsonWriter, validator)
@@ -212,13 +256,20 @@ func RunServer() error {
 	applicationHandler := http.NewApplicationHandler(applicationService, jsonDecoder, jsonWriter, validator)
 	auditLogHandler := http.NewAuditLogHandler(auditLogService, jsonDecoder, jsonWriter, validator)
 	settingsHandler := http.NewSettingsHandler(settingsRepo, notifier, jsonDecoder, jsonWriter, validator)
+	gitWebhookHandler := http.NewGitWebhookHandler(gitWebhookService, jsonDecoder, jsonWriter, validator)
+	previewHandler := http.NewPreviewHandler(previewService, jsonDecoder, jsonWriter, validator)
+	gitCredentialHandler := http.NewGitCredentialHandler(gitCredentialService, jsonDecoder, jsonWriter, validator)
+	registryCredentialHandler := http.NewRegistryCredentialHandler(registryCredentialService, jsonDecoder, jsonWriter, validator)
+	buildVariableHandler := http.NewBuildVariableHandler(buildVariableService, jsonDecoder, jsonWriter, validator)
 
 	// WebSocket Handlers
 	wsUserhub := userws.NewHub(runtimeCtx, log)
-	wsUserHandler := userws.NewHandler(wsUserhub, log, cfg.JWTSecret, cfg.AllowedOrigins)
+	// Container log streams run only while their channel has subscribers.
+	wsUserhub.OnChannel(containerLogService.ChannelActive)
+	wsUserHandler := userws.NewHandler(wsUserhub, log, roleService, cfg.JWTSecret, cfg.AllowedOrigins)
 
-	wsAgentRouter := agentws.NewRouter(runtimeCtx, log)
-	wsAgentHandler := agentws.NewHandler(wsAgentRouter, log, serverService)
+	wsAgentHandler := agentws.NewHandler(wsAgentRouter, log, serverService, containerLogService, execService)
+	wsExecHandler := execws.NewHandler(execService, log, cfg.AllowedOrigins)
 
 	go wsUserhub.Run()
 	go wsAgentRouter.Run()
@@ -229,6 +280,7 @@ func RunServer() error {
 	router := http.NewRouter(cfg, &http.RouterDeps{
 		WsUser:  wsUserHandler,
 		WsAgent: wsAgentHandler,
+		WsExec:  wsExecHandler,
 
 		Auth:        authHandler,
 		Account:     accountHandler,
@@ -241,11 +293,20 @@ func RunServer() error {
 		Deployment:  deploymentHandler,
 		AuditLog:    auditLogHandler,
 		Settings:    settingsHandler,
+		GitWebhook:  gitWebhookHandler,
+		Preview:     previewHandler,
+
+		GitCredential:      gitCredentialHandler,
+		RegistryCredential: registryCredentialHandler,
+		BuildVariable:      buildVariableHandler,
+		AgentUpgrade:       agentUpgradeHandler,
+		ContainerLogs:      containerLogsHandler,
 
 		SessionStore: sessionStore,
 
-		RoleService:   roleService,
-		ServerService: serverService,
+		RoleService:       roleService,
+		ServerService:     serverService,
+		AgentSpoolService: agentSpoolService,
 
 		MetricsRegistry: metricsRegistry,
 		Logger:          log,
@@ -258,7 +319,8 @@ func RunServer() error {
 		Server:      serverService,
 		Metrics:     metricsService,
 		Application: applicationService,
-	})
+		Preview:     previewService,
+	}, metricsRetention)
 	wManager.Start(runtimeCtx)
 
 	// HTTP Server
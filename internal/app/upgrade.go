@@ -6,6 +6,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,12 +18,15 @@ import (
 	"syscall"
 	"time"
 
+	"horizonx/internal/agent/executor"
+	"horizonx/internal/domain"
 	"horizonx/internal/version"
 )
 
 const (
 	githubAPI   = "https://api.github.com/repos/zlnew/horizonx/releases/latest"
 	githubDL    = "https://github.com/zlnew/horizonx/releases/latest/download"
+	githubTagDL = "https://github.com/zlnew/horizonx/releases/download/%s"
 	upgradeTime = 60 * time.Second
 )
 
@@ -30,7 +35,8 @@ const (
 // restart on the box running the tests.
 var restartServiceFn = restartService
 
-// RunUpgrade updates everything HorizonX on this box to the latest release:
+// RunUpgrade updates everything HorizonX on this box to the latest release
+// (or the one pinned with --version):
 //  1. Self-update the CLI binary (checksum-verified swap).
 //  2. Detect components and update each:
 //     - server instance (/opt/horizonx): regenerate tree (keeps .env), rebuild
@@ -60,85 +66,198 @@ var execSelfFn = func(exe string, args, env []string) error {
 // the self-update check and goes straight to the component pass.
 const envComponentsOnly = "HORIZONX_UPGRADE_COMPONENTS_ONLY"
 
-func RunUpgrade() error {
+// UpgradeOptions carries the flags for `horizonx upgrade`.
+type UpgradeOptions struct {
+	Version string // release to install (e.g. v0.4.0); default: the latest
+
+	// AgentRequest is set by the horizonx-agent-upgrade helper unit: upgrade
+	// as the agent_upgrade job at this path asks, then restart the agent.
+	AgentRequest string
+}
+
+func RunUpgrade(opts UpgradeOptions) error {
+	if opts.AgentRequest != "" {
+		return runAgentUpgrade(opts.AgentRequest)
+	}
+
 	// Re-exec'd after a binary swap: the new code is already running, so skip
 	// the update check and go straight to the component pass.
 	if os.Getenv(envComponentsOnly) == "1" {
 		return upgradeComponents()
 	}
 
+	if err := domain.ValidateAgentVersion(opts.Version); err != nil {
+		return err
+	}
+
 	fmt.Println("horizonx " + version.Version + " → checking for updates…")
 
-	rel, err := latestReleaseFn()
+	updated, err := selfUpdate(opts.Version)
 	if err != nil {
 		return err
 	}
+	if updated {
+		return reexecComponents()
+	}
 
-	tag := strings.TrimPrefix(rel.TagName, "v")
-	if tag == version.Version {
-		fmt.Println("already up to date (" + version.Version + ").")
-	} else {
-		exe, err := os.Executable()
+	return upgradeComponents()
+}
+
+// selfUpdate swaps this binary for the pinned release, or the latest one,
+// checksum-verified. It reports false when the binary is already on it.
+func selfUpdate(pinned string) (bool, error) {
+	tag := pinned
+	if tag == "" {
+		rel, err := latestReleaseFn()
 		if err != nil {
-			return fmt.Errorf("resolve executable: %w", err)
+			return false, err
 		}
+		tag = rel.TagName
+	}
 
-		arch := runtime.GOARCH
-		if arch == "amd64" {
-			arch = "x86_64"
-		}
-		asset := fmt.Sprintf("horizonx-%s-%s.tar.gz", runtime.GOOS, arch)
+	if version.Equal(tag, version.Version) {
+		fmt.Println("already up to date (" + version.Version + ").")
+		return false, nil
+	}
+	tag = strings.TrimPrefix(tag, "v")
+
+	exe, err := os.Executable()
+	if err != nil {
+		return false, fmt.Errorf("resolve executable: %w", err)
+	}
 
-		fmt.Printf("downloading %s (%s)…\n", asset, tag)
+	arch := runtime.GOARCH
+	if arch == "amd64" {
+		arch = "x86_64"
+	}
+	asset := fmt.Sprintf("horizonx-%s-%s.tar.gz", runtime.GOOS, arch)
 
-		url := fmt.Sprintf("%s/%s", githubDL, asset)
-		tarball, err := downloadFn(url, asset)
-		if err != nil {
-			return err
-		}
-		defer os.Remove(tarball)
+	fmt.Printf("downloading %s (%s)…\n", asset, tag)
 
-		checksumURL := fmt.Sprintf("%s/SHA256SUMS", githubDL)
-		sums, err := downloadFn(checksumURL, "SHA256SUMS")
-		if err != nil {
-			return err
-		}
-		defer os.Remove(sums)
+	baseURL := githubDL
+	if pinned != "" {
+		baseURL = fmt.Sprintf(githubTagDL, "v"+tag)
+	}
 
-		want, err := checksumFor(sums, asset)
-		if err != nil {
-			return err
-		}
-		if err := verifySHA256(tarball, want); err != nil {
-			return err
-		}
-		fmt.Println("checksum OK.")
+	url := fmt.Sprintf("%s/%s", baseURL, asset)
+	tarball, err := downloadFn(url, asset)
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tarball)
 
-		newBin, err := extractBinary(tarball, "horizonx")
-		if err != nil {
-			return err
-		}
-		defer os.Remove(newBin)
+	checksumURL := fmt.Sprintf("%s/SHA256SUMS", baseURL)
+	sums, err := downloadFn(checksumURL, "SHA256SUMS")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(sums)
 
-		if err := replaceBinaryFn(newBin, exe); err != nil {
-			return err
-		}
-		fmt.Printf("updated to horizonx %s.\n", tag)
-
-		// CRITICAL: the swap replaced the FILE, but THIS process is still
-		// executing the OLD binary's code. Running the component pass here
-		// would use the old detection logic — the exact bug that shipped
-		// v0.3.11: the still-running v0.3.10 process restarted the agent but
-		// never looked in /opt/horizonx, so server + dashboard stayed stale.
-		// Re-exec the new binary so the component pass runs with NEW code.
-		os.Setenv(envComponentsOnly, "1")
-		if err := execSelfFn(exe, os.Args, os.Environ()); err != nil {
-			return fmt.Errorf("re-exec new binary: %w", err)
+	want, err := checksumFor(sums, asset)
+	if err != nil {
+		return false, err
+	}
+	if err := verifySHA256(tarball, want); err != nil {
+		return false, err
+	}
+	fmt.Println("checksum OK.")
+
+	newBin, err := extractBinary(tarball, "horizonx")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(newBin)
+
+	if err := replaceBinaryFn(newBin, exe); err != nil {
+		return false, err
+	}
+	fmt.Printf("updated to horizonx %s.\n", tag)
+
+	return true, nil
+}
+
+// reexecComponents hands the rest of the upgrade to the swapped binary.
+//
+// CRITICAL: the swap replaced the FILE, but THIS process is still executing
+// the OLD binary's code. Running the component pass here would use the old
+// detection logic — the exact bug that shipped v0.3.11: the still-running
+// v0.3.10 process restarted the agent but never looked in /opt/horizonx, so
+// server + dashboard stayed stale. Re-exec the new binary so the component
+// pass runs with NEW code.
+func reexecComponents() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolve executable: %w", err)
+	}
+
+	os.Setenv(envComponentsOnly, "1")
+	if err := execSelfFn(exe, os.Args, os.Environ()); err != nil {
+		return fmt.Errorf("re-exec new binary: %w", err)
+	}
+	return nil // unreachable when execSelfFn is syscall.Exec (image replaced)
+}
+
+// runAgentUpgrade serves an agent_upgrade job. It runs as root from the
+// horizonx-agent-upgrade unit whenever the agent writes a request: swap the
+// binary like a manual upgrade, then restart only the agent, from the new
+// binary. The job is waiting on the agent, so a failure, or a binary that is
+// already on the release, is written back as a result; a restart speaks for
+// itself once the agent reconnects on the new version.
+func runAgentUpgrade(requestPath string) error {
+	req, err := executor.ReadUpgradeRequest(requestPath)
+	if err != nil {
+		return fmt.Errorf("agent upgrade request: %w", err)
+	}
+	dir := filepath.Dir(requestPath)
+
+	if os.Getenv(envComponentsOnly) == "1" {
+		if err := restartServiceFn(agentUnit, false); err != nil {
+			return reportAgentUpgrade(dir, req, err)
 		}
-		return nil // unreachable when execSelfFn is syscall.Exec (image replaced)
+		fmt.Println("✔ agent restarting on horizonx " + version.Version)
+		return nil
 	}
 
-	return upgradeComponents()
+	fmt.Printf("horizonx %s → agent upgrade requested by job %d\n", version.Version, req.JobID)
+
+	updated, err := selfUpdate(req.Version)
+	if err != nil {
+		return reportAgentUpgrade(dir, req, err)
+	}
+	if !updated {
+		return reportAgentUpgrade(dir, req, nil)
+	}
+
+	if err := reexecComponents(); err != nil {
+		return reportAgentUpgrade(dir, req, err)
+	}
+	return nil
+}
+
+// reportAgentUpgrade writes the job's result and returns upgradeErr, so a
+// failed upgrade also fails the helper unit.
+func reportAgentUpgrade(dir string, req executor.UpgradeRequest, upgradeErr error) error {
+	res := executor.UpgradeResult{JobID: req.JobID, Version: version.Version}
+	if upgradeErr != nil {
+		res.Error = upgradeErr.Error()
+	}
+
+	if err := executor.WriteUpgradeResult(dir, res); err != nil {
+		return errors.Join(upgradeErr, fmt.Errorf("write agent upgrade result: %w", err))
+	}
+	return upgradeErr
+}
+
+// UpgradeFlags parses `horizonx upgrade` flags.
+func UpgradeFlags(args []string) (UpgradeOptions, error) {
+	fs := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	var opts UpgradeOptions
+	fs.StringVar(&opts.Version, "version", "", "release to install (e.g. v0.4.0); default: the latest")
+	fs.StringVar(&opts.AgentRequest, "agent-request", "", "agent upgrade request to serve (used by the horizonx-agent-upgrade unit)")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	return opts, nil
 }
 
 // upgradeComponents detects what's installed on this box and updates each
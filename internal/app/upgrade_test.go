@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -11,6 +12,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"horizonx/internal/agent/executor"
 )
 
 func TestExtractBinaryPlainName(t *testing.T) {
@@ -302,7 +305,7 @@ func TestUpgradeReexecsAfterSwap(t *testing.T) {
 	})
 	defer restoreCompose()
 
-	err = RunUpgrade()
+	err = RunUpgrade(UpgradeOptions{})
 	if err != nil {
 		t.Fatalf("RunUpgrade: %v", err)
 	}
@@ -338,7 +341,7 @@ func TestUpgradeComponentsOnlyEnv(t *testing.T) {
 	}
 	defer func() { latestReleaseFn = oldRel }()
 
-	err := RunUpgrade()
+	err := RunUpgrade(UpgradeOptions{})
 	if err != nil {
 		t.Fatalf("RunUpgrade: %v", err)
 	}
@@ -374,7 +377,7 @@ func TestUpgradeInstanceOnly(t *testing.T) {
 	}
 	t.Setenv("HORIZONX_PREFIX", instance)
 
-	err := RunUpgrade()
+	err := RunUpgrade(UpgradeOptions{})
 	if err != nil {
 		t.Fatalf("RunUpgrade: %v", err)
 	}
@@ -408,7 +411,7 @@ func TestUpgradeAgentOnly(t *testing.T) {
 	// No instance anywhere.
 	t.Setenv("HORIZONX_PREFIX", filepath.Join(t.TempDir(), "no-instance"))
 
-	err := RunUpgrade()
+	err := RunUpgrade(UpgradeOptions{})
 	if err != nil {
 		t.Fatalf("RunUpgrade: %v", err)
 	}
@@ -452,7 +455,7 @@ func TestUpgradeBoth(t *testing.T) {
 	}
 	t.Setenv("HORIZONX_PREFIX", instance)
 
-	err := RunUpgrade()
+	err := RunUpgrade(UpgradeOptions{})
 	if err != nil {
 		t.Fatalf("RunUpgrade: %v", err)
 	}
@@ -471,7 +474,7 @@ func TestUpgradeNothingDetected(t *testing.T) {
 	defer restoreNet()
 	t.Setenv("HORIZONX_PREFIX", filepath.Join(t.TempDir(), "no-instance"))
 
-	err := RunUpgrade()
+	err := RunUpgrade(UpgradeOptions{})
 	if err != nil {
 		t.Fatalf("RunUpgrade: %v", err)
 	}
@@ -519,7 +522,7 @@ func TestUpgradeInstanceFailureDoesNotAbort(t *testing.T) {
 	}
 	t.Setenv("HORIZONX_PREFIX", instance)
 
-	err := RunUpgrade()
+	err := RunUpgrade(UpgradeOptions{})
 	if err != nil {
 		t.Fatalf("RunUpgrade must not abort on instance failure, got: %v", err)
 	}
@@ -528,6 +531,129 @@ func TestUpgradeInstanceFailureDoesNotAbort(t *testing.T) {
 	}
 }
 
+// writeAgentRequest leaves an agent_upgrade request like the agent does.
+func writeAgentRequest(t *testing.T, req executor.UpgradeRequest) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), executor.UpgradeDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, executor.UpgradeRequestFile)
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readAgentResult(t *testing.T, requestPath string) executor.UpgradeResult {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(requestPath), executor.UpgradeResultFile))
+	if err != nil {
+		t.Fatalf("no agent upgrade result written: %v", err)
+	}
+	var res executor.UpgradeResult
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestAgentRequestAlreadyCurrentReportsSuccess(t *testing.T) {
+	// Earlier re-exec tests leave the child marker set in the process env.
+	t.Setenv(envComponentsOnly, "")
+
+	restore := fakeUpgradeNetwork(t)
+	defer restore()
+
+	var restarted []string
+	oldRestart := restartServiceFn
+	restartServiceFn = func(unit string, userScope bool) error {
+		restarted = append(restarted, unit)
+		return nil
+	}
+	defer func() { restartServiceFn = oldRestart }()
+
+	path := writeAgentRequest(t, executor.UpgradeRequest{JobID: 42})
+	if err := RunUpgrade(UpgradeOptions{AgentRequest: path}); err != nil {
+		t.Fatalf("RunUpgrade: %v", err)
+	}
+
+	res := readAgentResult(t, path)
+	if res.JobID != 42 || res.Error != "" {
+		t.Errorf("result = %+v, want success for job 42", res)
+	}
+	if len(restarted) != 0 {
+		t.Errorf("nothing to restart into, but restarted %v", restarted)
+	}
+}
+
+func TestAgentRequestPinnedDownloadFailureReportsError(t *testing.T) {
+	// Earlier re-exec tests leave the child marker set in the process env.
+	t.Setenv(envComponentsOnly, "")
+
+	var urls []string
+	oldDownload := downloadFn
+	downloadFn = func(url, name string) (string, error) {
+		urls = append(urls, url)
+		return "", errors.New("HTTP 404")
+	}
+	defer func() { downloadFn = oldDownload }()
+
+	oldRel := latestReleaseFn
+	latestReleaseFn = func() (*ghRelease, error) {
+		t.Fatal("a pinned upgrade must not look up the latest release")
+		return nil, nil
+	}
+	defer func() { latestReleaseFn = oldRel }()
+
+	path := writeAgentRequest(t, executor.UpgradeRequest{JobID: 42, Version: "0.4.0"})
+	if err := RunUpgrade(UpgradeOptions{AgentRequest: path}); err == nil {
+		t.Fatal("RunUpgrade must fail the helper when the download fails")
+	}
+
+	if len(urls) == 0 || !strings.Contains(urls[0], "/releases/download/v0.4.0/") {
+		t.Errorf("download urls = %v, want the pinned v0.4.0 release", urls)
+	}
+	if res := readAgentResult(t, path); res.JobID != 42 || !strings.Contains(res.Error, "HTTP 404") {
+		t.Errorf("result = %+v, want the download error for job 42", res)
+	}
+}
+
+func TestAgentRequestChildRestartsOnlyAgent(t *testing.T) {
+	// The re-exec'd child after the swap restarts the agent and nothing else,
+	// even on a box that also hosts the server instance.
+	t.Setenv(envComponentsOnly, "1")
+
+	var restarted []string
+	oldRestart := restartServiceFn
+	restartServiceFn = func(unit string, userScope bool) error {
+		restarted = append(restarted, unit)
+		return nil
+	}
+	defer func() { restartServiceFn = oldRestart }()
+
+	restoreCompose := setExecCompose(func(args ...string) (string, error) {
+		t.Fatal("agent upgrade must not touch the server instance")
+		return "", nil
+	})
+	defer restoreCompose()
+
+	path := writeAgentRequest(t, executor.UpgradeRequest{JobID: 42})
+	if err := RunUpgrade(UpgradeOptions{AgentRequest: path}); err != nil {
+		t.Fatalf("RunUpgrade: %v", err)
+	}
+	if len(restarted) != 1 || restarted[0] != agentUnit {
+		t.Errorf("restarted %v, want only %s", restarted, agentUnit)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), executor.UpgradeResultFile)); err == nil {
+		t.Error("a restart must not write a result: the server completes the job on reconnect")
+	}
+}
+
 func TestNeedsSudoNonRoot(t *testing.T) {
 	// can't easily fake euid; just ensure the function exists and returns bool.
 	if isRoot() {
@@ -0,0 +1,33 @@
+// Package agentspool tracks which records of an agent's on-disk spool the
+// server has applied, so replayed reports are applied once.
+package agentspool
+
+import (
+	"context"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo domain.AgentSpoolRepository
+}
+
+func NewService(repo domain.AgentSpoolRepository) domain.AgentSpoolService {
+	return &Service{repo: repo}
+}
+
+// Applied works because the agent replays its spool strictly in order: every
+// record up to the last acked one has been applied.
+func (s *Service) Applied(ctx context.Context, serverID, spoolID uuid.UUID, seq int64) (bool, error) {
+	last, err := s.repo.LastSeq(ctx, serverID, spoolID)
+	if err != nil {
+		return false, err
+	}
+	return seq <= last, nil
+}
+
+func (s *Service) Ack(ctx context.Context, serverID, spoolID uuid.UUID, seq int64) error {
+	return s.repo.Ack(ctx, serverID, spoolID, seq)
+}
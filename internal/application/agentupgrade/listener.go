@@ -0,0 +1,67 @@
+package agentupgrade
+
+import (
+	"context"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+	"horizonx/internal/logger"
+)
+
+type Listener struct {
+	svc domain.AgentUpgradeService
+	log logger.Logger
+}
+
+func NewListener(svc domain.AgentUpgradeService, log logger.Logger) *Listener {
+	return &Listener{
+		svc: svc,
+		log: log,
+	}
+}
+
+func (l *Listener) Register(bus *event.Bus) {
+	bus.Subscribe("agent_connected", l.handleAgentConnected)
+	bus.Subscribe("job_finished", l.handleJobFinished)
+}
+
+func (l *Listener) handleAgentConnected(event any) {
+	evt, ok := event.(domain.EventAgentConnected)
+	if !ok {
+		l.log.Warn("invalid event payload for agent_connected", "event", event)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := l.svc.AgentConnected(ctx, evt.ServerID, evt.Info); err != nil {
+		l.log.Error("failed to complete agent upgrade", "server_id", evt.ServerID, "error", err)
+	}
+}
+
+func (l *Listener) handleJobFinished(event any) {
+	evt, ok := event.(domain.EventJobFinished)
+	if !ok {
+		l.log.Warn("invalid event payload for job_finished", "event", event)
+		return
+	}
+
+	if evt.Type != domain.JobTypeAgentUpgrade {
+		return
+	}
+
+	if evt.Status != domain.JobSuccess {
+		l.log.Warn("agent upgrade did not succeed, rollout stopped",
+			"job_id", evt.JobID, "trace_id", evt.TraceID, "server_id", evt.ServerID, "status", evt.Status)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := l.svc.ContinueRollout(ctx, evt.JobID); err != nil {
+		l.log.Error("failed to continue agent rollout, rollout stopped", "job_id", evt.JobID, "trace_id", evt.TraceID, "error", err)
+	}
+}
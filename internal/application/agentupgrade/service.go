@@ -0,0 +1,219 @@
+// Package agentupgrade
+package agentupgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/version"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	serverSvc domain.ServerService
+	jobSvc    domain.JobService
+}
+
+func NewService(serverSvc domain.ServerService, jobSvc domain.JobService) domain.AgentUpgradeService {
+	return &Service{
+		serverSvc: serverSvc,
+		jobSvc:    jobSvc,
+	}
+}
+
+func (s *Service) Upgrade(ctx context.Context, serverID uuid.UUID, req domain.AgentUpgradeRequest) (*domain.Job, error) {
+	if err := domain.ValidateAgentVersion(req.Version); err != nil {
+		return nil, err
+	}
+
+	srv, err := s.serverSvc.GetByID(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queue(ctx, srv, uuid.New(), req.Version, nil)
+}
+
+// Rollout upgrades servers one at a time. Only the first server's job is
+// queued here; the rest ride along in its payload and each is queued by
+// ContinueRollout once the one before it succeeded.
+func (s *Service) Rollout(ctx context.Context, req domain.AgentRolloutRequest) (*domain.AgentRollout, error) {
+	if err := domain.ValidateAgentVersion(req.Version); err != nil {
+		return nil, err
+	}
+
+	servers, err := s.rolloutServers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]*domain.Server, 0, len(servers))
+	for _, srv := range servers {
+		if srv.AgentInfo == nil {
+			return nil, errPredatesUpgrades(srv)
+		}
+		if req.Version != "" && version.Equal(srv.AgentInfo.Version, req.Version) {
+			continue
+		}
+		pending = append(pending, srv)
+	}
+
+	rollout := &domain.AgentRollout{
+		TraceID:   uuid.New(),
+		Version:   req.Version,
+		ServerIDs: make([]uuid.UUID, 0, len(pending)),
+	}
+	for _, srv := range pending {
+		rollout.ServerIDs = append(rollout.ServerIDs, srv.ID)
+	}
+
+	if len(pending) == 0 {
+		return rollout, nil
+	}
+
+	if _, err := s.queue(ctx, pending[0], rollout.TraceID, req.Version, rollout.ServerIDs[1:]); err != nil {
+		return nil, err
+	}
+
+	return rollout, nil
+}
+
+// rolloutServers resolves the servers a rollout covers, in the order they
+// will be upgraded. Naming an offline server is refused up front rather than
+// stalling the rollout on it.
+func (s *Service) rolloutServers(ctx context.Context, req domain.AgentRolloutRequest) ([]*domain.Server, error) {
+	if len(req.ServerIDs) == 0 {
+		online := true
+		res, err := s.serverSvc.List(ctx, domain.ServerListOptions{IsOnline: &online})
+		if err != nil {
+			return nil, err
+		}
+		return res.Data, nil
+	}
+
+	servers := make([]*domain.Server, 0, len(req.ServerIDs))
+	for _, id := range req.ServerIDs {
+		srv, err := s.serverSvc.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !srv.IsOnline {
+			return nil, fmt.Errorf("%w: %s", domain.ErrServerOffline, srv.Name)
+		}
+		servers = append(servers, srv)
+	}
+
+	return servers, nil
+}
+
+func (s *Service) queue(ctx context.Context, srv *domain.Server, traceID uuid.UUID, pinned string, rest []uuid.UUID) (*domain.Job, error) {
+	if srv.AgentInfo == nil {
+		return nil, errPredatesUpgrades(srv)
+	}
+
+	active, err := s.jobSvc.List(ctx, domain.JobListOptions{
+		ServerID: &srv.ID,
+		Type:     string(domain.JobTypeAgentUpgrade),
+		Statuses: []string{string(domain.JobQueued), string(domain.JobRunning)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(active.Data) > 0 {
+		return nil, domain.ErrAgentUpgradeInProgress
+	}
+
+	payload := domain.AgentUpgradePayload{
+		Version:     pinned,
+		FromVersion: srv.AgentInfo.Version,
+		Rollout:     rest,
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.jobSvc.Create(ctx, &domain.Job{
+		TraceID:  traceID,
+		ServerID: srv.ID,
+		Type:     domain.JobTypeAgentUpgrade,
+		Payload:  payloadBytes,
+	})
+}
+
+// errPredatesUpgrades refuses servers whose agent never sent a hello: it
+// has no agent_upgrade handler and would fail the job as unknown.
+func errPredatesUpgrades(srv *domain.Server) error {
+	return fmt.Errorf("%w: agent on %s predates remote upgrades; run `horizonx upgrade` on the server", domain.ErrJobUnsupported, srv.Name)
+}
+
+// AgentConnected finishes the server's running upgrade when the agent that
+// just connected is on the target release: the pinned one, or any other
+// than it ran before for an unpinned upgrade. An agent that comes back on
+// its old release only reconnected; its job is left running for the agent
+// to report, or for the reaper.
+func (s *Service) AgentConnected(ctx context.Context, serverID uuid.UUID, info domain.AgentInfo) error {
+	running, err := s.jobSvc.List(ctx, domain.JobListOptions{
+		ServerID: &serverID,
+		Type:     string(domain.JobTypeAgentUpgrade),
+		Statuses: []string{string(domain.JobRunning)},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, job := range running.Data {
+		var payload domain.AgentUpgradePayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return fmt.Errorf("job %d: invalid agent upgrade payload: %w", job.ID, err)
+		}
+
+		upgraded := !version.Equal(info.Version, payload.FromVersion)
+		if payload.Version != "" {
+			upgraded = version.Equal(info.Version, payload.Version)
+		}
+		if !upgraded {
+			continue
+		}
+
+		if _, err := s.jobSvc.Finish(ctx, job.ID, domain.JobSuccess, job.StartedAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) ContinueRollout(ctx context.Context, jobID int64) error {
+	job, err := s.jobSvc.GetByID(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if job.Type != domain.JobTypeAgentUpgrade || job.Status != domain.JobSuccess {
+		return nil
+	}
+
+	var payload domain.AgentUpgradePayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("job %d: invalid agent upgrade payload: %w", job.ID, err)
+	}
+
+	for i, id := range payload.Rollout {
+		srv, err := s.serverSvc.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if srv.AgentInfo != nil && payload.Version != "" && version.Equal(srv.AgentInfo.Version, payload.Version) {
+			continue
+		}
+
+		_, err = s.queue(ctx, srv, job.TraceID, payload.Version, payload.Rollout[i+1:])
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package agentupgrade
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+
+	"github.com/google/uuid"
+)
+
+type fakeServerService struct {
+	domain.ServerService
+	servers []*domain.Server
+}
+
+func (f *fakeServerService) List(_ context.Context, opts domain.ServerListOptions) (*domain.ListResult[*domain.Server], error) {
+	var out []*domain.Server
+	for _, s := range f.servers {
+		if opts.IsOnline == nil || s.IsOnline == *opts.IsOnline {
+			out = append(out, s)
+		}
+	}
+	return &domain.ListResult[*domain.Server]{Data: out}, nil
+}
+
+func (f *fakeServerService) GetByID(_ context.Context, serverID uuid.UUID) (*domain.Server, error) {
+	for _, s := range f.servers {
+		if s.ID == serverID {
+			return s, nil
+		}
+	}
+	return nil, domain.ErrServerNotFound
+}
+
+// fakeJobService keeps jobs in memory and publishes job_finished like the
+// real service, so the listener drives the rollout.
+type fakeJobService struct {
+	domain.JobService
+	bus  *event.Bus
+	jobs []*domain.Job
+}
+
+func (f *fakeJobService) List(_ context.Context, opts domain.JobListOptions) (*domain.ListResult[*domain.Job], error) {
+	var out []*domain.Job
+	for _, j := range f.jobs {
+		if opts.ServerID != nil && j.ServerID != *opts.ServerID {
+			continue
+		}
+		if opts.Type != "" && string(j.Type) != opts.Type {
+			continue
+		}
+		if len(opts.Statuses) > 0 && !slices.Contains(opts.Statuses, string(j.Status)) {
+			continue
+		}
+		out = append(out, j)
+	}
+	return &domain.ListResult[*domain.Job]{Data: out}, nil
+}
+
+func (f *fakeJobService) GetByID(_ context.Context, jobID int64) (*domain.Job, error) {
+	for _, j := range f.jobs {
+		if j.ID == jobID {
+			return j, nil
+		}
+	}
+	return nil, domain.ErrJobNotFound
+}
+
+func (f *fakeJobService) Create(_ context.Context, j *domain.Job) (*domain.Job, error) {
+	j.ID = int64(len(f.jobs) + 1)
+	j.Status = domain.JobQueued
+	f.jobs = append(f.jobs, j)
+	return j, nil
+}
+
+func (f *fakeJobService) Finish(_ context.Context, jobID int64, status domain.JobStatus, _ *time.Time) (*domain.Job, error) {
+	j, err := f.GetByID(context.Background(), jobID)
+	if err != nil {
+		return nil, err
+	}
+	j.Status = status
+	f.bus.Publish("job_finished", domain.EventJobFinished{
+		JobID:    j.ID,
+		TraceID:  j.TraceID,
+		ServerID: j.ServerID,
+		Type:     j.Type,
+		Status:   status,
+	})
+	return j, nil
+}
+
+// start marks the server's queued upgrade as picked up by its agent.
+func (f *fakeJobService) start(t *testing.T, serverID uuid.UUID) *domain.Job {
+	t.Helper()
+	for _, j := range f.jobs {
+		if j.ServerID == serverID && j.Status == domain.JobQueued {
+			j.Status = domain.JobRunning
+			return j
+		}
+	}
+	t.Fatalf("no queued upgrade for server %s", serverID)
+	return nil
+}
+
+type noopLog struct{}
+
+func (noopLog) Debug(string, ...any) {}
+func (noopLog) Info(string, ...any)  {}
+func (noopLog) Warn(string, ...any)  {}
+func (noopLog) Error(string, ...any) {}
+
+func newAgentServer(version string) *domain.Server {
+	return &domain.Server{ID: uuid.New(), Name: "srv", IsOnline: true, AgentInfo: &domain.AgentInfo{
+		Version:  version,
+		JobTypes: maps.Clone(domain.JobPayloadVersions),
+	}}
+}
+
+func newTestService(servers ...*domain.Server) (domain.AgentUpgradeService, *fakeJobService, *event.Bus) {
+	bus := event.New()
+	jobs := &fakeJobService{bus: bus}
+	svc := NewService(&fakeServerService{servers: servers}, jobs)
+	NewListener(svc, noopLog{}).Register(bus)
+	return svc, jobs, bus
+}
+
+func TestUpgradeSucceedsWhenAgentReconnectsOnNewVersion(t *testing.T) {
+	srv := newAgentServer("v1.0.0")
+	svc, jobs, bus := newTestService(srv)
+
+	job, err := svc.Upgrade(context.Background(), srv.ID, domain.AgentUpgradeRequest{})
+	if err != nil {
+		t.Fatalf("Upgrade: %v", err)
+	}
+
+	var payload domain.AgentUpgradePayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.FromVersion != "v1.0.0" || payload.Version != "" {
+		t.Fatalf("payload = %+v, want an unpinned upgrade from v1.0.0", payload)
+	}
+
+	if _, err := svc.Upgrade(context.Background(), srv.ID, domain.AgentUpgradeRequest{}); !errors.Is(err, domain.ErrAgentUpgradeInProgress) {
+		t.Fatalf("second upgrade: err = %v, want ErrAgentUpgradeInProgress", err)
+	}
+
+	jobs.start(t, srv.ID)
+
+	// A socket blip reconnects the agent on the old release: not done yet.
+	bus.Publish("agent_connected", domain.EventAgentConnected{ServerID: srv.ID, Info: domain.AgentInfo{Version: "1.0.0"}})
+	if job.Status != domain.JobRunning {
+		t.Fatalf("status after reconnect on old version = %s, want running", job.Status)
+	}
+
+	bus.Publish("agent_connected", domain.EventAgentConnected{ServerID: srv.ID, Info: domain.AgentInfo{Version: "v1.1.0"}})
+	if job.Status != domain.JobSuccess {
+		t.Fatalf("status after reconnect on new version = %s, want success", job.Status)
+	}
+}
+
+func TestUpgradeRejectsBadVersionAndLegacyAgents(t *testing.T) {
+	legacy := &domain.Server{ID: uuid.New(), Name: "legacy", IsOnline: true}
+	srv := newAgentServer("v1.0.0")
+	svc, jobs, _ := newTestService(srv, legacy)
+
+	if _, err := svc.Upgrade(context.Background(), srv.ID, domain.AgentUpgradeRequest{Version: "latest; rm -rf /"}); !errors.Is(err, domain.ErrInvalidAgentVersion) {
+		t.Fatalf("bad version: err = %v, want ErrInvalidAgentVersion", err)
+	}
+	if _, err := svc.Upgrade(context.Background(), legacy.ID, domain.AgentUpgradeRequest{}); !errors.Is(err, domain.ErrJobUnsupported) {
+		t.Fatalf("legacy agent: err = %v, want ErrJobUnsupported", err)
+	}
+	if len(jobs.jobs) != 0 {
+		t.Fatalf("queued %d jobs, want none", len(jobs.jobs))
+	}
+}
+
+func TestRolloutUpgradesOneServerAtATime(t *testing.T) {
+	a, b, current, c := newAgentServer("v1.0.0"), newAgentServer("v1.0.0"), newAgentServer("v1.1.0"), newAgentServer("v1.0.0")
+	svc, jobs, bus := newTestService(a, b, current, c)
+
+	rollout, err := svc.Rollout(context.Background(), domain.AgentRolloutRequest{
+		ServerIDs: []uuid.UUID{a.ID, b.ID, current.ID, c.ID},
+		Version:   "v1.1.0",
+	})
+	if err != nil {
+		t.Fatalf("Rollout: %v", err)
+	}
+	if !slices.Equal(rollout.ServerIDs, []uuid.UUID{a.ID, b.ID, c.ID}) {
+		t.Fatalf("rollout servers = %v, want a, b, c (current skipped)", rollout.ServerIDs)
+	}
+	if len(jobs.jobs) != 1 || jobs.jobs[0].ServerID != a.ID {
+		t.Fatalf("rollout must queue only the first server, got %d jobs", len(jobs.jobs))
+	}
+
+	for _, srv := range []*domain.Server{a, b, c} {
+		job := jobs.start(t, srv.ID)
+		if job.TraceID != rollout.TraceID {
+			t.Fatalf("job trace = %s, want the rollout's %s", job.TraceID, rollout.TraceID)
+		}
+		bus.Publish("agent_connected", domain.EventAgentConnected{ServerID: srv.ID, Info: domain.AgentInfo{Version: "1.1.0"}})
+	}
+
+	if len(jobs.jobs) != 3 {
+		t.Fatalf("queued %d jobs, want 3", len(jobs.jobs))
+	}
+	for _, j := range jobs.jobs {
+		if j.Status != domain.JobSuccess {
+			t.Fatalf("job %d = %s, want success", j.ID, j.Status)
+		}
+	}
+}
+
+func TestRolloutStopsAtFirstFailure(t *testing.T) {
+	a, b, c := newAgentServer("v1.0.0"), newAgentServer("v1.0.0"), newAgentServer("v1.0.0")
+	offline := newAgentServer("v1.0.0")
+	offline.IsOnline = false
+	svc, jobs, _ := newTestService(a, b, c, offline)
+
+	if _, err := svc.Rollout(context.Background(), domain.AgentRolloutRequest{ServerIDs: []uuid.UUID{a.ID, offline.ID}}); !errors.Is(err, domain.ErrServerOffline) {
+		t.Fatalf("offline server: err = %v, want ErrServerOffline", err)
+	}
+
+	// No servers named: every online one.
+	rollout, err := svc.Rollout(context.Background(), domain.AgentRolloutRequest{})
+	if err != nil {
+		t.Fatalf("Rollout: %v", err)
+	}
+	if !slices.Equal(rollout.ServerIDs, []uuid.UUID{a.ID, b.ID, c.ID}) {
+		t.Fatalf("rollout servers = %v, want the online ones", rollout.ServerIDs)
+	}
+
+	jobs.start(t, a.ID)
+	if _, err := jobs.Finish(context.Background(), jobs.jobs[0].ID, domain.JobFailed, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(jobs.jobs) != 1 {
+		t.Fatalf("rollout continued past a failed upgrade: %d jobs queued", len(jobs.jobs))
+	}
+}
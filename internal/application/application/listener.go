@@ -11,14 +11,16 @@ import (
 )
 
 type Listener struct {
-	svc domain.ApplicationService
-	log logger.Logger
+	svc           domain.ApplicationService
+	deploymentSvc domain.DeploymentService
+	log           logger.Logger
 }
 
-func NewListener(svc domain.ApplicationService, log logger.Logger) *Listener {
+func NewListener(svc domain.ApplicationService, deploymentSvc domain.DeploymentService, log logger.Logger) *Listener {
 	return &Listener{
-		svc: svc,
-		log: log,
+		svc:           svc,
+		deploymentSvc: deploymentSvc,
+		log:           log,
 	}
 }
 
@@ -66,18 +68,31 @@ func (l *Listener) handleJobFinished(event any) {
 		return
 	}
 
-	if evt.ApplicationID == nil {
+	// A per-service job touches one service: the service health it reports
+	// afterwards settles the app's status, not the job's outcome.
+	if evt.ApplicationID == nil || evt.Type == domain.JobTypeAppService {
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if evt.Status == domain.JobFailed {
+	if evt.Status == domain.JobFailed || evt.Status == domain.JobExpired {
+		// A deploy that was automatically rolled back left the previous
+		// release running.
+		if evt.Type == domain.JobTypeAppDeploy && l.isRolledBack(ctx, evt.DeploymentID) {
+			_ = l.updateStatus(ctx, *evt.ApplicationID, domain.AppStatusRunning)
+			return
+		}
 		_ = l.svc.UpdateStatus(ctx, *evt.ApplicationID, domain.AppStatusFailed)
 		return
 	}
 
+	if evt.Status == domain.JobCancelled {
+		l.handleJobCancelled(ctx, *evt.ApplicationID)
+		return
+	}
+
 	switch evt.Type {
 	case domain.JobTypeAppDeploy:
 		_ = l.updateStatus(ctx, *evt.ApplicationID, domain.AppStatusRunning)
@@ -107,6 +122,37 @@ func (l *Listener) handleJobFinished(event any) {
 	}
 }
 
+// handleJobCancelled leaves an app whose job was cancelled mid-flight in
+// "unknown": the job may have stopped halfway through a deploy or restart.
+// The health check worker settles the real state on its next run. An app
+// that never left its steady state (job cancelled while queued) is untouched.
+func (l *Listener) handleJobCancelled(ctx context.Context, appID int64) {
+	app, err := l.svc.GetByID(ctx, appID)
+	if err != nil {
+		l.log.Error("failed to get application", "app_id", appID, "error", err)
+		return
+	}
+
+	switch app.Status {
+	case domain.AppStatusDeploying,
+		domain.AppStatusStarting,
+		domain.AppStatusStopping,
+		domain.AppStatusRestarting:
+		_ = l.updateStatus(ctx, appID, domain.AppStatusUnknown)
+	}
+}
+
+func (l *Listener) isRolledBack(ctx context.Context, deploymentID *int64) bool {
+	if deploymentID == nil {
+		return false
+	}
+	d, err := l.deploymentSvc.GetByID(ctx, *deploymentID)
+	if err != nil {
+		return false
+	}
+	return d.Status == domain.DeploymentRolledBack
+}
+
 func (l *Listener) updateStatus(ctx context.Context, appID int64, status domain.ApplicationStatus) error {
 	err := l.svc.UpdateStatus(ctx, appID, status)
 	if err != nil {
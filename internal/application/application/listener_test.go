@@ -0,0 +1,60 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"horizonx/internal/application/application"
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type noopLog struct{}
+
+func (noopLog) Debug(msg string, args ...any) {}
+func (noopLog) Info(msg string, args ...any)  {}
+func (noopLog) Warn(msg string, args ...any)  {}
+func (noopLog) Error(msg string, args ...any) {}
+
+type fakeAppSvc struct {
+	domain.ApplicationService
+	statuses []domain.ApplicationStatus
+}
+
+func (f *fakeAppSvc) UpdateStatus(ctx context.Context, appID int64, status domain.ApplicationStatus) error {
+	f.statuses = append(f.statuses, status)
+	return nil
+}
+
+func publishJobFinished(svc domain.ApplicationService, jobType domain.JobType, status domain.JobStatus) {
+	bus := event.New()
+	application.NewListener(svc, &fakeDeploymentSvc{}, noopLog{}).Register(bus)
+
+	appID := int64(1)
+	bus.Publish("job_finished", domain.EventJobFinished{
+		JobID:         7,
+		ApplicationID: &appID,
+		Type:          jobType,
+		Status:        status,
+	})
+}
+
+func TestListenerMarksAppFailedOnFailedJob(t *testing.T) {
+	svc := &fakeAppSvc{}
+	publishJobFinished(svc, domain.JobTypeAppRestart, domain.JobFailed)
+
+	assert.Equal(t, []domain.ApplicationStatus{domain.AppStatusFailed}, svc.statuses)
+}
+
+// A failed or expired service action leaves the app's status to the
+// service health the agent reports.
+func TestListenerIgnoresFinishedServiceJob(t *testing.T) {
+	for _, status := range []domain.JobStatus{domain.JobFailed, domain.JobExpired, domain.JobSuccess} {
+		svc := &fakeAppSvc{}
+		publishJobFinished(svc, domain.JobTypeAppService, status)
+
+		assert.Empty(t, svc.statuses, "status %s", status)
+	}
+}
@@ -4,7 +4,9 @@ package application
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 
 	"horizonx/internal/domain"
 	"horizonx/internal/event"
@@ -17,6 +19,9 @@ type Service struct {
 	serverSvc     domain.ServerService
 	jobSvc        domain.JobService
 	deploymentSvc domain.DeploymentService
+	credRepo      domain.GitCredentialRepository
+	registryRepo  domain.RegistryCredentialRepository
+	buildVarRepo  domain.BuildVariableRepository
 	bus           *event.Bus
 }
 
@@ -25,6 +30,9 @@ func NewService(
 	serverSvc domain.ServerService,
 	jobSvc domain.JobService,
 	deploymentSvc domain.DeploymentService,
+	credRepo domain.GitCredentialRepository,
+	registryRepo domain.RegistryCredentialRepository,
+	buildVarRepo domain.BuildVariableRepository,
 	bus *event.Bus,
 ) domain.ApplicationService {
 	return &Service{
@@ -32,6 +40,9 @@ func NewService(
 		serverSvc:     serverSvc,
 		jobSvc:        jobSvc,
 		deploymentSvc: deploymentSvc,
+		credRepo:      credRepo,
+		registryRepo:  registryRepo,
+		buildVarRepo:  buildVarRepo,
 		bus:           bus,
 	}
 }
@@ -72,6 +83,13 @@ func (s *Service) GetByID(ctx context.Context, appID int64) (*domain.Application
 }
 
 func (s *Service) Create(ctx context.Context, req domain.ApplicationCreateRequest) (*domain.Application, error) {
+	if err := req.Compose.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validateProbes(req.ReadinessProbes); err != nil {
+		return nil, err
+	}
+
 	_, err := s.serverSvc.GetByID(ctx, req.ServerID)
 	if err != nil {
 		return nil, fmt.Errorf("server not found: %w", err)
@@ -85,7 +103,29 @@ func (s *Service) Create(ctx context.Context, req domain.ApplicationCreateReques
 		SiteURL:  req.SiteURL,
 		Branch:   req.Branch,
 		Status:   domain.AppStatusStopped,
+
+		SourceType:        req.SourceType,
+		Image:             req.Image,
+		ComposeDefinition: req.ComposeDefinition,
+
+		AutoRollback:    req.AutoRollback,
+		ReadinessProbes: req.ReadinessProbes,
+
+		PreDeployCommands:  req.PreDeployCommands,
+		PostDeployCommands: req.PostDeployCommands,
+
+		Compose: req.Compose,
+
+		PreviewsEnabled: req.PreviewsEnabled,
+		PreviewTTLHours: req.PreviewTTLHours,
+	}
+	if app.SourceType == "" {
+		app.SourceType = domain.SourceGit
+	}
+	if err := app.ValidateSource(); err != nil {
+		return nil, err
 	}
+
 	created, err := s.repo.Create(ctx, app)
 	if err != nil {
 		return nil, err
@@ -97,6 +137,7 @@ func (s *Service) Create(ctx context.Context, req domain.ApplicationCreateReques
 			Key:       env.Key,
 			Value:     env.Value,
 			IsPreview: env.IsPreview,
+			IsSecret:  env.IsSecret,
 		})
 	}
 	if err := s.repo.SyncEnvVars(ctx, created.ID, envVars); err != nil {
@@ -114,7 +155,14 @@ func (s *Service) Create(ctx context.Context, req domain.ApplicationCreateReques
 }
 
 func (s *Service) Update(ctx context.Context, req domain.ApplicationUpdateRequest, appID int64) error {
-	_, err := s.repo.GetByID(ctx, appID)
+	if err := req.Compose.Validate(); err != nil {
+		return err
+	}
+	if err := validateProbes(req.ReadinessProbes); err != nil {
+		return err
+	}
+
+	current, err := s.repo.GetByID(ctx, appID)
 	if err != nil {
 		return err
 	}
@@ -123,7 +171,27 @@ func (s *Service) Update(ctx context.Context, req domain.ApplicationUpdateReques
 		Name:    req.Name,
 		SiteURL: req.SiteURL,
 		Branch:  req.Branch,
+
+		RepoURL:           current.RepoURL,
+		SourceType:        current.SourceType,
+		Image:             req.Image,
+		ComposeDefinition: req.ComposeDefinition,
+
+		AutoRollback:    req.AutoRollback,
+		ReadinessProbes: req.ReadinessProbes,
+
+		PreDeployCommands:  req.PreDeployCommands,
+		PostDeployCommands: req.PostDeployCommands,
+
+		Compose: req.Compose,
+
+		PreviewsEnabled: req.PreviewsEnabled,
+		PreviewTTLHours: req.PreviewTTLHours,
 	}
+	if err := app.ValidateSource(); err != nil {
+		return err
+	}
+
 	if err := s.repo.Update(ctx, app, appID); err != nil {
 		return err
 	}
@@ -134,6 +202,7 @@ func (s *Service) Update(ctx context.Context, req domain.ApplicationUpdateReques
 			Key:       env.Key,
 			Value:     env.Value,
 			IsPreview: env.IsPreview,
+			IsSecret:  env.IsSecret,
 		})
 	}
 	if err := s.repo.SyncEnvVars(ctx, appID, envVars); err != nil {
@@ -162,9 +231,27 @@ func (s *Service) Delete(ctx context.Context, appID int64) error {
 		)
 	}
 
+	// An app's previews go with it.
+	previews, _, err := s.repo.List(ctx, domain.ApplicationListOptions{
+		ListOptions:         domain.ListOptions{Limit: 100},
+		ParentApplicationID: &appID,
+	})
+	if err != nil {
+		return err
+	}
+	for _, preview := range previews {
+		if err := s.Delete(ctx, preview.ID); err != nil {
+			return fmt.Errorf("failed to delete preview %s: %w", preview.Name, err)
+		}
+	}
+
 	payload := domain.AppDestroyPayload{
-		ApplicationID: app.ID,
-		AppKey:        domain.GetAppKey(app),
+		AppInfo: domain.AppInfo{
+			ApplicationID: app.ID,
+			AppKey:        domain.GetAppKey(app),
+			Compose:       app.Compose,
+		},
+		Preview: app.ParentApplicationID != nil,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -207,26 +294,65 @@ func (s *Service) UpdateLastDeployment(ctx context.Context, appID int64) error {
 	return s.repo.UpdateLastDeployment(ctx, appID)
 }
 
-func (s *Service) Deploy(ctx context.Context, appID int64, deployedBy int64) (*domain.Deployment, error) {
+// Deploy builds and ships the app. With req.Ref set the agent checks out
+// that exact commit or tag instead of the branch tip; the deployment keeps
+// the ref next to the commit hash it resolved to. Image apps are pulled
+// rather than built, so they take no ref.
+func (s *Service) Deploy(ctx context.Context, appID int64, req domain.ApplicationDeployRequest, deployedBy int64) (*domain.Deployment, error) {
+	var ref *string
+	if req.Ref != "" {
+		if err := domain.ValidateGitRef(req.Ref); err != nil {
+			return nil, err
+		}
+		ref = &req.Ref
+	}
+
 	app, err := s.repo.GetByID(ctx, appID)
 	if err != nil {
 		return nil, err
 	}
+	if app.IsImage() && ref != nil {
+		return nil, fmt.Errorf("%w: image apps deploy their image, not a git ref", domain.ErrInvalidApplicationSource)
+	}
 
 	envVars, err := s.repo.ListEnvVars(ctx, appID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch env vars: %w", err)
 	}
 
-	envMap := make(map[string]string)
-	for _, env := range envVars {
-		envMap[env.Key] = env.Value
+	envMap := baseEnv(envVars)
+
+	var (
+		creds         *domain.GitCredentials
+		registryCreds *domain.RegistryCredentials
+
+		buildArgs, buildSecrets map[string]string
+	)
+	if app.IsImage() {
+		if registryCreds, err = s.registryCredentials(ctx, app); err != nil {
+			return nil, fmt.Errorf("failed to fetch registry credentials: %w", err)
+		}
+	} else {
+		if creds, err = s.gitCredentials(ctx, app); err != nil {
+			return nil, fmt.Errorf("failed to fetch git credentials: %w", err)
+		}
+		if buildArgs, buildSecrets, err = s.buildVariables(ctx, app); err != nil {
+			return nil, fmt.Errorf("failed to fetch build variables: %w", err)
+		}
+	}
+
+	// deployedBy is 0 for system-triggered deploys (git push webhooks),
+	// which have no user to attribute.
+	var deployer *int64
+	if deployedBy != 0 {
+		deployer = &deployedBy
 	}
 
 	deployment, err := s.deploymentSvc.Create(ctx, domain.DeploymentCreateRequest{
 		ApplicationID: appID,
 		Branch:        app.Branch,
-		DeployedBy:    &deployedBy,
+		Ref:           ref,
+		DeployedBy:    deployer,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create deployment record: %w", err)
@@ -243,7 +369,26 @@ func (s *Service) Deploy(ctx context.Context, appID int64, deployedBy int64) (*d
 		AppKey:        domain.GetAppKey(app),
 		RepoURL:       app.RepoURL,
 		Branch:        app.Branch,
+		Ref:           req.Ref,
 		EnvVars:       envMap,
+		AutoRollback:  app.AutoRollback,
+		Compose:       app.Compose,
+
+		SecretEnvKeys: secretEnvKeys(envVars),
+
+		GitCredentials: creds,
+
+		BuildArgs:    buildArgs,
+		BuildSecrets: buildSecrets,
+
+		SourceType:          app.SourceType,
+		Image:               app.Image,
+		ComposeDefinition:   app.ComposeDefinition,
+		RegistryCredentials: registryCreds,
+
+		ReadinessProbes:    app.ReadinessProbes,
+		PreDeployCommands:  app.PreDeployCommands,
+		PostDeployCommands: app.PostDeployCommands,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -268,61 +413,205 @@ func (s *Service) Deploy(ctx context.Context, appID int64, deployedBy int64) (*d
 	return deployment, nil
 }
 
-// Rollback re-deploys the last successfully built image for an app (P0-4).
-// The previous image tag is derived from the most recent successful
-// deployment's commit hash (image tags are `<appKey>:<commitHash>`), so no
-// extra schema is needed — the tag already exists in the docker daemon.
+// gitCredentials returns what the agent needs to fetch the app's repository.
+// Previews clone the parent's repository, so they use the parent's
+// credentials.
+func (s *Service) gitCredentials(ctx context.Context, app *domain.Application) (*domain.GitCredentials, error) {
+	if s.credRepo == nil {
+		return nil, nil
+	}
+
+	ownerID := app.ID
+	if app.ParentApplicationID != nil {
+		ownerID = *app.ParentApplicationID
+	}
+
+	creds, err := s.credRepo.GetByApplicationID(ctx, ownerID)
+	if err != nil {
+		if errors.Is(err, domain.ErrGitCredentialsNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return creds.ForDeploy(), nil
+}
+
+// buildVariables returns the build args and secrets for the app's build.
+// Previews build the parent's repository, so they use the parent's.
+func (s *Service) buildVariables(ctx context.Context, app *domain.Application) (map[string]string, map[string]string, error) {
+	if s.buildVarRepo == nil {
+		return nil, nil, nil
+	}
+
+	ownerID := app.ID
+	if app.ParentApplicationID != nil {
+		ownerID = *app.ParentApplicationID
+	}
+
+	vars, err := s.buildVarRepo.List(ctx, ownerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	args, secrets := domain.SplitBuildVariables(vars)
+	return args, secrets, nil
+}
+
+// registryCredentials returns the login the agent pulls an image app's image
+// with, or nil for public images.
+func (s *Service) registryCredentials(ctx context.Context, app *domain.Application) (*domain.RegistryCredentials, error) {
+	if s.registryRepo == nil {
+		return nil, nil
+	}
+
+	creds, err := s.registryRepo.GetByApplicationID(ctx, app.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrRegistryCredentialsNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return creds.ForDeploy(), nil
+}
+
+// Rollback re-deploys the previous release (P0-4): the newest successful
+// deployment is the one running now, so the target is the newest successful
+// one before it that shipped a different commit or image digest.
 func (s *Service) Rollback(ctx context.Context, appID int64, deployedBy int64) (*domain.Deployment, error) {
 	app, err := s.repo.GetByID(ctx, appID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Find the last successful deployment to learn the image tag to roll back to.
 	success := "success"
 	result, err := s.deploymentSvc.List(ctx, domain.DeploymentListOptions{
-		ListOptions:   domain.ListOptions{Limit: 1},
+		ListOptions:   domain.ListOptions{Limit: 20},
 		ApplicationID: &appID,
 		Statuses:      []string{success},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch previous deployments: %w", err)
 	}
-	var prev []*domain.Deployment
+
+	var current, target *domain.Deployment
 	if result != nil {
-		prev = result.Data
+		for _, d := range result.Data {
+			if d.Release() == "" {
+				continue
+			}
+			if current == nil {
+				current = d
+				continue
+			}
+			if d.Release() != current.Release() {
+				target = d
+				break
+			}
+		}
 	}
-	if len(prev) == 0 || prev[0].CommitHash == nil || *prev[0].CommitHash == "" {
+	if target == nil {
 		return nil, fmt.Errorf("no successful deployment to roll back to")
 	}
 
-	imageTag := fmt.Sprintf("%s:%s", domain.GetAppKey(app), *prev[0].CommitHash)
+	return s.rollbackTo(ctx, app, target, deployedBy)
+}
 
-	envVars, err := s.repo.ListEnvVars(ctx, appID)
+// RollbackTo re-deploys the image and env snapshot of a chosen past
+// deployment. The new deployment links back to it via
+// RollbackOfDeploymentID.
+func (s *Service) RollbackTo(ctx context.Context, appID int64, deploymentID int64, deployedBy int64) (*domain.Deployment, error) {
+	app, err := s.repo.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := s.deploymentSvc.GetByID(ctx, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	if source.ApplicationID != appID {
+		return nil, domain.ErrDeploymentNotFound
+	}
+	if source.Status != domain.DeploymentSuccess || source.Release() == "" {
+		return nil, domain.ErrDeploymentNotRollbackable
+	}
+
+	return s.rollbackTo(ctx, app, source, deployedBy)
+}
+
+func (s *Service) rollbackTo(ctx context.Context, app *domain.Application, source *domain.Deployment, deployedBy int64) (*domain.Deployment, error) {
+	appKey := domain.GetAppKey(app)
+
+	// Image deploys recorded the digest-pinned reference they pulled; built
+	// images are tagged with their commit.
+	imageTag := source.Release()
+	if source.ImageDigest == nil || *source.ImageDigest == "" {
+		imageTag = fmt.Sprintf("%s:%s", appKey, *source.CommitHash)
+	}
+
+	envVars, err := s.repo.ListEnvVars(ctx, app.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch env vars: %w", err)
 	}
+	envMap, err := rollbackEnv(source, envVars)
+	if err != nil {
+		return nil, err
+	}
 
-	envMap := make(map[string]string)
-	for _, env := range envVars {
-		envMap[env.Key] = env.Value
+	var registryCreds *domain.RegistryCredentials
+	if app.IsImage() {
+		if registryCreds, err = s.registryCredentials(ctx, app); err != nil {
+			return nil, fmt.Errorf("failed to fetch registry credentials: %w", err)
+		}
 	}
 
 	deployment, err := s.deploymentSvc.Create(ctx, domain.DeploymentCreateRequest{
-		ApplicationID: appID,
-		Branch:        app.Branch,
-		DeployedBy:    &deployedBy,
+		ApplicationID:          app.ID,
+		Branch:                 source.Branch,
+		Ref:                    source.Ref,
+		DeployedBy:             &deployedBy,
+		RollbackOfDeploymentID: &source.ID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create deployment record: %w", err)
 	}
 
+	// The rollback ships the source's release and env, so record both on the
+	// new deployment: the diff view and a later rollback to it rely on them.
+	if err := s.deploymentSvc.UpdateEnvSnapshot(ctx, deployment.ID, envMap); err != nil {
+		return nil, fmt.Errorf("failed to snapshot env vars: %w", err)
+	}
+
+	if source.ImageDigest != nil && *source.ImageDigest != "" {
+		if err := s.deploymentSvc.UpdateImageDigest(ctx, deployment.ID, *source.ImageDigest); err != nil {
+			return nil, fmt.Errorf("failed to record image digest: %w", err)
+		}
+	} else {
+		commitMessage := ""
+		if source.CommitMessage != nil {
+			commitMessage = *source.CommitMessage
+		}
+		if err := s.deploymentSvc.UpdateCommitInfo(ctx, deployment.ID, *source.CommitHash, commitMessage); err != nil {
+			return nil, fmt.Errorf("failed to record commit info: %w", err)
+		}
+	}
+
 	payload := domain.AppRollbackPayload{
-		ApplicationID: appID,
+		ApplicationID: app.ID,
 		DeploymentID:  deployment.ID,
-		AppKey:        domain.GetAppKey(app),
+		AppKey:        appKey,
 		ImageTag:      imageTag,
 		EnvVars:       envMap,
+		Compose:       app.Compose,
+
+		SecretEnvKeys: secretEnvKeys(envVars),
+
+		ReadinessProbes: app.ReadinessProbes,
+
+		SourceType:          app.SourceType,
+		RegistryCredentials: registryCreds,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -333,20 +622,62 @@ func (s *Service) Rollback(ctx context.Context, appID int64, deployedBy int64) (
 	job := &domain.Job{
 		TraceID:       uuid.New(),
 		ServerID:      app.ServerID,
-		ApplicationID: &appID,
+		ApplicationID: &app.ID,
 		DeploymentID:  &deployment.ID,
 		Type:          domain.JobTypeAppRollback,
 		Payload:       payloadBytes,
 	}
 
 	if _, err := s.jobSvc.Create(ctx, job); err != nil {
-		s.repo.UpdateStatus(ctx, appID, domain.AppStatusFailed)
+		s.repo.UpdateStatus(ctx, app.ID, domain.AppStatusFailed)
 		return nil, fmt.Errorf("failed to create rollback job: %w", err)
 	}
 
 	return deployment, nil
 }
 
+// rollbackEnv returns the env vars the source deployment ran with. Rows
+// written before env snapshots existed have an empty one; those fall back
+// to the app's current env, envVars, rather than booting the old release
+// bare.
+func rollbackEnv(source *domain.Deployment, envVars []domain.EnvironmentVariable) (map[string]string, error) {
+	envMap := make(map[string]string)
+	if len(source.EnvSnapshot) > 0 {
+		if err := json.Unmarshal(source.EnvSnapshot, &envMap); err != nil {
+			return nil, fmt.Errorf("failed to read env snapshot: %w", err)
+		}
+	}
+	if len(envMap) > 0 {
+		return envMap, nil
+	}
+
+	return baseEnv(envVars), nil
+}
+
+// baseEnv is the env an app deploys with. Preview-flagged vars only apply to
+// the app's preview environments, layered over these.
+func baseEnv(envVars []domain.EnvironmentVariable) map[string]string {
+	envMap := make(map[string]string)
+	for _, env := range envVars {
+		if env.IsPreview {
+			continue
+		}
+		envMap[env.Key] = env.Value
+	}
+	return envMap
+}
+
+// secretEnvKeys names the env vars the agent masks in a job's output.
+func secretEnvKeys(envVars []domain.EnvironmentVariable) []string {
+	var keys []string
+	for _, env := range envVars {
+		if env.IsSecret {
+			keys = append(keys, env.Key)
+		}
+	}
+	return keys
+}
+
 func (s *Service) Start(ctx context.Context, appID int64) error {
 	app, err := s.repo.GetByID(ctx, appID)
 	if err != nil {
@@ -364,6 +695,7 @@ func (s *Service) Start(ctx context.Context, appID int64) error {
 	payload := domain.AppStartPayload{
 		ApplicationID: appID,
 		AppKey:        domain.GetAppKey(app),
+		Compose:       app.Compose,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -396,6 +728,7 @@ func (s *Service) Stop(ctx context.Context, appID int64) error {
 	payload := domain.AppStopPayload{
 		ApplicationID: appID,
 		AppKey:        domain.GetAppKey(app),
+		Compose:       app.Compose,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -428,6 +761,7 @@ func (s *Service) Restart(ctx context.Context, appID int64) error {
 	payload := domain.AppRestartPayload{
 		ApplicationID: appID,
 		AppKey:        domain.GetAppKey(app),
+		Compose:       app.Compose,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -447,6 +781,66 @@ func (s *Service) Restart(ctx context.Context, appID int64) error {
 	return err
 }
 
+func (s *Service) ListServices(ctx context.Context, appID int64) ([]domain.ServiceHealth, error) {
+	app, err := s.repo.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if app.Services == nil {
+		return []domain.ServiceHealth{}, nil
+	}
+	return app.Services, nil
+}
+
+func (s *Service) ServiceAction(ctx context.Context, appID int64, service string, action domain.ServiceAction, replicas int) error {
+	if err := domain.ValidateServiceAction(service, action, replicas); err != nil {
+		return err
+	}
+
+	app, err := s.repo.GetByID(ctx, appID)
+	if err != nil {
+		return err
+	}
+
+	// Until the agent has reported the app there is nothing to check the
+	// name against; compose itself rejects unknown services.
+	if len(app.Services) > 0 && !slices.ContainsFunc(app.Services, func(svc domain.ServiceHealth) bool {
+		return svc.Name == service
+	}) {
+		return fmt.Errorf("%w: %q", domain.ErrServiceNotFound, service)
+	}
+
+	payload := domain.AppServicePayload{
+		AppInfo: domain.AppInfo{
+			ApplicationID: appID,
+			AppKey:        domain.GetAppKey(app),
+			Compose:       app.Compose,
+		},
+		Service: service,
+		Action:  action,
+	}
+	if action == domain.ServiceScale {
+		payload.Replicas = replicas
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	job := &domain.Job{
+		TraceID:       uuid.New(),
+		ServerID:      app.ServerID,
+		ApplicationID: &appID,
+		Type:          domain.JobTypeAppService,
+		Payload:       payloadBytes,
+	}
+
+	_, err = s.jobSvc.Create(ctx, job)
+	return err
+}
+
 func (s *Service) ListEnvVars(ctx context.Context, appID int64) ([]domain.EnvironmentVariable, error) {
 	_, err := s.repo.GetByID(ctx, appID)
 	if err != nil {
@@ -467,6 +861,7 @@ func (s *Service) AddEnvVar(ctx context.Context, appID int64, req domain.Environ
 		Key:           req.Key,
 		Value:         req.Value,
 		IsPreview:     req.IsPreview,
+		IsSecret:      req.IsSecret,
 	}
 
 	return s.repo.CreateEnvVar(ctx, env)
@@ -483,6 +878,7 @@ func (s *Service) UpdateEnvVar(ctx context.Context, appID int64, key string, req
 		Key:           key,
 		Value:         req.Value,
 		IsPreview:     req.IsPreview,
+		IsSecret:      req.IsSecret,
 	}
 
 	return s.repo.UpdateEnvVar(ctx, env)
@@ -500,3 +896,12 @@ func (s *Service) DeleteEnvVar(ctx context.Context, appID int64, key string) err
 func (s *Service) UpdateHealth(ctx context.Context, serverID uuid.UUID, reports []domain.ApplicationHealth) error {
 	return s.repo.UpdateHealth(ctx, serverID, reports)
 }
+
+func validateProbes(probes []domain.ReadinessProbe) error {
+	for _, probe := range probes {
+		if err := probe.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package application_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"horizonx/internal/application/application"
 	"horizonx/internal/domain"
@@ -15,18 +17,29 @@ import (
 
 // fakeDeploymentSvc implements domain.DeploymentService for rollback tests.
 type fakeDeploymentSvc struct {
-	prev []*domain.Deployment
+	prev    []*domain.Deployment
+	created []domain.DeploymentCreateRequest
+
+	byID      map[int64]*domain.Deployment
+	snapshots map[int64]map[string]string
+	commits   map[int64]string
+	digests   map[int64]string
 }
 
 func (f *fakeDeploymentSvc) List(_ context.Context, _ domain.DeploymentListOptions) (*domain.ListResult[*domain.Deployment], error) {
 	return &domain.ListResult[*domain.Deployment]{Data: f.prev}, nil
 }
 
-func (f *fakeDeploymentSvc) GetByID(context.Context, int64) (*domain.Deployment, error) {
-	return nil, nil
+func (f *fakeDeploymentSvc) GetByID(_ context.Context, id int64) (*domain.Deployment, error) {
+	d, ok := f.byID[id]
+	if !ok {
+		return nil, domain.ErrDeploymentNotFound
+	}
+	return d, nil
 }
 
-func (f *fakeDeploymentSvc) Create(context.Context, domain.DeploymentCreateRequest) (*domain.Deployment, error) {
+func (f *fakeDeploymentSvc) Create(_ context.Context, req domain.DeploymentCreateRequest) (*domain.Deployment, error) {
+	f.created = append(f.created, req)
 	return &domain.Deployment{ID: 99, Status: domain.DeploymentPending}, nil
 }
 
@@ -36,15 +49,33 @@ func (f *fakeDeploymentSvc) UpdateStatus(context.Context, int64, domain.Deployme
 	return nil
 }
 
-func (f *fakeDeploymentSvc) UpdateCommitInfo(context.Context, int64, string, string) error {
+func (f *fakeDeploymentSvc) UpdateCommitInfo(_ context.Context, id int64, hash string, _ string) error {
+	if f.commits == nil {
+		f.commits = map[int64]string{}
+	}
+	f.commits[id] = hash
+	return nil
+}
+func (f *fakeDeploymentSvc) UpdateImageDigest(_ context.Context, id int64, digest string) error {
+	if f.digests == nil {
+		f.digests = map[int64]string{}
+	}
+	f.digests[id] = digest
 	return nil
 }
-func (f *fakeDeploymentSvc) UpdateEnvSnapshot(context.Context, int64, map[string]string) error {
+func (f *fakeDeploymentSvc) UpdateEnvSnapshot(_ context.Context, id int64, snapshot map[string]string) error {
+	if f.snapshots == nil {
+		f.snapshots = map[int64]map[string]string{}
+	}
+	f.snapshots[id] = snapshot
 	return nil
 }
 func (f *fakeDeploymentSvc) Diff(context.Context, int64) (*domain.DeploymentDiff, error) {
 	return nil, nil
 }
+func (f *fakeDeploymentSvc) RecordAutoRollback(context.Context, int64, domain.DeploymentAutoRollbackRequest) error {
+	return nil
+}
 
 func commitPtr(s string) *string { return &s }
 
@@ -70,7 +101,10 @@ func (f *fakeJobSvc) Retry(context.Context, int64, *domain.Job) (*domain.Job, er
 	return nil, nil
 }
 func (f *fakeJobSvc) Start(context.Context, int64) (*domain.Job, error)             { return nil, nil }
-func (f *fakeJobSvc) Finish(context.Context, int64, domain.JobStatus) (*domain.Job, error) {
+func (f *fakeJobSvc) Finish(context.Context, int64, domain.JobStatus, *time.Time) (*domain.Job, error) {
+	return nil, nil
+}
+func (f *fakeJobSvc) Cancel(context.Context, int64, int64) (*domain.Job, error) {
 	return nil, nil
 }
 func (f *fakeJobSvc) Summary(context.Context) (*domain.JobStatusCounts, error) {
@@ -78,9 +112,9 @@ func (f *fakeJobSvc) Summary(context.Context) (*domain.JobStatusCounts, error) {
 }
 
 
-// P0-4: Rollback creates a job pointing at the last successful deployment's
-// image tag (<appKey>:<commitHash>).
-func TestRollbackUsesLastSuccessfulCommit(t *testing.T) {
+// P0-4: Rollback creates a job pointing at the previous release's image tag
+// (<appKey>:<commitHash>) — not the newest success, which is running now.
+func TestRollbackUsesPreviousRelease(t *testing.T) {
 	appRepo := mocks.NewMockApplicationRepository(t)
 
 	serverID := uuid.New()
@@ -91,24 +125,96 @@ func TestRollbackUsesLastSuccessfulCommit(t *testing.T) {
 		Branch:   "main",
 		Status:   domain.AppStatusRunning,
 	}, nil)
-
-	appRepo.EXPECT().ListEnvVars(mock.Anything, int64(1)).Return([]domain.EnvironmentVariable{
-		{Key: "FOO", Value: "bar"},
-	}, nil)
+	appRepo.EXPECT().ListEnvVars(mock.Anything, int64(1)).Return(nil, nil)
 
 	jobSvc := &fakeJobSvc{}
 
 	deploySvc := &fakeDeploymentSvc{prev: []*domain.Deployment{
-		{ID: 3, Status: domain.DeploymentSuccess, CommitHash: commitPtr("0123456789abcdef0123456789abcdef01234567")},
+		{ID: 4, Status: domain.DeploymentSuccess, CommitHash: commitPtr("fedcba9876543210fedcba9876543210fedcba98"), EnvSnapshot: []byte(`{"FOO":"new"}`)},
+		{ID: 3, Status: domain.DeploymentSuccess, CommitHash: commitPtr("0123456789abcdef0123456789abcdef01234567"), EnvSnapshot: []byte(`{"FOO":"bar"}`)},
 	}}
 
-	svc := application.NewService(appRepo, nil, jobSvc, deploySvc, nil)
+	svc := application.NewService(appRepo, nil, jobSvc, deploySvc, nil, nil, nil, nil)
 
 	dep, err := svc.Rollback(context.Background(), 1, 1)
 	assert.NoError(t, err)
 	assert.NotNil(t, dep)
 	assert.Len(t, jobSvc.created, 1)
 	assert.Equal(t, domain.JobTypeAppRollback, jobSvc.created[0].Type)
+
+	var payload domain.AppRollbackPayload
+	assert.NoError(t, json.Unmarshal(jobSvc.created[0].Payload, &payload))
+	assert.Equal(t, "demo-app-1:0123456789abcdef0123456789abcdef01234567", payload.ImageTag)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, payload.EnvVars)
+}
+
+// RollbackTo restores the chosen deployment's image and env snapshot and
+// links the new deployment to it.
+func TestRollbackToRestoresSnapshot(t *testing.T) {
+	appRepo := mocks.NewMockApplicationRepository(t)
+
+	appRepo.EXPECT().GetByID(mock.Anything, int64(1)).Return(&domain.Application{
+		ID:       1,
+		ServerID: uuid.New(),
+		RepoName: "demo-app",
+		Branch:   "main",
+	}, nil)
+	// The snapshot's values are deployed; which of them are secret is the
+	// app's current say.
+	appRepo.EXPECT().ListEnvVars(mock.Anything, int64(1)).Return([]domain.EnvironmentVariable{
+		{Key: "FOO", Value: "new", IsSecret: true},
+	}, nil)
+
+	jobSvc := &fakeJobSvc{}
+	deploySvc := &fakeDeploymentSvc{byID: map[int64]*domain.Deployment{
+		3: {
+			ID:            3,
+			ApplicationID: 1,
+			Branch:        "main",
+			Status:        domain.DeploymentSuccess,
+			CommitHash:    commitPtr("0123456789abcdef0123456789abcdef01234567"),
+			EnvSnapshot:   []byte(`{"FOO":"old"}`),
+		},
+	}}
+
+	svc := application.NewService(appRepo, nil, jobSvc, deploySvc, nil, nil, nil, nil)
+
+	dep, err := svc.RollbackTo(context.Background(), 1, 3, 1)
+	assert.NoError(t, err)
+
+	assert.Len(t, deploySvc.created, 1)
+	assert.Equal(t, int64(3), *deploySvc.created[0].RollbackOfDeploymentID)
+	assert.Equal(t, map[string]string{"FOO": "old"}, deploySvc.snapshots[dep.ID])
+	assert.Equal(t, "0123456789abcdef0123456789abcdef01234567", deploySvc.commits[dep.ID])
+
+	var payload domain.AppRollbackPayload
+	assert.NoError(t, json.Unmarshal(jobSvc.created[0].Payload, &payload))
+	assert.Equal(t, "demo-app-1:0123456789abcdef0123456789abcdef01234567", payload.ImageTag)
+	assert.Equal(t, map[string]string{"FOO": "old"}, payload.EnvVars)
+	assert.Equal(t, []string{"FOO"}, payload.SecretEnvKeys)
+}
+
+func TestRollbackToRejectsFailedDeployment(t *testing.T) {
+	appRepo := mocks.NewMockApplicationRepository(t)
+
+	appRepo.EXPECT().GetByID(mock.Anything, int64(1)).Return(&domain.Application{
+		ID:       1,
+		ServerID: uuid.New(),
+		RepoName: "demo-app",
+	}, nil)
+
+	deploySvc := &fakeDeploymentSvc{byID: map[int64]*domain.Deployment{
+		3: {ID: 3, ApplicationID: 1, Status: domain.DeploymentFailed, CommitHash: commitPtr("0123456789abcdef")},
+		4: {ID: 4, ApplicationID: 2, Status: domain.DeploymentSuccess, CommitHash: commitPtr("0123456789abcdef")},
+	}}
+
+	svc := application.NewService(appRepo, nil, &fakeJobSvc{}, deploySvc, nil, nil, nil, nil)
+
+	_, err := svc.RollbackTo(context.Background(), 1, 3, 1)
+	assert.ErrorIs(t, err, domain.ErrDeploymentNotRollbackable)
+
+	_, err = svc.RollbackTo(context.Background(), 1, 4, 1)
+	assert.ErrorIs(t, err, domain.ErrDeploymentNotFound, "another app's deployment")
 }
 
 // P0-4: rollback with no successful deployment must fail cleanly.
@@ -122,9 +228,42 @@ func TestRollbackFailsWithoutSuccessfulDeployment(t *testing.T) {
 		Branch:   "main",
 	}, nil)
 
-	svc := application.NewService(appRepo, nil, &fakeJobSvc{}, &fakeDeploymentSvc{}, nil)
+	svc := application.NewService(appRepo, nil, &fakeJobSvc{}, &fakeDeploymentSvc{}, nil, nil, nil, nil)
 
 	_, err := svc.Rollback(context.Background(), 1, 1)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no successful deployment")
 }
+
+// Image apps roll back to the exact digest the chosen deployment pulled.
+func TestRollbackToImageDeploymentReplaysDigest(t *testing.T) {
+	appRepo := mocks.NewMockApplicationRepository(t)
+
+	appRepo.EXPECT().GetByID(mock.Anything, int64(1)).Return(&domain.Application{
+		ID:         1,
+		ServerID:   uuid.New(),
+		RepoName:   "api",
+		SourceType: domain.SourceImage,
+		Image:      "registry.example.com/api:latest",
+	}, nil)
+	appRepo.EXPECT().ListEnvVars(mock.Anything, int64(1)).Return(nil, nil)
+
+	pinned := "registry.example.com/api@sha256:0123456789abcdef"
+	jobSvc := &fakeJobSvc{}
+	deploySvc := &fakeDeploymentSvc{byID: map[int64]*domain.Deployment{
+		3: {ID: 3, ApplicationID: 1, Status: domain.DeploymentSuccess, ImageDigest: &pinned, EnvSnapshot: []byte(`{"FOO":"old"}`)},
+	}}
+
+	svc := application.NewService(appRepo, nil, jobSvc, deploySvc, nil, nil, nil, nil)
+
+	dep, err := svc.RollbackTo(context.Background(), 1, 3, 1)
+	assert.NoError(t, err)
+
+	assert.Equal(t, pinned, deploySvc.digests[dep.ID])
+	assert.Empty(t, deploySvc.commits)
+
+	var payload domain.AppRollbackPayload
+	assert.NoError(t, json.Unmarshal(jobSvc.created[0].Payload, &payload))
+	assert.Equal(t, pinned, payload.ImageTag)
+	assert.Equal(t, domain.SourceImage, payload.SourceType)
+}
@@ -0,0 +1,84 @@
+package application_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"horizonx/internal/application/application"
+	"horizonx/internal/domain"
+	"horizonx/internal/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func reportedApp() *domain.Application {
+	return &domain.Application{
+		ID:       1,
+		ServerID: uuid.New(),
+		RepoName: "shop",
+		Status:   domain.AppStatusRunning,
+		Services: []domain.ServiceHealth{
+			{Name: "web", Status: domain.AppStatusRunning},
+			{Name: "worker", Status: domain.AppStatusRunning},
+		},
+	}
+}
+
+// Scaling one service queues an app_service job for it alone and leaves
+// the app's status to the agent's report.
+func TestServiceActionQueuesJob(t *testing.T) {
+	appRepo := mocks.NewMockApplicationRepository(t)
+	appRepo.EXPECT().GetByID(mock.Anything, int64(1)).Return(reportedApp(), nil)
+
+	jobSvc := &fakeJobSvc{}
+	svc := application.NewService(appRepo, nil, jobSvc, &fakeDeploymentSvc{}, nil, nil, nil, nil)
+
+	require.NoError(t, svc.ServiceAction(context.Background(), 1, "worker", domain.ServiceScale, 3))
+
+	require.Len(t, jobSvc.created, 1)
+	assert.Equal(t, domain.JobTypeAppService, jobSvc.created[0].Type)
+
+	var payload domain.AppServicePayload
+	require.NoError(t, json.Unmarshal(jobSvc.created[0].Payload, &payload))
+	assert.Equal(t, "shop-1", payload.AppKey)
+	assert.Equal(t, "worker", payload.Service)
+	assert.Equal(t, domain.ServiceScale, payload.Action)
+	assert.Equal(t, 3, payload.Replicas)
+}
+
+func TestServiceActionRejectsUnknownService(t *testing.T) {
+	appRepo := mocks.NewMockApplicationRepository(t)
+	appRepo.EXPECT().GetByID(mock.Anything, int64(1)).Return(reportedApp(), nil)
+
+	jobSvc := &fakeJobSvc{}
+	svc := application.NewService(appRepo, nil, jobSvc, &fakeDeploymentSvc{}, nil, nil, nil, nil)
+
+	err := svc.ServiceAction(context.Background(), 1, "cron", domain.ServiceRestart, 0)
+	assert.ErrorIs(t, err, domain.ErrServiceNotFound)
+	assert.Empty(t, jobSvc.created)
+}
+
+func TestServiceActionRejectsInvalidRequests(t *testing.T) {
+	appRepo := mocks.NewMockApplicationRepository(t)
+	svc := application.NewService(appRepo, nil, &fakeJobSvc{}, &fakeDeploymentSvc{}, nil, nil, nil, nil)
+
+	cases := []struct {
+		service  string
+		action   domain.ServiceAction
+		replicas int
+	}{
+		{"", domain.ServiceStart, 0},
+		{"--all", domain.ServiceStop, 0},
+		{"web", "down", 0},
+		{"web", domain.ServiceScale, -1},
+		{"web", domain.ServiceScale, domain.MaxServiceReplicas + 1},
+	}
+	for _, tc := range cases {
+		err := svc.ServiceAction(context.Background(), 1, tc.service, tc.action, tc.replicas)
+		assert.ErrorIs(t, err, domain.ErrInvalidServiceAction, tc)
+	}
+}
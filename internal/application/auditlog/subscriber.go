@@ -25,6 +25,10 @@ func (s *Subscriber) Register(bus *event.Bus) {
 	bus.Subscribe("deployment_status_changed", s.OnDeploymentStatusChanged)
 	bus.Subscribe("application_created", s.OnApplicationCreated)
 	bus.Subscribe("server_status_changed", s.OnServerStatusChanged)
+	bus.Subscribe("job_cancelled", s.OnJobCancelled)
+	bus.Subscribe("deployment_auto_rollback", s.OnDeploymentAutoRollback)
+	bus.Subscribe("exec_session_started", s.OnExecSessionStarted)
+	bus.Subscribe("exec_session_ended", s.OnExecSessionEnded)
 }
 
 func (s *Subscriber) OnDeploymentCreated(event any) {
@@ -32,8 +36,11 @@ func (s *Subscriber) OnDeploymentCreated(event any) {
 	if !ok {
 		return
 	}
-	actor := e.DeployedBy
-	_, _ = s.svc.Create(context.Background(), &actor, "deployment.created", "deployment", strconv.FormatInt(e.DeploymentID, 10), map[string]any{
+	var actor *int64
+	if e.DeployedBy != 0 {
+		actor = &e.DeployedBy
+	}
+	_, _ = s.svc.Create(context.Background(), actor, "deployment.created", "deployment", strconv.FormatInt(e.DeploymentID, 10), map[string]any{
 		"application_id": e.ApplicationID,
 	})
 }
@@ -70,3 +77,58 @@ func (s *Subscriber) OnServerStatusChanged(event any) {
 	}
 	_, _ = s.svc.Create(context.Background(), nil, "server.status."+state, "server", e.ServerID.String(), nil)
 }
+
+func (s *Subscriber) OnJobCancelled(event any) {
+	e, ok := event.(domain.EventJobCancelled)
+	if !ok {
+		return
+	}
+	actor := e.CancelledBy
+	_, _ = s.svc.Create(context.Background(), &actor, "job.cancelled", "job", strconv.FormatInt(e.JobID, 10), map[string]any{
+		"type":           e.Type,
+		"application_id": e.ApplicationID,
+		"deployment_id":  e.DeploymentID,
+		"was_running":    e.WasRunning,
+	})
+}
+
+// OnDeploymentAutoRollback records every automatic rollback attempt, including
+// ones that could not bring the previous release back.
+func (s *Subscriber) OnDeploymentAutoRollback(event any) {
+	e, ok := event.(domain.EventDeploymentAutoRollback)
+	if !ok {
+		return
+	}
+	_, _ = s.svc.Create(context.Background(), nil, "deployment.auto_rollback", "deployment", strconv.FormatInt(e.DeploymentID, 10), map[string]any{
+		"application_id": e.ApplicationID,
+		"failed_image":   e.FailedImage,
+		"restored_image": e.RestoredImage,
+		"restored":       e.Restored,
+		"reason":         e.Reason,
+	})
+}
+
+func (s *Subscriber) OnExecSessionStarted(event any) {
+	e, ok := event.(domain.EventExecSessionStarted)
+	if !ok {
+		return
+	}
+	actor := e.UserID
+	_, _ = s.svc.Create(context.Background(), &actor, "application.exec.started", "application", strconv.FormatInt(e.ApplicationID, 10), map[string]any{
+		"session_id": e.SessionID,
+		"server_id":  e.ServerID.String(),
+		"service":    e.Service,
+		"shell":      e.Shell,
+	})
+}
+
+// OnExecSessionEnded records how a shell session ended along with everything
+// its terminal showed.
+func (s *Subscriber) OnExecSessionEnded(event any) {
+	e, ok := event.(domain.EventExecSessionEnded)
+	if !ok {
+		return
+	}
+	actor := e.UserID
+	_, _ = s.svc.Create(context.Background(), &actor, "application.exec.ended", "application", strconv.FormatInt(e.ApplicationID, 10), e)
+}
@@ -62,6 +62,36 @@ func TestSubscriberRecordsStatusAndServer(t *testing.T) {
 	}
 }
 
+func TestSubscriberRecordsExecSessionTranscript(t *testing.T) {
+	svc := NewService(&fakeAuditRepo{})
+	sub := NewSubscriber(svc)
+
+	started := domain.EventExecSessionStarted{SessionID: "e1", UserID: 5, ApplicationID: 3, ServerID: uuid.New(), Service: "web", Shell: "sh"}
+	code := 0
+	sub.OnExecSessionStarted(started)
+	sub.OnExecSessionEnded(domain.EventExecSessionEnded{
+		EventExecSessionStarted: started,
+		ExecResult:              domain.ExecResult{ExitCode: &code, Reason: "shell exited"},
+		Transcript:              "$ ls\r\napp\r\n",
+	})
+
+	res, _ := svc.List(context.Background(), domain.AuditLogListOptions{})
+	if len(res.Data) != 2 || res.Data[0].Action != "application.exec.started" || res.Data[1].Action != "application.exec.ended" {
+		t.Fatalf("unexpected logs: %+v", res.Data)
+	}
+	ended := res.Data[1]
+	if ended.ResourceID != "3" || ended.ActorID == nil || *ended.ActorID != 5 {
+		t.Fatalf("unexpected log: %+v", ended)
+	}
+	var details map[string]any
+	if err := json.Unmarshal(ended.Details, &details); err != nil {
+		t.Fatal(err)
+	}
+	if details["transcript"] != "$ ls\r\napp\r\n" || details["session_id"] != "e1" || details["exit_code"] != 0.0 {
+		t.Fatalf("unexpected details: %v", details)
+	}
+}
+
 func TestSubscriberIgnoresWrongPayload(t *testing.T) {
 	svc := NewService(&fakeAuditRepo{})
 	sub := NewSubscriber(svc)
@@ -87,3 +117,47 @@ func TestServiceCreateMarshalsDetails(t *testing.T) {
 		t.Fatalf("unexpected details: %s", got)
 	}
 }
+
+func TestSubscriberRecordsJobCancelled(t *testing.T) {
+	svc := NewService(&fakeAuditRepo{})
+	sub := NewSubscriber(svc)
+
+	sub.OnJobCancelled(domain.EventJobCancelled{JobID: 11, Type: domain.JobTypeAppDeploy, CancelledBy: 42, WasRunning: true})
+
+	res, _ := svc.List(context.Background(), domain.AuditLogListOptions{})
+	if len(res.Data) != 1 {
+		t.Fatalf("expected 1 log, got %d", len(res.Data))
+	}
+	l := res.Data[0]
+	if l.Action != "job.cancelled" || l.ResourceType != "job" || l.ResourceID != "11" {
+		t.Fatalf("unexpected log: %+v", l)
+	}
+	if l.ActorID == nil || *l.ActorID != 42 {
+		t.Fatalf("expected actor 42, got %v", l.ActorID)
+	}
+}
+
+func TestSubscriberRecordsAutoRollback(t *testing.T) {
+	svc := NewService(&fakeAuditRepo{})
+	sub := NewSubscriber(svc)
+
+	sub.OnDeploymentAutoRollback(domain.EventDeploymentAutoRollback{
+		DeploymentID:  12,
+		ApplicationID: 3,
+		FailedImage:   "demo-3:bbbb",
+		RestoredImage: "demo-3:aaaa",
+		Restored:      true,
+	})
+
+	res, _ := svc.List(context.Background(), domain.AuditLogListOptions{})
+	if len(res.Data) != 1 {
+		t.Fatalf("expected 1 log, got %d", len(res.Data))
+	}
+	l := res.Data[0]
+	if l.Action != "deployment.auto_rollback" || l.ResourceType != "deployment" || l.ResourceID != "12" {
+		t.Fatalf("unexpected log: %+v", l)
+	}
+	if l.ActorID != nil {
+		t.Fatalf("expected a system entry, got actor %v", *l.ActorID)
+	}
+}
@@ -0,0 +1,76 @@
+// Package buildvariable manages the per-application build args and build
+// secrets that `compose build` sees but the running app does not.
+package buildvariable
+
+import (
+	"context"
+
+	"horizonx/internal/domain"
+)
+
+type Service struct {
+	repo   domain.BuildVariableRepository
+	appSvc domain.ApplicationService
+}
+
+func NewService(repo domain.BuildVariableRepository, appSvc domain.ApplicationService) domain.BuildVariableService {
+	return &Service{repo: repo, appSvc: appSvc}
+}
+
+func (s *Service) List(ctx context.Context, appID int64) ([]domain.BuildVariable, error) {
+	if _, err := s.appSvc.GetByID(ctx, appID); err != nil {
+		return nil, err
+	}
+
+	vars, err := s.repo.List(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]domain.BuildVariable, 0, len(vars))
+	for _, v := range vars {
+		out = append(out, redact(v))
+	}
+	return out, nil
+}
+
+// Set creates or replaces one build variable. Image apps are pulled, not
+// built, so they are refused.
+func (s *Service) Set(ctx context.Context, appID int64, key string, req domain.BuildVariableRequest) (*domain.BuildVariable, error) {
+	if err := domain.ValidateBuildVariableKey(key); err != nil {
+		return nil, err
+	}
+
+	app, err := s.appSvc.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if app.IsImage() {
+		return nil, domain.ErrInvalidApplicationSource
+	}
+
+	v := &domain.BuildVariable{
+		ApplicationID: appID,
+		Key:           key,
+		Value:         req.Value,
+		IsSecret:      req.IsSecret,
+	}
+	if err := s.repo.Save(ctx, v); err != nil {
+		return nil, err
+	}
+
+	out := redact(*v)
+	return &out, nil
+}
+
+func (s *Service) Delete(ctx context.Context, appID int64, key string) error {
+	return s.repo.Delete(ctx, appID, key)
+}
+
+// redact drops secret values before they leave the service.
+func redact(v domain.BuildVariable) domain.BuildVariable {
+	if v.IsSecret {
+		v.Value = ""
+	}
+	return v
+}
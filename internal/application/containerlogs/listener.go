@@ -0,0 +1,33 @@
+package containerlogs
+
+import (
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+	"horizonx/internal/logger"
+)
+
+type Listener struct {
+	svc domain.ContainerLogService
+	log logger.Logger
+}
+
+func NewListener(svc domain.ContainerLogService, log logger.Logger) *Listener {
+	return &Listener{
+		svc: svc,
+		log: log,
+	}
+}
+
+func (l *Listener) Register(bus *event.Bus) {
+	bus.Subscribe("agent_connected", l.handleAgentConnected)
+}
+
+func (l *Listener) handleAgentConnected(event any) {
+	evt, ok := event.(domain.EventAgentConnected)
+	if !ok {
+		l.log.Warn("invalid event payload for agent_connected", "event", event)
+		return
+	}
+
+	l.svc.AgentConnected(evt.ServerID)
+}
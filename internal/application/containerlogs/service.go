@@ -0,0 +1,322 @@
+// Package containerlogs
+package containerlogs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+// fetchTimeout bounds a one-shot read: how long Fetch waits for the agent to
+// send every line.
+var fetchTimeout = 30 * time.Second
+
+// stream is the live `compose logs -f` behind one user WS channel.
+type stream struct {
+	id       string
+	channel  string
+	appID    int64
+	service  string
+	serverID uuid.UUID
+
+	// req is what the agent was sent; nil until the app was looked up.
+	req *domain.ContainerLogsRequest
+	// lastSeen is when the stream last relayed lines, where it resumes
+	// after its agent reconnects.
+	lastSeen time.Time
+}
+
+// fetch is a one-shot read waiting for its batches. The final batch comes
+// on done, which has room for it however far behind the reader is: the fetch
+// always learns that it is over.
+type fetch struct {
+	batches chan domain.ContainerLogsBatch
+	done    chan domain.ContainerLogsBatch
+	// dropped counts the batches that found batches full; s.mu guards it.
+	dropped int
+}
+
+type Service struct {
+	appSvc    domain.ApplicationService
+	serverSvc domain.ServerService
+	transport domain.ContainerLogsTransport
+	bus       *event.Bus
+	log       logger.Logger
+
+	mu      sync.Mutex
+	streams map[string]*stream // by channel
+	byID    map[string]*stream
+	fetches map[string]*fetch
+}
+
+func NewService(appSvc domain.ApplicationService, serverSvc domain.ServerService, transport domain.ContainerLogsTransport, bus *event.Bus, log logger.Logger) domain.ContainerLogService {
+	return &Service{
+		appSvc:    appSvc,
+		serverSvc: serverSvc,
+		transport: transport,
+		bus:       bus,
+		log:       log,
+
+		streams: make(map[string]*stream),
+		byID:    make(map[string]*stream),
+		fetches: make(map[string]*fetch),
+	}
+}
+
+func (s *Service) ChannelActive(channel string, active bool) {
+	appID, service, ok := domain.ParseContainerLogsChannel(channel)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, running := s.streams[channel]
+
+	if !active {
+		if !running {
+			return
+		}
+		s.forget(st)
+		if st.req != nil {
+			s.stop(st.serverID, st.id)
+		}
+		return
+	}
+
+	if running {
+		return
+	}
+
+	st = &stream{id: uuid.NewString(), channel: channel, appID: appID, service: service}
+	s.streams[channel] = st
+	s.byID[st.id] = st
+
+	// The hub calls in from its loop: look the app up elsewhere.
+	go s.start(st)
+}
+
+// start looks up the stream's app and asks its agent for the logs, unless
+// the channel was left in the meantime.
+func (s *Service) start(st *stream) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	app, err := s.appSvc.GetByID(ctx, st.appID)
+	if err != nil {
+		s.log.Warn("container logs: failed to load application", "application_id", st.appID, "error", err)
+
+		s.mu.Lock()
+		current := s.streams[st.channel] == st
+		if current {
+			s.forget(st)
+		}
+		s.mu.Unlock()
+
+		if current {
+			s.publish(domain.EventContainerLogs{Channel: st.channel, Done: true, Error: err.Error()})
+		}
+		return
+	}
+
+	req := s.request(st.id, app, st.service)
+	req.Tail = domain.ContainerLogsStreamTail
+	req.Follow = true
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.streams[st.channel] != st {
+		return
+	}
+
+	st.serverID = app.ServerID
+	st.req = &req
+	st.lastSeen = time.Now()
+
+	if err := s.transport.StartContainerLogs(st.serverID, req); err != nil {
+		s.log.Error("container logs: failed to start stream", "channel", st.channel, "error", err)
+	}
+}
+
+func (s *Service) AgentConnected(serverID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, st := range s.streams {
+		if st.req == nil || st.serverID != serverID {
+			continue
+		}
+
+		// Pick up where the stream was cut off instead of replaying its
+		// tail.
+		req := *st.req
+		req.Tail = 0
+		req.Since = st.lastSeen.UTC().Format(time.RFC3339Nano)
+
+		if err := s.transport.StartContainerLogs(serverID, req); err != nil {
+			s.log.Error("container logs: failed to resume stream", "channel", st.channel, "error", err)
+		}
+	}
+}
+
+func (s *Service) HandleBatch(serverID uuid.UUID, batch domain.ContainerLogsBatch) {
+	s.mu.Lock()
+
+	if f, ok := s.fetches[batch.ID]; ok {
+		if batch.Done {
+			s.mu.Unlock()
+			select {
+			case f.done <- batch:
+			default: // a repeated done marker
+			}
+			return
+		}
+		select {
+		case f.batches <- batch:
+		default:
+			f.dropped++
+			s.log.Warn("container logs: fetch is not keeping up, dropping batch", "id", batch.ID)
+		}
+		s.mu.Unlock()
+		return
+	}
+
+	st, ok := s.byID[batch.ID]
+	if !ok || st.serverID != serverID {
+		s.mu.Unlock()
+		// Nobody watches this stream anymore (e.g. the server restarted
+		// while it ran): tell the agent to stop it.
+		if !batch.Done {
+			s.stop(serverID, batch.ID)
+		}
+		return
+	}
+
+	st.lastSeen = time.Now()
+	if batch.Done {
+		s.forget(st)
+	}
+	s.mu.Unlock()
+
+	s.publish(domain.EventContainerLogs{
+		Channel: st.channel,
+		Lines:   batch.Lines,
+		Done:    batch.Done,
+		Error:   batch.Error,
+	})
+}
+
+func (s *Service) Fetch(ctx context.Context, appID int64, query domain.ContainerLogsQuery) ([]domain.ContainerLogLine, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	app, err := s.appSvc.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := s.serverSvc.GetByID(ctx, app.ServerID)
+	if err != nil {
+		return nil, err
+	}
+	if !srv.IsOnline {
+		return nil, domain.ErrServerOffline
+	}
+
+	req := s.request(uuid.NewString(), app, query.Service)
+	req.Tail = query.Tail
+	req.Since = query.Since
+	req.Until = query.Until
+
+	f := &fetch{
+		batches: make(chan domain.ContainerLogsBatch, 64),
+		done:    make(chan domain.ContainerLogsBatch, 1),
+	}
+
+	s.mu.Lock()
+	s.fetches[req.ID] = f
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.fetches, req.ID)
+		s.mu.Unlock()
+	}()
+
+	if err := s.transport.StartContainerLogs(app.ServerID, req); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	lines := []domain.ContainerLogLine{}
+	for {
+		select {
+		case <-ctx.Done():
+			s.stop(app.ServerID, req.ID)
+			return nil, ctx.Err()
+
+		case batch := <-f.batches:
+			lines = append(lines, batch.Lines...)
+
+		case batch := <-f.done:
+			// The batches before it were all queued by now.
+			for len(f.batches) > 0 {
+				lines = append(lines, (<-f.batches).Lines...)
+			}
+			lines = append(lines, batch.Lines...)
+			if batch.Error != "" {
+				return nil, fmt.Errorf("%w: %s", domain.ErrContainerLogsFailed, batch.Error)
+			}
+
+			s.mu.Lock()
+			dropped := f.dropped
+			s.mu.Unlock()
+			if dropped > 0 {
+				return nil, fmt.Errorf("%w: %d batches dropped while reading them", domain.ErrContainerLogsFailed, dropped)
+			}
+			return lines, nil
+		}
+	}
+}
+
+func (s *Service) request(id string, app *domain.Application, service string) domain.ContainerLogsRequest {
+	return domain.ContainerLogsRequest{
+		ID: id,
+		AppInfo: domain.AppInfo{
+			ApplicationID: app.ID,
+			AppKey:        domain.GetAppKey(app),
+			Compose:       app.Compose,
+		},
+		Service: service,
+	}
+}
+
+// forget drops st; the caller holds s.mu.
+func (s *Service) forget(st *stream) {
+	delete(s.streams, st.channel)
+	delete(s.byID, st.id)
+}
+
+func (s *Service) stop(serverID uuid.UUID, id string) {
+	if err := s.transport.StopContainerLogs(serverID, id); err != nil {
+		s.log.Error("container logs: failed to stop stream", "id", id, "error", err)
+	}
+}
+
+func (s *Service) publish(evt domain.EventContainerLogs) {
+	if s.bus != nil {
+		s.bus.Publish("container_logs_received", evt)
+	}
+}
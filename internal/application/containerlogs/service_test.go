@@ -0,0 +1,214 @@
+package containerlogs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+
+	"github.com/google/uuid"
+)
+
+type fakeAppService struct {
+	domain.ApplicationService
+	app *domain.Application
+}
+
+func (f *fakeAppService) GetByID(_ context.Context, appID int64) (*domain.Application, error) {
+	if f.app == nil || f.app.ID != appID {
+		return nil, domain.ErrApplicationNotFound
+	}
+	return f.app, nil
+}
+
+type fakeServerService struct {
+	domain.ServerService
+	srv *domain.Server
+}
+
+func (f *fakeServerService) GetByID(context.Context, uuid.UUID) (*domain.Server, error) {
+	return f.srv, nil
+}
+
+// fakeTransport records what would be sent to the agent. reply, when set,
+// answers a start request like the agent would.
+type fakeTransport struct {
+	starts chan domain.ContainerLogsRequest
+	stops  chan string
+	reply  func(req domain.ContainerLogsRequest)
+}
+
+func (f *fakeTransport) StartContainerLogs(_ uuid.UUID, req domain.ContainerLogsRequest) error {
+	f.starts <- req
+	if f.reply != nil {
+		go f.reply(req)
+	}
+	return nil
+}
+
+func (f *fakeTransport) StopContainerLogs(_ uuid.UUID, id string) error {
+	f.stops <- id
+	return nil
+}
+
+type noopLog struct{}
+
+func (noopLog) Debug(string, ...any) {}
+func (noopLog) Info(string, ...any)  {}
+func (noopLog) Warn(string, ...any)  {}
+func (noopLog) Error(string, ...any) {}
+
+func newTestService() (*Service, *fakeTransport, *domain.Server, chan domain.EventContainerLogs) {
+	srv := &domain.Server{ID: uuid.New(), IsOnline: true}
+	app := &domain.Application{ID: 7, ServerID: srv.ID, RepoName: "shop"}
+	transport := &fakeTransport{
+		starts: make(chan domain.ContainerLogsRequest, 8),
+		stops:  make(chan string, 8),
+	}
+
+	bus := event.New()
+	events := make(chan domain.EventContainerLogs, 8)
+	bus.Subscribe("container_logs_received", func(e any) { events <- e.(domain.EventContainerLogs) })
+
+	svc := NewService(&fakeAppService{app: app}, &fakeServerService{srv: srv}, transport, bus, noopLog{})
+	return svc.(*Service), transport, srv, events
+}
+
+func receive[T any](t *testing.T, ch chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing received")
+		var zero T
+		return zero
+	}
+}
+
+func TestStreamFollowsChannelSubscribers(t *testing.T) {
+	svc, transport, srv, events := newTestService()
+	channel := domain.ContainerLogsChannel(7, "web")
+
+	svc.ChannelActive(channel, true)
+	req := receive(t, transport.starts)
+	if req.AppKey != "shop-7" || req.Service != "web" || !req.Follow || req.Tail != domain.ContainerLogsStreamTail {
+		t.Fatalf("start = %+v, want a followed tail of shop-7's web service", req)
+	}
+
+	svc.HandleBatch(srv.ID, domain.ContainerLogsBatch{ID: req.ID, Lines: []domain.ContainerLogLine{{Line: "hello"}}})
+	if evt := receive(t, events); evt.Channel != channel || len(evt.Lines) != 1 {
+		t.Fatalf("event = %+v, want the line on %s", evt, channel)
+	}
+
+	// The agent reconnects: the stream resumes where it was cut off.
+	svc.AgentConnected(srv.ID)
+	resumed := receive(t, transport.starts)
+	if resumed.ID != req.ID || resumed.Tail != 0 || resumed.Since == "" {
+		t.Fatalf("resume = %+v, want the same stream since its last lines", resumed)
+	}
+
+	svc.ChannelActive(channel, false)
+	if id := receive(t, transport.stops); id != req.ID {
+		t.Fatalf("stopped %s, want %s", id, req.ID)
+	}
+
+	// A straggler batch of the dropped stream stops it again, quietly.
+	svc.HandleBatch(srv.ID, domain.ContainerLogsBatch{ID: req.ID, Lines: []domain.ContainerLogLine{{Line: "late"}}})
+	receive(t, transport.stops)
+	select {
+	case evt := <-events:
+		t.Fatalf("dropped stream published %+v", evt)
+	default:
+	}
+}
+
+func TestStreamIgnoresOtherChannelsAndReportsMissingApp(t *testing.T) {
+	svc, transport, _, events := newTestService()
+
+	svc.ChannelActive("deployment:7", true)
+	svc.ChannelActive("app:7:container-logs:--help", true)
+	svc.ChannelActive(domain.ContainerLogsChannel(99, ""), true)
+
+	evt := receive(t, events)
+	if evt.Channel != "app:99:container-logs" || !evt.Done || evt.Error == "" {
+		t.Fatalf("event = %+v, want the missing app's stream to end with an error", evt)
+	}
+	select {
+	case req := <-transport.starts:
+		t.Fatalf("started %+v, want nothing", req)
+	default:
+	}
+}
+
+func TestFetchCollectsBatchesUntilDone(t *testing.T) {
+	svc, transport, srv, _ := newTestService()
+	transport.reply = func(req domain.ContainerLogsRequest) {
+		svc.HandleBatch(srv.ID, domain.ContainerLogsBatch{ID: req.ID, Lines: []domain.ContainerLogLine{{Line: "a"}}})
+		svc.HandleBatch(srv.ID, domain.ContainerLogsBatch{ID: req.ID, Lines: []domain.ContainerLogLine{{Line: "b"}}, Done: true})
+	}
+
+	lines, err := svc.Fetch(context.Background(), 7, domain.ContainerLogsQuery{Since: "15m"})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(lines) != 2 || lines[0].Line != "a" || lines[1].Line != "b" {
+		t.Fatalf("lines = %+v, want a then b", lines)
+	}
+
+	req := receive(t, transport.starts)
+	if req.Follow || req.Tail != domain.ContainerLogsDefaultTail || req.Since != "15m" {
+		t.Fatalf("request = %+v, want a one-shot read with the default tail", req)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	svc, transport, srv, _ := newTestService()
+
+	if _, err := svc.Fetch(context.Background(), 7, domain.ContainerLogsQuery{Until: "yesterday"}); !errors.Is(err, domain.ErrInvalidContainerLogsQuery) {
+		t.Fatalf("bad until: err = %v, want ErrInvalidContainerLogsQuery", err)
+	}
+
+	transport.reply = func(req domain.ContainerLogsRequest) {
+		svc.HandleBatch(srv.ID, domain.ContainerLogsBatch{ID: req.ID, Done: true, Error: "app is not deployed on this server"})
+	}
+	if _, err := svc.Fetch(context.Background(), 7, domain.ContainerLogsQuery{}); !errors.Is(err, domain.ErrContainerLogsFailed) {
+		t.Fatalf("agent error: err = %v, want ErrContainerLogsFailed", err)
+	}
+
+	srv.IsOnline = false
+	if _, err := svc.Fetch(context.Background(), 7, domain.ContainerLogsQuery{}); !errors.Is(err, domain.ErrServerOffline) {
+		t.Fatalf("offline server: err = %v, want ErrServerOffline", err)
+	}
+}
+
+// A fetch that falls behind loses data batches, never the done marker: it
+// learns it is over, and that it missed lines, instead of timing out.
+func TestHandleBatchAlwaysDeliversDoneToFetch(t *testing.T) {
+	svc, _, srv, _ := newTestService()
+
+	f := &fetch{
+		batches: make(chan domain.ContainerLogsBatch, 1),
+		done:    make(chan domain.ContainerLogsBatch, 1),
+	}
+	svc.fetches["r1"] = f
+
+	svc.HandleBatch(srv.ID, domain.ContainerLogsBatch{ID: "r1", Lines: []domain.ContainerLogLine{{Line: "a"}}})
+	svc.HandleBatch(srv.ID, domain.ContainerLogsBatch{ID: "r1", Lines: []domain.ContainerLogLine{{Line: "b"}}})
+	svc.HandleBatch(srv.ID, domain.ContainerLogsBatch{ID: "r1", Done: true})
+
+	if f.dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", f.dropped)
+	}
+	select {
+	case batch := <-f.done:
+		if !batch.Done {
+			t.Fatalf("done channel got %+v", batch)
+		}
+	default:
+		t.Fatal("done marker dropped")
+	}
+}
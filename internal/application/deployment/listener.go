@@ -95,11 +95,18 @@ func (l *Listener) handleJobFinished(event any) {
 	defer cancel()
 
 	status := domain.DeploymentSuccess
-	if evt.Status == domain.JobFailed {
+	switch evt.Status {
+	case domain.JobFailed, domain.JobExpired:
 		status = domain.DeploymentFailed
+	case domain.JobCancelled:
+		status = domain.DeploymentCancelled
 	}
 
-	_ = l.updateStatus(ctx, *evt.DeploymentID, status)
+	// The agent already reported an automatic rollback: keep rolled_back
+	// rather than overwriting it with the failure that triggered it.
+	if status != domain.DeploymentFailed || !l.isRolledBack(ctx, *evt.DeploymentID) {
+		_ = l.updateStatus(ctx, *evt.DeploymentID, status)
+	}
 
 	if err := l.svc.Finish(ctx, *evt.DeploymentID); err != nil {
 		l.log.Error("failed to finish deployment", "deployment_id", *evt.DeploymentID)
@@ -120,3 +127,11 @@ func (l *Listener) updateStatus(ctx context.Context, deploymentID int64, status
 
 	return nil
 }
+
+func (l *Listener) isRolledBack(ctx context.Context, deploymentID int64) bool {
+	d, err := l.svc.GetByID(ctx, deploymentID)
+	if err != nil {
+		return false
+	}
+	return d.Status == domain.DeploymentRolledBack
+}
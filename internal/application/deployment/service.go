@@ -87,8 +87,11 @@ func (s *Service) Create(ctx context.Context, req domain.DeploymentCreateRequest
 	deployment := &domain.Deployment{
 		ApplicationID: req.ApplicationID,
 		Branch:        req.Branch,
+		Ref:           req.Ref,
 		DeployedBy:    req.DeployedBy,
 		Status:        domain.DeploymentPending,
+
+		RollbackOfDeploymentID: req.RollbackOfDeploymentID,
 	}
 
 	created, err := s.repo.Create(ctx, deployment)
@@ -97,10 +100,14 @@ func (s *Service) Create(ctx context.Context, req domain.DeploymentCreateRequest
 	}
 
 	if s.bus != nil {
+		var deployedBy int64
+		if created.DeployedBy != nil {
+			deployedBy = *created.DeployedBy
+		}
 		s.bus.Publish("deployment_created", domain.EventDeploymentCreated{
 			DeploymentID:  created.ID,
 			ApplicationID: created.ApplicationID,
-			DeployedBy:    *created.DeployedBy,
+			DeployedBy:    deployedBy,
 			TriggeredAt:   created.TriggeredAt,
 		})
 	}
@@ -178,11 +185,45 @@ func (s *Service) UpdateCommitInfo(ctx context.Context, deploymentID int64, comm
 	return nil
 }
 
+func (s *Service) UpdateImageDigest(ctx context.Context, deploymentID int64, imageDigest string) error {
+	_, err := s.repo.UpdateImageDigest(ctx, deploymentID, imageDigest)
+	return err
+}
+
 func (s *Service) UpdateEnvSnapshot(ctx context.Context, deploymentID int64, snapshot map[string]string) error {
 	_, err := s.repo.UpdateEnvSnapshot(ctx, deploymentID, snapshot)
 	return err
 }
 
+// RecordAutoRollback stores the agent's report of an automatic rollback. A
+// successful one ends the deployment as rolled_back; either way the attempt
+// is published so webhooks and the audit log can say it happened.
+func (s *Service) RecordAutoRollback(ctx context.Context, deploymentID int64, req domain.DeploymentAutoRollbackRequest) error {
+	d, err := s.repo.GetByID(ctx, deploymentID)
+	if err != nil {
+		return err
+	}
+
+	if req.Restored {
+		if err := s.UpdateStatus(ctx, deploymentID, domain.DeploymentRolledBack); err != nil {
+			return err
+		}
+	}
+
+	if s.bus != nil {
+		s.bus.Publish("deployment_auto_rollback", domain.EventDeploymentAutoRollback{
+			DeploymentID:  d.ID,
+			ApplicationID: d.ApplicationID,
+			FailedImage:   req.FailedImage,
+			RestoredImage: req.RestoredImage,
+			Restored:      req.Restored,
+			Reason:        req.Reason,
+		})
+	}
+
+	return nil
+}
+
 func (s *Service) Diff(ctx context.Context, deploymentID int64) (*domain.DeploymentDiff, error) {
 	d, err := s.repo.GetByID(ctx, deploymentID)
 	if err != nil {
@@ -32,6 +32,9 @@ func (f *fakeDeploymentRepo) UpdateStatus(ctx context.Context, id int64, s domai
 func (f *fakeDeploymentRepo) UpdateCommitInfo(ctx context.Context, id int64, hash, msg string) (*domain.Deployment, error) {
 	return nil, nil
 }
+func (f *fakeDeploymentRepo) UpdateImageDigest(ctx context.Context, id int64, digest string) (*domain.Deployment, error) {
+	return nil, nil
+}
 func (f *fakeDeploymentRepo) UpdateEnvSnapshot(ctx context.Context, id int64, snapshot map[string]string) (*domain.Deployment, error) {
 	raw, _ := json.Marshal(snapshot)
 	if d, ok := f.deployments[id]; ok {
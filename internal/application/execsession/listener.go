@@ -0,0 +1,35 @@
+package execsession
+
+import (
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+	"horizonx/internal/logger"
+)
+
+type Listener struct {
+	svc domain.ExecService
+	log logger.Logger
+}
+
+func NewListener(svc domain.ExecService, log logger.Logger) *Listener {
+	return &Listener{
+		svc: svc,
+		log: log,
+	}
+}
+
+func (l *Listener) Register(bus *event.Bus) {
+	bus.Subscribe("server_status_changed", l.handleServerStatusChanged)
+}
+
+func (l *Listener) handleServerStatusChanged(event any) {
+	evt, ok := event.(domain.EventServerStatusChanged)
+	if !ok {
+		l.log.Warn("invalid event payload for server_status_changed", "event", event)
+		return
+	}
+
+	if !evt.IsOnline {
+		l.svc.ServerOffline(evt.ServerID)
+	}
+}
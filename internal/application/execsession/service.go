@@ -0,0 +1,323 @@
+// Package execsession
+package execsession
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+	"horizonx/internal/logger"
+
+	"github.com/google/uuid"
+)
+
+// outputBuffer is how many output chunks may wait for a client that is not
+// reading; the session is ended rather than stall the agent socket.
+const outputBuffer = 1024
+
+type Service struct {
+	appSvc      domain.ApplicationService
+	serverSvc   domain.ServerService
+	transport   domain.ExecTransport
+	bus         *event.Bus
+	log         logger.Logger
+	idleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+func NewService(appSvc domain.ApplicationService, serverSvc domain.ServerService, transport domain.ExecTransport, bus *event.Bus, log logger.Logger, idleTimeout time.Duration) domain.ExecService {
+	return &Service{
+		appSvc:      appSvc,
+		serverSvc:   serverSvc,
+		transport:   transport,
+		bus:         bus,
+		log:         log,
+		idleTimeout: idleTimeout,
+
+		sessions: make(map[string]*session),
+	}
+}
+
+func (s *Service) Open(ctx context.Context, userID int64, appID int64, opts domain.ExecOptions) (domain.ExecSession, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	app, err := s.appSvc.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := s.serverSvc.GetByID(ctx, app.ServerID)
+	if err != nil {
+		return nil, err
+	}
+	if !srv.IsOnline {
+		return nil, domain.ErrServerOffline
+	}
+
+	sess := &session{
+		svc: s,
+		info: domain.EventExecSessionStarted{
+			SessionID:     uuid.NewString(),
+			UserID:        userID,
+			ApplicationID: app.ID,
+			ServerID:      app.ServerID,
+			Service:       opts.Service,
+			Shell:         opts.Shell,
+		},
+		startedAt:  time.Now(),
+		lastActive: time.Now(),
+		output:     make(chan []byte, outputBuffer),
+	}
+
+	s.mu.Lock()
+	s.sessions[sess.info.SessionID] = sess
+	s.mu.Unlock()
+
+	err = s.transport.StartExec(app.ServerID, domain.ExecStart{
+		ID: sess.info.SessionID,
+		AppInfo: domain.AppInfo{
+			ApplicationID: app.ID,
+			AppKey:        domain.GetAppKey(app),
+			Compose:       app.Compose,
+		},
+		Service: opts.Service,
+		Shell:   opts.Shell,
+		Cols:    opts.Cols,
+		Rows:    opts.Rows,
+	})
+	if err != nil {
+		s.forget(sess.info.SessionID)
+		return nil, err
+	}
+
+	s.publish("exec_session_started", sess.info)
+	s.log.Info("exec session opened", "id", sess.info.SessionID, "user_id", userID, "application_id", app.ID, "service", opts.Service)
+
+	if s.idleTimeout > 0 {
+		sess.mu.Lock()
+		sess.idle = time.AfterFunc(s.idleTimeout, sess.checkIdle)
+		sess.mu.Unlock()
+	}
+
+	return sess, nil
+}
+
+func (s *Service) HandleOutput(serverID uuid.UUID, output domain.ExecOutput) {
+	sess, ok := s.lookup(serverID, output.ID)
+	if !ok {
+		// Nobody is attached anymore (e.g. the server restarted while the
+		// shell ran): tell the agent to kill it.
+		s.closeOnAgent(serverID, output.ID)
+		return
+	}
+
+	sess.deliver(output.Data)
+}
+
+func (s *Service) HandleExit(serverID uuid.UUID, exit domain.ExecExit) {
+	sess, ok := s.lookup(serverID, exit.ID)
+	if !ok {
+		return
+	}
+
+	result := domain.ExecResult{Reason: "shell exited"}
+	if exit.Error != "" {
+		result.Reason = exit.Error
+	} else {
+		code := exit.ExitCode
+		result.ExitCode = &code
+	}
+	sess.end(result, false)
+}
+
+func (s *Service) ServerOffline(serverID uuid.UUID) {
+	s.mu.Lock()
+	var ended []*session
+	for _, sess := range s.sessions {
+		if sess.info.ServerID == serverID {
+			ended = append(ended, sess)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sess := range ended {
+		sess.end(domain.ExecResult{Reason: "agent disconnected"}, false)
+	}
+}
+
+func (s *Service) lookup(serverID uuid.UUID, id string) (*session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok || sess.info.ServerID != serverID {
+		return nil, false
+	}
+	return sess, true
+}
+
+func (s *Service) forget(id string) {
+	s.mu.Lock()
+	delete(s.sessions, id)
+	s.mu.Unlock()
+}
+
+func (s *Service) closeOnAgent(serverID uuid.UUID, id string) {
+	if err := s.transport.CloseExec(serverID, id); err != nil {
+		s.log.Error("exec: failed to close session", "id", id, "error", err)
+	}
+}
+
+func (s *Service) publish(name string, evt any) {
+	if s.bus != nil {
+		s.bus.Publish(name, evt)
+	}
+}
+
+// session is one open shell; it implements domain.ExecSession.
+type session struct {
+	svc       *Service
+	info      domain.EventExecSessionStarted
+	startedAt time.Time
+
+	mu     sync.Mutex
+	idle   *time.Timer
+	output chan []byte
+	// lastActive is when the user last typed. Output does not count: a
+	// `tail -f` left running would otherwise keep the shell open forever.
+	lastActive time.Time
+	transcript bytes.Buffer
+	truncated  bool
+	ended      bool
+	result     domain.ExecResult
+}
+
+func (e *session) ID() string { return e.info.SessionID }
+
+func (e *session) Output() <-chan []byte { return e.output }
+
+func (e *session) Result() domain.ExecResult {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.result
+}
+
+func (e *session) Write(data []byte) error {
+	e.mu.Lock()
+	if e.ended {
+		e.mu.Unlock()
+		return domain.ErrExecSessionClosed
+	}
+	e.lastActive = time.Now()
+	e.mu.Unlock()
+
+	return e.svc.transport.SendExecInput(e.info.ServerID, domain.ExecInput{ID: e.info.SessionID, Data: data})
+}
+
+func (e *session) Resize(cols, rows uint16) error {
+	if cols == 0 || rows == 0 {
+		return domain.ErrInvalidExecOptions
+	}
+
+	e.mu.Lock()
+	ended := e.ended
+	e.mu.Unlock()
+	if ended {
+		return domain.ErrExecSessionClosed
+	}
+
+	return e.svc.transport.ResizeExec(e.info.ServerID, domain.ExecResize{ID: e.info.SessionID, Cols: cols, Rows: rows})
+}
+
+func (e *session) Close(reason string) {
+	e.end(domain.ExecResult{Reason: reason}, true)
+}
+
+// deliver hands output to the client and records it in the transcript.
+func (e *session) deliver(data []byte) {
+	e.mu.Lock()
+	if e.ended {
+		e.mu.Unlock()
+		return
+	}
+
+	if room := domain.ExecTranscriptLimit - e.transcript.Len(); room < len(data) {
+		e.transcript.Write(data[:max(room, 0)])
+		e.truncated = true
+	} else {
+		e.transcript.Write(data)
+	}
+
+	select {
+	case e.output <- data:
+		e.mu.Unlock()
+	default:
+		e.mu.Unlock()
+		e.end(domain.ExecResult{Reason: "client is not keeping up"}, true)
+	}
+}
+
+func (e *session) checkIdle() {
+	e.mu.Lock()
+	if e.ended {
+		e.mu.Unlock()
+		return
+	}
+	if idleFor := time.Since(e.lastActive); idleFor < e.svc.idleTimeout {
+		e.idle.Reset(e.svc.idleTimeout - idleFor)
+		e.mu.Unlock()
+		return
+	}
+	e.mu.Unlock()
+
+	e.end(domain.ExecResult{Reason: "idle timeout"}, true)
+}
+
+// end closes the session once: it stops relaying, kills the shell when the
+// agent still runs it, and publishes the transcript for the audit log.
+func (e *session) end(result domain.ExecResult, closeAgent bool) {
+	e.mu.Lock()
+	if e.ended {
+		e.mu.Unlock()
+		return
+	}
+	e.ended = true
+	e.result = result
+	close(e.output)
+	if e.idle != nil {
+		e.idle.Stop()
+	}
+	transcript := e.transcript.String()
+	truncated := e.truncated
+	e.mu.Unlock()
+
+	e.svc.forget(e.info.SessionID)
+	if closeAgent {
+		e.svc.closeOnAgent(e.info.ServerID, e.info.SessionID)
+	}
+
+	e.svc.log.Info("exec session ended", "id", e.info.SessionID, "reason", result.Reason)
+	e.svc.publish("exec_session_ended", domain.EventExecSessionEnded{
+		EventExecSessionStarted: e.info,
+		StartedAt:               e.startedAt,
+		EndedAt:                 time.Now(),
+		ExecResult:              result,
+		Transcript:              sanitizeTranscript(transcript),
+		TranscriptTruncated:     truncated,
+	})
+}
+
+// sanitizeTranscript makes raw terminal output storable as JSON text:
+// Postgres rejects NUL in jsonb, and split multibyte characters are not
+// valid UTF-8.
+func sanitizeTranscript(s string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(s, "\uFFFD"), "\x00", "")
+}
@@ -0,0 +1,268 @@
+package execsession
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/event"
+
+	"github.com/google/uuid"
+)
+
+type fakeAppService struct {
+	domain.ApplicationService
+	app *domain.Application
+}
+
+func (f *fakeAppService) GetByID(_ context.Context, appID int64) (*domain.Application, error) {
+	if f.app == nil || f.app.ID != appID {
+		return nil, domain.ErrApplicationNotFound
+	}
+	return f.app, nil
+}
+
+type fakeServerService struct {
+	domain.ServerService
+	srv *domain.Server
+}
+
+func (f *fakeServerService) GetByID(context.Context, uuid.UUID) (*domain.Server, error) {
+	return f.srv, nil
+}
+
+// fakeTransport records what would be sent to the agent.
+type fakeTransport struct {
+	starts  chan domain.ExecStart
+	inputs  chan domain.ExecInput
+	resizes chan domain.ExecResize
+	closes  chan string
+}
+
+func (f *fakeTransport) StartExec(_ uuid.UUID, req domain.ExecStart) error {
+	f.starts <- req
+	return nil
+}
+
+func (f *fakeTransport) SendExecInput(_ uuid.UUID, input domain.ExecInput) error {
+	f.inputs <- input
+	return nil
+}
+
+func (f *fakeTransport) ResizeExec(_ uuid.UUID, resize domain.ExecResize) error {
+	f.resizes <- resize
+	return nil
+}
+
+func (f *fakeTransport) CloseExec(_ uuid.UUID, id string) error {
+	f.closes <- id
+	return nil
+}
+
+type noopLog struct{}
+
+func (noopLog) Debug(string, ...any) {}
+func (noopLog) Info(string, ...any)  {}
+func (noopLog) Warn(string, ...any)  {}
+func (noopLog) Error(string, ...any) {}
+
+func newTestService(idleTimeout time.Duration) (*Service, *fakeTransport, *domain.Server, chan domain.EventExecSessionEnded) {
+	srv := &domain.Server{ID: uuid.New(), IsOnline: true}
+	app := &domain.Application{ID: 7, ServerID: srv.ID, RepoName: "shop"}
+	transport := &fakeTransport{
+		starts:  make(chan domain.ExecStart, 8),
+		inputs:  make(chan domain.ExecInput, 8),
+		resizes: make(chan domain.ExecResize, 8),
+		closes:  make(chan string, 8),
+	}
+
+	bus := event.New()
+	ended := make(chan domain.EventExecSessionEnded, 8)
+	bus.Subscribe("exec_session_ended", func(e any) { ended <- e.(domain.EventExecSessionEnded) })
+
+	svc := NewService(&fakeAppService{app: app}, &fakeServerService{srv: srv}, transport, bus, noopLog{}, idleTimeout)
+	return svc.(*Service), transport, srv, ended
+}
+
+func receive[T any](t *testing.T, ch chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing received")
+		var zero T
+		return zero
+	}
+}
+
+func TestSessionRelaysAndRecordsTranscript(t *testing.T) {
+	svc, transport, srv, ended := newTestService(time.Hour)
+
+	sess, err := svc.Open(context.Background(), 5, 7, domain.ExecOptions{Service: "web"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	req := receive(t, transport.starts)
+	if req.ID != sess.ID() || req.AppKey != "shop-7" || req.Service != "web" || req.Shell != "sh" || req.Cols != 80 || req.Rows != 24 {
+		t.Fatalf("start = %+v, want sh on an 80x24 terminal in shop-7's web service", req)
+	}
+
+	if err := sess.Write([]byte("ls\r")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if input := receive(t, transport.inputs); string(input.Data) != "ls\r" {
+		t.Fatalf("input = %q", input.Data)
+	}
+	if err := sess.Resize(120, 40); err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	if resize := receive(t, transport.resizes); resize.Cols != 120 || resize.Rows != 40 {
+		t.Fatalf("resize = %+v", resize)
+	}
+
+	svc.HandleOutput(srv.ID, domain.ExecOutput{ID: sess.ID(), Data: []byte("ls\r\napp\r\n")})
+	if out := <-sess.Output(); string(out) != "ls\r\napp\r\n" {
+		t.Fatalf("output = %q", out)
+	}
+
+	// Output for another server's session is not relayed.
+	svc.HandleOutput(uuid.New(), domain.ExecOutput{ID: sess.ID(), Data: []byte("spoofed")})
+	receive(t, transport.closes)
+
+	svc.HandleExit(srv.ID, domain.ExecExit{ID: sess.ID(), ExitCode: 0})
+	if _, open := <-sess.Output(); open {
+		t.Fatal("output is still open after the shell exited")
+	}
+
+	evt := receive(t, ended)
+	if evt.UserID != 5 || evt.ApplicationID != 7 || evt.Transcript != "ls\r\napp\r\n" || evt.ExitCode == nil || *evt.ExitCode != 0 {
+		t.Fatalf("ended = %+v, want user 5's transcript and exit code 0", evt)
+	}
+	if err := sess.Write([]byte("x")); !errors.Is(err, domain.ErrExecSessionClosed) {
+		t.Fatalf("Write after exit: err = %v, want ErrExecSessionClosed", err)
+	}
+}
+
+func TestSessionEndsWhenIdle(t *testing.T) {
+	svc, transport, _, ended := newTestService(50 * time.Millisecond)
+
+	sess, err := svc.Open(context.Background(), 5, 7, domain.ExecOptions{Service: "web"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if id := receive(t, transport.closes); id != sess.ID() {
+		t.Fatalf("closed %s, want %s", id, sess.ID())
+	}
+	if evt := receive(t, ended); evt.Reason != "idle timeout" || evt.ExitCode != nil {
+		t.Fatalf("ended = %+v, want an idle timeout", evt)
+	}
+}
+
+// Output from a command left running does not keep an unattended session
+// open; typing does.
+func TestSessionIdleIgnoresOutput(t *testing.T) {
+	svc, transport, srv, ended := newTestService(100 * time.Millisecond)
+
+	sess, err := svc.Open(context.Background(), 5, 7, domain.ExecOptions{Service: "web"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		tick := time.NewTicker(10 * time.Millisecond)
+		defer tick.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-tick.C:
+				svc.HandleOutput(srv.ID, domain.ExecOutput{ID: sess.ID(), Data: []byte("tick\r\n")})
+			}
+		}
+	}()
+	go func() {
+		for range sess.Output() {
+		}
+	}()
+
+	evt := receive(t, ended)
+	close(stop)
+	if evt.Reason != "idle timeout" {
+		t.Fatalf("ended = %+v, want an idle timeout", evt)
+	}
+	if id := receive(t, transport.closes); id != sess.ID() {
+		t.Fatalf("closed %s, want %s", id, sess.ID())
+	}
+}
+
+func TestSessionEndsWhenServerGoesOffline(t *testing.T) {
+	svc, transport, srv, ended := newTestService(time.Hour)
+
+	sess, err := svc.Open(context.Background(), 5, 7, domain.ExecOptions{Service: "web"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	svc.ServerOffline(srv.ID)
+	if evt := receive(t, ended); evt.SessionID != sess.ID() || evt.Reason != "agent disconnected" {
+		t.Fatalf("ended = %+v, want the agent gone", evt)
+	}
+
+	// The agent is gone: nothing to close there.
+	select {
+	case id := <-transport.closes:
+		t.Fatalf("closed %s on an offline agent", id)
+	default:
+	}
+}
+
+func TestTranscriptIsCapped(t *testing.T) {
+	svc, _, srv, ended := newTestService(time.Hour)
+
+	sess, err := svc.Open(context.Background(), 5, 7, domain.ExecOptions{Service: "web"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	chunk := make([]byte, domain.ExecTranscriptLimit/2+1)
+	for i := range chunk {
+		chunk[i] = 'a'
+	}
+	svc.HandleOutput(srv.ID, domain.ExecOutput{ID: sess.ID(), Data: chunk})
+	svc.HandleOutput(srv.ID, domain.ExecOutput{ID: sess.ID(), Data: chunk})
+	sess.Close("client disconnected")
+
+	evt := receive(t, ended)
+	if len(evt.Transcript) != domain.ExecTranscriptLimit || !evt.TranscriptTruncated {
+		t.Fatalf("transcript = %d bytes truncated=%v, want the first %d", len(evt.Transcript), evt.TranscriptTruncated, domain.ExecTranscriptLimit)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	svc, transport, srv, _ := newTestService(time.Hour)
+	ctx := context.Background()
+
+	if _, err := svc.Open(ctx, 5, 7, domain.ExecOptions{Service: "web", Shell: "python"}); !errors.Is(err, domain.ErrInvalidExecOptions) {
+		t.Fatalf("bad shell: err = %v, want ErrInvalidExecOptions", err)
+	}
+	if _, err := svc.Open(ctx, 5, 99, domain.ExecOptions{Service: "web"}); !errors.Is(err, domain.ErrApplicationNotFound) {
+		t.Fatalf("missing app: err = %v, want ErrApplicationNotFound", err)
+	}
+
+	srv.IsOnline = false
+	if _, err := svc.Open(ctx, 5, 7, domain.ExecOptions{Service: "web"}); !errors.Is(err, domain.ErrServerOffline) {
+		t.Fatalf("offline server: err = %v, want ErrServerOffline", err)
+	}
+
+	select {
+	case req := <-transport.starts:
+		t.Fatalf("started %+v, want nothing", req)
+	default:
+	}
+}
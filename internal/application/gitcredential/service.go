@@ -0,0 +1,104 @@
+// Package gitcredential manages the per-application credentials the agent
+// uses to fetch private repositories: a generated deploy key and an HTTPS
+// access token.
+package gitcredential
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"horizonx/internal/domain"
+
+	"golang.org/x/crypto/ssh"
+)
+
+type Service struct {
+	repo   domain.GitCredentialRepository
+	appSvc domain.ApplicationService
+}
+
+func NewService(repo domain.GitCredentialRepository, appSvc domain.ApplicationService) domain.GitCredentialService {
+	return &Service{repo: repo, appSvc: appSvc}
+}
+
+func (s *Service) Get(ctx context.Context, appID int64) (*domain.ApplicationGitCredentials, error) {
+	creds, err := s.repo.GetByApplicationID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	return redact(creds), nil
+}
+
+// GenerateDeployKey replaces the application's deploy key with a fresh
+// ed25519 key pair and returns the public half for pasting into the forge.
+func (s *Service) GenerateDeployKey(ctx context.Context, appID int64) (*domain.ApplicationGitCredentials, error) {
+	if _, err := s.appSvc.GetByID(ctx, appID); err != nil {
+		return nil, err
+	}
+
+	publicKey, privateKey, err := generateDeployKey(fmt.Sprintf("horizonx-app-%d", appID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveDeployKey(ctx, appID, publicKey, privateKey); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, appID)
+}
+
+func (s *Service) DeleteDeployKey(ctx context.Context, appID int64) error {
+	if _, err := s.repo.GetByApplicationID(ctx, appID); err != nil {
+		return err
+	}
+	return s.repo.SaveDeployKey(ctx, appID, "", "")
+}
+
+func (s *Service) SetHTTPSCredentials(ctx context.Context, appID int64, req domain.GitHTTPSCredentialsRequest) error {
+	if _, err := s.appSvc.GetByID(ctx, appID); err != nil {
+		return err
+	}
+	return s.repo.SaveHTTPSCredentials(ctx, appID, req.Username, req.Token)
+}
+
+func (s *Service) DeleteHTTPSCredentials(ctx context.Context, appID int64) error {
+	if _, err := s.repo.GetByApplicationID(ctx, appID); err != nil {
+		return err
+	}
+	return s.repo.SaveHTTPSCredentials(ctx, appID, "", "")
+}
+
+// redact drops the secret halves before credentials leave the service.
+func redact(creds *domain.ApplicationGitCredentials) *domain.ApplicationGitCredentials {
+	out := *creds
+	out.SSHPrivateKey = ""
+	out.HTTPSToken = ""
+	return &out
+}
+
+// generateDeployKey returns an ed25519 key pair as an authorized_keys line
+// and an OpenSSH PEM private key.
+func generateDeployKey(comment string) (string, string, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate deploy key: %w", err)
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode deploy key: %w", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(priv, comment)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode deploy key: %w", err)
+	}
+
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub))) + " " + comment
+	return publicKey, string(pem.EncodeToMemory(block)), nil
+}
@@ -0,0 +1,127 @@
+package gitwebhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"horizonx/internal/domain"
+)
+
+// githubPullRequest is the pull_request event body; Gitea sends the same
+// shape.
+type githubPullRequest struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Head struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+}
+
+// gitlabMergeRequest is the "Merge Request Hook" body. OldRev is only set on
+// updates that pushed new commits.
+type gitlabMergeRequest struct {
+	ObjectAttributes struct {
+		IID          int    `json:"iid"`
+		Action       string `json:"action"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		OldRev       string `json:"oldrev"`
+		LastCommit   struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+	} `json:"object_attributes"`
+}
+
+func isPullRequestEvent(provider domain.GitProvider, event string) bool {
+	switch provider {
+	case domain.GitProviderGitLab:
+		return event == "Merge Request Hook"
+	case domain.GitProviderGitea:
+		return event == "pull_request" || event == "pull_request_sync"
+	default:
+		return event == "pull_request"
+	}
+}
+
+// evaluatePullRequest decides what a PR event means for the app's preview:
+// opening or pushing to a PR targeting the app's branch deploys it, closing
+// or merging tears it down. Other actions (edits, labels) are ignored.
+func evaluatePullRequest(app *domain.Application, req domain.GitWebhookRequest, delivery *domain.GitWebhookDelivery) (action, domain.PullRequest) {
+	pr, act, err := parsePullRequest(req.Provider, req.Body)
+	if err != nil {
+		return ignore(delivery, "malformed pull request payload"), pr
+	}
+
+	delivery.Branch = pr.HeadBranch
+	delivery.CommitHash = pr.HeadSHA
+	delivery.PullRequest = &pr.Number
+
+	if act == actionNone {
+		return ignore(delivery, "pull request action needs no preview change"), pr
+	}
+	if pr.BaseBranch != app.Branch {
+		return ignore(delivery, fmt.Sprintf("pull request targets %s, application tracks %s", pr.BaseBranch, app.Branch)), pr
+	}
+	if act == actionPreviewDeploy {
+		if !app.PreviewsEnabled {
+			return ignore(delivery, "previews are disabled"), pr
+		}
+		if err := domain.ValidateGitRef(pr.HeadSHA); err != nil {
+			return ignore(delivery, "invalid commit in pull request payload"), pr
+		}
+	}
+
+	return act, pr
+}
+
+func parsePullRequest(provider domain.GitProvider, body []byte) (domain.PullRequest, action, error) {
+	if provider == domain.GitProviderGitLab {
+		var mr gitlabMergeRequest
+		if err := json.Unmarshal(body, &mr); err != nil {
+			return domain.PullRequest{}, actionNone, err
+		}
+		attrs := mr.ObjectAttributes
+		pr := domain.PullRequest{
+			Number:     attrs.IID,
+			BaseBranch: attrs.TargetBranch,
+			HeadBranch: attrs.SourceBranch,
+			HeadSHA:    attrs.LastCommit.ID,
+		}
+		switch {
+		case attrs.Action == "open" || attrs.Action == "reopen":
+			return pr, actionPreviewDeploy, nil
+		case attrs.Action == "update" && attrs.OldRev != "":
+			return pr, actionPreviewDeploy, nil
+		case attrs.Action == "close" || attrs.Action == "merge":
+			return pr, actionPreviewDestroy, nil
+		default:
+			return pr, actionNone, nil
+		}
+	}
+
+	var event githubPullRequest
+	if err := json.Unmarshal(body, &event); err != nil {
+		return domain.PullRequest{}, actionNone, err
+	}
+	pr := domain.PullRequest{
+		Number:     event.Number,
+		BaseBranch: event.PullRequest.Base.Ref,
+		HeadBranch: event.PullRequest.Head.Ref,
+		HeadSHA:    event.PullRequest.Head.SHA,
+	}
+	switch event.Action {
+	// GitHub says "synchronize", Gitea "synchronized".
+	case "opened", "reopened", "synchronize", "synchronized":
+		return pr, actionPreviewDeploy, nil
+	case "closed":
+		return pr, actionPreviewDestroy, nil
+	default:
+		return pr, actionNone, nil
+	}
+}
@@ -0,0 +1,309 @@
+// Package gitwebhook turns git provider deliveries into deploys: pushes
+// deploy the app, pull and merge requests drive its preview environments.
+package gitwebhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"horizonx/internal/domain"
+)
+
+const (
+	branchRefPrefix = "refs/heads/"
+	zeroCommit      = "0000000000000000000000000000000000000000"
+)
+
+type Service struct {
+	repo       domain.GitWebhookRepository
+	appSvc     domain.ApplicationService
+	previewSvc domain.PreviewService
+}
+
+func NewService(repo domain.GitWebhookRepository, appSvc domain.ApplicationService, previewSvc domain.PreviewService) domain.GitWebhookService {
+	return &Service{repo: repo, appSvc: appSvc, previewSvc: previewSvc}
+}
+
+func (s *Service) Get(ctx context.Context, appID int64) (*domain.GitWebhook, error) {
+	hook, err := s.repo.GetByApplicationID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	hook.Secret = ""
+	return hook, nil
+}
+
+func (s *Service) Rotate(ctx context.Context, appID int64) (*domain.GitWebhook, error) {
+	if _, err := s.appSvc.GetByID(ctx, appID); err != nil {
+		return nil, err
+	}
+
+	token, err := domain.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := domain.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Save keeps auto_deploy on an existing webhook; new ones start enabled.
+	return s.repo.Save(ctx, &domain.GitWebhook{
+		ApplicationID: appID,
+		Token:         token,
+		Secret:        secret,
+		AutoDeploy:    true,
+	})
+}
+
+func (s *Service) Update(ctx context.Context, appID int64, req domain.GitWebhookUpdateRequest) error {
+	return s.repo.UpdateAutoDeploy(ctx, appID, req.AutoDeploy)
+}
+
+func (s *Service) ListDeliveries(ctx context.Context, opts domain.GitWebhookDeliveryListOptions) (*domain.ListResult[*domain.GitWebhookDelivery], error) {
+	if opts.IsPaginate {
+		if opts.Page <= 0 {
+			opts.Page = 1
+		}
+		if opts.Limit <= 0 {
+			opts.Limit = 10
+		}
+	} else {
+		if opts.Limit <= 0 {
+			opts.Limit = 100
+		}
+	}
+
+	deliveries, total, err := s.repo.ListDeliveries(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ListResult[*domain.GitWebhookDelivery]{
+		Data: deliveries,
+		Meta: domain.CalculateMeta(total, opts.Page, opts.Limit),
+	}, nil
+}
+
+// pushPayload holds the fields GitHub, GitLab and Gitea push events share.
+type pushPayload struct {
+	Ref   string `json:"ref"`
+	After string `json:"after"`
+}
+
+// action is what a verified delivery asks for.
+type action int
+
+const (
+	actionNone action = iota
+	actionDeploy
+	actionPreviewDeploy
+	actionPreviewDestroy
+)
+
+// Receive verifies a delivery and acts on it: a push to the app's branch with
+// auto-deploy on deploys the pushed commit, and pull/merge request events
+// targeting that branch deploy or tear down the PR's preview. Every verified
+// delivery is recorded with its outcome; a redelivery of one already handled
+// is recorded as a duplicate and does nothing.
+func (s *Service) Receive(ctx context.Context, token string, req domain.GitWebhookRequest) (*domain.GitWebhookDelivery, error) {
+	hook, err := s.repo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &domain.GitWebhookDelivery{
+		ApplicationID: hook.ApplicationID,
+		Provider:      req.Provider,
+		DeliveryID:    req.DeliveryID,
+		Event:         req.Event,
+	}
+	if delivery.DeliveryID == "" {
+		sum := sha256.Sum256(req.Body)
+		delivery.DeliveryID = hex.EncodeToString(sum[:])
+	}
+
+	if !verifySignature(req, hook.Secret) {
+		delivery.Outcome = domain.WebhookRejected
+		delivery.Message = "signature verification failed"
+		if _, err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+			return nil, err
+		}
+		return delivery, domain.ErrInvalidWebhookSignature
+	}
+
+	app, err := s.appSvc.GetByID(ctx, hook.ApplicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		act action
+		pr  domain.PullRequest
+	)
+	switch {
+	case isPushEvent(req.Provider, req.Event):
+		act = evaluatePush(hook, app, req.Body, delivery)
+	case isPullRequestEvent(req.Provider, req.Event):
+		act, pr = evaluatePullRequest(app, req, delivery)
+	default:
+		ignore(delivery, fmt.Sprintf("event %q is not handled", req.Event))
+	}
+
+	if act != actionNone {
+		// Claimed as failed until the action went through, so a crash in
+		// between is not reported as done.
+		delivery.Outcome = domain.WebhookFailed
+		delivery.Message = "not started"
+	}
+
+	if _, err := s.repo.ClaimDelivery(ctx, delivery); err != nil {
+		if !errors.Is(err, domain.ErrDuplicateWebhookDelivery) {
+			return nil, err
+		}
+		delivery.Outcome = domain.WebhookDuplicate
+		delivery.Message = "delivery already received"
+		if _, err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+			return nil, err
+		}
+		return delivery, nil
+	}
+
+	if act == actionNone {
+		return delivery, nil
+	}
+
+	s.perform(ctx, act, app.ID, pr, delivery)
+
+	if err := s.repo.FinishDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// perform runs the delivery's action and records how it went.
+func (s *Service) perform(ctx context.Context, act action, appID int64, pr domain.PullRequest, delivery *domain.GitWebhookDelivery) {
+	var (
+		deployment *domain.Deployment
+		err        error
+	)
+
+	switch act {
+	case actionDeploy:
+		deployment, err = s.appSvc.Deploy(ctx, appID, domain.ApplicationDeployRequest{Ref: delivery.CommitHash}, 0)
+	case actionPreviewDeploy:
+		_, deployment, err = s.previewSvc.Deploy(ctx, appID, pr)
+		if errors.Is(err, domain.ErrPreviewsDisabled) {
+			ignore(delivery, "previews are disabled")
+			return
+		}
+	case actionPreviewDestroy:
+		err = s.previewSvc.Destroy(ctx, appID, pr.Number)
+		if errors.Is(err, domain.ErrPreviewNotFound) {
+			ignore(delivery, fmt.Sprintf("no preview for #%d", pr.Number))
+			return
+		}
+	}
+
+	switch {
+	case err != nil:
+		delivery.Outcome = domain.WebhookFailed
+		delivery.Message = err.Error()
+	case act == actionPreviewDestroy:
+		delivery.Outcome = domain.WebhookDestroyed
+		delivery.Message = ""
+	default:
+		delivery.Outcome = domain.WebhookDeployed
+		delivery.Message = ""
+		delivery.DeploymentID = &deployment.ID
+	}
+}
+
+// evaluatePush fills in the pushed branch and commit and decides whether the
+// push should deploy.
+func evaluatePush(hook *domain.GitWebhook, app *domain.Application, body []byte, delivery *domain.GitWebhookDelivery) action {
+	var push pushPayload
+	if err := json.Unmarshal(body, &push); err != nil {
+		return ignore(delivery, "malformed push payload")
+	}
+
+	branch, ok := strings.CutPrefix(push.Ref, branchRefPrefix)
+	if !ok {
+		return ignore(delivery, fmt.Sprintf("ref %q is not a branch", push.Ref))
+	}
+	delivery.Branch = branch
+	delivery.CommitHash = push.After
+
+	if push.After == "" || push.After == zeroCommit {
+		return ignore(delivery, "branch was deleted")
+	}
+	if err := domain.ValidateGitRef(push.After); err != nil {
+		return ignore(delivery, "invalid commit in push payload")
+	}
+
+	if branch != app.Branch {
+		return ignore(delivery, fmt.Sprintf("push to %s, application tracks %s", branch, app.Branch))
+	}
+
+	if !hook.AutoDeploy {
+		return ignore(delivery, "auto-deploy is disabled")
+	}
+
+	return actionDeploy
+}
+
+// ignore marks a delivery that needs no action, with the reason.
+func ignore(delivery *domain.GitWebhookDelivery, msg string) action {
+	delivery.Outcome = domain.WebhookIgnored
+	delivery.Message = msg
+	return actionNone
+}
+
+func isPushEvent(provider domain.GitProvider, event string) bool {
+	if provider == domain.GitProviderGitLab {
+		return event == "Push Hook"
+	}
+	return event == "push"
+}
+
+// verifySignature checks the provider's proof of the shared secret: GitHub
+// sends "sha256=<hex hmac>", Gitea the bare hex HMAC of the body, GitLab the
+// secret itself.
+func verifySignature(req domain.GitWebhookRequest, secret string) bool {
+	if secret == "" || req.Signature == "" {
+		return false
+	}
+
+	switch req.Provider {
+	case domain.GitProviderGitLab:
+		return subtle.ConstantTimeCompare([]byte(req.Signature), []byte(secret)) == 1
+	case domain.GitProviderGitHub:
+		sig, ok := strings.CutPrefix(req.Signature, "sha256=")
+		if !ok {
+			return false
+		}
+		return validHMAC(req.Body, sig, secret)
+	case domain.GitProviderGitea:
+		return validHMAC(req.Body, req.Signature, secret)
+	default:
+		return false
+	}
+}
+
+func validHMAC(body []byte, signature, secret string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,393 @@
+package gitwebhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"horizonx/internal/domain"
+)
+
+const (
+	testToken  = "hzx_token"
+	testSecret = "hzx_secret"
+	testCommit = "9fceb02d0ae598e95dc970b74767f19372d61af8"
+)
+
+type fakeRepo struct {
+	hook       *domain.GitWebhook
+	claimed    map[string]bool
+	deliveries []*domain.GitWebhookDelivery
+}
+
+func newFakeRepo(autoDeploy bool) *fakeRepo {
+	return &fakeRepo{
+		hook: &domain.GitWebhook{
+			ApplicationID: 7,
+			Token:         testToken,
+			Secret:        testSecret,
+			AutoDeploy:    autoDeploy,
+		},
+		claimed: map[string]bool{},
+	}
+}
+
+func (f *fakeRepo) GetByApplicationID(ctx context.Context, appID int64) (*domain.GitWebhook, error) {
+	return f.hook, nil
+}
+func (f *fakeRepo) GetByToken(ctx context.Context, token string) (*domain.GitWebhook, error) {
+	if token != f.hook.Token {
+		return nil, domain.ErrGitWebhookNotFound
+	}
+	hook := *f.hook
+	return &hook, nil
+}
+func (f *fakeRepo) Save(ctx context.Context, hook *domain.GitWebhook) (*domain.GitWebhook, error) {
+	f.hook = hook
+	return hook, nil
+}
+func (f *fakeRepo) UpdateAutoDeploy(ctx context.Context, appID int64, autoDeploy bool) error {
+	f.hook.AutoDeploy = autoDeploy
+	return nil
+}
+func (f *fakeRepo) ClaimDelivery(ctx context.Context, d *domain.GitWebhookDelivery) (*domain.GitWebhookDelivery, error) {
+	if f.claimed[d.DeliveryID] {
+		return nil, domain.ErrDuplicateWebhookDelivery
+	}
+	f.claimed[d.DeliveryID] = true
+	f.deliveries = append(f.deliveries, d)
+	return d, nil
+}
+func (f *fakeRepo) CreateDelivery(ctx context.Context, d *domain.GitWebhookDelivery) (*domain.GitWebhookDelivery, error) {
+	f.deliveries = append(f.deliveries, d)
+	return d, nil
+}
+func (f *fakeRepo) FinishDelivery(ctx context.Context, d *domain.GitWebhookDelivery) error {
+	if d.Outcome == domain.WebhookFailed {
+		delete(f.claimed, d.DeliveryID)
+	}
+	return nil
+}
+func (f *fakeRepo) ListDeliveries(ctx context.Context, opts domain.GitWebhookDeliveryListOptions) ([]*domain.GitWebhookDelivery, int64, error) {
+	return f.deliveries, int64(len(f.deliveries)), nil
+}
+
+// fakeAppSvc records Deploy calls; only GetByID and Deploy are used here.
+type fakeAppSvc struct {
+	domain.ApplicationService
+
+	previews  bool
+	deployErr error
+	deploys   []domain.ApplicationDeployRequest
+	deployers []int64
+}
+
+func (f *fakeAppSvc) GetByID(ctx context.Context, appID int64) (*domain.Application, error) {
+	return &domain.Application{ID: appID, Branch: "main", PreviewsEnabled: f.previews}, nil
+}
+func (f *fakeAppSvc) Deploy(ctx context.Context, appID int64, req domain.ApplicationDeployRequest, deployedBy int64) (*domain.Deployment, error) {
+	if f.deployErr != nil {
+		return nil, f.deployErr
+	}
+	f.deploys = append(f.deploys, req)
+	f.deployers = append(f.deployers, deployedBy)
+	return &domain.Deployment{ID: int64(100 + len(f.deploys))}, nil
+}
+
+type fakePreviewSvc struct {
+	deployed  []domain.PullRequest
+	destroyed []int
+}
+
+func (f *fakePreviewSvc) List(ctx context.Context, parentID int64) ([]*domain.Application, error) {
+	return nil, nil
+}
+func (f *fakePreviewSvc) Deploy(ctx context.Context, parentID int64, pr domain.PullRequest) (*domain.Application, *domain.Deployment, error) {
+	f.deployed = append(f.deployed, pr)
+	return &domain.Application{ID: 50}, &domain.Deployment{ID: 200}, nil
+}
+func (f *fakePreviewSvc) Destroy(ctx context.Context, parentID int64, number int) error {
+	f.destroyed = append(f.destroyed, number)
+	return nil
+}
+func (f *fakePreviewSvc) ReapExpired(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func pushBody(branch, after string) []byte {
+	return []byte(`{"ref":"refs/heads/` + branch + `","after":"` + after + `"}`)
+}
+
+func githubSignature(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func githubPush(deliveryID string, body []byte) domain.GitWebhookRequest {
+	return domain.GitWebhookRequest{
+		Provider:   domain.GitProviderGitHub,
+		Event:      "push",
+		DeliveryID: deliveryID,
+		Signature:  githubSignature(body, testSecret),
+		Body:       body,
+	}
+}
+
+func TestReceiveGitHubPushDeploysCommit(t *testing.T) {
+	repo := newFakeRepo(true)
+	apps := &fakeAppSvc{}
+	svc := NewService(repo, apps, &fakePreviewSvc{})
+
+	d, err := svc.Receive(context.Background(), testToken, githubPush("d-1", pushBody("main", testCommit)))
+	if err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+
+	if d.Outcome != domain.WebhookDeployed || d.DeploymentID == nil || *d.DeploymentID != 101 {
+		t.Fatalf("unexpected delivery: %+v", d)
+	}
+	if len(apps.deploys) != 1 || apps.deploys[0].Ref != testCommit {
+		t.Fatalf("expected deploy of pushed commit, got %+v", apps.deploys)
+	}
+	if apps.deployers[0] != 0 {
+		t.Fatalf("webhook deploys must not be attributed to a user, got %d", apps.deployers[0])
+	}
+}
+
+func TestReceiveRejectsBadSignature(t *testing.T) {
+	repo := newFakeRepo(true)
+	apps := &fakeAppSvc{}
+	svc := NewService(repo, apps, &fakePreviewSvc{})
+
+	req := githubPush("d-1", pushBody("main", testCommit))
+	req.Signature = githubSignature(req.Body, "wrong-secret")
+
+	d, err := svc.Receive(context.Background(), testToken, req)
+	if !errors.Is(err, domain.ErrInvalidWebhookSignature) {
+		t.Fatalf("expected invalid signature, got %v", err)
+	}
+	if d.Outcome != domain.WebhookRejected || len(repo.deliveries) != 1 {
+		t.Fatalf("expected rejected delivery to be logged, got %+v", repo.deliveries)
+	}
+	if len(apps.deploys) != 0 {
+		t.Fatal("rejected delivery must not deploy")
+	}
+}
+
+func TestReceiveUnknownToken(t *testing.T) {
+	svc := NewService(newFakeRepo(true), &fakeAppSvc{}, &fakePreviewSvc{})
+
+	_, err := svc.Receive(context.Background(), "hzx_other", githubPush("d-1", pushBody("main", testCommit)))
+	if !errors.Is(err, domain.ErrGitWebhookNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestReceiveIgnoresOtherBranchesAndDisabledAutoDeploy(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoDeploy bool
+		req        domain.GitWebhookRequest
+	}{
+		{"other branch", true, githubPush("d-1", pushBody("feature", testCommit))},
+		{"branch deleted", true, githubPush("d-2", pushBody("main", zeroCommit))},
+		{"auto-deploy off", false, githubPush("d-3", pushBody("main", testCommit))},
+		{"ping event", true, func() domain.GitWebhookRequest {
+			req := githubPush("d-4", []byte(`{"zen":"hi"}`))
+			req.Event = "ping"
+			return req
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apps := &fakeAppSvc{}
+			svc := NewService(newFakeRepo(tt.autoDeploy), apps, &fakePreviewSvc{})
+
+			d, err := svc.Receive(context.Background(), testToken, tt.req)
+			if err != nil {
+				t.Fatalf("receive: %v", err)
+			}
+			if d.Outcome != domain.WebhookIgnored || d.Message == "" {
+				t.Fatalf("expected ignored with a reason, got %+v", d)
+			}
+			if len(apps.deploys) != 0 {
+				t.Fatal("ignored delivery must not deploy")
+			}
+		})
+	}
+}
+
+func TestReceiveDeduplicatesRedelivery(t *testing.T) {
+	repo := newFakeRepo(true)
+	apps := &fakeAppSvc{}
+	svc := NewService(repo, apps, &fakePreviewSvc{})
+	req := githubPush("d-1", pushBody("main", testCommit))
+
+	if _, err := svc.Receive(context.Background(), testToken, req); err != nil {
+		t.Fatalf("first receive: %v", err)
+	}
+	d, err := svc.Receive(context.Background(), testToken, req)
+	if err != nil {
+		t.Fatalf("second receive: %v", err)
+	}
+
+	if d.Outcome != domain.WebhookDuplicate {
+		t.Fatalf("expected duplicate, got %s", d.Outcome)
+	}
+	if len(apps.deploys) != 1 {
+		t.Fatalf("expected a single deploy, got %d", len(apps.deploys))
+	}
+	if len(repo.deliveries) != 2 {
+		t.Fatalf("expected both deliveries logged, got %d", len(repo.deliveries))
+	}
+}
+
+// A failed deploy releases the delivery so the provider's retry can deploy.
+func TestReceiveRetriesAfterFailedDeploy(t *testing.T) {
+	repo := newFakeRepo(true)
+	apps := &fakeAppSvc{deployErr: errors.New("server offline")}
+	svc := NewService(repo, apps, &fakePreviewSvc{})
+	req := githubPush("d-1", pushBody("main", testCommit))
+
+	d, err := svc.Receive(context.Background(), testToken, req)
+	if err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	if d.Outcome != domain.WebhookFailed || d.Message != "server offline" {
+		t.Fatalf("unexpected delivery: %+v", d)
+	}
+
+	apps.deployErr = nil
+	d, err = svc.Receive(context.Background(), testToken, req)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if d.Outcome != domain.WebhookDeployed {
+		t.Fatalf("expected retry to deploy, got %s", d.Outcome)
+	}
+}
+
+func TestReceiveGitLabAndGiteaSignatures(t *testing.T) {
+	body := pushBody("main", testCommit)
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write(body)
+
+	tests := []struct {
+		name string
+		req  domain.GitWebhookRequest
+	}{
+		{"gitlab", domain.GitWebhookRequest{
+			Provider: domain.GitProviderGitLab, Event: "Push Hook", Signature: testSecret, Body: body,
+		}},
+		{"gitea", domain.GitWebhookRequest{
+			Provider: domain.GitProviderGitea, Event: "push", Signature: hex.EncodeToString(mac.Sum(nil)), Body: body,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apps := &fakeAppSvc{}
+			svc := NewService(newFakeRepo(true), apps, &fakePreviewSvc{})
+
+			d, err := svc.Receive(context.Background(), testToken, tt.req)
+			if err != nil {
+				t.Fatalf("receive: %v", err)
+			}
+			if d.Outcome != domain.WebhookDeployed || len(apps.deploys) != 1 {
+				t.Fatalf("expected deploy, got %+v", d)
+			}
+
+			tt.req.Signature = "nope"
+			if _, err := svc.Receive(context.Background(), testToken, tt.req); !errors.Is(err, domain.ErrInvalidWebhookSignature) {
+				t.Fatalf("expected invalid signature, got %v", err)
+			}
+		})
+	}
+}
+
+func githubPullRequestEvent(deliveryID, action, base string) domain.GitWebhookRequest {
+	body := []byte(`{"action":"` + action + `","number":12,"pull_request":{` +
+		`"head":{"ref":"feature","sha":"` + testCommit + `"},"base":{"ref":"` + base + `"}}}`)
+	req := githubPush(deliveryID, body)
+	req.Event = "pull_request"
+	return req
+}
+
+func TestReceivePullRequestDrivesPreview(t *testing.T) {
+	previews := &fakePreviewSvc{}
+	svc := NewService(newFakeRepo(true), &fakeAppSvc{previews: true}, previews)
+
+	d, err := svc.Receive(context.Background(), testToken, githubPullRequestEvent("d-1", "opened", "main"))
+	if err != nil {
+		t.Fatalf("receive opened: %v", err)
+	}
+	if d.Outcome != domain.WebhookDeployed || d.DeploymentID == nil || *d.DeploymentID != 200 {
+		t.Fatalf("unexpected delivery: %+v", d)
+	}
+	if d.PullRequest == nil || *d.PullRequest != 12 || d.Branch != "feature" {
+		t.Fatalf("expected PR details on the delivery, got %+v", d)
+	}
+	if len(previews.deployed) != 1 || previews.deployed[0].HeadSHA != testCommit {
+		t.Fatalf("expected preview deploy of PR head, got %+v", previews.deployed)
+	}
+
+	d, err = svc.Receive(context.Background(), testToken, githubPullRequestEvent("d-2", "closed", "main"))
+	if err != nil {
+		t.Fatalf("receive closed: %v", err)
+	}
+	if d.Outcome != domain.WebhookDestroyed || len(previews.destroyed) != 1 || previews.destroyed[0] != 12 {
+		t.Fatalf("expected preview teardown, got %+v / %v", d, previews.destroyed)
+	}
+}
+
+func TestReceivePullRequestIgnored(t *testing.T) {
+	tests := []struct {
+		name     string
+		previews bool
+		req      domain.GitWebhookRequest
+	}{
+		{"previews disabled", false, githubPullRequestEvent("d-1", "opened", "main")},
+		{"other base branch", true, githubPullRequestEvent("d-2", "opened", "develop")},
+		{"label change", true, githubPullRequestEvent("d-3", "labeled", "main")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previews := &fakePreviewSvc{}
+			svc := NewService(newFakeRepo(true), &fakeAppSvc{previews: tt.previews}, previews)
+
+			d, err := svc.Receive(context.Background(), testToken, tt.req)
+			if err != nil {
+				t.Fatalf("receive: %v", err)
+			}
+			if d.Outcome != domain.WebhookIgnored {
+				t.Fatalf("expected ignored, got %+v", d)
+			}
+			if len(previews.deployed) != 0 {
+				t.Fatal("ignored delivery must not deploy a preview")
+			}
+		})
+	}
+}
+
+func TestReceiveGitLabMergeRequest(t *testing.T) {
+	previews := &fakePreviewSvc{}
+	svc := NewService(newFakeRepo(true), &fakeAppSvc{previews: true}, previews)
+
+	body := []byte(`{"object_attributes":{"iid":3,"action":"update","oldrev":"abc",` +
+		`"source_branch":"fix","target_branch":"main","last_commit":{"id":"` + testCommit + `"}}}`)
+	d, err := svc.Receive(context.Background(), testToken, domain.GitWebhookRequest{
+		Provider: domain.GitProviderGitLab, Event: "Merge Request Hook", Signature: testSecret, Body: body,
+	})
+	if err != nil {
+		t.Fatalf("receive: %v", err)
+	}
+	if d.Outcome != domain.WebhookDeployed || len(previews.deployed) != 1 || previews.deployed[0].Number != 3 {
+		t.Fatalf("expected merge request preview deploy, got %+v", d)
+	}
+}
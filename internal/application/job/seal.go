@@ -0,0 +1,111 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/security"
+)
+
+// sealedFields are payload keys that carry secrets. They are stored
+// encrypted and only opened on the way to the agent, so job reads over the
+// API (and a retry, which copies the stored payload) never see them.
+var sealedFields = []string{"git_credentials", "registry_credentials", "build_secrets"}
+
+// seal encrypts the sealed fields of a payload. Fields already sealed (a
+// retried payload) are left alone. Without a key the payload is stored as is,
+// like env var values in legacy mode.
+func (s *JobService) seal(payload json.RawMessage) (json.RawMessage, error) {
+	if len(s.encKey) == 0 {
+		return payload, nil
+	}
+
+	fields, ok := payloadFields(payload)
+	if !ok {
+		return payload, nil
+	}
+
+	changed := false
+	for _, key := range sealedFields {
+		raw, ok := fields[key]
+		if !ok || len(raw) == 0 || raw[0] != '{' {
+			continue
+		}
+
+		sealed, err := security.Encrypt(string(raw), s.encKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to seal %s: %w", key, err)
+		}
+		if fields[key], err = json.Marshal(sealed); err != nil {
+			return nil, err
+		}
+		changed = true
+	}
+
+	if !changed {
+		return payload, nil
+	}
+	return json.Marshal(fields)
+}
+
+// unseal returns a copy of job with its sealed fields decrypted, for handing
+// to the agent. A field that no longer decrypts (the key changed) is dropped:
+// the job still runs and fails where the secret was needed, with the agent's
+// error in its logs.
+func (s *JobService) unseal(job *domain.Job) *domain.Job {
+	if len(s.encKey) == 0 {
+		return job
+	}
+
+	fields, ok := payloadFields(job.Payload)
+	if !ok {
+		return job
+	}
+
+	changed := false
+	for _, key := range sealedFields {
+		raw, ok := fields[key]
+		if !ok {
+			continue
+		}
+
+		var sealed string
+		if err := json.Unmarshal(raw, &sealed); err != nil {
+			continue
+		}
+
+		changed = true
+		opened, err := security.Decrypt(sealed, s.encKey)
+		if err != nil {
+			delete(fields, key)
+			continue
+		}
+		fields[key] = json.RawMessage(opened)
+	}
+
+	if !changed {
+		return job
+	}
+
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return job
+	}
+
+	out := *job
+	out.Payload = payload
+	return &out
+}
+
+func payloadFields(payload json.RawMessage) (map[string]json.RawMessage, bool) {
+	if len(payload) == 0 {
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil || fields == nil {
+		return nil, false
+	}
+	return fields, true
+}
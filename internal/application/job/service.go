@@ -3,6 +3,8 @@ package job
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"horizonx/internal/domain"
 	"horizonx/internal/event"
@@ -11,16 +13,25 @@ import (
 )
 
 type JobService struct {
-	repo   domain.JobRepository
-	logSvc domain.LogService
-	bus    *event.Bus
+	repo       domain.JobRepository
+	servers    domain.ServerRepository
+	logSvc     domain.LogService
+	dispatcher domain.JobDispatcher
+	bus        *event.Bus
+
+	// encKey seals secrets inside payloads at rest (see seal.go). Empty
+	// means payloads are stored as given.
+	encKey []byte
 }
 
-func NewService(repo domain.JobRepository, logSvc domain.LogService, events *event.Bus) domain.JobService {
+func NewService(repo domain.JobRepository, servers domain.ServerRepository, logSvc domain.LogService, dispatcher domain.JobDispatcher, events *event.Bus, encKey []byte) domain.JobService {
 	return &JobService{
-		repo:   repo,
-		logSvc: logSvc,
-		bus:    events,
+		repo:       repo,
+		servers:    servers,
+		logSvc:     logSvc,
+		dispatcher: dispatcher,
+		bus:        events,
+		encKey:     encKey,
 	}
 }
 
@@ -56,7 +67,17 @@ func (s *JobService) List(ctx context.Context, opts domain.JobListOptions) (*dom
 }
 
 func (s *JobService) GetPending(ctx context.Context, serverID uuid.UUID) ([]*domain.Job, error) {
-	return s.repo.GetPending(ctx, serverID)
+	jobs, err := s.repo.GetPending(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pending jobs go to the agent, which needs the sealed secrets.
+	for i, job := range jobs {
+		jobs[i] = s.unseal(job)
+	}
+
+	return jobs, nil
 }
 
 func (s *JobService) GetByID(ctx context.Context, jobID int64) (*domain.Job, error) {
@@ -89,6 +110,16 @@ func (s *JobService) GetByID(ctx context.Context, jobID int64) (*domain.Job, err
 }
 
 func (s *JobService) Create(ctx context.Context, j *domain.Job) (*domain.Job, error) {
+	if err := s.checkAgent(ctx, j); err != nil {
+		return nil, err
+	}
+
+	payload, err := s.seal(j.Payload)
+	if err != nil {
+		return nil, err
+	}
+	j.Payload = payload
+
 	job, err := s.repo.Create(ctx, j)
 	if err != nil {
 		return nil, err
@@ -111,14 +142,50 @@ func (s *JobService) Create(ctx context.Context, j *domain.Job) (*domain.Job, er
 		})
 	}
 
+	s.dispatch(job)
+
 	return job, nil
 }
 
+// checkAgent stamps j with its payload version and refuses it when the
+// target agent advertised it can't run it, rather than letting the agent
+// fail it as unknown. Agents that never sent a hello predate negotiation
+// and get the job as before.
+func (s *JobService) checkAgent(ctx context.Context, j *domain.Job) error {
+	if j.PayloadVersion == 0 {
+		j.PayloadVersion = domain.JobPayloadVersions[j.Type]
+	}
+
+	if s.servers == nil {
+		return nil
+	}
+
+	server, err := s.servers.GetByID(ctx, j.ServerID)
+	if err != nil {
+		return err
+	}
+	if server.AgentInfo == nil {
+		return nil
+	}
+
+	return server.AgentInfo.Supports(j.Type, j.PayloadVersion)
+}
+
 func (s *JobService) Delete(ctx context.Context, jobID int64) error {
 	return s.repo.Delete(ctx, jobID)
 }
 
 func (s *JobService) Retry(ctx context.Context, jobID int64, j *domain.Job) (*domain.Job, error) {
+	if err := s.checkAgent(ctx, j); err != nil {
+		return nil, err
+	}
+
+	payload, err := s.seal(j.Payload)
+	if err != nil {
+		return nil, err
+	}
+	j.Payload = payload
+
 	job, err := s.repo.Retry(ctx, jobID, j)
 	if err != nil {
 		return nil, err
@@ -132,6 +199,8 @@ func (s *JobService) Retry(ctx context.Context, jobID int64, j *domain.Job) (*do
 		})
 	}
 
+	s.dispatch(job)
+
 	return job, nil
 }
 
@@ -161,12 +230,21 @@ func (s *JobService) Start(ctx context.Context, jobID int64) (*domain.Job, error
 	return job, nil
 }
 
-func (s *JobService) Finish(ctx context.Context, jobID int64, status domain.JobStatus) (*domain.Job, error) {
-	job, err := s.repo.MarkFinished(ctx, jobID, status)
+// Finish records a job's outcome. A report that no longer applies (the job
+// already finished, or the reaper and the agent raced) leaves the job as it
+// is and publishes nothing, so listeners never act on an outcome the job
+// does not have. With startedAt, only that run is finished: a report of a
+// run that has since been retried is stale as well.
+func (s *JobService) Finish(ctx context.Context, jobID int64, status domain.JobStatus, startedAt *time.Time) (*domain.Job, error) {
+	job, err := s.repo.MarkFinished(ctx, jobID, status, startedAt)
 	if err != nil {
 		return nil, err
 	}
 
+	if job.Status != status {
+		return job, nil
+	}
+
 	if s.bus != nil {
 		s.bus.Publish("job_finished", domain.EventJobFinished{
 			JobID:         job.ID,
@@ -188,6 +266,115 @@ func (s *JobService) Finish(ctx context.Context, jobID int64, status domain.JobS
 	return job, err
 }
 
+// Cancel stops a job. A queued job is cancelled in the database right away;
+// a running job is cancelled by signalling its agent, which kills the job's
+// processes and reports the job as cancelled when it finishes. Either way the
+// request is recorded in the job's logs.
+func (s *JobService) Cancel(ctx context.Context, jobID int64, cancelledBy int64) (*domain.Job, error) {
+	job, err := s.repo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status == domain.JobQueued {
+		cancelled, err := s.repo.CancelQueued(ctx, jobID)
+		switch {
+		case err == nil:
+			s.recordCancel(ctx, cancelled, cancelledBy, false)
+
+			if s.bus != nil {
+				s.bus.Publish("job_finished", domain.EventJobFinished{
+					JobID:         cancelled.ID,
+					TraceID:       cancelled.TraceID,
+					ServerID:      cancelled.ServerID,
+					ApplicationID: cancelled.ApplicationID,
+					DeploymentID:  cancelled.DeploymentID,
+					Type:          cancelled.Type,
+					Status:        cancelled.Status,
+				})
+
+				s.bus.Publish("job_status_changed", domain.EventJobStatusChanged{
+					JobID:   cancelled.ID,
+					TraceID: cancelled.TraceID,
+					Status:  cancelled.Status,
+				})
+			}
+
+			return cancelled, nil
+
+		case errors.Is(err, domain.ErrInvalidJobState):
+			// An agent started it in the meantime — cancel it as running.
+			if job, err = s.repo.GetByID(ctx, jobID); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, err
+		}
+	}
+
+	if job.Status != domain.JobRunning {
+		return nil, domain.ErrInvalidJobState
+	}
+
+	if s.dispatcher != nil {
+		s.dispatcher.CancelJob(job)
+	}
+
+	s.recordCancel(ctx, job, cancelledBy, true)
+
+	return job, nil
+}
+
+func (s *JobService) recordCancel(ctx context.Context, job *domain.Job, cancelledBy int64, wasRunning bool) {
+	if s.logSvc != nil {
+		message := "job cancelled before it started"
+		if wasRunning {
+			message = "cancellation requested, signalling agent to stop the job"
+		}
+
+		_, _ = s.logSvc.Create(ctx, &domain.Log{
+			Timestamp:     time.Now().UTC(),
+			Level:         domain.LogWarn,
+			Source:        domain.LogServer,
+			Action:        domain.ActionJobCancel,
+			TraceID:       job.TraceID,
+			JobID:         &job.ID,
+			ServerID:      &job.ServerID,
+			ApplicationID: job.ApplicationID,
+			DeploymentID:  job.DeploymentID,
+			Message:       message,
+			Context: &domain.LogContext{
+				Status: string(domain.JobCancelled),
+			},
+		})
+	}
+
+	if s.bus != nil {
+		s.bus.Publish("job_cancelled", domain.EventJobCancelled{
+			JobID:         job.ID,
+			TraceID:       job.TraceID,
+			ServerID:      job.ServerID,
+			ApplicationID: job.ApplicationID,
+			DeploymentID:  job.DeploymentID,
+			Type:          job.Type,
+			CancelledBy:   cancelledBy,
+			WasRunning:    wasRunning,
+		})
+	}
+}
+
+// dispatch pushes a queued job straight to its agent over the agent socket,
+// so it starts immediately instead of waiting for the agent's next poll.
+// Polling stays the fallback for agents that are offline or reconnecting.
+func (s *JobService) dispatch(job *domain.Job) {
+	if s.dispatcher == nil || job.Status != domain.JobQueued {
+		return
+	}
+
+	s.dispatcher.Dispatch(s.unseal(job))
+}
+
 // Summary returns job queue counts by status.
 // P2-17: queue visibility — feeds GET /jobs/summary.
 func (s *JobService) Summary(ctx context.Context) (*domain.JobStatusCounts, error) {
@@ -2,16 +2,22 @@ package job
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"horizonx/internal/domain"
 	"horizonx/internal/event"
+	"horizonx/internal/security"
 
 	"github.com/google/uuid"
 )
 
 type fakeJobRepo struct {
 	counts *domain.JobStatusCounts
+	jobs   map[int64]*domain.Job
 }
 
 func (f *fakeJobRepo) List(ctx context.Context, opts domain.JobListOptions) ([]*domain.Job, int64, error) {
@@ -21,10 +27,16 @@ func (f *fakeJobRepo) GetPending(ctx context.Context, serverID uuid.UUID) ([]*do
 	return nil, nil
 }
 func (f *fakeJobRepo) GetByID(ctx context.Context, jobID int64) (*domain.Job, error) {
-	return nil, nil
+	job, ok := f.jobs[jobID]
+	if !ok {
+		return nil, domain.ErrJobNotFound
+	}
+	return job, nil
 }
 func (f *fakeJobRepo) Create(ctx context.Context, j *domain.Job) (*domain.Job, error) {
-	return nil, nil
+	j.ID = 1
+	j.Status = domain.JobQueued
+	return j, nil
 }
 func (f *fakeJobRepo) Delete(ctx context.Context, jobID int64) error {
 	return nil
@@ -35,8 +47,29 @@ func (f *fakeJobRepo) Retry(ctx context.Context, jobID int64, j *domain.Job) (*d
 func (f *fakeJobRepo) MarkRunning(ctx context.Context, jobID int64) (*domain.Job, error) {
 	return nil, nil
 }
-func (f *fakeJobRepo) MarkFinished(ctx context.Context, jobID int64, status domain.JobStatus) (*domain.Job, error) {
-	return nil, nil
+func (f *fakeJobRepo) MarkFinished(ctx context.Context, jobID int64, status domain.JobStatus, startedAt *time.Time) (*domain.Job, error) {
+	job, ok := f.jobs[jobID]
+	if !ok {
+		return nil, domain.ErrJobNotFound
+	}
+	if startedAt != nil && (job.StartedAt == nil || !job.StartedAt.Equal(*startedAt)) {
+		return job, nil
+	}
+	if job.Status == domain.JobRunning || (job.Status == domain.JobExpired && status != domain.JobExpired) {
+		job.Status = status
+	}
+	return job, nil
+}
+func (f *fakeJobRepo) CancelQueued(ctx context.Context, jobID int64) (*domain.Job, error) {
+	job, ok := f.jobs[jobID]
+	if !ok {
+		return nil, domain.ErrJobNotFound
+	}
+	if job.Status != domain.JobQueued {
+		return nil, domain.ErrInvalidJobState
+	}
+	job.Status = domain.JobCancelled
+	return job, nil
 }
 func (f *fakeJobRepo) CountsByStatus(ctx context.Context) (*domain.JobStatusCounts, error) {
 	return f.counts, nil
@@ -45,7 +78,7 @@ func (f *fakeJobRepo) CountsByStatus(ctx context.Context) (*domain.JobStatusCoun
 func TestServiceSummaryReturnsCounts(t *testing.T) {
 	svc := NewService(&fakeJobRepo{
 		counts: &domain.JobStatusCounts{Queued: 4, Running: 2, Success: 10, Failed: 1, Total: 17},
-	}, nil, event.New())
+	}, nil, nil, nil, event.New(), nil)
 
 	counts, err := svc.Summary(context.Background())
 	if err != nil {
@@ -55,3 +88,268 @@ func TestServiceSummaryReturnsCounts(t *testing.T) {
 		t.Fatalf("unexpected counts: %+v", counts)
 	}
 }
+
+type fakeDispatcher struct {
+	dispatched []*domain.Job
+	cancelled  []*domain.Job
+}
+
+func (f *fakeDispatcher) Dispatch(job *domain.Job) {
+	f.dispatched = append(f.dispatched, job)
+}
+
+func (f *fakeDispatcher) CancelJob(job *domain.Job) {
+	f.cancelled = append(f.cancelled, job)
+}
+
+// New jobs are pushed to the agent over its socket instead of waiting for
+// the next poll.
+func TestServiceCreateDispatchesToAgent(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	svc := NewService(&fakeJobRepo{}, nil, nil, dispatcher, event.New(), nil)
+
+	job, err := svc.Create(context.Background(), &domain.Job{
+		ServerID: uuid.New(),
+		Type:     domain.JobTypeAppDeploy,
+	})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	if len(dispatcher.dispatched) != 1 || dispatcher.dispatched[0].ID != job.ID {
+		t.Fatalf("expected job %d to be dispatched, got %+v", job.ID, dispatcher.dispatched)
+	}
+}
+
+type fakeServerRepo struct {
+	domain.ServerRepository
+	servers map[uuid.UUID]*domain.Server
+}
+
+func (f *fakeServerRepo) GetByID(_ context.Context, serverID uuid.UUID) (*domain.Server, error) {
+	if s, ok := f.servers[serverID]; ok {
+		return s, nil
+	}
+	return nil, domain.ErrServerNotFound
+}
+
+// A job the agent advertised it can't run is refused before it is queued;
+// agents that never sent a hello get it as before.
+func TestServiceCreateRespectsAgentCapabilities(t *testing.T) {
+	oldAgent, legacyAgent := uuid.New(), uuid.New()
+	servers := &fakeServerRepo{servers: map[uuid.UUID]*domain.Server{
+		oldAgent: {ID: oldAgent, AgentInfo: &domain.AgentInfo{
+			Version:  "0.9.0",
+			JobTypes: map[domain.JobType]int{domain.JobTypeAppDeploy: 1},
+		}},
+		legacyAgent: {ID: legacyAgent},
+	}}
+	dispatcher := &fakeDispatcher{}
+	svc := NewService(&fakeJobRepo{}, servers, nil, dispatcher, event.New(), nil)
+
+	_, err := svc.Create(context.Background(), &domain.Job{ServerID: oldAgent, Type: domain.JobTypeAppRollback})
+	if !errors.Is(err, domain.ErrJobUnsupported) {
+		t.Fatalf("unknown job type: err = %v, want ErrJobUnsupported", err)
+	}
+
+	_, err = svc.Create(context.Background(), &domain.Job{ServerID: oldAgent, Type: domain.JobTypeAppDeploy, PayloadVersion: 2})
+	if !errors.Is(err, domain.ErrJobUnsupported) {
+		t.Fatalf("newer payload: err = %v, want ErrJobUnsupported", err)
+	}
+
+	job, err := svc.Create(context.Background(), &domain.Job{ServerID: oldAgent, Type: domain.JobTypeAppDeploy})
+	if err != nil {
+		t.Fatalf("supported job: %v", err)
+	}
+	if job.PayloadVersion != domain.JobPayloadVersions[domain.JobTypeAppDeploy] {
+		t.Fatalf("payload version = %d, want the current one", job.PayloadVersion)
+	}
+
+	if _, err := svc.Create(context.Background(), &domain.Job{ServerID: legacyAgent, Type: domain.JobTypeAppRollback}); err != nil {
+		t.Fatalf("legacy agent: %v", err)
+	}
+
+	if len(dispatcher.dispatched) != 2 {
+		t.Fatalf("dispatched %d jobs, want 2", len(dispatcher.dispatched))
+	}
+}
+
+// A queued job is cancelled in place and never reaches the agent.
+func TestServiceCancelQueuedJob(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	bus := event.New()
+
+	var cancelled []domain.EventJobCancelled
+	bus.Subscribe("job_cancelled", func(e any) {
+		cancelled = append(cancelled, e.(domain.EventJobCancelled))
+	})
+
+	svc := NewService(&fakeJobRepo{
+		jobs: map[int64]*domain.Job{5: {ID: 5, Status: domain.JobQueued}},
+	}, nil, nil, dispatcher, bus, nil)
+
+	job, err := svc.Cancel(context.Background(), 5, 42)
+	if err != nil {
+		t.Fatalf("Cancel returned error: %v", err)
+	}
+	if job.Status != domain.JobCancelled {
+		t.Fatalf("expected cancelled, got %s", job.Status)
+	}
+	if len(dispatcher.cancelled) != 0 {
+		t.Fatal("a queued job must not be signalled to the agent")
+	}
+	if len(cancelled) != 1 || cancelled[0].CancelledBy != 42 || cancelled[0].WasRunning {
+		t.Fatalf("unexpected job_cancelled events: %+v", cancelled)
+	}
+}
+
+// A running job is left running until its agent reports back; the service
+// only signals the agent.
+func TestServiceCancelRunningJobSignalsAgent(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	svc := NewService(&fakeJobRepo{
+		jobs: map[int64]*domain.Job{5: {ID: 5, Status: domain.JobRunning}},
+	}, nil, nil, dispatcher, event.New(), nil)
+
+	job, err := svc.Cancel(context.Background(), 5, 42)
+	if err != nil {
+		t.Fatalf("Cancel returned error: %v", err)
+	}
+	if job.Status != domain.JobRunning {
+		t.Fatalf("expected running, got %s", job.Status)
+	}
+	if len(dispatcher.cancelled) != 1 || dispatcher.cancelled[0].ID != 5 {
+		t.Fatalf("expected job 5 to be signalled, got %+v", dispatcher.cancelled)
+	}
+}
+
+func TestServiceCancelFinishedJob(t *testing.T) {
+	svc := NewService(&fakeJobRepo{
+		jobs: map[int64]*domain.Job{5: {ID: 5, Status: domain.JobSuccess}},
+	}, nil, nil, &fakeDispatcher{}, event.New(), nil)
+
+	if _, err := svc.Cancel(context.Background(), 5, 42); !errors.Is(err, domain.ErrInvalidJobState) {
+		t.Fatalf("expected ErrInvalidJobState, got %v", err)
+	}
+}
+
+// An agent cut off from the server reports a job the reaper already expired;
+// its real outcome replaces the expiry.
+func TestServiceFinishReplacesExpiry(t *testing.T) {
+	bus := event.New()
+
+	var finished []domain.JobStatus
+	bus.Subscribe("job_finished", func(e any) {
+		finished = append(finished, e.(domain.EventJobFinished).Status)
+	})
+
+	svc := NewService(&fakeJobRepo{
+		jobs: map[int64]*domain.Job{5: {ID: 5, Status: domain.JobExpired}},
+	}, nil, nil, &fakeDispatcher{}, bus, nil)
+
+	job, err := svc.Finish(context.Background(), 5, domain.JobSuccess, nil)
+	if err != nil {
+		t.Fatalf("Finish returned error: %v", err)
+	}
+	if job.Status != domain.JobSuccess {
+		t.Fatalf("expected success, got %s", job.Status)
+	}
+	if len(finished) != 1 || finished[0] != domain.JobSuccess {
+		t.Fatalf("unexpected job_finished events: %v", finished)
+	}
+}
+
+// A report that no longer applies leaves the job alone and publishes nothing.
+func TestServiceFinishIgnoresStaleReport(t *testing.T) {
+	bus := event.New()
+
+	published := 0
+	bus.Subscribe("job_finished", func(any) { published++ })
+
+	svc := NewService(&fakeJobRepo{
+		jobs: map[int64]*domain.Job{5: {ID: 5, Status: domain.JobCancelled}},
+	}, nil, nil, &fakeDispatcher{}, bus, nil)
+
+	job, err := svc.Finish(context.Background(), 5, domain.JobSuccess, nil)
+	if err != nil {
+		t.Fatalf("Finish returned error: %v", err)
+	}
+	if job.Status != domain.JobCancelled || published != 0 {
+		t.Fatalf("status %s, %d job_finished events", job.Status, published)
+	}
+}
+
+// A report of a run that has since been retried must not finish the new run.
+func TestServiceFinishIgnoresReportOfEarlierRun(t *testing.T) {
+	bus := event.New()
+
+	published := 0
+	bus.Subscribe("job_finished", func(any) { published++ })
+
+	earlier := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	retried := earlier.Add(time.Hour)
+	svc := NewService(&fakeJobRepo{
+		jobs: map[int64]*domain.Job{5: {ID: 5, Status: domain.JobRunning, StartedAt: &retried}},
+	}, nil, nil, &fakeDispatcher{}, bus, nil)
+
+	job, err := svc.Finish(context.Background(), 5, domain.JobFailed, &earlier)
+	if err != nil {
+		t.Fatalf("Finish returned error: %v", err)
+	}
+	if job.Status != domain.JobRunning || published != 0 {
+		t.Fatalf("status %s, %d job_finished events", job.Status, published)
+	}
+
+	if job, _ = svc.Finish(context.Background(), 5, domain.JobSuccess, &retried); job.Status != domain.JobSuccess {
+		t.Fatalf("status %s, want the retried run finished", job.Status)
+	}
+}
+
+// Git credentials are stored sealed, so job reads over the API never show
+// them, and opened only for the agent.
+func TestServiceCreateSealsGitCredentials(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	svc := NewService(&fakeJobRepo{}, nil, nil, dispatcher, event.New(), security.KeyFromSecret("test-secret"))
+
+	payload, _ := json.Marshal(domain.AppDeployPayload{
+		ApplicationID:  1,
+		RepoURL:        "git@example.com:demo/demo.git",
+		GitCredentials: &domain.GitCredentials{SSHPrivateKey: "PRIVATE-KEY"},
+	})
+
+	job, err := svc.Create(context.Background(), &domain.Job{
+		ServerID: uuid.New(),
+		Type:     domain.JobTypeAppDeploy,
+		Payload:  payload,
+	})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	if strings.Contains(string(job.Payload), "PRIVATE-KEY") {
+		t.Fatalf("stored payload leaks the deploy key: %s", job.Payload)
+	}
+
+	if len(dispatcher.dispatched) != 1 {
+		t.Fatalf("expected one dispatched job, got %d", len(dispatcher.dispatched))
+	}
+	var sent domain.AppDeployPayload
+	if err := json.Unmarshal(dispatcher.dispatched[0].Payload, &sent); err != nil {
+		t.Fatalf("dispatched payload: %v", err)
+	}
+	if sent.GitCredentials == nil || sent.GitCredentials.SSHPrivateKey != "PRIVATE-KEY" {
+		t.Fatalf("agent did not get the deploy key: %+v", sent.GitCredentials)
+	}
+	if sent.RepoURL != "git@example.com:demo/demo.git" {
+		t.Fatalf("unexpected repo url %q", sent.RepoURL)
+	}
+
+	// A retry copies the stored payload; sealing it again must be a no-op.
+	resealed, err := svc.(*JobService).seal(job.Payload)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if string(resealed) != string(job.Payload) {
+		t.Fatal("sealed payload was sealed twice")
+	}
+}
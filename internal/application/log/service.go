@@ -63,3 +63,19 @@ func (s *LogService) Create(ctx context.Context, l *domain.Log) (*domain.Log, er
 
 	return log, nil
 }
+
+// CreateBatch stores an agent's log batch in one insert, then publishes each
+// line like Create so live log views see them in order.
+func (s *LogService) CreateBatch(ctx context.Context, logs []*domain.Log) error {
+	if err := s.repo.BulkInsert(ctx, logs); err != nil {
+		return err
+	}
+
+	if s.bus != nil {
+		for _, l := range logs {
+			s.bus.Publish("log_received", l)
+		}
+	}
+
+	return nil
+}
@@ -2,8 +2,13 @@
 package metrics
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +22,7 @@ import (
 
 type Service struct {
 	repo     domain.MetricsRepository
+	apps     domain.ApplicationRepository
 	registry *redis.Registry
 
 	bus *event.Bus
@@ -35,8 +41,24 @@ type Service struct {
 	cpuUsageMu sync.RWMutex
 	netSpeedMu sync.RWMutex
 
+	// appLatest is each app's last per-container breakdown; serverApps is
+	// which apps a server last reported, to drop the ones it no longer runs.
+	appLatest       map[int64]domain.AppMetric
+	appUsageHistory map[int64][]domain.AppUsageSample
+	serverApps      map[uuid.UUID][]int64
+
+	// appServer caches which server each reported app belongs to.
+	appServer map[int64]uuid.UUID
+
+	appMu sync.Mutex
+
 	cpuUsageHistoryRetention time.Duration
 	netSpeedHistoryRetention time.Duration
+	appUsageHistoryRetention time.Duration
+
+	// rawRetention is how long raw samples are kept: metrics the rollups do
+	// not keep cannot be queried further back.
+	rawRetention time.Duration
 
 	flushInterval     time.Duration
 	broadcastInterval time.Duration
@@ -44,9 +66,10 @@ type Service struct {
 	batchSize int
 }
 
-func NewService(repo domain.MetricsRepository, registry *redis.Registry, bus *event.Bus, log logger.Logger) domain.MetricsService {
+func NewService(repo domain.MetricsRepository, apps domain.ApplicationRepository, registry *redis.Registry, bus *event.Bus, log logger.Logger, retention domain.MetricsRetention) domain.MetricsService {
 	svc := &Service{
 		repo:     repo,
+		apps:     apps,
 		registry: registry,
 
 		bus: bus,
@@ -58,8 +81,16 @@ func NewService(repo domain.MetricsRepository, registry *redis.Registry, bus *ev
 		cpuUsageHistory: make(map[uuid.UUID][]domain.CPUUsageSample),
 		netSpeedHistory: make(map[uuid.UUID][]domain.NetworkSpeedSample),
 
+		appLatest:       make(map[int64]domain.AppMetric),
+		appUsageHistory: make(map[int64][]domain.AppUsageSample),
+		serverApps:      make(map[uuid.UUID][]int64),
+		appServer:       make(map[int64]uuid.UUID),
+
 		cpuUsageHistoryRetention: 15 * time.Minute,
 		netSpeedHistoryRetention: 15 * time.Minute,
+		appUsageHistoryRetention: 15 * time.Minute,
+
+		rawRetention: time.Duration(retention.RawDays) * 24 * time.Hour,
 
 		flushInterval:     15 * time.Second,
 		broadcastInterval: 10 * time.Second,
@@ -80,6 +111,7 @@ func (s *Service) Ingest(ctx context.Context, m domain.Metrics) error {
 	s.recordLatest(ctx, m)
 	s.recordCPUUsage(ctx, sid, m.CPU.Usage.EMA, at)
 	s.recordNetSpeed(ctx, sid, m.Network.RXSpeedMBs.EMA, m.Network.TXSpeedMBs.EMA, at)
+	s.recordAppUsage(ctx, sid, m.Apps, at)
 
 	s.bufferMu.Lock()
 	s.buffer = append(s.buffer, m)
@@ -169,10 +201,201 @@ func (s *Service) NetSpeedHistory(ctx context.Context, serverID uuid.UUID) ([]do
 	return speeds, nil
 }
 
+func (s *Service) AppMetrics(ctx context.Context, appID int64) (*domain.AppMetrics, error) {
+	s.appMu.Lock()
+	var latest *domain.AppMetric
+	if m, ok := s.appLatest[appID]; ok {
+		latest = &m
+	}
+	history, ok := s.appUsageHistory[appID]
+	s.appMu.Unlock()
+
+	if !ok {
+		msg, err := s.registry.GetRangeDesc(ctx, fmt.Sprintf("metrics:app:%d:usage", appID), 900)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get app usage from registry: %w", err)
+		}
+
+		history, _, err = redis.ParseStreamMessages[domain.AppUsageSample](msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse app usage messages: %w", err)
+		}
+		if len(history) == 0 {
+			return nil, domain.ErrMetricsNotFound
+		}
+
+		s.appMu.Lock()
+		if _, ok := s.appUsageHistory[appID]; !ok {
+			s.appUsageHistory[appID] = history
+		}
+		s.appMu.Unlock()
+	}
+
+	return &domain.AppMetrics{
+		Latest:  latest,
+		History: history,
+	}, nil
+}
+
 func (s *Service) Cleanup(ctx context.Context, serverID uuid.UUID, cutoff time.Time) error {
 	return s.repo.Cleanup(ctx, serverID, cutoff)
 }
 
+func (s *Service) RollUp(ctx context.Context, resolution domain.MetricsResolution, now time.Time) error {
+	if !resolution.Valid() {
+		return domain.ErrInvalidMetricsRollupQuery
+	}
+
+	// Only finished buckets: the current one is rolled up once it ends.
+	buckets, err := s.repo.StaleRollups(ctx, resolution, resolution.Truncate(now))
+	if err != nil {
+		return err
+	}
+
+	// Consecutive buckets are redone together, up to a day at a time, so
+	// catching up on a long backlog never holds one huge statement.
+	for len(buckets) > 0 {
+		from := buckets[0]
+		to := resolution.Next(from)
+		buckets = buckets[1:]
+		for len(buckets) > 0 && buckets[0].Equal(to) && to.Before(from.AddDate(0, 0, 1)) {
+			to = resolution.Next(to)
+			buckets = buckets[1:]
+		}
+
+		if err := s.repo.RollUp(ctx, resolution, from, to); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) Rollups(ctx context.Context, serverID uuid.UUID, opts domain.MetricsRollupListOptions) ([]domain.MetricsRollup, error) {
+	if err := opts.Validate(time.Now()); err != nil {
+		return nil, err
+	}
+	return s.repo.ListRollups(ctx, serverID, opts)
+}
+
+func (s *Service) CleanupRollups(ctx context.Context, resolution domain.MetricsResolution, cutoff time.Time) error {
+	return s.repo.CleanupRollups(ctx, resolution, cutoff)
+}
+
+func (s *Service) Query(ctx context.Context, serverID uuid.UUID, q domain.MetricsQuery) (*domain.MetricsQueryResult, error) {
+	now := time.Now()
+	if err := q.Validate(now); err != nil {
+		return nil, err
+	}
+	metric, _ := domain.LookupMetricsQueryMetric(q.Metric)
+
+	resolution, err := s.querySource(ctx, serverID, metric, q, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollup buckets cannot be split: steps start on a bucket and span whole
+	// ones.
+	if resolution != domain.MetricsRaw {
+		q.From = resolution.Truncate(q.From)
+		bucket := resolution.Next(q.From).Sub(q.From)
+		q.Step = (q.Step + bucket - 1) / bucket * bucket
+	}
+
+	samples, err := s.repo.QueryMetrics(ctx, serverID, resolution, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.MetricsQueryResult{
+		Metric:      metric.Name,
+		Unit:        metric.Unit,
+		Resolution:  resolution,
+		From:        q.From,
+		To:          q.To,
+		StepSeconds: int64(q.Step / time.Second),
+		Series:      stepSeries(q, samples),
+	}, nil
+}
+
+// querySource picks where to read a query from: the server's raw samples
+// when they go back far enough and the step is under an hour, else the
+// finest of its rollups that does. Metrics the rollups do not keep always
+// come from the raw samples, so a range reaching past the raw retention is
+// rejected rather than answered with empty series.
+func (s *Service) querySource(ctx context.Context, serverID uuid.UUID, metric domain.MetricsQueryMetric, q domain.MetricsQuery, now time.Time) (domain.MetricsResolution, error) {
+	if !metric.Rollup {
+		if s.rawRetention > 0 && q.From.Before(now.Add(-s.rawRetention)) {
+			return "", fmt.Errorf("%w: %s is only kept as raw samples, for %d days", domain.ErrInvalidMetricsQuery, metric.Name, int(s.rawRetention/(24*time.Hour)))
+		}
+		return domain.MetricsRaw, nil
+	}
+
+	oldest, ok, err := s.repo.OldestSample(ctx, serverID)
+	if err != nil {
+		return "", err
+	}
+	if ok && !q.From.Before(oldest) && q.Step < time.Hour {
+		return domain.MetricsRaw, nil
+	}
+
+	oldestHourly, hasHourly, err := s.repo.OldestRollup(ctx, serverID, domain.MetricsHourly)
+	if err != nil {
+		return "", err
+	}
+	if hasHourly && !q.From.Before(oldestHourly) && q.Step < 24*time.Hour {
+		return domain.MetricsHourly, nil
+	}
+
+	_, hasDaily, err := s.repo.OldestRollup(ctx, serverID, domain.MetricsDaily)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case hasDaily:
+		return domain.MetricsDaily, nil
+	case hasHourly:
+		return domain.MetricsHourly, nil
+	}
+	return domain.MetricsRaw, nil
+}
+
+// stepSeries spreads the samples, ordered by label then time, over a point
+// per step of the query; steps without samples stay nil.
+func stepSeries(q domain.MetricsQuery, samples []domain.MetricsQuerySample) []domain.MetricsSeries {
+	series := make([]domain.MetricsSeries, 0)
+	points := q.Points()
+
+	for _, sample := range samples {
+		if len(series) == 0 || series[len(series)-1].Label != sample.Label {
+			s := domain.MetricsSeries{Label: sample.Label, Points: make([]domain.MetricsPoint, points)}
+			for i := range s.Points {
+				s.Points[i].At = q.From.Add(time.Duration(i) * q.Step)
+			}
+			series = append(series, s)
+		}
+
+		i := int(sample.At.Sub(q.From) / q.Step)
+		if i < 0 || i >= points {
+			continue
+		}
+		value := sample.Value
+		series[len(series)-1].Points[i].Value = &value
+	}
+
+	// Numbered labels (CPU cores) in numeric rather than text order.
+	slices.SortStableFunc(series, func(a, b domain.MetricsSeries) int {
+		x, errX := strconv.Atoi(a.Label)
+		y, errY := strconv.Atoi(b.Label)
+		if errX == nil && errY == nil {
+			return cmp.Compare(x, y)
+		}
+		return strings.Compare(a.Label, b.Label)
+	})
+
+	return series
+}
+
 func (s *Service) recordLatest(ctx context.Context, m domain.Metrics) {
 	s.latestMu.Lock()
 	defer s.latestMu.Unlock()
@@ -234,6 +457,83 @@ func (s *Service) recordNetSpeed(ctx context.Context, serverID uuid.UUID, rxMBs
 	}
 }
 
+func (s *Service) recordAppUsage(ctx context.Context, serverID uuid.UUID, apps []domain.AppMetric, at time.Time) {
+	apps = s.ownApps(ctx, serverID, apps)
+
+	ids := make([]int64, 0, len(apps))
+	samples := make([]domain.AppUsageSample, 0, len(apps))
+
+	s.appMu.Lock()
+	for _, app := range apps {
+		ids = append(ids, app.ApplicationID)
+		s.appLatest[app.ApplicationID] = app
+
+		sample := domain.AppUsageSample{AppUsage: app.AppUsage, At: at}
+		samples = append(samples, sample)
+
+		points := append(s.appUsageHistory[app.ApplicationID], sample)
+		cutoff := at.Add(-s.appUsageHistoryRetention)
+		i := 0
+		for ; i < len(points); i++ {
+			if points[i].At.After(cutoff) {
+				break
+			}
+		}
+		s.appUsageHistory[app.ApplicationID] = points[i:]
+	}
+
+	// An app the server stopped reporting has no containers running: its
+	// breakdown is stale, its history stays until it ages out.
+	for _, id := range s.serverApps[serverID] {
+		if !slices.Contains(ids, id) {
+			delete(s.appLatest, id)
+		}
+	}
+	s.serverApps[serverID] = ids
+	s.appMu.Unlock()
+
+	for i, id := range ids {
+		if _, err := s.registry.Append(ctx, fmt.Sprintf("metrics:app:%d:usage", id), &samples[i], 900); err != nil {
+			s.log.Error("failed to append app usage sample to registry", "applicationID", id, "err", err)
+		}
+	}
+}
+
+// ownApps returns the apps of a server's report that belong to the server.
+// The application ID comes from the agent: usage reported for another
+// server's app is dropped.
+func (s *Service) ownApps(ctx context.Context, serverID uuid.UUID, apps []domain.AppMetric) []domain.AppMetric {
+	own := make([]domain.AppMetric, 0, len(apps))
+	for _, app := range apps {
+		s.appMu.Lock()
+		owner, ok := s.appServer[app.ApplicationID]
+		s.appMu.Unlock()
+
+		// An app moved to this server since it was cached is looked up again.
+		if !ok || owner != serverID {
+			a, err := s.apps.GetByID(ctx, app.ApplicationID)
+			if err != nil {
+				if !errors.Is(err, domain.ErrApplicationNotFound) {
+					s.log.Error("failed to look up reported app", "applicationID", app.ApplicationID, "err", err)
+				}
+				continue
+			}
+			owner = a.ServerID
+
+			s.appMu.Lock()
+			s.appServer[app.ApplicationID] = owner
+			s.appMu.Unlock()
+		}
+
+		if owner != serverID {
+			s.log.Warn("server reported usage of another server's app", "serverID", serverID, "applicationID", app.ApplicationID)
+			continue
+		}
+		own = append(own, app)
+	}
+	return own
+}
+
 func (s *Service) backgroundFlusher() {
 	ticker := time.NewTicker(s.flushInterval)
 	defer ticker.Stop()
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
+
+type fakeAppRepo struct {
+	domain.ApplicationRepository
+	apps    map[int64]*domain.Application
+	lookups int
+}
+
+func (f *fakeAppRepo) GetByID(_ context.Context, appID int64) (*domain.Application, error) {
+	f.lookups++
+	app, ok := f.apps[appID]
+	if !ok {
+		return nil, domain.ErrApplicationNotFound
+	}
+	return app, nil
+}
+
+// An agent reports usage under application IDs of its own choosing: only
+// the apps that belong to its server are kept.
+func TestOwnAppsDropsOtherServersApps(t *testing.T) {
+	mine, theirs := uuid.New(), uuid.New()
+	apps := &fakeAppRepo{apps: map[int64]*domain.Application{
+		1: {ID: 1, ServerID: mine},
+		2: {ID: 2, ServerID: theirs},
+	}}
+	svc := &Service{apps: apps, log: nopLogger{}, appServer: make(map[int64]uuid.UUID)}
+
+	report := []domain.AppMetric{{ApplicationID: 1}, {ApplicationID: 2}, {ApplicationID: 3}}
+	for range 2 {
+		own := svc.ownApps(context.Background(), mine, report)
+		if len(own) != 1 || own[0].ApplicationID != 1 {
+			t.Fatalf("kept %+v, want app 1 only", own)
+		}
+	}
+
+	// App 1 is cached once resolved; the others are looked up each time.
+	if apps.lookups != 5 {
+		t.Fatalf("looked up apps %d times, want 5", apps.lookups)
+	}
+}
+
+// An app moved to another server is recorded for its new server.
+func TestOwnAppsFollowsMovedApp(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+	app := &domain.Application{ID: 1, ServerID: from}
+	svc := &Service{
+		apps:      &fakeAppRepo{apps: map[int64]*domain.Application{1: app}},
+		log:       nopLogger{},
+		appServer: make(map[int64]uuid.UUID),
+	}
+
+	report := []domain.AppMetric{{ApplicationID: 1}}
+	if own := svc.ownApps(context.Background(), from, report); len(own) != 1 {
+		t.Fatal("app not recorded for its server")
+	}
+
+	app.ServerID = to
+	if own := svc.ownApps(context.Background(), to, report); len(own) != 1 {
+		t.Fatal("moved app not recorded for its new server")
+	}
+	if own := svc.ownApps(context.Background(), from, report); len(own) != 0 {
+		t.Fatal("moved app still recorded for its old server")
+	}
+}
@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func (f *fakeMetricsRepo) OldestRollup(_ context.Context, serverID uuid.UUID, resolution domain.MetricsResolution) (time.Time, bool, error) {
+	f.oldestOf = append(f.oldestOf, serverID)
+	oldest, ok := f.oldestRollups[resolution]
+	return oldest, ok, nil
+}
+
+func (f *fakeMetricsRepo) QueryMetrics(_ context.Context, _ uuid.UUID, resolution domain.MetricsResolution, q domain.MetricsQuery) ([]domain.MetricsQuerySample, error) {
+	f.queried = &rollupWindow{resolution, q.From, q.To}
+	return f.samples, nil
+}
+
+func TestQueryPicksTheFinestSourceCoveringTheRange(t *testing.T) {
+	repo := &fakeMetricsRepo{
+		oldest: at(20, 0, 0),
+		oldestRollups: map[domain.MetricsResolution]time.Time{
+			domain.MetricsHourly: at(1, 0, 0),
+			domain.MetricsDaily:  at(1, 0, 0).AddDate(-1, 0, 0),
+		},
+	}
+	svc := &Service{repo: repo}
+
+	cases := []struct {
+		name   string
+		metric string
+		from   time.Time
+		step   time.Duration
+		want   domain.MetricsResolution
+	}{
+		{"recent", "cpu.usage_percent", at(25, 0, 0), 5 * time.Minute, domain.MetricsRaw},
+		{"hourly steps", "cpu.usage_percent", at(25, 0, 0), time.Hour, domain.MetricsHourly},
+		{"past the raw retention", "network.rx_mbs", at(10, 0, 0), 30 * time.Minute, domain.MetricsHourly},
+		{"daily steps", "cpu.usage_percent", at(10, 0, 0), 24 * time.Hour, domain.MetricsDaily},
+		{"past the hourly retention", "memory.usage_percent", at(1, 0, 0).AddDate(0, -2, 0), 12 * time.Hour, domain.MetricsDaily},
+		{"not rolled up", "filesystem.usage_percent", at(10, 0, 0), time.Hour, domain.MetricsRaw},
+	}
+
+	serverID := uuid.New()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := svc.Query(context.Background(), serverID, domain.MetricsQuery{
+				Metric: tc.metric,
+				From:   tc.from,
+				To:     at(28, 0, 0),
+				Step:   tc.step,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Resolution != tc.want || repo.queried.resolution != tc.want {
+				t.Fatalf("resolution = %s (queried %s), want %s", result.Resolution, repo.queried.resolution, tc.want)
+			}
+		})
+	}
+
+	// What another server has kept says nothing about this one.
+	for _, id := range repo.oldestOf {
+		if id != serverID {
+			t.Fatalf("looked up the oldest data of server %s, want %s", id, serverID)
+		}
+	}
+}
+
+// Metrics the rollups do not keep are gone past the raw retention: a range
+// reaching back there is an error, not empty series.
+func TestQueryRejectsRawOnlyMetricPastRawRetention(t *testing.T) {
+	repo := &fakeMetricsRepo{}
+	svc := &Service{repo: repo, rawRetention: 7 * 24 * time.Hour}
+	now := time.Now()
+
+	_, err := svc.Query(context.Background(), uuid.New(), domain.MetricsQuery{
+		Metric: "filesystem.usage_percent",
+		From:   now.AddDate(0, 0, -8),
+		To:     now,
+		Step:   time.Hour,
+	})
+	if !errors.Is(err, domain.ErrInvalidMetricsQuery) {
+		t.Fatalf("err = %v, want ErrInvalidMetricsQuery", err)
+	}
+	if repo.queried != nil {
+		t.Fatal("queried samples past the raw retention")
+	}
+
+	if _, err := svc.Query(context.Background(), uuid.New(), domain.MetricsQuery{
+		Metric: "filesystem.usage_percent",
+		From:   now.AddDate(0, 0, -6),
+		To:     now,
+		Step:   time.Hour,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueryAlignsStepsToRollupBuckets(t *testing.T) {
+	repo := &fakeMetricsRepo{
+		oldestRollups: map[domain.MetricsResolution]time.Time{domain.MetricsHourly: at(1, 0, 0)},
+	}
+	svc := &Service{repo: repo}
+
+	result, err := svc.Query(context.Background(), uuid.New(), domain.MetricsQuery{
+		Metric: "cpu.usage_percent",
+		From:   at(10, 6, 20),
+		To:     at(12, 0, 0),
+		Step:   90 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.From.Equal(at(10, 6, 0)) || result.StepSeconds != 2*60*60 {
+		t.Fatalf("from = %v, step = %ds, want 06:00 and 2h", result.From, result.StepSeconds)
+	}
+}
+
+func TestQueryStepsEverySeries(t *testing.T) {
+	repo := &fakeMetricsRepo{
+		oldest: at(1, 0, 0),
+		samples: []domain.MetricsQuerySample{
+			{Label: "0", At: at(10, 0, 0), Value: 10},
+			{Label: "0", At: at(10, 0, 2), Value: 30},
+			{Label: "10", At: at(10, 0, 1), Value: 50},
+			{Label: "2", At: at(10, 0, 0), Value: 20},
+		},
+	}
+	svc := &Service{repo: repo}
+
+	result, err := svc.Query(context.Background(), uuid.New(), domain.MetricsQuery{
+		Metric: "cpu.core_usage_percent",
+		From:   at(10, 0, 0),
+		To:     at(10, 0, 3),
+		Step:   time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels := make([]string, 0, len(result.Series))
+	for _, series := range result.Series {
+		labels = append(labels, series.Label)
+		if len(series.Points) != 3 {
+			t.Fatalf("series %s has %d points, want 3", series.Label, len(series.Points))
+		}
+		for i, point := range series.Points {
+			if want := at(10, 0, i); !point.At.Equal(want) {
+				t.Fatalf("series %s point %d at %v, want %v", series.Label, i, point.At, want)
+			}
+		}
+	}
+	if len(labels) != 3 || labels[0] != "0" || labels[1] != "2" || labels[2] != "10" {
+		t.Fatalf("labels = %v, want cores in numeric order", labels)
+	}
+
+	core0 := result.Series[0].Points
+	if *core0[0].Value != 10 || core0[1].Value != nil || *core0[2].Value != 30 {
+		t.Fatalf("core 0 = %v %v %v, want 10, null, 30", core0[0].Value, core0[1].Value, core0[2].Value)
+	}
+}
+
+func TestQueryValidates(t *testing.T) {
+	svc := &Service{repo: &fakeMetricsRepo{}}
+
+	cases := map[string]domain.MetricsQuery{
+		"unknown metric": {Metric: "cpu.nope"},
+		"empty range":    {Metric: "cpu.usage_percent", From: at(10, 0, 0), To: at(9, 0, 0)},
+		"step too small": {Metric: "cpu.usage_percent", Step: time.Second},
+		"too many steps": {Metric: "cpu.usage_percent", From: at(1, 0, 0), To: at(10, 0, 0), Step: 5 * time.Second},
+	}
+	for name, q := range cases {
+		if _, err := svc.Query(context.Background(), uuid.New(), q); !errors.Is(err, domain.ErrInvalidMetricsQuery) {
+			t.Errorf("%s: err = %v, want ErrInvalidMetricsQuery", name, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type rollupWindow struct {
+	resolution domain.MetricsResolution
+	from, to   time.Time
+}
+
+type fakeMetricsRepo struct {
+	domain.MetricsRepository
+
+	oldest   time.Time
+	stale    []time.Time
+	staleEnd time.Time
+	rollups  []rollupWindow
+	listed   *domain.MetricsRollupListOptions
+
+	oldestRollups map[domain.MetricsResolution]time.Time
+	oldestOf      []uuid.UUID
+	samples       []domain.MetricsQuerySample
+	queried       *rollupWindow
+}
+
+func (f *fakeMetricsRepo) OldestSample(_ context.Context, serverID uuid.UUID) (time.Time, bool, error) {
+	f.oldestOf = append(f.oldestOf, serverID)
+	return f.oldest, !f.oldest.IsZero(), nil
+}
+
+func (f *fakeMetricsRepo) StaleRollups(_ context.Context, _ domain.MetricsResolution, end time.Time) ([]time.Time, error) {
+	f.staleEnd = end
+	return f.stale, nil
+}
+
+func (f *fakeMetricsRepo) RollUp(_ context.Context, resolution domain.MetricsResolution, from, to time.Time) error {
+	f.rollups = append(f.rollups, rollupWindow{resolution, from, to})
+	return nil
+}
+
+func (f *fakeMetricsRepo) ListRollups(_ context.Context, _ uuid.UUID, opts domain.MetricsRollupListOptions) ([]domain.MetricsRollup, error) {
+	f.listed = &opts
+	return nil, nil
+}
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+}
+
+func hours(from, to time.Time) []time.Time {
+	var buckets []time.Time
+	for t := from; t.Before(to); t = t.Add(time.Hour) {
+		buckets = append(buckets, t)
+	}
+	return buckets
+}
+
+func assertRollups(t *testing.T, got, want []rollupWindow) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("rollups = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("rollup %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRollUpWithoutStaleBuckets(t *testing.T) {
+	repo := &fakeMetricsRepo{}
+	svc := &Service{repo: repo}
+
+	if err := svc.RollUp(context.Background(), domain.MetricsHourly, at(10, 12, 30)); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.rollups) != 0 {
+		t.Fatalf("rolled up %v without stale buckets", repo.rollups)
+	}
+	if want := at(10, 12, 0); !repo.staleEnd.Equal(want) {
+		t.Fatalf("looked for stale buckets before %v, want %v", repo.staleEnd, want)
+	}
+}
+
+func TestRollUpCatchesUpADayAtATime(t *testing.T) {
+	repo := &fakeMetricsRepo{stale: hours(at(8, 22, 0), at(10, 12, 0))}
+	svc := &Service{repo: repo}
+
+	if err := svc.RollUp(context.Background(), domain.MetricsHourly, at(10, 12, 30)); err != nil {
+		t.Fatal(err)
+	}
+
+	assertRollups(t, repo.rollups, []rollupWindow{
+		{domain.MetricsHourly, at(8, 22, 0), at(9, 22, 0)},
+		{domain.MetricsHourly, at(9, 22, 0), at(10, 12, 0)},
+	})
+}
+
+// Samples that arrive late, say replayed from an agent's spool after an
+// outage, make their old buckets stale again; those are redone alone.
+func TestRollUpRedoesLateBuckets(t *testing.T) {
+	repo := &fakeMetricsRepo{stale: []time.Time{at(3, 5, 0), at(9, 10, 0), at(9, 11, 0), at(10, 11, 0)}}
+	svc := &Service{repo: repo}
+
+	if err := svc.RollUp(context.Background(), domain.MetricsHourly, at(10, 12, 30)); err != nil {
+		t.Fatal(err)
+	}
+
+	assertRollups(t, repo.rollups, []rollupWindow{
+		{domain.MetricsHourly, at(3, 5, 0), at(3, 6, 0)},
+		{domain.MetricsHourly, at(9, 10, 0), at(9, 12, 0)},
+		{domain.MetricsHourly, at(10, 11, 0), at(10, 12, 0)},
+	})
+}
+
+func TestRollUpCutsDaysInUTC(t *testing.T) {
+	repo := &fakeMetricsRepo{stale: []time.Time{at(8, 0, 0), at(9, 0, 0)}}
+	svc := &Service{repo: repo}
+
+	// Local midnight is not the bucket boundary: days are cut in UTC.
+	now := time.Date(2026, 3, 10, 8, 0, 0, 0, time.FixedZone("WIB", 7*60*60))
+	if err := svc.RollUp(context.Background(), domain.MetricsDaily, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := at(10, 0, 0); !repo.staleEnd.Equal(want) {
+		t.Fatalf("looked for stale buckets before %v, want %v", repo.staleEnd, want)
+	}
+	assertRollups(t, repo.rollups, []rollupWindow{
+		{domain.MetricsDaily, at(8, 0, 0), at(9, 0, 0)},
+		{domain.MetricsDaily, at(9, 0, 0), at(10, 0, 0)},
+	})
+}
+
+func TestRollUpRejectsUnknownResolution(t *testing.T) {
+	svc := &Service{repo: &fakeMetricsRepo{}}
+
+	if err := svc.RollUp(context.Background(), "minute", at(10, 0, 0)); !errors.Is(err, domain.ErrInvalidMetricsRollupQuery) {
+		t.Fatalf("err = %v, want ErrInvalidMetricsRollupQuery", err)
+	}
+}
+
+func TestRollupsValidatesOptions(t *testing.T) {
+	repo := &fakeMetricsRepo{}
+	svc := &Service{repo: repo}
+	serverID := uuid.New()
+
+	if _, err := svc.Rollups(context.Background(), serverID, domain.MetricsRollupListOptions{
+		Resolution: domain.MetricsDaily,
+		From:       at(10, 0, 0),
+		To:         at(9, 0, 0),
+	}); !errors.Is(err, domain.ErrInvalidMetricsRollupQuery) {
+		t.Fatalf("err = %v, want ErrInvalidMetricsRollupQuery", err)
+	}
+	if repo.listed != nil {
+		t.Fatal("listed rollups for an empty range")
+	}
+
+	if _, err := svc.Rollups(context.Background(), serverID, domain.MetricsRollupListOptions{
+		Resolution: domain.MetricsDaily,
+		To:         at(31, 0, 0),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := at(31, 0, 0).AddDate(0, 0, -90); !repo.listed.From.Equal(want) {
+		t.Fatalf("from = %v, want %v", repo.listed.From, want)
+	}
+}
@@ -0,0 +1,199 @@
+// Package preview runs per-pull-request preview environments: ephemeral
+// child applications deployed from a PR's head commit.
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"horizonx/internal/domain"
+)
+
+// reapBatch bounds how many expired previews one ReapExpired pass tears down.
+const reapBatch = 100
+
+type Service struct {
+	repo   domain.ApplicationRepository
+	appSvc domain.ApplicationService
+}
+
+func NewService(repo domain.ApplicationRepository, appSvc domain.ApplicationService) domain.PreviewService {
+	return &Service{repo: repo, appSvc: appSvc}
+}
+
+func (s *Service) List(ctx context.Context, parentID int64) ([]*domain.Application, error) {
+	previews, _, err := s.repo.List(ctx, domain.ApplicationListOptions{
+		ListOptions:         domain.ListOptions{Limit: 100},
+		ParentApplicationID: &parentID,
+	})
+	return previews, err
+}
+
+func (s *Service) Deploy(ctx context.Context, parentID int64, pr domain.PullRequest) (*domain.Application, *domain.Deployment, error) {
+	if err := domain.ValidateGitRef(pr.HeadSHA); err != nil {
+		return nil, nil, err
+	}
+
+	parent, err := s.repo.GetByID(ctx, parentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !parent.PreviewsEnabled || parent.IsPreview() {
+		return nil, nil, domain.ErrPreviewsDisabled
+	}
+
+	expiresAt := time.Now().UTC().Add(parent.PreviewTTL())
+
+	preview, err := s.find(ctx, parentID, pr.Number)
+	switch {
+	case errors.Is(err, domain.ErrPreviewNotFound):
+		if preview, err = s.create(ctx, parent, pr.Number, expiresAt); err != nil {
+			return nil, nil, err
+		}
+	case err != nil:
+		return nil, nil, err
+	default:
+		if err := s.repo.UpdatePreviewExpiry(ctx, preview.ID, expiresAt); err != nil {
+			return nil, nil, err
+		}
+		preview.PreviewExpiresAt = &expiresAt
+	}
+
+	// Re-layer on every deploy so env changes on the parent reach open
+	// previews with their next push.
+	if err := s.syncEnv(ctx, parentID, preview.ID); err != nil {
+		return nil, nil, err
+	}
+
+	deployment, err := s.appSvc.Deploy(ctx, preview.ID, domain.ApplicationDeployRequest{Ref: pr.HeadSHA}, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return preview, deployment, nil
+}
+
+// Destroy tears the preview down through the regular app delete, which
+// queues its app_destroy job.
+func (s *Service) Destroy(ctx context.Context, parentID int64, number int) error {
+	preview, err := s.find(ctx, parentID, number)
+	if err != nil {
+		return err
+	}
+	return s.appSvc.Delete(ctx, preview.ID)
+}
+
+func (s *Service) ReapExpired(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	expired, _, err := s.repo.List(ctx, domain.ApplicationListOptions{
+		ListOptions:      domain.ListOptions{Limit: reapBatch},
+		PreviewExpiredAt: &now,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired previews: %w", err)
+	}
+
+	// A preview that is mid-deploy can't be deleted yet; it is retried on
+	// the next pass.
+	var errs []error
+	reaped := 0
+	for _, preview := range expired {
+		if err := s.appSvc.Delete(ctx, preview.ID); err != nil {
+			errs = append(errs, fmt.Errorf("preview %d: %w", preview.ID, err))
+			continue
+		}
+		reaped++
+	}
+
+	return reaped, errors.Join(errs...)
+}
+
+func (s *Service) find(ctx context.Context, parentID int64, number int) (*domain.Application, error) {
+	previews, _, err := s.repo.List(ctx, domain.ApplicationListOptions{
+		ListOptions:         domain.ListOptions{Limit: 1},
+		ParentApplicationID: &parentID,
+		PreviewNumber:       &number,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(previews) == 0 {
+		return nil, domain.ErrPreviewNotFound
+	}
+	return previews[0], nil
+}
+
+// create registers the preview as a child app on the parent's server. It
+// gets its own repo name, and so its own AppKey and workdir, and deploys the
+// PR head on top of the parent's branch.
+func (s *Service) create(ctx context.Context, parent *domain.Application, number int, expiresAt time.Time) (*domain.Application, error) {
+	suffix := fmt.Sprintf("-pr-%d", number)
+
+	return s.repo.Create(ctx, &domain.Application{
+		ServerID: parent.ServerID,
+		Name:     truncate(fmt.Sprintf("%s (PR #%d)", parent.Name, number), 100),
+		RepoName: truncate(parent.RepoName, 100-len(suffix)) + suffix,
+		RepoURL:  parent.RepoURL,
+		Branch:   parent.Branch,
+		Status:   domain.AppStatusStopped,
+
+		SourceType: domain.SourceGit,
+
+		AutoRollback:    parent.AutoRollback,
+		ReadinessProbes: parent.ReadinessProbes,
+
+		PreDeployCommands:  parent.PreDeployCommands,
+		PostDeployCommands: parent.PostDeployCommands,
+
+		Compose: parent.Compose,
+
+		ParentApplicationID: &parent.ID,
+		PreviewNumber:       &number,
+		PreviewExpiresAt:    &expiresAt,
+	})
+}
+
+// syncEnv gives the preview the parent's base env with the preview-flagged
+// vars layered over it.
+func (s *Service) syncEnv(ctx context.Context, parentID, previewID int64) error {
+	envVars, err := s.repo.ListEnvVars(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch env vars: %w", err)
+	}
+
+	layered := make(map[string]domain.EnvironmentVariable)
+	for _, env := range envVars {
+		if !env.IsPreview {
+			layered[env.Key] = env
+		}
+	}
+	for _, env := range envVars {
+		if env.IsPreview {
+			layered[env.Key] = env
+		}
+	}
+
+	keys := make([]string, 0, len(layered))
+	for key := range layered {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	previewEnv := make([]domain.EnvironmentVariable, 0, len(keys))
+	for _, key := range keys {
+		env := layered[key]
+		previewEnv = append(previewEnv, domain.EnvironmentVariable{Key: key, Value: env.Value, IsSecret: env.IsSecret})
+	}
+
+	return s.repo.SyncEnvVars(ctx, previewID, previewEnv)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,157 @@
+package preview_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"horizonx/internal/application/preview"
+	"horizonx/internal/domain"
+	"horizonx/internal/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAppSvc struct {
+	domain.ApplicationService
+
+	deployed []int64
+	refs     []string
+	deleted  []int64
+}
+
+func (f *fakeAppSvc) Deploy(ctx context.Context, appID int64, req domain.ApplicationDeployRequest, deployedBy int64) (*domain.Deployment, error) {
+	f.deployed = append(f.deployed, appID)
+	f.refs = append(f.refs, req.Ref)
+	return &domain.Deployment{ID: 900, ApplicationID: appID}, nil
+}
+
+func (f *fakeAppSvc) Delete(ctx context.Context, appID int64) error {
+	f.deleted = append(f.deleted, appID)
+	return nil
+}
+
+const headSHA = "9fceb02d0ae598e95dc970b74767f19372d61af8"
+
+func parentApp() *domain.Application {
+	return &domain.Application{
+		ID:              1,
+		Name:            "shop",
+		RepoName:        "shop",
+		RepoURL:         "git@github.com:acme/shop.git",
+		Branch:          "main",
+		PreviewsEnabled: true,
+		PreviewTTLHours: 24,
+	}
+}
+
+func isPreviewLookup(number int) func(domain.ApplicationListOptions) bool {
+	return func(opts domain.ApplicationListOptions) bool {
+		return opts.ParentApplicationID != nil && *opts.ParentApplicationID == 1 &&
+			opts.PreviewNumber != nil && *opts.PreviewNumber == number
+	}
+}
+
+func TestDeploy_CreatesPreviewWithLayeredEnv(t *testing.T) {
+	repo := mocks.NewMockApplicationRepository(t)
+	apps := &fakeAppSvc{}
+
+	repo.EXPECT().GetByID(mock.Anything, int64(1)).Return(parentApp(), nil)
+	repo.EXPECT().List(mock.Anything, mock.MatchedBy(isPreviewLookup(7))).Return(nil, 0, nil)
+
+	var created *domain.Application
+	repo.EXPECT().Create(mock.Anything, mock.Anything).RunAndReturn(
+		func(ctx context.Context, app *domain.Application) (*domain.Application, error) {
+			app.ID = 42
+			created = app
+			return app, nil
+		})
+	repo.EXPECT().ListEnvVars(mock.Anything, int64(1)).Return([]domain.EnvironmentVariable{
+		{Key: "API_URL", Value: "https://api.example.com"},
+		{Key: "API_URL", Value: "https://staging-api.example.com", IsPreview: true},
+		{Key: "APP_ENV", Value: "production"},
+		{Key: "DEBUG", Value: "true", IsPreview: true},
+	}, nil)
+
+	var synced []domain.EnvironmentVariable
+	repo.EXPECT().SyncEnvVars(mock.Anything, int64(42), mock.Anything).RunAndReturn(
+		func(ctx context.Context, appID int64, env []domain.EnvironmentVariable) error {
+			synced = env
+			return nil
+		})
+
+	svc := preview.NewService(repo, apps)
+	app, deployment, err := svc.Deploy(context.Background(), 1, domain.PullRequest{
+		Number: 7, BaseBranch: "main", HeadBranch: "feature", HeadSHA: headSHA,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(42), app.ID)
+	assert.Equal(t, int64(900), deployment.ID)
+	assert.Equal(t, "shop-pr-7", created.RepoName)
+	assert.Equal(t, "main", created.Branch)
+	assert.Equal(t, int64(1), *created.ParentApplicationID)
+	assert.Equal(t, 7, *created.PreviewNumber)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), *created.PreviewExpiresAt, time.Minute)
+
+	assert.Equal(t, []domain.EnvironmentVariable{
+		{Key: "API_URL", Value: "https://staging-api.example.com"},
+		{Key: "APP_ENV", Value: "production"},
+		{Key: "DEBUG", Value: "true"},
+	}, synced)
+
+	assert.Equal(t, []int64{42}, apps.deployed)
+	assert.Equal(t, []string{headSHA}, apps.refs)
+}
+
+func TestDeploy_ExistingPreviewExtendsTTL(t *testing.T) {
+	repo := mocks.NewMockApplicationRepository(t)
+	apps := &fakeAppSvc{}
+	number := 7
+	parentID := int64(1)
+
+	repo.EXPECT().GetByID(mock.Anything, int64(1)).Return(parentApp(), nil)
+	repo.EXPECT().List(mock.Anything, mock.MatchedBy(isPreviewLookup(7))).Return([]*domain.Application{
+		{ID: 42, ParentApplicationID: &parentID, PreviewNumber: &number},
+	}, 1, nil)
+	repo.EXPECT().UpdatePreviewExpiry(mock.Anything, int64(42), mock.Anything).Return(nil)
+	repo.EXPECT().ListEnvVars(mock.Anything, int64(1)).Return(nil, nil)
+	repo.EXPECT().SyncEnvVars(mock.Anything, int64(42), mock.Anything).Return(nil)
+
+	svc := preview.NewService(repo, apps)
+	_, _, err := svc.Deploy(context.Background(), 1, domain.PullRequest{Number: 7, HeadSHA: headSHA})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int64{42}, apps.deployed)
+}
+
+func TestDeploy_PreviewsDisabled(t *testing.T) {
+	repo := mocks.NewMockApplicationRepository(t)
+	parent := parentApp()
+	parent.PreviewsEnabled = false
+
+	repo.EXPECT().GetByID(mock.Anything, int64(1)).Return(parent, nil)
+
+	svc := preview.NewService(repo, &fakeAppSvc{})
+	_, _, err := svc.Deploy(context.Background(), 1, domain.PullRequest{Number: 7, HeadSHA: headSHA})
+
+	assert.ErrorIs(t, err, domain.ErrPreviewsDisabled)
+}
+
+func TestReapExpired_DeletesExpiredPreviews(t *testing.T) {
+	repo := mocks.NewMockApplicationRepository(t)
+	apps := &fakeAppSvc{}
+
+	repo.EXPECT().List(mock.Anything, mock.MatchedBy(func(opts domain.ApplicationListOptions) bool {
+		return opts.PreviewExpiredAt != nil && !opts.PreviewExpiredAt.After(time.Now())
+	})).Return([]*domain.Application{{ID: 42}, {ID: 43}}, 2, nil)
+
+	svc := preview.NewService(repo, apps)
+	reaped, err := svc.ReapExpired(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, reaped)
+	assert.Equal(t, []int64{42, 43}, apps.deleted)
+}
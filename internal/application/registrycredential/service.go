@@ -0,0 +1,60 @@
+// Package registrycredential manages the per-application container registry
+// login the agent pulls an image app's image with.
+package registrycredential
+
+import (
+	"context"
+
+	"horizonx/internal/domain"
+)
+
+type Service struct {
+	repo   domain.RegistryCredentialRepository
+	appSvc domain.ApplicationService
+}
+
+func NewService(repo domain.RegistryCredentialRepository, appSvc domain.ApplicationService) domain.RegistryCredentialService {
+	return &Service{repo: repo, appSvc: appSvc}
+}
+
+func (s *Service) Get(ctx context.Context, appID int64) (*domain.ApplicationRegistryCredentials, error) {
+	creds, err := s.repo.GetByApplicationID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	return redact(creds), nil
+}
+
+// Set replaces the app's registry login. Only image apps pull from a
+// registry, so git apps are refused.
+func (s *Service) Set(ctx context.Context, appID int64, req domain.RegistryCredentialsRequest) (*domain.ApplicationRegistryCredentials, error) {
+	app, err := s.appSvc.GetByID(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	if !app.IsImage() {
+		return nil, domain.ErrInvalidApplicationSource
+	}
+
+	if err := s.repo.Save(ctx, &domain.ApplicationRegistryCredentials{
+		ApplicationID: appID,
+		Registry:      req.Registry,
+		Username:      req.Username,
+		Password:      req.Password,
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, appID)
+}
+
+func (s *Service) Delete(ctx context.Context, appID int64) error {
+	return s.repo.Delete(ctx, appID)
+}
+
+// redact drops the password before credentials leave the service.
+func redact(creds *domain.ApplicationRegistryCredentials) *domain.ApplicationRegistryCredentials {
+	out := *creds
+	out.Password = ""
+	return &out
+}
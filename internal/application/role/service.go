@@ -23,6 +23,7 @@ var roleHasPermissions = map[domain.RoleConst]map[domain.PermissionConst]bool{
 		domain.PermMemberWrite: true,
 		domain.PermAppRead:     true,
 		domain.PermAppWrite:    true,
+		domain.PermAppExec:     true,
 	},
 	domain.RoleViewer: {
 		domain.PermMetricsRead: true,
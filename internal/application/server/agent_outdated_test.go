@@ -0,0 +1,49 @@
+package server_test
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"horizonx/internal/application/server"
+	"horizonx/internal/domain"
+	"horizonx/internal/mocks"
+	"horizonx/internal/version"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_List_FlagsOutdatedAgents(t *testing.T) {
+	mockRepo := mocks.NewMockServerRepository(t)
+
+	current := &domain.Server{ID: uuid.New(), OSInfo: &domain.OSInfo{}, AgentInfo: &domain.AgentInfo{
+		Version:  version.Version,
+		JobTypes: maps.Clone(domain.JobPayloadVersions),
+	}}
+	missingJob := &domain.Server{ID: uuid.New(), OSInfo: &domain.OSInfo{}, AgentInfo: &domain.AgentInfo{
+		Version:  version.Version,
+		JobTypes: map[domain.JobType]int{domain.JobTypeAppDeploy: 1},
+	}}
+	olderBuild := &domain.Server{ID: uuid.New(), OSInfo: &domain.OSInfo{}, AgentInfo: &domain.AgentInfo{
+		Version:  "0.0.1",
+		JobTypes: maps.Clone(domain.JobPayloadVersions),
+	}}
+	preHello := &domain.Server{ID: uuid.New(), OSInfo: &domain.OSInfo{}}
+	neverConnected := &domain.Server{ID: uuid.New()}
+
+	mockRepo.EXPECT().
+		List(mock.Anything, mock.Anything).
+		Return([]*domain.Server{current, missingJob, olderBuild, preHello, neverConnected}, int64(5), nil)
+
+	svc := server.NewService(mockRepo, nil)
+	_, err := svc.List(context.Background(), domain.ServerListOptions{})
+
+	assert.NoError(t, err)
+	assert.False(t, current.AgentOutdated)
+	assert.True(t, missingJob.AgentOutdated)
+	assert.True(t, olderBuild.AgentOutdated)
+	assert.True(t, preHello.AgentOutdated)
+	assert.False(t, neverConnected.AgentOutdated)
+}
@@ -6,6 +6,7 @@ import (
 
 	"horizonx/internal/domain"
 	"horizonx/internal/event"
+	"horizonx/internal/version"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -42,6 +43,10 @@ func (s *Service) List(ctx context.Context, opts domain.ServerListOptions) (*dom
 		return nil, err
 	}
 
+	for _, srv := range servers {
+		flagOutdatedAgent(srv)
+	}
+
 	res := &domain.ListResult[*domain.Server]{
 		Data: servers,
 		Meta: nil,
@@ -55,7 +60,24 @@ func (s *Service) List(ctx context.Context, opts domain.ServerListOptions) (*dom
 }
 
 func (s *Service) GetByID(ctx context.Context, serverID uuid.UUID) (*domain.Server, error) {
-	return s.repo.GetByID(ctx, serverID)
+	srv, err := s.repo.GetByID(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	flagOutdatedAgent(srv)
+	return srv, nil
+}
+
+// flagOutdatedAgent sets AgentOutdated for the dashboard. An agent that has
+// reported its OS info but never sent a hello predates version negotiation;
+// a server whose agent never connected is not flagged.
+func flagOutdatedAgent(srv *domain.Server) {
+	if srv.AgentInfo != nil {
+		srv.AgentOutdated = srv.AgentInfo.Outdated(version.Version)
+		return
+	}
+	srv.AgentOutdated = srv.OSInfo != nil
 }
 
 func (s *Service) Register(ctx context.Context, req domain.ServerSaveRequest) (*domain.Server, string, error) {
@@ -102,6 +124,21 @@ func (s *Service) UpdateOSInfo(ctx context.Context, serverID uuid.UUID, osInfo d
 	return s.repo.UpdateOSInfo(ctx, serverID, osInfo)
 }
 
+func (s *Service) UpdateAgentInfo(ctx context.Context, serverID uuid.UUID, info domain.AgentInfo) error {
+	if err := s.repo.UpdateAgentInfo(ctx, serverID, info); err != nil {
+		return err
+	}
+
+	if s.bus != nil {
+		s.bus.Publish("agent_connected", domain.EventAgentConnected{
+			ServerID: serverID,
+			Info:     info,
+		})
+	}
+
+	return nil
+}
+
 func (s *Service) Delete(ctx context.Context, serverID uuid.UUID) error {
 	if _, err := s.repo.GetByID(ctx, serverID); err != nil {
 		return err
@@ -30,6 +30,23 @@ type Config struct {
 	AgentServerID       uuid.UUID
 	AgentJobWorkerCount int
 
+	// AgentMetricsBuffer is where the agent keeps recent metrics samples:
+	// "file" (default, next to the apps) or "redis" (REDIS_* settings).
+	AgentMetricsBuffer string
+
+	// ExecIdleTimeout ends an interactive shell into an app container when
+	// nothing was typed or printed for this long (EXEC_IDLE_TIMEOUT, default
+	// 15m).
+	ExecIdleTimeout time.Duration
+
+	// Days of metrics history kept: raw samples (METRICS_RAW_RETENTION_DAYS,
+	// default 7, at least 2 so a day is rolled up before it is deleted),
+	// hourly rollups (METRICS_HOURLY_RETENTION_DAYS, default 90) and daily
+	// rollups (METRICS_DAILY_RETENTION_DAYS, default 730).
+	MetricsRawRetentionDays    int
+	MetricsHourlyRetentionDays int
+	MetricsDailyRetentionDays  int
+
 	// P2-15: optional webhook notified on deployment events.
 	// Discord-style: POSTed a JSON payload with a text content field.
 	WebhookURL string
@@ -102,6 +119,19 @@ func Load() *Config {
 		}
 	}
 
+	// Exec Idle Timeout
+	execIdleTimeout := 15 * time.Minute
+	if raw := os.Getenv("EXEC_IDLE_TIMEOUT"); raw != "" {
+		if duration, err := time.ParseDuration(raw); err == nil && duration > 0 {
+			execIdleTimeout = duration
+		}
+	}
+
+	// Metrics Retention
+	metricsRawRetentionDays := max(getDays("METRICS_RAW_RETENTION_DAYS", 7), 2)
+	metricsHourlyRetentionDays := getDays("METRICS_HOURLY_RETENTION_DAYS", 90)
+	metricsDailyRetentionDays := getDays("METRICS_DAILY_RETENTION_DAYS", 730)
+
 	// AGENT Target URL
 	agentTargetAPIURL := getEnv("HORIZONX_API_URL", "http://localhost:3000")
 	agentTargetWsURL := getEnv("HORIZONX_WS_URL", "ws://localhost:3000/ws/agent")
@@ -122,6 +152,8 @@ func Load() *Config {
 		}
 	}
 
+	agentMetricsBuffer := strings.ToLower(getEnv("AGENT_METRICS_BUFFER", "file"))
+
 	// P2-15: optional webhook (e.g. Discord) notified on deploy events.
 	webhookURL := getEnv("WEBHOOK_URL", "")
 
@@ -176,6 +208,13 @@ func Load() *Config {
 		AgentServerAPIToken: agentServerAPIToken,
 		AgentServerID:       agentServerID,
 		AgentJobWorkerCount: agentJobWorkerCount,
+		AgentMetricsBuffer:  agentMetricsBuffer,
+
+		ExecIdleTimeout: execIdleTimeout,
+
+		MetricsRawRetentionDays:    metricsRawRetentionDays,
+		MetricsHourlyRetentionDays: metricsHourlyRetentionDays,
+		MetricsDailyRetentionDays:  metricsDailyRetentionDays,
 
 		WebhookURL: webhookURL,
 
@@ -199,3 +238,12 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getDays reads a positive number of days, falling back when unset or
+// invalid.
+func getDays(key string, fallback int) int {
+	if days, err := strconv.Atoi(os.Getenv(key)); err == nil && days > 0 {
+		return days
+	}
+	return fallback
+}
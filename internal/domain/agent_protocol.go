@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrJobUnsupported is returned when a job is created for an agent that
+// advertised it can't run it: the job type is unknown to it, or its payload
+// is newer than the agent understands.
+var ErrJobUnsupported = errors.New("agent does not support this job")
+
+// JobPayloadVersions is the payload schema version of every job type this
+// build knows. The control plane stamps new jobs with it and the agent
+// advertises it on connect. Bump a type's version when its payload gains a
+// field an older agent would ignore or misread; adding a job type adds an
+// entry.
+var JobPayloadVersions = map[JobType]int{
+	JobTypeAppDeploy:      1,
+	JobTypeAppStart:       1,
+	JobTypeAppStop:        1,
+	JobTypeAppRestart:     1,
+	JobTypeAppRollback:    1,
+	JobTypeAppHealthCheck: 1,
+	JobTypeAppDestroy:     1,
+	JobTypeAppService:     1,
+	JobTypeMetricsCollect: 1,
+	JobTypeAgentUpgrade:   1,
+}
+
+// AgentInfo is what an agent advertises in its "agent_hello" message when it
+// connects: its build version and, for each job type it can run, the newest
+// payload version it understands.
+type AgentInfo struct {
+	Version  string          `json:"version"`
+	JobTypes map[JobType]int `json:"job_types"`
+
+	// ReportedAt is set by the server when the hello is stored.
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// Supports returns ErrJobUnsupported when the agent can't run a job of type t
+// whose payload is at payloadVersion.
+func (a *AgentInfo) Supports(t JobType, payloadVersion int) error {
+	supported, ok := a.JobTypes[t]
+	if !ok {
+		return fmt.Errorf("%w: agent %s does not know job type %s; upgrade the agent", ErrJobUnsupported, a.Version, t)
+	}
+	if payloadVersion > supported {
+		return fmt.Errorf("%w: agent %s understands %s payloads up to v%d, job needs v%d; upgrade the agent",
+			ErrJobUnsupported, a.Version, t, supported, payloadVersion)
+	}
+	return nil
+}
+
+// Outdated reports whether the agent runs a different build than the server
+// (version.Version, passed as serverVersion) or can't run every job this
+// build creates.
+func (a *AgentInfo) Outdated(serverVersion string) bool {
+	if a.Version != serverVersion {
+		return true
+	}
+	for t, v := range JobPayloadVersions {
+		if a.Supports(t, v) != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// While the server is unreachable the agent spools its reports (job results,
+// logs, metrics) on disk and replays them in order once it is back. Each
+// replayed request carries the spool's ID and the record's sequence number,
+// so a request delivered twice — applied by the server, but the agent never
+// saw the response — is acknowledged without being applied again.
+const (
+	AgentSpoolIDHeader  = "X-Agent-Spool-ID"
+	AgentSpoolSeqHeader = "X-Agent-Spool-Seq"
+)
+
+type AgentSpoolRepository interface {
+	// LastSeq returns the highest sequence number applied from the server's
+	// spool spoolID, or 0 when none was.
+	LastSeq(ctx context.Context, serverID, spoolID uuid.UUID) (int64, error)
+	// Ack records seq as applied. A server keeps one spool: acking a new
+	// spool ID replaces the old one.
+	Ack(ctx context.Context, serverID, spoolID uuid.UUID, seq int64) error
+}
+
+type AgentSpoolService interface {
+	// Applied reports whether the record seq of spoolID was already applied.
+	Applied(ctx context.Context, serverID, spoolID uuid.UUID, seq int64) (bool, error)
+	Ack(ctx context.Context, serverID, spoolID uuid.UUID, seq int64) error
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidAgentVersion    = errors.New("invalid agent version")
+	ErrAgentUpgradeInProgress = errors.New("an agent upgrade is already queued or running for this server")
+	ErrServerOffline          = errors.New("server is offline")
+)
+
+// agentVersionPattern matches a release tag, with or without its leading v.
+var agentVersionPattern = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-[0-9A-Za-z.]+)?$`)
+
+// ValidateAgentVersion rejects anything but a release tag. Empty means the
+// latest release.
+func ValidateAgentVersion(v string) error {
+	if v != "" && !agentVersionPattern.MatchString(v) {
+		return fmt.Errorf("%w: %q is not a release tag like v1.2.3", ErrInvalidAgentVersion, v)
+	}
+	return nil
+}
+
+type AgentUpgradeRequest struct {
+	// Version pins the release to install; empty means the latest.
+	Version string `json:"version" validate:"omitempty,max=64"`
+}
+
+type AgentRolloutRequest struct {
+	// ServerIDs are upgraded one at a time, in order; empty means every
+	// online server.
+	ServerIDs []uuid.UUID `json:"server_ids"`
+	Version   string      `json:"version" validate:"omitempty,max=64"`
+}
+
+// AgentRollout is a fleet upgrade: one agent_upgrade job per server, run one
+// after another and stopped at the first that doesn't succeed. Its jobs
+// share TraceID, so the rollout's progress is the job list for that trace.
+type AgentRollout struct {
+	TraceID   uuid.UUID   `json:"trace_id"`
+	Version   string      `json:"version,omitempty"`
+	ServerIDs []uuid.UUID `json:"server_ids"`
+}
+
+type AgentUpgradeService interface {
+	Upgrade(ctx context.Context, serverID uuid.UUID, req AgentUpgradeRequest) (*Job, error)
+	Rollout(ctx context.Context, req AgentRolloutRequest) (*AgentRollout, error)
+	// AgentConnected completes the server's running upgrade once its agent
+	// is back on the requested release.
+	AgentConnected(ctx context.Context, serverID uuid.UUID, info AgentInfo) error
+	// ContinueRollout queues the next server of the rollout jobID belongs
+	// to, if any.
+	ContinueRollout(ctx context.Context, jobID int64) error
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +14,26 @@ import (
 var (
 	ErrApplicationNotFound  = errors.New("application not found")
 	ErrInvalidDockerCompose = errors.New("invalid docker compose configuration")
+
+	// ErrInvalidApplicationSource is returned when an application's source
+	// fields do not match its source type.
+	ErrInvalidApplicationSource = errors.New("invalid application source")
+
+	ErrServiceNotFound      = errors.New("service not found")
+	ErrInvalidServiceAction = errors.New("invalid service action")
+
+	ErrInvalidReadinessProbe = errors.New("invalid readiness probe")
+)
+
+// ApplicationSourceType is where a deploy gets the app's image from.
+type ApplicationSourceType string
+
+const (
+	// SourceGit clones the repository and builds it with `compose build`.
+	SourceGit ApplicationSourceType = "git"
+	// SourceImage pulls a prebuilt image from a container registry; nothing
+	// is cloned or built on the server.
+	SourceImage ApplicationSourceType = "image"
 )
 
 type ApplicationStatus string
@@ -36,6 +58,35 @@ type Application struct {
 	SiteURL          string            `json:"site_url,omitempty"`
 	Branch           string            `json:"branch"`
 	Status           ApplicationStatus `json:"status"`
+
+	// SourceType defaults to git. Image apps deploy Image, run by
+	// ComposeDefinition or, when that is empty, a generated single-service
+	// compose file.
+	SourceType        ApplicationSourceType `json:"source_type"`
+	Image             string                `json:"image,omitempty"`
+	ComposeDefinition string                `json:"compose_definition,omitempty"`
+
+	AutoRollback     bool              `json:"auto_rollback"`
+	ReadinessProbes  []ReadinessProbe  `json:"readiness_probes"`
+
+	PreDeployCommands  []ReleaseCommand `json:"pre_deploy_commands"`
+	PostDeployCommands []ReleaseCommand `json:"post_deploy_commands"`
+
+	Compose ComposeSpec `json:"compose"`
+
+	// Services is what the agent's last health check saw of each compose
+	// service.
+	Services []ServiceHealth `json:"services"`
+
+	PreviewsEnabled bool `json:"previews_enabled"`
+	PreviewTTLHours int  `json:"preview_ttl_hours"`
+
+	// Set on preview environments only: the app being previewed, the PR it
+	// previews and when it is torn down.
+	ParentApplicationID *int64     `json:"parent_application_id,omitempty"`
+	PreviewNumber       *int       `json:"preview_number,omitempty"`
+	PreviewExpiresAt    *time.Time `json:"preview_expires_at,omitempty"`
+
 	LastDeploymentAt *time.Time        `json:"last_deployment_at,omitempty"`
 	CreatedAt        time.Time         `json:"created_at"`
 	UpdatedAt        time.Time         `json:"updated_at"`
@@ -46,23 +97,73 @@ type Application struct {
 type ApplicationListOptions struct {
 	ListOptions
 	ServerID *uuid.UUID `json:"server_id"`
+
+	ParentApplicationID *int64     `json:"parent_application_id"`
+	PreviewNumber       *int       `json:"preview_number"`
+	PreviewExpiredAt    *time.Time `json:"preview_expired_at"`
 }
 
 type ApplicationCreateRequest struct {
 	ServerID uuid.UUID `json:"server_id" validate:"required"`
 	Name     string    `json:"name" validate:"required,min=3,max=100"`
 	RepoName string    `json:"repo_name" validate:"required,max=100"`
-	RepoURL  string    `json:"repo_url" validate:"required,max=255"`
+	RepoURL  string    `json:"repo_url" validate:"required_unless=SourceType image,omitempty,max=255"`
 	SiteURL  string    `json:"site_url" validate:"omitempty,max=255"`
-	Branch   string    `json:"branch" validate:"required"`
+	Branch   string    `json:"branch" validate:"required_unless=SourceType image"`
+
+	// SourceType is git when empty. Image apps need Image; RepoName still
+	// names the app's directory on the server.
+	SourceType        ApplicationSourceType `json:"source_type" validate:"omitempty,oneof=git image"`
+	Image             string                `json:"image" validate:"required_if=SourceType image,omitempty,max=255"`
+	ComposeDefinition string                `json:"compose_definition" validate:"omitempty,max=65536"`
+
+	// AutoRollback restores the previously running image when a deploy
+	// fails its health gate.
+	AutoRollback bool `json:"auto_rollback"`
+
+	ReadinessProbes []ReadinessProbe `json:"readiness_probes" validate:"omitempty,max=10,dive"`
+
+	PreDeployCommands  []ReleaseCommand `json:"pre_deploy_commands" validate:"omitempty,max=10,dive"`
+	PostDeployCommands []ReleaseCommand `json:"post_deploy_commands" validate:"omitempty,max=10,dive"`
+
+	Compose ComposeSpec `json:"compose"`
+
+	// PreviewsEnabled deploys a preview environment per pull request;
+	// PreviewTTLHours (0 = DefaultPreviewTTL) bounds how long one lives
+	// without a new push.
+	PreviewsEnabled bool `json:"previews_enabled"`
+	PreviewTTLHours int  `json:"preview_ttl_hours" validate:"omitempty,min=1,max=720"`
 
 	EnvVars []EnvironmentVariableRequest `json:"env_vars" validate:"omitempty,dive"`
 }
 
+// ApplicationDeployRequest is the optional body of a deploy. Ref pins the
+// deploy to a commit SHA or tag; empty means the tip of the app's branch.
+type ApplicationDeployRequest struct {
+	Ref string `json:"ref" validate:"omitempty,max=255"`
+}
+
 type ApplicationUpdateRequest struct {
 	Name    string `json:"name" validate:"required,min=3,max=100"`
 	SiteURL string `json:"site_url" validate:"omitempty,max=255"`
-	Branch  string `json:"branch" validate:"required"`
+	// Branch is required for git apps, Image for image apps; the source
+	// type itself cannot change.
+	Branch string `json:"branch" validate:"omitempty,max=255"`
+
+	Image             string `json:"image" validate:"omitempty,max=255"`
+	ComposeDefinition string `json:"compose_definition" validate:"omitempty,max=65536"`
+
+	AutoRollback bool `json:"auto_rollback"`
+
+	ReadinessProbes []ReadinessProbe `json:"readiness_probes" validate:"omitempty,max=10,dive"`
+
+	PreDeployCommands  []ReleaseCommand `json:"pre_deploy_commands" validate:"omitempty,max=10,dive"`
+	PostDeployCommands []ReleaseCommand `json:"post_deploy_commands" validate:"omitempty,max=10,dive"`
+
+	Compose ComposeSpec `json:"compose"`
+
+	PreviewsEnabled bool `json:"previews_enabled"`
+	PreviewTTLHours int  `json:"preview_ttl_hours" validate:"omitempty,min=1,max=720"`
 
 	EnvVars []EnvironmentVariableRequest `json:"env_vars" validate:"omitempty,dive"`
 }
@@ -70,14 +171,76 @@ type ApplicationUpdateRequest struct {
 type ApplicationHealth struct {
 	ApplicationID int64             `json:"application_id"`
 	Status        ApplicationStatus `json:"status"`
+
+	// Services breaks Status down per compose service. Agents that predate
+	// it leave it nil, which keeps the last reported breakdown.
+	Services []ServiceHealth `json:"services,omitempty"`
 }
 
+// ServiceHealth is one compose service of an app: Status aggregates its
+// containers like ApplicationHealth does the app's, and is stopped when none
+// exist (scaled to zero or never started).
+type ServiceHealth struct {
+	Name       string             `json:"name"`
+	Status     ApplicationStatus  `json:"status"`
+	Image      string             `json:"image,omitempty"`
+	Ports      []string           `json:"ports"`
+	Containers []ServiceContainer `json:"containers"`
+}
+
+type ServiceContainer struct {
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Health   string `json:"health,omitempty"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// ServiceAction is what a JobTypeAppService job does to one service.
+type ServiceAction string
+
+const (
+	ServiceStart   ServiceAction = "start"
+	ServiceStop    ServiceAction = "stop"
+	ServiceRestart ServiceAction = "restart"
+	ServiceScale   ServiceAction = "scale"
+)
+
+// MaxServiceReplicas bounds a scale action.
+const MaxServiceReplicas = 50
+
+// ValidateServiceAction checks a service action before it is queued or run;
+// replicas only matters to ServiceScale.
+func ValidateServiceAction(service string, action ServiceAction, replicas int) error {
+	if service == "" || ValidateComposeService(service) != nil {
+		return fmt.Errorf("%w: invalid service name %q", ErrInvalidServiceAction, service)
+	}
+
+	switch action {
+	case ServiceStart, ServiceStop, ServiceRestart:
+		return nil
+	case ServiceScale:
+		if replicas < 0 || replicas > MaxServiceReplicas {
+			return fmt.Errorf("%w: replicas must be between 0 and %d", ErrInvalidServiceAction, MaxServiceReplicas)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidServiceAction, action)
+	}
+}
+
+type ApplicationServiceScaleRequest struct {
+	Replicas *int `json:"replicas" validate:"required,min=0,max=50"`
+}
+
+// EnvironmentVariable is a runtime env var of an app. Secret values are
+// masked by the agent wherever they show up in job output.
 type EnvironmentVariable struct {
 	ID            int64     `json:"id"`
 	ApplicationID int64     `json:"application_id"`
 	Key           string    `json:"key"`
 	Value         string    `json:"value"`
 	IsPreview     bool      `json:"is_preview"`
+	IsSecret      bool      `json:"is_secret"`
 	ValueEncrypted bool     `json:"value_encrypted"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
@@ -87,6 +250,75 @@ type EnvironmentVariableRequest struct {
 	Key       string `json:"key" validate:"required"`
 	Value     string `json:"value" validate:"required"`
 	IsPreview bool   `json:"is_preview"`
+	IsSecret  bool   `json:"is_secret"`
+}
+
+type ProbeType string
+
+const (
+	ProbeHTTP    ProbeType = "http"
+	ProbeTCP     ProbeType = "tcp"
+	ProbeCommand ProbeType = "command"
+)
+
+// ReadinessProbe is an app-defined check the agent runs after `compose up`:
+// a deploy or rollback only passes its health gate once every probe has
+// succeeded SuccessThreshold times in a row. HTTP and TCP probes dial the
+// published Port on the server; command probes run inside Service.
+type ReadinessProbe struct {
+	Type ProbeType `json:"type" validate:"required,oneof=http tcp command"`
+
+	Port           int    `json:"port,omitempty" validate:"required_unless=Type command,omitempty,min=1,max=65535"`
+	Path           string `json:"path,omitempty" validate:"omitempty,startswith=/,max=255"`
+	ExpectedStatus int    `json:"expected_status,omitempty" validate:"omitempty,min=100,max=599"`
+	BodyContains   string `json:"body_contains,omitempty" validate:"omitempty,max=255"`
+
+	Service string `json:"service,omitempty" validate:"required_if=Type command,omitempty,max=100"`
+	Command string `json:"command,omitempty" validate:"required_if=Type command,omitempty,max=1000"`
+
+	TimeoutSeconds   int `json:"timeout_seconds,omitempty" validate:"omitempty,min=1,max=900"`
+	IntervalSeconds  int `json:"interval_seconds,omitempty" validate:"omitempty,min=1,max=300"`
+	SuccessThreshold int `json:"success_threshold,omitempty" validate:"omitempty,min=1,max=20"`
+
+	// AttemptTimeoutSeconds bounds a single attempt; at most the interval,
+	// which it defaults to.
+	AttemptTimeoutSeconds int `json:"attempt_timeout_seconds,omitempty" validate:"omitempty,min=1,max=300"`
+}
+
+// DefaultProbeInterval is how often a probe without IntervalSeconds is tried.
+const DefaultProbeInterval = 3 * time.Second
+
+// Interval is how often the probe is tried.
+func (p ReadinessProbe) Interval() time.Duration {
+	if p.IntervalSeconds <= 0 {
+		return DefaultProbeInterval
+	}
+	return time.Duration(p.IntervalSeconds) * time.Second
+}
+
+// AttemptTimeout is how long one attempt may take.
+func (p ReadinessProbe) AttemptTimeout() time.Duration {
+	if p.AttemptTimeoutSeconds <= 0 {
+		return p.Interval()
+	}
+	return time.Duration(p.AttemptTimeoutSeconds) * time.Second
+}
+
+// Validate rejects an attempt timeout longer than the interval: attempts
+// would overlap the next one's turn.
+func (p ReadinessProbe) Validate() error {
+	if p.AttemptTimeout() > p.Interval() {
+		return fmt.Errorf("%w: attempt timeout %s is longer than the interval %s", ErrInvalidReadinessProbe, p.AttemptTimeout(), p.Interval())
+	}
+	return nil
+}
+
+// ReleaseCommand runs once per deploy in a one-off container of Service
+// built from the new image: pre-deploy commands (migrations) between build and
+// `compose up`, post-deploy commands (cache warmup) once the app is healthy.
+type ReleaseCommand struct {
+	Service string `json:"service" validate:"required,max=100"`
+	Command string `json:"command" validate:"required,max=1000"`
 }
 
 type ApplicationRepository interface {
@@ -97,6 +329,7 @@ type ApplicationRepository interface {
 	UpdateStatus(ctx context.Context, appID int64, status ApplicationStatus) error
 	UpdateLastDeployment(ctx context.Context, appID int64) error
 	UpdateHealth(ctx context.Context, serverID uuid.UUID, reports []ApplicationHealth) error
+	UpdatePreviewExpiry(ctx context.Context, appID int64, expiresAt time.Time) error
 	Delete(ctx context.Context, appID int64) error
 
 	SyncEnvVars(ctx context.Context, appID int64, envVars []EnvironmentVariable) error
@@ -116,18 +349,68 @@ type ApplicationService interface {
 	UpdateHealth(ctx context.Context, serverID uuid.UUID, reports []ApplicationHealth) error
 	Delete(ctx context.Context, appID int64) error
 
-	Deploy(ctx context.Context, appID int64, deployedBy int64) (*Deployment, error)
+	Deploy(ctx context.Context, appID int64, req ApplicationDeployRequest, deployedBy int64) (*Deployment, error)
 	Rollback(ctx context.Context, appID int64, deployedBy int64) (*Deployment, error)
+	RollbackTo(ctx context.Context, appID int64, deploymentID int64, deployedBy int64) (*Deployment, error)
 	Start(ctx context.Context, appID int64) error
 	Stop(ctx context.Context, appID int64) error
 	Restart(ctx context.Context, appID int64) error
 
+	// ListServices returns the app's compose services as last reported by
+	// its agent. The service actions queue a JobTypeAppService job; replicas
+	// only applies to ServiceScale.
+	ListServices(ctx context.Context, appID int64) ([]ServiceHealth, error)
+	ServiceAction(ctx context.Context, appID int64, service string, action ServiceAction, replicas int) error
+
 	ListEnvVars(ctx context.Context, appID int64) ([]EnvironmentVariable, error)
 	AddEnvVar(ctx context.Context, appID int64, req EnvironmentVariableRequest) error
 	UpdateEnvVar(ctx context.Context, appID int64, key string, req EnvironmentVariableRequest) error
 	DeleteEnvVar(ctx context.Context, appID int64, key string) error
 }
 
+// IsImage reports whether the app deploys a prebuilt registry image.
+func (a *Application) IsImage() bool {
+	return a.SourceType == SourceImage
+}
+
+// ValidateSource checks that the fields of the app's source type are set and
+// that no other source's fields are. Image apps have no checkout, so they
+// cannot name compose files in one or get pull-request previews.
+func (a *Application) ValidateSource() error {
+	switch a.SourceType {
+	case SourceGit, "":
+		if a.RepoURL == "" || a.Branch == "" {
+			return fmt.Errorf("%w: git apps need a repository url and branch", ErrInvalidApplicationSource)
+		}
+		if a.Image != "" || a.ComposeDefinition != "" {
+			return fmt.Errorf("%w: image and compose definition are for image apps", ErrInvalidApplicationSource)
+		}
+	case SourceImage:
+		if a.Image == "" || strings.ContainsAny(a.Image, " \t\n") {
+			return fmt.Errorf("%w: image apps need an image reference", ErrInvalidApplicationSource)
+		}
+		if len(a.Compose.Files) > 0 || a.Compose.ProjectDir != "" {
+			return fmt.Errorf("%w: image apps take a compose definition, not compose files", ErrInvalidApplicationSource)
+		}
+		if a.PreviewsEnabled {
+			return fmt.Errorf("%w: previews need a git source", ErrInvalidApplicationSource)
+		}
+	default:
+		return fmt.Errorf("%w: unknown source type %q", ErrInvalidApplicationSource, a.SourceType)
+	}
+	return nil
+}
+
 func GetAppKey(app *Application) string {
 	return fmt.Sprintf("%s-%d", app.RepoName, app.ID)
 }
+
+// ParseAppKey returns the application ID an app key ends in.
+func ParseAppKey(key string) (int64, bool) {
+	i := strings.LastIndexByte(key, '-')
+	if i < 0 {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(key[i+1:], 10, 64)
+	return id, err == nil && id > 0
+}
@@ -11,3 +11,13 @@ type EventApplicationStatusChanged struct {
 	ApplicationID int64             `json:"application_id"`
 	Status        ApplicationStatus `json:"status"`
 }
+
+// EventContainerLogs carries lines of a container logs stream to the user WS
+// channel that asked for it. Done ends the stream, with Error when the agent
+// could not read the logs.
+type EventContainerLogs struct {
+	Channel string             `json:"-"`
+	Lines   []ContainerLogLine `json:"lines,omitempty"`
+	Done    bool               `json:"done,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrBuildVariableNotFound = errors.New("build variable not found")
+	ErrInvalidBuildVariable  = errors.New("invalid build variable")
+)
+
+// buildVariableKey is what both a Dockerfile ARG and a BuildKit secret id
+// accept. Secret keys also name the file the value is written to.
+var buildVariableKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}$`)
+
+// BuildVariable is a value only the image build sees: a build arg passed as
+// `--build-arg`, or a secret mounted with `RUN --mount=type=secret,id=<key>`.
+// Unlike env vars neither is written to the app's runtime .env. Values are
+// encrypted at rest; secret values are never returned by the API.
+type BuildVariable struct {
+	ID             int64     `json:"id"`
+	ApplicationID  int64     `json:"application_id"`
+	Key            string    `json:"key"`
+	Value          string    `json:"value,omitempty"`
+	IsSecret       bool      `json:"is_secret"`
+	ValueEncrypted bool      `json:"value_encrypted"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// BuildVariableRequest sets the build variable named by the URL's key.
+type BuildVariableRequest struct {
+	Value    string `json:"value" validate:"required,max=65536"`
+	IsSecret bool   `json:"is_secret"`
+}
+
+// ValidateBuildVariableKey checks that key is usable as a build arg name and
+// as a secret id.
+func ValidateBuildVariableKey(key string) error {
+	if !buildVariableKey.MatchString(key) {
+		return fmt.Errorf("%w: key must be letters, digits and underscores, not starting with a digit", ErrInvalidBuildVariable)
+	}
+	return nil
+}
+
+// SplitBuildVariables returns the build args and build secrets for a deploy
+// payload, or nils when there are none.
+func SplitBuildVariables(vars []BuildVariable) (args, secrets map[string]string) {
+	for _, v := range vars {
+		if v.IsSecret {
+			if secrets == nil {
+				secrets = make(map[string]string)
+			}
+			secrets[v.Key] = v.Value
+			continue
+		}
+		if args == nil {
+			args = make(map[string]string)
+		}
+		args[v.Key] = v.Value
+	}
+	return args, secrets
+}
+
+type BuildVariableRepository interface {
+	List(ctx context.Context, appID int64) ([]BuildVariable, error)
+	Save(ctx context.Context, v *BuildVariable) error
+	Delete(ctx context.Context, appID int64, key string) error
+}
+
+type BuildVariableService interface {
+	List(ctx context.Context, appID int64) ([]BuildVariable, error)
+	Set(ctx context.Context, appID int64, key string, req BuildVariableRequest) (*BuildVariable, error)
+	Delete(ctx context.Context, appID int64, key string) error
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+// composeProfilePattern is the profile name format compose accepts.
+var composeProfilePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ComposeSpec selects an app's compose stack. ProjectDir is the directory
+// inside the repository compose runs from (and where the agent writes the
+// app's .env); Files are stacked in order like repeated -f flags, relative
+// to ProjectDir; Profiles are activated with --profile. The zero value keeps
+// the agent's auto-detection of a single compose file at the repository root.
+type ComposeSpec struct {
+	Files      []string `json:"files,omitempty" validate:"omitempty,max=10,dive,required,max=255"`
+	ProjectDir string   `json:"project_dir,omitempty" validate:"omitempty,max=255"`
+	Profiles   []string `json:"profiles,omitempty" validate:"omitempty,max=10,dive,required,max=100"`
+}
+
+// Validate rejects paths that would leave the checkout and profile names
+// compose would refuse. The agent checks again before running anything.
+func (c ComposeSpec) Validate() error {
+	if c.ProjectDir != "" && !filepath.IsLocal(c.ProjectDir) {
+		return fmt.Errorf("%w: project dir %q must be a relative path inside the repository", ErrInvalidDockerCompose, c.ProjectDir)
+	}
+	for _, f := range c.Files {
+		if !filepath.IsLocal(f) {
+			return fmt.Errorf("%w: compose file %q must be a relative path inside the repository", ErrInvalidDockerCompose, f)
+		}
+	}
+	for _, p := range c.Profiles {
+		if !composeProfilePattern.MatchString(p) {
+			return fmt.Errorf("%w: invalid profile name %q", ErrInvalidDockerCompose, p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidContainerLogsQuery = errors.New("invalid container logs query")
+	// ErrContainerLogsFailed is returned when the agent could not read the
+	// app's logs, e.g. the app was never deployed on its server.
+	ErrContainerLogsFailed = errors.New("agent failed to read container logs")
+)
+
+const (
+	// ContainerLogsStreamTail is how many past lines a live stream starts
+	// with.
+	ContainerLogsStreamTail  = 100
+	ContainerLogsDefaultTail = 500
+	ContainerLogsMaxTail     = 5000
+)
+
+// ContainerLogLine is one line an app's container printed, as read with
+// `docker compose logs --timestamps`.
+type ContainerLogLine struct {
+	Container string     `json:"container"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Level     LogLevel   `json:"level"`
+	Line      string     `json:"line"`
+}
+
+// ParseContainerLogLine splits a `docker compose logs --no-color
+// --timestamps` line into its container prefix ("web-1  | "), timestamp and
+// text. Lines without a prefix or timestamp are kept whole.
+func ParseContainerLogLine(raw string, level LogLevel) ContainerLogLine {
+	line := ContainerLogLine{Level: level, Line: raw}
+
+	if container, rest, ok := strings.Cut(raw, "| "); ok && !strings.ContainsAny(strings.TrimSpace(container), " \t") {
+		line.Container = strings.TrimSpace(container)
+		line.Line = rest
+	}
+
+	if ts, rest, ok := strings.Cut(line.Line, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			line.Timestamp = &t
+			line.Line = rest
+		}
+	}
+
+	return line
+}
+
+// ContainerLogsQuery is a one-shot read of an app's container logs. Since
+// and Until take what compose does: an RFC 3339 timestamp or a duration
+// back from now such as "15m".
+type ContainerLogsQuery struct {
+	Service string `json:"service,omitempty"`
+	Tail    int    `json:"tail,omitempty"`
+	Since   string `json:"since,omitempty"`
+	Until   string `json:"until,omitempty"`
+}
+
+// Validate checks the query and applies the default tail.
+func (q *ContainerLogsQuery) Validate() error {
+	if err := ValidateComposeService(q.Service); err != nil {
+		return err
+	}
+
+	switch {
+	case q.Tail == 0:
+		q.Tail = ContainerLogsDefaultTail
+	case q.Tail < 0 || q.Tail > ContainerLogsMaxTail:
+		return fmt.Errorf("%w: tail must be between 1 and %d", ErrInvalidContainerLogsQuery, ContainerLogsMaxTail)
+	}
+
+	if !validLogTime(q.Since) || !validLogTime(q.Until) {
+		return fmt.Errorf("%w: since and until must be RFC 3339 timestamps or durations like 15m", ErrInvalidContainerLogsQuery)
+	}
+
+	return nil
+}
+
+func validLogTime(v string) bool {
+	if v == "" {
+		return true
+	}
+	if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return true
+	}
+	d, err := time.ParseDuration(v)
+	return err == nil && d > 0
+}
+
+// ValidateComposeService rejects names compose would not accept as a
+// service. Empty means every service of the stack.
+func ValidateComposeService(service string) error {
+	if service != "" && (len(service) > 100 || !composeProfilePattern.MatchString(service)) {
+		return fmt.Errorf("%w: invalid service name %q", ErrInvalidContainerLogsQuery, service)
+	}
+	return nil
+}
+
+// ContainerLogsChannel is the user WS channel streaming an app's container
+// logs: app:{id}:container-logs, or app:{id}:container-logs:{service} for
+// one service.
+func ContainerLogsChannel(appID int64, service string) string {
+	channel := fmt.Sprintf("app:%d:container-logs", appID)
+	if service != "" {
+		channel += ":" + service
+	}
+	return channel
+}
+
+// ParseContainerLogsChannel is the inverse of ContainerLogsChannel; ok is
+// false for any other channel.
+func ParseContainerLogsChannel(channel string) (appID int64, service string, ok bool) {
+	parts := strings.SplitN(channel, ":", 4)
+	if len(parts) < 3 || parts[0] != "app" || parts[2] != "container-logs" {
+		return 0, "", false
+	}
+
+	appID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || appID <= 0 {
+		return 0, "", false
+	}
+
+	if len(parts) == 4 {
+		service = parts[3]
+		if service == "" || ValidateComposeService(service) != nil {
+			return 0, "", false
+		}
+	}
+
+	return appID, service, true
+}
+
+// ContainerLogsRequest asks an agent to run `docker compose logs` on an
+// app's stack ("container_logs_start"). ID names the stream in the agent's
+// batches and in "container_logs_stop". Tail 0 means every line.
+type ContainerLogsRequest struct {
+	ID string `json:"id"`
+	AppInfo
+	Service string `json:"service,omitempty"`
+	Tail    int    `json:"tail,omitempty"`
+	Follow  bool   `json:"follow,omitempty"`
+	Since   string `json:"since,omitempty"`
+	Until   string `json:"until,omitempty"`
+}
+
+type ContainerLogsStop struct {
+	ID string `json:"id"`
+}
+
+// ContainerLogsBatch carries lines of stream ID from the agent
+// ("container_logs"). Done is set on the last batch, with Error when compose
+// failed.
+type ContainerLogsBatch struct {
+	ID    string             `json:"id"`
+	Lines []ContainerLogLine `json:"lines,omitempty"`
+	Done  bool               `json:"done,omitempty"`
+	Error string             `json:"error,omitempty"`
+}
+
+// ContainerLogsTransport reaches the agent running an app. Delivery is best
+// effort: a request sent to an offline agent is dropped.
+type ContainerLogsTransport interface {
+	StartContainerLogs(serverID uuid.UUID, req ContainerLogsRequest) error
+	StopContainerLogs(serverID uuid.UUID, id string) error
+}
+
+type ContainerLogService interface {
+	// ChannelActive starts the stream of a container logs channel when it
+	// gets its first subscriber and stops it when the last one leaves.
+	// Other channels are ignored. It must not block: the user WS hub calls
+	// it from its loop.
+	ChannelActive(channel string, active bool)
+	// AgentConnected resumes the server's streams after its agent
+	// reconnected.
+	AgentConnected(serverID uuid.UUID)
+	// HandleBatch takes lines the agent on serverID sent.
+	HandleBatch(serverID uuid.UUID, batch ContainerLogsBatch)
+	// Fetch reads an app's logs once, without following them.
+	Fetch(ctx context.Context, appID int64, query ContainerLogsQuery) ([]ContainerLogLine, error)
+}
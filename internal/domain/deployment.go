@@ -4,10 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 )
 
-var ErrDeploymentNotFound = errors.New("deployment not found")
+var (
+	ErrDeploymentNotFound = errors.New("deployment not found")
+	ErrInvalidGitRef      = errors.New("invalid git ref")
+
+	// ErrDeploymentNotRollbackable is returned for rollback targets that never
+	// produced an image: unfinished or failed deployments, or ones with no
+	// recorded commit or image digest.
+	ErrDeploymentNotRollbackable = errors.New("deployment cannot be rolled back to")
+)
 
 type DeploymentStatus string
 
@@ -16,14 +25,20 @@ const (
 	DeploymentDeploying DeploymentStatus = "deploying"
 	DeploymentSuccess   DeploymentStatus = "success"
 	DeploymentFailed    DeploymentStatus = "failed"
+	DeploymentCancelled DeploymentStatus = "cancelled"
+	// DeploymentRolledBack marks a deploy that failed its health gate and was
+	// automatically replaced by the previously running image.
+	DeploymentRolledBack DeploymentStatus = "rolled_back"
 )
 
 type Deployment struct {
 	ID            int64            `json:"id"`
 	ApplicationID int64            `json:"application_id"`
 	Branch        string           `json:"branch"`
+	Ref           *string          `json:"ref,omitempty"`
 	CommitHash    *string          `json:"commit_hash,omitempty"`
 	CommitMessage *string          `json:"commit_message,omitempty"`
+	ImageDigest   *string          `json:"image_digest,omitempty"`
 	Status        DeploymentStatus `json:"status"`
 	TriggeredAt   time.Time        `json:"triggered_at"`
 	StartedAt     *time.Time       `json:"started_at,omitempty"`
@@ -35,6 +50,9 @@ type Deployment struct {
 	PreviousDeploymentID *int64          `json:"previous_deployment_id,omitempty"`
 	PreviousCommitHash   *string         `json:"previous_commit_hash,omitempty"`
 
+	// RollbackOfDeploymentID is the deployment a rollback restored.
+	RollbackOfDeploymentID *int64 `json:"rollback_of_deployment_id,omitempty"`
+
 	Deployer *User `json:"deployer,omitempty"`
 	Logs     []Log `json:"logs,omitempty"`
 }
@@ -58,6 +76,41 @@ type EnvDiffEntry struct {
 	New   string `json:"new,omitempty"`
 }
 
+// Release returns the image a deployment shipped: the pinned image
+// reference for image deploys, the commit its image was built from
+// otherwise. Empty until the agent has reported it.
+func (d *Deployment) Release() string {
+	if d.ImageDigest != nil && *d.ImageDigest != "" {
+		return *d.ImageDigest
+	}
+	if d.CommitHash != nil {
+		return *d.CommitHash
+	}
+	return ""
+}
+
+// ValidateGitRef rejects refs git would refuse (see git check-ref-format) and
+// anything that could be read as a command-line flag by the agent's git.
+func ValidateGitRef(ref string) error {
+	if ref == "" || len(ref) > 255 {
+		return ErrInvalidGitRef
+	}
+	if strings.HasPrefix(ref, "-") || strings.HasPrefix(ref, "/") ||
+		strings.HasSuffix(ref, "/") || strings.HasSuffix(ref, ".") ||
+		strings.HasSuffix(ref, ".lock") {
+		return ErrInvalidGitRef
+	}
+	if strings.Contains(ref, "..") || strings.Contains(ref, "@{") || strings.Contains(ref, "//") {
+		return ErrInvalidGitRef
+	}
+	for _, c := range ref {
+		if c <= ' ' || c == 0x7f || strings.ContainsRune("~^:?*[\\", c) {
+			return ErrInvalidGitRef
+		}
+	}
+	return nil
+}
+
 type DeploymentListOptions struct {
 	ListOptions
 	ApplicationID *int64   `json:"application_id,omitempty"`
@@ -66,14 +119,30 @@ type DeploymentListOptions struct {
 }
 
 type DeploymentCreateRequest struct {
-	ApplicationID int64  `json:"application_id"`
-	Branch        string `json:"branch"`
-	DeployedBy    *int64 `json:"deployed_by,omitempty"`
+	ApplicationID          int64   `json:"application_id"`
+	Branch                 string  `json:"branch"`
+	Ref                    *string `json:"ref,omitempty"`
+	DeployedBy             *int64  `json:"deployed_by,omitempty"`
+	RollbackOfDeploymentID *int64  `json:"rollback_of_deployment_id,omitempty"`
 }
 
+// DeploymentCommitInfoRequest is the agent's report of what a deploy
+// resolved to: the commit for git apps, the digest-pinned image reference
+// for image apps.
 type DeploymentCommitInfoRequest = struct {
 	CommitHash    string `json:"commit_hash"`
 	CommitMessage string `json:"commit_message"`
+	ImageDigest   string `json:"image_digest,omitempty"`
+}
+
+// DeploymentAutoRollbackRequest is the agent's report of an automatic
+// rollback after a failed health gate. Restored is false when the previous
+// image could not be brought back either.
+type DeploymentAutoRollbackRequest struct {
+	FailedImage   string `json:"failed_image"`
+	RestoredImage string `json:"restored_image"`
+	Restored      bool   `json:"restored"`
+	Reason        string `json:"reason,omitempty"`
 }
 
 type DeploymentLogsRequest struct {
@@ -89,6 +158,7 @@ type DeploymentRepository interface {
 	Finish(ctx context.Context, deploymentID int64) (*Deployment, error)
 	UpdateStatus(ctx context.Context, deploymentID int64, status DeploymentStatus) (*Deployment, error)
 	UpdateCommitInfo(ctx context.Context, deploymentID int64, commitHash string, commitMessage string) (*Deployment, error)
+	UpdateImageDigest(ctx context.Context, deploymentID int64, imageDigest string) (*Deployment, error)
 	// P3: snapshot the env vars used for this deployment and link the previous
 	// successful deployment (for the diff view).
 	UpdateEnvSnapshot(ctx context.Context, deploymentID int64, snapshot map[string]string) (*Deployment, error)
@@ -102,7 +172,9 @@ type DeploymentService interface {
 	Finish(ctx context.Context, deploymentID int64) error
 	UpdateStatus(ctx context.Context, deploymentID int64, status DeploymentStatus) error
 	UpdateCommitInfo(ctx context.Context, deploymentID int64, commitHash string, commitMessage string) error
+	UpdateImageDigest(ctx context.Context, deploymentID int64, imageDigest string) error
 	// P3: record env snapshot + previous-deployment link, return the diff.
 	UpdateEnvSnapshot(ctx context.Context, deploymentID int64, snapshot map[string]string) error
 	Diff(ctx context.Context, deploymentID int64) (*DeploymentDiff, error)
+	RecordAutoRollback(ctx context.Context, deploymentID int64, req DeploymentAutoRollbackRequest) error
 }
@@ -34,3 +34,14 @@ type EventDeploymentCommitInfoReceived struct {
 	CommitHash    string `json:"commit_hash"`
 	CommitMessage string `json:"commit_message"`
 }
+
+// EventDeploymentAutoRollback is published whenever the agent attempted an
+// automatic rollback, whether or not the previous image came back.
+type EventDeploymentAutoRollback struct {
+	DeploymentID  int64  `json:"deployment_id"`
+	ApplicationID int64  `json:"application_id"`
+	FailedImage   string `json:"failed_image"`
+	RestoredImage string `json:"restored_image"`
+	Restored      bool   `json:"restored"`
+	Reason        string `json:"reason,omitempty"`
+}
@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidExecOptions = errors.New("invalid exec session options")
+	ErrExecSessionClosed  = errors.New("exec session is closed")
+)
+
+// ExecShells are the shells an exec session may start, bare or under /bin
+// or /usr/bin.
+var ExecShells = []string{"sh", "bash", "ash", "zsh"}
+
+var execShellPattern = regexp.MustCompile(`^(/usr)?(/bin/)?([a-z]+)$`)
+
+// ExecOptions opens an interactive shell in one service's container of an
+// app. Cols and Rows are the initial terminal size.
+type ExecOptions struct {
+	Service string `json:"service"`
+	Shell   string `json:"shell,omitempty"`
+	Cols    uint16 `json:"cols,omitempty"`
+	Rows    uint16 `json:"rows,omitempty"`
+}
+
+// Validate checks the options and applies the defaults: sh on an 80x24
+// terminal.
+func (o *ExecOptions) Validate() error {
+	if o.Service == "" {
+		return fmt.Errorf("%w: service is required", ErrInvalidExecOptions)
+	}
+	if err := ValidateComposeService(o.Service); err != nil {
+		return fmt.Errorf("%w: invalid service name %q", ErrInvalidExecOptions, o.Service)
+	}
+
+	if o.Shell == "" {
+		o.Shell = "sh"
+	}
+	m := execShellPattern.FindStringSubmatch(o.Shell)
+	if m == nil || (m[1] != "" && m[2] == "") || !slices.Contains(ExecShells, m[3]) {
+		return fmt.Errorf("%w: shell must be one of %v", ErrInvalidExecOptions, ExecShells)
+	}
+
+	if o.Cols == 0 {
+		o.Cols = 80
+	}
+	if o.Rows == 0 {
+		o.Rows = 24
+	}
+
+	return nil
+}
+
+// ExecStart asks an agent to run `docker compose exec` on a pseudo-terminal
+// ("exec_start"). ID names the session in every later message.
+type ExecStart struct {
+	ID string `json:"id"`
+	AppInfo
+	Service string `json:"service"`
+	Shell   string `json:"shell"`
+	Cols    uint16 `json:"cols"`
+	Rows    uint16 `json:"rows"`
+}
+
+// ExecInput is typed into a session ("exec_input").
+type ExecInput struct {
+	ID   string `json:"id"`
+	Data []byte `json:"data"`
+}
+
+// ExecResize resizes a session's terminal ("exec_resize").
+type ExecResize struct {
+	ID   string `json:"id"`
+	Cols uint16 `json:"cols"`
+	Rows uint16 `json:"rows"`
+}
+
+// ExecClose ends a session ("exec_close").
+type ExecClose struct {
+	ID string `json:"id"`
+}
+
+// ExecOutput is what a session's terminal printed ("exec_output", agent to
+// server).
+type ExecOutput struct {
+	ID   string `json:"id"`
+	Data []byte `json:"data"`
+}
+
+// ExecExit reports that a session's shell exited ("exec_exit", agent to
+// server). Error is set when it could not be started.
+type ExecExit struct {
+	ID       string `json:"id"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ExecTransport reaches the agent running an app's containers.
+type ExecTransport interface {
+	StartExec(serverID uuid.UUID, req ExecStart) error
+	SendExecInput(serverID uuid.UUID, input ExecInput) error
+	ResizeExec(serverID uuid.UUID, resize ExecResize) error
+	CloseExec(serverID uuid.UUID, id string) error
+}
+
+// ExecSession is a running shell in an app container.
+type ExecSession interface {
+	ID() string
+	// Output delivers what the terminal prints. It is closed when the
+	// session ends.
+	Output() <-chan []byte
+	// Result is how the session ended, once Output is closed.
+	Result() ExecResult
+	Write(data []byte) error
+	Resize(cols, rows uint16) error
+	// Close ends the session, for reason, unless it already ended.
+	Close(reason string)
+}
+
+type ExecResult struct {
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+type ExecService interface {
+	// Open starts a shell for userID in the app's service container.
+	Open(ctx context.Context, userID int64, appID int64, opts ExecOptions) (ExecSession, error)
+	HandleOutput(serverID uuid.UUID, output ExecOutput)
+	HandleExit(serverID uuid.UUID, exit ExecExit)
+	// ServerOffline ends the sessions of a server whose agent went away.
+	ServerOffline(serverID uuid.UUID)
+}
+
+// EventExecSessionStarted and EventExecSessionEnded are published for the
+// audit log. Transcript is what the terminal showed, input echoed, capped at
+// ExecTranscriptLimit bytes.
+type EventExecSessionStarted struct {
+	SessionID     string    `json:"session_id"`
+	UserID        int64     `json:"user_id"`
+	ApplicationID int64     `json:"application_id"`
+	ServerID      uuid.UUID `json:"server_id"`
+	Service       string    `json:"service"`
+	Shell         string    `json:"shell"`
+}
+
+type EventExecSessionEnded struct {
+	EventExecSessionStarted
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	ExecResult
+	Transcript          string `json:"transcript"`
+	TranscriptTruncated bool   `json:"transcript_truncated,omitempty"`
+}
+
+const ExecTranscriptLimit = 1 << 20
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrGitCredentialsNotFound = errors.New("git credentials not found")
+
+// GitCredentials is how the agent authenticates git for a single deploy. It
+// rides in the deploy payload and is sealed by the job service before the
+// payload is stored, so it is only readable by the agent running the job.
+type GitCredentials struct {
+	SSHPrivateKey string `json:"ssh_private_key,omitempty"`
+	HTTPSUsername string `json:"https_username,omitempty"`
+	HTTPSToken    string `json:"https_token,omitempty"`
+}
+
+// ApplicationGitCredentials are an application's stored git credentials:
+// a generated ed25519 deploy key and/or an HTTPS access token. The private
+// key and the token are encrypted at rest and never returned by the API.
+type ApplicationGitCredentials struct {
+	ApplicationID int64     `json:"application_id"`
+	SSHPublicKey  string    `json:"ssh_public_key,omitempty"`
+	SSHPrivateKey string    `json:"-"`
+	HTTPSUsername string    `json:"https_username,omitempty"`
+	HTTPSToken    string    `json:"-"`
+	HasHTTPSToken bool      `json:"has_https_token"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ForDeploy returns the credentials to hand to the agent, or nil when there
+// are none.
+func (c *ApplicationGitCredentials) ForDeploy() *GitCredentials {
+	if c == nil || (c.SSHPrivateKey == "" && c.HTTPSToken == "") {
+		return nil
+	}
+	return &GitCredentials{
+		SSHPrivateKey: c.SSHPrivateKey,
+		HTTPSUsername: c.HTTPSUsername,
+		HTTPSToken:    c.HTTPSToken,
+	}
+}
+
+type GitHTTPSCredentialsRequest struct {
+	Username string `json:"username" validate:"required,max=255"`
+	Token    string `json:"token" validate:"required,max=4096"`
+}
+
+type GitCredentialRepository interface {
+	GetByApplicationID(ctx context.Context, appID int64) (*ApplicationGitCredentials, error)
+	// SaveDeployKey and SaveHTTPSCredentials upsert their half of the row;
+	// empty values clear it.
+	SaveDeployKey(ctx context.Context, appID int64, publicKey, privateKey string) error
+	SaveHTTPSCredentials(ctx context.Context, appID int64, username, token string) error
+}
+
+type GitCredentialService interface {
+	Get(ctx context.Context, appID int64) (*ApplicationGitCredentials, error)
+	GenerateDeployKey(ctx context.Context, appID int64) (*ApplicationGitCredentials, error)
+	DeleteDeployKey(ctx context.Context, appID int64) error
+	SetHTTPSCredentials(ctx context.Context, appID int64, req GitHTTPSCredentialsRequest) error
+	DeleteHTTPSCredentials(ctx context.Context, appID int64) error
+}
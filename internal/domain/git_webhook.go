@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrGitWebhookNotFound       = errors.New("git webhook not found")
+	ErrInvalidWebhookSignature  = errors.New("invalid webhook signature")
+	ErrDuplicateWebhookDelivery = errors.New("duplicate webhook delivery")
+)
+
+type GitProvider string
+
+const (
+	GitProviderGitHub GitProvider = "github"
+	GitProviderGitLab GitProvider = "gitlab"
+	GitProviderGitea  GitProvider = "gitea"
+)
+
+type GitWebhookOutcome string
+
+const (
+	WebhookDeployed  GitWebhookOutcome = "deployed"
+	WebhookDestroyed GitWebhookOutcome = "destroyed"
+	WebhookIgnored   GitWebhookOutcome = "ignored"
+	WebhookDuplicate GitWebhookOutcome = "duplicate"
+	WebhookRejected  GitWebhookOutcome = "rejected"
+	WebhookFailed    GitWebhookOutcome = "failed"
+)
+
+// GitWebhook is an application's push receiver at POST /hooks/git/{token}.
+// Secret verifies deliveries (HMAC key for GitHub and Gitea, the shared
+// X-Gitlab-Token for GitLab); it is only returned when (re)generated.
+type GitWebhook struct {
+	ApplicationID int64     `json:"application_id"`
+	Token         string    `json:"token"`
+	Secret        string    `json:"secret,omitempty"`
+	AutoDeploy    bool      `json:"auto_deploy"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type GitWebhookUpdateRequest struct {
+	AutoDeploy bool `json:"auto_deploy"`
+}
+
+// GitWebhookRequest is a delivery as read off the wire: the provider and its
+// headers are resolved by the HTTP adapter, the body is kept raw for
+// signature checks.
+type GitWebhookRequest struct {
+	Provider   GitProvider
+	Event      string
+	DeliveryID string
+	Signature  string
+	Body       []byte
+}
+
+// GitWebhookDelivery logs one received delivery and what became of it.
+type GitWebhookDelivery struct {
+	ID            int64             `json:"id"`
+	ApplicationID int64             `json:"application_id"`
+	Provider      GitProvider       `json:"provider"`
+	DeliveryID    string            `json:"delivery_id"`
+	Event         string            `json:"event"`
+	Branch        string            `json:"branch,omitempty"`
+	CommitHash    string            `json:"commit_hash,omitempty"`
+	PullRequest   *int              `json:"pull_request,omitempty"`
+	Outcome       GitWebhookOutcome `json:"outcome"`
+	Message       string            `json:"message,omitempty"`
+	DeploymentID  *int64            `json:"deployment_id,omitempty"`
+	ReceivedAt    time.Time         `json:"received_at"`
+}
+
+type GitWebhookDeliveryListOptions struct {
+	ListOptions
+	ApplicationID int64 `json:"application_id"`
+}
+
+type GitWebhookRepository interface {
+	GetByApplicationID(ctx context.Context, appID int64) (*GitWebhook, error)
+	GetByToken(ctx context.Context, token string) (*GitWebhook, error)
+	Save(ctx context.Context, hook *GitWebhook) (*GitWebhook, error)
+	UpdateAutoDeploy(ctx context.Context, appID int64, autoDeploy bool) error
+
+	// ClaimDelivery records a delivery under its dedupe key and returns
+	// ErrDuplicateWebhookDelivery when that key was already claimed.
+	ClaimDelivery(ctx context.Context, d *GitWebhookDelivery) (*GitWebhookDelivery, error)
+	CreateDelivery(ctx context.Context, d *GitWebhookDelivery) (*GitWebhookDelivery, error)
+	// FinishDelivery stores the outcome of a claimed delivery. A failed one
+	// releases its dedupe key so the provider's redelivery is retried.
+	FinishDelivery(ctx context.Context, d *GitWebhookDelivery) error
+	ListDeliveries(ctx context.Context, opts GitWebhookDeliveryListOptions) ([]*GitWebhookDelivery, int64, error)
+}
+
+type GitWebhookService interface {
+	Get(ctx context.Context, appID int64) (*GitWebhook, error)
+	// Rotate issues a new token and secret, creating the webhook on first
+	// use. The secret is returned exactly once.
+	Rotate(ctx context.Context, appID int64) (*GitWebhook, error)
+	Update(ctx context.Context, appID int64, req GitWebhookUpdateRequest) error
+	Receive(ctx context.Context, token string, req GitWebhookRequest) (*GitWebhookDelivery, error)
+	ListDeliveries(ctx context.Context, opts GitWebhookDeliveryListOptions) (*ListResult[*GitWebhookDelivery], error)
+}
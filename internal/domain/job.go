@@ -27,7 +27,9 @@ const (
 	JobTypeAppRollback    JobType = "app_rollback"
 	JobTypeAppHealthCheck JobType = "app_health_check"
 	JobTypeAppDestroy     JobType = "app_destroy"
+	JobTypeAppService     JobType = "app_service"
 	JobTypeMetricsCollect JobType = "metrics_collect"
+	JobTypeAgentUpgrade   JobType = "agent_upgrade"
 )
 
 const (
@@ -36,6 +38,10 @@ const (
 	JobSuccess JobStatus = "success"
 	JobFailed  JobStatus = "failed"
 	JobExpired JobStatus = "expired"
+
+	// JobCancelled is set when a user stops a job: queued jobs are cancelled
+	// in the database, running ones by the agent after it kills the job.
+	JobCancelled JobStatus = "cancelled"
 )
 
 type Job struct {
@@ -52,6 +58,10 @@ type Job struct {
 	FinishedAt    *time.Time      `json:"finished_at"`
 	ExpiredAt     *time.Time      `json:"expired_at"`
 
+	// PayloadVersion is the schema version Payload was written at; see
+	// JobPayloadVersions. Jobs queued before versioning are at 1.
+	PayloadVersion int `json:"payload_version"`
+
 	Logs []Log `json:"logs,omitempty"`
 }
 
@@ -67,6 +77,12 @@ type JobListOptions struct {
 
 type JobFinishRequest struct {
 	Status JobStatus `json:"status"`
+
+	// StartedAt is the started_at of the run being reported, as the agent
+	// got it from start. A retry re-runs the job under the same ID, so a
+	// late report of an earlier run must not finish the new one. Agents
+	// that predate it leave it out.
+	StartedAt *time.Time `json:"started_at,omitempty"`
 }
 
 type JobStatusCounts struct {
@@ -75,9 +91,16 @@ type JobStatusCounts struct {
 	Success   int64 `json:"success"`
 	Failed    int64 `json:"failed"`
 	Expired   int64 `json:"expired"`
+	Cancelled int64 `json:"cancelled"`
 	Total     int64 `json:"total"`
 }
 
+// JobCancelSignal tells the agent running a job to cancel its context,
+// killing whatever process tree the job has spawned.
+type JobCancelSignal struct {
+	JobID int64 `json:"job_id"`
+}
+
 type JobRepository interface {
 	List(ctx context.Context, opts JobListOptions) ([]*Job, int64, error)
 	GetPending(ctx context.Context, serverID uuid.UUID) ([]*Job, error)
@@ -86,10 +109,24 @@ type JobRepository interface {
 	Delete(ctx context.Context, jobID int64) error
 	Retry(ctx context.Context, jobID int64, j *Job) (*Job, error)
 	MarkRunning(ctx context.Context, jobID int64) (*Job, error)
-	MarkFinished(ctx context.Context, jobID int64, status JobStatus) (*Job, error)
+	// MarkFinished finishes the run started at startedAt, or the current run
+	// when startedAt is nil.
+	MarkFinished(ctx context.Context, jobID int64, status JobStatus, startedAt *time.Time) (*Job, error)
+	// CancelQueued moves a queued job straight to cancelled. It returns
+	// ErrInvalidJobState when the job is no longer queued.
+	CancelQueued(ctx context.Context, jobID int64) (*Job, error)
 	CountsByStatus(ctx context.Context) (*JobStatusCounts, error)
 }
 
+// JobDispatcher pushes a freshly queued job to the agent that owns it over
+// the live agent socket. Delivery is best-effort: an agent that is offline
+// (or whose send buffer is full) picks the job up on its next HTTP poll.
+// CancelJob signals the agent to stop a job it is running.
+type JobDispatcher interface {
+	Dispatch(job *Job)
+	CancelJob(job *Job)
+}
+
 type JobService interface {
 	List(ctx context.Context, opts JobListOptions) (*ListResult[*Job], error)
 	GetPending(ctx context.Context, serverID uuid.UUID) ([]*Job, error)
@@ -98,6 +135,8 @@ type JobService interface {
 	Delete(ctx context.Context, jobID int64) error
 	Retry(ctx context.Context, jobID int64, j *Job) (*Job, error)
 	Start(ctx context.Context, jobID int64) (*Job, error)
-	Finish(ctx context.Context, jobID int64, status JobStatus) (*Job, error)
+	Finish(ctx context.Context, jobID int64, status JobStatus, startedAt *time.Time) (*Job, error)
+	// Cancel stops a queued or running job on behalf of cancelledBy.
+	Cancel(ctx context.Context, jobID int64, cancelledBy int64) (*Job, error)
 	Summary(ctx context.Context) (*JobStatusCounts, error)
 }
@@ -34,6 +34,17 @@ type EventJobFinished struct {
 	Status        JobStatus `json:"status"`
 }
 
+type EventJobCancelled struct {
+	JobID         int64     `json:"job_id"`
+	TraceID       uuid.UUID `json:"trace_id"`
+	ServerID      uuid.UUID `json:"server_id"`
+	ApplicationID *int64    `json:"application_id"`
+	DeploymentID  *int64    `json:"deployment_id"`
+	Type          JobType   `json:"type"`
+	CancelledBy   int64     `json:"cancelled_by"`
+	WasRunning    bool      `json:"was_running"`
+}
+
 type EventJobStatusChanged struct {
 	JobID   int64     `json:"job_id"`
 	TraceID uuid.UUID `json:"trace_id"`
@@ -49,8 +60,19 @@ type EventLogEmitted struct {
 	Context   *LogContext
 }
 
+// EventCommitInfoEmitted reports what a deploy resolved to. Image deploys
+// have no commit and set ImageDigest instead.
 type EventCommitInfoEmitted struct {
 	DeploymentID int64
 	Hash         string
 	Message      string
+	ImageDigest  string
+}
+
+type EventAutoRollbackEmitted struct {
+	DeploymentID  int64
+	FailedImage   string
+	RestoredImage string
+	Restored      bool
+	Reason        string
 }
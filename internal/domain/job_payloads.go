@@ -2,9 +2,12 @@ package domain
 
 import "github.com/google/uuid"
 
+// AppInfo addresses an app's stack on the agent. Compose is carried by every
+// payload that runs compose so each job resolves the same stack.
 type AppInfo struct {
-	ApplicationID int64  `json:"application_id"`
-	AppKey        string `json:"app_key"`
+	ApplicationID int64       `json:"application_id"`
+	AppKey        string      `json:"app_key"`
+	Compose       ComposeSpec `json:"compose"`
 }
 
 type AppDeployPayload struct {
@@ -13,7 +16,31 @@ type AppDeployPayload struct {
 	AppKey        string            `json:"app_dir"`
 	RepoURL       string            `json:"repo_url"`
 	Branch        string            `json:"branch"`
+	Ref           string            `json:"ref,omitempty"`
 	EnvVars       map[string]string `json:"env_vars,omitempty"`
+	AutoRollback  bool              `json:"auto_rollback,omitempty"`
+	Compose       ComposeSpec       `json:"compose"`
+
+	// SecretEnvKeys names the EnvVars the agent masks in job output.
+	SecretEnvKeys []string `json:"secret_env_keys,omitempty"`
+
+	ReadinessProbes    []ReadinessProbe `json:"readiness_probes,omitempty"`
+	PreDeployCommands  []ReleaseCommand `json:"pre_deploy_commands,omitempty"`
+	PostDeployCommands []ReleaseCommand `json:"post_deploy_commands,omitempty"`
+
+	// GitCredentials is sealed at rest; see GitCredentials.
+	GitCredentials *GitCredentials `json:"git_credentials,omitempty"`
+
+	// BuildArgs and BuildSecrets only reach `compose build`, never the
+	// app's .env. BuildSecrets is sealed at rest like GitCredentials.
+	BuildArgs    map[string]string `json:"build_args,omitempty"`
+	BuildSecrets map[string]string `json:"build_secrets,omitempty"`
+
+	// Image apps pull Image instead of cloning RepoURL; see Application.
+	SourceType          ApplicationSourceType `json:"source_type,omitempty"`
+	Image               string                `json:"image,omitempty"`
+	ComposeDefinition   string                `json:"compose_definition,omitempty"`
+	RegistryCredentials *RegistryCredentials  `json:"registry_credentials,omitempty"`
 }
 
 type AppStartPayload = AppInfo
@@ -22,20 +49,54 @@ type AppStopPayload = AppInfo
 
 type AppRestartPayload = AppInfo
 
-type AppDestroyPayload = AppInfo
+// AppDestroyPayload removes an app from its server. A preview goes
+// completely, volumes and checkout included; nothing of it is kept.
+type AppDestroyPayload struct {
+	AppInfo
+	Preview bool `json:"preview,omitempty"`
+}
+
+// AppServicePayload starts, stops, restarts or scales one compose service of
+// an app, leaving the others alone. Replicas is only set for ServiceScale.
+type AppServicePayload struct {
+	AppInfo
+	Service  string        `json:"service"`
+	Action   ServiceAction `json:"action"`
+	Replicas int           `json:"replicas,omitempty"`
+}
 
 // AppRollbackPayload tells the agent to bring the stack back to a previously
-// deployed image tag (P0-4). The image must already exist locally (built by an
-// earlier successful deploy of the same commit).
+// deployed image tag (P0-4). For git apps the image must already exist
+// locally (built by an earlier successful deploy of the same commit); image
+// apps roll back to a digest-pinned reference, pulled again if it was pruned.
 type AppRollbackPayload struct {
 	ApplicationID int64  `json:"application_id"`
 	DeploymentID  int64  `json:"deployment_id"`
 	AppKey        string `json:"app_dir"`
 	ImageTag      string `json:"image_tag"`
 	EnvVars       map[string]string `json:"env_vars,omitempty"`
+	Compose       ComposeSpec `json:"compose"`
+
+	SecretEnvKeys []string `json:"secret_env_keys,omitempty"`
+
+	ReadinessProbes []ReadinessProbe `json:"readiness_probes,omitempty"`
+
+	SourceType          ApplicationSourceType `json:"source_type,omitempty"`
+	RegistryCredentials *RegistryCredentials  `json:"registry_credentials,omitempty"`
 }
 
 type AppHealthCheckPayload struct {
 	ServerID     uuid.UUID `json:"server_id"`
 	Applications []AppInfo `json:"applications"`
 }
+
+// AgentUpgradePayload asks the agent to install another release of itself:
+// Version, or the latest when empty. The job completes when the agent
+// reconnects on the new release; FromVersion is what it ran when the job
+// was queued. Rollout lists the servers a fleet rollout upgrades next, in
+// order, once this one succeeds.
+type AgentUpgradePayload struct {
+	Version     string      `json:"version,omitempty"`
+	FromVersion string      `json:"from_version,omitempty"`
+	Rollout     []uuid.UUID `json:"rollout,omitempty"`
+}
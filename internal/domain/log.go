@@ -10,6 +10,13 @@ import (
 
 var ErrLogNotFound = errors.New("log not found")
 
+const (
+	// MaxLogBatchSize bounds the lines in one agent log batch; MaxLogBatchBytes
+	// bounds its decompressed NDJSON body.
+	MaxLogBatchSize  = 1000
+	MaxLogBatchBytes = 8 << 20
+)
+
 type (
 	LogSource string
 	LogLevel  string
@@ -37,7 +44,10 @@ const (
 	ActionAppStop        LogAction = "app_stop"
 	ActionAppRestart     LogAction = "app_restart"
 	ActionAppDestroy     LogAction = "app_destroy"
+	ActionAppService     LogAction = "app_service"
 	ActionAppHealthCheck LogAction = "app_health_check"
+	ActionJobCancel      LogAction = "job_cancel"
+	ActionAgentUpgrade   LogAction = "agent_upgrade"
 )
 
 const (
@@ -45,13 +55,18 @@ const (
 	StepGitInfo           LogStep = "git_info"
 	StepBuildPrepare      LogStep = "build_prepare"
 	StepDockerBuild       LogStep = "docker_build"
+	StepDockerPull        LogStep = "docker_pull"
+	StepPreDeploy         LogStep = "pre_deploy"
+	StepPostDeploy        LogStep = "post_deploy"
 	StepDockerStart       LogStep = "docker_start"
 	StepDockerStop        LogStep = "docker_stop"
 	StepDockerRestart     LogStep = "docker_restart"
+	StepDockerScale       LogStep = "docker_scale"
 	StepDockerHealthCheck LogStep = "docker_health_check"
 	StepDockerCommit      LogStep = "docker_commit"
 	StepDockerSave        LogStep = "docker_save"
 	StepDockerRemove      LogStep = "docker_remove"
+	StepAgentUpgrade      LogStep = "agent_upgrade"
 )
 
 const (
@@ -119,9 +134,11 @@ type LogEmitRequest struct {
 type LogRepository interface {
 	List(ctx context.Context, opts LogListOptions) ([]*Log, int64, error)
 	Create(ctx context.Context, l *Log) (*Log, error)
+	BulkInsert(ctx context.Context, logs []*Log) error
 }
 
 type LogService interface {
 	List(ctx context.Context, opts LogListOptions) (*ListResult[*Log], error)
 	Create(ctx context.Context, l *Log) (*Log, error)
+	CreateBatch(ctx context.Context, logs []*Log) error
 }
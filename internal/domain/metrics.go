@@ -3,12 +3,16 @@ package domain
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-var ErrMetricsNotFound = errors.New("metrics not found")
+var (
+	ErrMetricsNotFound           = errors.New("metrics not found")
+	ErrInvalidMetricsRollupQuery = errors.New("invalid metrics rollup query")
+)
 
 type ServerMetrics struct {
 	ID                 int64     `json:"id"`
@@ -28,6 +32,9 @@ type Metrics struct {
 	Network       NetworkMetric `json:"network"`
 	UptimeSeconds float64       `json:"uptime_seconds"`
 	RecordedAt    time.Time     `json:"recorded_at"`
+
+	// Apps is the resource use of each app's containers on the server.
+	Apps []AppMetric `json:"apps,omitempty"`
 }
 
 type Signal struct {
@@ -102,6 +109,46 @@ type NetworkMetric struct {
 	TXSpeedMBs Signal `json:"tx_speed_mbs"`
 }
 
+// AppMetric sums an app's containers. CPU is a percentage of one core, as
+// `docker stats` reports it; rates are since the previous sample.
+type AppMetric struct {
+	ApplicationID int64 `json:"application_id"`
+
+	AppUsage
+
+	Containers []ContainerMetric `json:"containers"`
+}
+
+type ContainerMetric struct {
+	Name    string `json:"name"`
+	Service string `json:"service"`
+
+	AppUsage
+
+	MemoryLimitBytes uint64 `json:"memory_limit_bytes,omitempty"`
+}
+
+type AppUsage struct {
+	CPUPercent    float64 `json:"cpu_percent"`
+	MemoryBytes   uint64  `json:"memory_bytes"`
+	NetRXMBs      float64 `json:"net_rx_mbs"`
+	NetTXMBs      float64 `json:"net_tx_mbs"`
+	BlockReadMBs  float64 `json:"block_read_mbs"`
+	BlockWriteMBs float64 `json:"block_write_mbs"`
+}
+
+type AppUsageSample struct {
+	AppUsage
+	At time.Time `json:"at"`
+}
+
+// AppMetrics is what GET /applications/{id}/metrics returns: the latest
+// per-container breakdown and the app's recent history.
+type AppMetrics struct {
+	Latest  *AppMetric       `json:"latest"`
+	History []AppUsageSample `json:"history"`
+}
+
 type CPUUsageSample struct {
 	UsagePercent float64   `json:"usage_percent"`
 	At           time.Time `json:"at"`
@@ -113,15 +160,145 @@ type NetworkSpeedSample struct {
 	At    time.Time `json:"at"`
 }
 
+// MetricsResolution is the bucket size of a metrics rollup. Buckets start on
+// the hour or at midnight UTC.
+type MetricsResolution string
+
+const (
+	MetricsHourly MetricsResolution = "hour"
+	MetricsDaily  MetricsResolution = "day"
+)
+
+func (r MetricsResolution) Valid() bool {
+	return r == MetricsHourly || r == MetricsDaily
+}
+
+// Truncate returns the start of the bucket t falls in.
+func (r MetricsResolution) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	if r == MetricsDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+// Next returns the start of the bucket after the one starting at bucket.
+func (r MetricsResolution) Next(bucket time.Time) time.Time {
+	if r == MetricsDaily {
+		return bucket.AddDate(0, 0, 1)
+	}
+	return bucket.Add(time.Hour)
+}
+
+// RollupStat summarises one series over a bucket.
+type RollupStat struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+	P95 float64 `json:"p95"`
+}
+
+// MetricsRollup is a server's raw samples over one bucket. Disk rates sum
+// the server's disks; GPU usage averages its cards and is nil without one.
+type MetricsRollup struct {
+	ServerID   uuid.UUID         `json:"server_id"`
+	Resolution MetricsResolution `json:"resolution"`
+	Bucket     time.Time         `json:"bucket"`
+	Samples    int               `json:"samples"`
+
+	CPUUsagePercent    RollupStat  `json:"cpu_usage_percent"`
+	MemoryUsagePercent RollupStat  `json:"memory_usage_percent"`
+	DiskReadMBps       RollupStat  `json:"disk_read_mbps"`
+	DiskWriteMBps      RollupStat  `json:"disk_write_mbps"`
+	NetRXMBs           RollupStat  `json:"net_rx_mbs"`
+	NetTXMBs           RollupStat  `json:"net_tx_mbs"`
+	GPUUsagePercent    *RollupStat `json:"gpu_usage_percent"`
+}
+
+// MetricsRollupListOptions selects the buckets starting in [From, To).
+type MetricsRollupListOptions struct {
+	Resolution MetricsResolution
+	From       time.Time
+	To         time.Time
+}
+
+// Validate checks the options and defaults the range to the week (hourly)
+// or the 90 days (daily) up to now.
+func (o *MetricsRollupListOptions) Validate(now time.Time) error {
+	if !o.Resolution.Valid() {
+		return fmt.Errorf("%w: resolution must be %q or %q", ErrInvalidMetricsRollupQuery, MetricsHourly, MetricsDaily)
+	}
+
+	if o.To.IsZero() {
+		o.To = now
+	}
+	if o.From.IsZero() {
+		days := 7
+		if o.Resolution == MetricsDaily {
+			days = 90
+		}
+		o.From = o.To.AddDate(0, 0, -days)
+	}
+	if !o.From.Before(o.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidMetricsRollupQuery)
+	}
+
+	return nil
+}
+
+// MetricsRetention is how many days of raw samples and of each rollup
+// resolution are kept.
+type MetricsRetention struct {
+	RawDays    int
+	HourlyDays int
+	DailyDays  int
+}
+
 type MetricsService interface {
 	Ingest(ctx context.Context, m Metrics) error
 	Latest(ctx context.Context, serverID uuid.UUID) (*Metrics, error)
 	CPUUsageHistory(ctx context.Context, serverID uuid.UUID) ([]CPUUsageSample, error)
 	NetSpeedHistory(ctx context.Context, serverID uuid.UUID) ([]NetworkSpeedSample, error)
+	AppMetrics(ctx context.Context, appID int64) (*AppMetrics, error)
 	Cleanup(ctx context.Context, serverID uuid.UUID, cutoff time.Time) error
+
+	// RollUp aggregates the raw samples of every bucket completed by now
+	// since the last one rolled up, which is redone for samples that arrived
+	// late (an agent replaying its spool).
+	RollUp(ctx context.Context, resolution MetricsResolution, now time.Time) error
+	Rollups(ctx context.Context, serverID uuid.UUID, opts MetricsRollupListOptions) ([]MetricsRollup, error)
+	CleanupRollups(ctx context.Context, resolution MetricsResolution, cutoff time.Time) error
+
+	// Query charts a metric over a time range, from the raw samples or, past
+	// their retention or for steps of an hour and more, from the rollups.
+	Query(ctx context.Context, serverID uuid.UUID, q MetricsQuery) (*MetricsQueryResult, error)
 }
 
 type MetricsRepository interface {
 	BulkInsert(ctx context.Context, metrics []Metrics) error
 	Cleanup(ctx context.Context, serverID uuid.UUID, cutoff time.Time) error
+
+	// OldestSample returns when the server's oldest raw sample was recorded;
+	// false when there are none.
+	OldestSample(ctx context.Context, serverID uuid.UUID) (time.Time, bool, error)
+	// StaleRollups returns, oldest first, the buckets starting before end
+	// whose raw samples are not all counted in their rollup at the resolution.
+	StaleRollups(ctx context.Context, resolution MetricsResolution, end time.Time) ([]time.Time, error)
+	// RollUp upserts the rollups of the raw samples recorded in [from, to).
+	RollUp(ctx context.Context, resolution MetricsResolution, from, to time.Time) error
+	ListRollups(ctx context.Context, serverID uuid.UUID, opts MetricsRollupListOptions) ([]MetricsRollup, error)
+	CleanupRollups(ctx context.Context, resolution MetricsResolution, cutoff time.Time) error
+	// OldestRollup returns the server's oldest bucket kept at the resolution.
+	OldestRollup(ctx context.Context, serverID uuid.UUID, resolution MetricsResolution) (time.Time, bool, error)
+	// QueryMetrics averages a metric over q's steps, from the raw samples
+	// (MetricsRaw) or a rollup resolution, ordered by label then time.
+	QueryMetrics(ctx context.Context, serverID uuid.UUID, resolution MetricsResolution, q MetricsQuery) ([]MetricsQuerySample, error)
+}
+
+// MetricsBuffer keeps an agent's most recent samples across restarts, so the
+// collector's smoothing and Latest pick up where they left off.
+type MetricsBuffer interface {
+	Append(ctx context.Context, m *Metrics) error
+	// Recent returns up to n of the newest samples, oldest first.
+	Recent(ctx context.Context, n int) ([]Metrics, error)
 }
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidMetricsQuery = errors.New("invalid metrics query")
+
+const (
+	// MetricsQueryDefaultPoints is how many steps a query without a step
+	// splits its range into; MetricsQueryMaxPoints is the most it may ask for.
+	MetricsQueryDefaultPoints = 300
+	MetricsQueryMaxPoints     = 2000
+
+	// MetricsQueryMinStep is the agent's sampling interval: a finer step
+	// would only add empty points.
+	MetricsQueryMinStep = 5 * time.Second
+)
+
+// MetricsRaw is where a metrics query reads the raw samples rather than a
+// rollup resolution.
+const MetricsRaw MetricsResolution = "raw"
+
+// MetricsQueryMetric is a series GET /servers/{id}/metrics/query can chart.
+type MetricsQueryMetric struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+
+	// Label is what tells the metric's series apart ("disk", "core",
+	// "mountpoint", "card"); empty when there is a single series.
+	Label string `json:"label,omitempty"`
+
+	// Rollup is set for the metrics the hourly and daily rollups keep, which
+	// can be charted past the raw retention.
+	Rollup bool `json:"rollup"`
+}
+
+var MetricsQueryMetrics = []MetricsQueryMetric{
+	{Name: "cpu.usage_percent", Unit: "percent", Rollup: true},
+	{Name: "cpu.core_usage_percent", Unit: "percent", Label: "core"},
+	{Name: "cpu.temperature", Unit: "celsius"},
+	{Name: "cpu.frequency", Unit: "mhz"},
+	{Name: "cpu.power_watt", Unit: "watt"},
+
+	{Name: "memory.usage_percent", Unit: "percent", Rollup: true},
+	{Name: "memory.used_gb", Unit: "gb"},
+	{Name: "memory.available_gb", Unit: "gb"},
+	{Name: "memory.swap_used_gb", Unit: "gb"},
+	{Name: "memory.swap_usage_percent", Unit: "percent"},
+
+	{Name: "disk.total_read_mbps", Unit: "mbps", Rollup: true},
+	{Name: "disk.total_write_mbps", Unit: "mbps", Rollup: true},
+	{Name: "disk.read_mbps", Unit: "mbps", Label: "disk"},
+	{Name: "disk.write_mbps", Unit: "mbps", Label: "disk"},
+	{Name: "disk.util_percent", Unit: "percent", Label: "disk"},
+	{Name: "disk.temperature", Unit: "celsius", Label: "disk"},
+
+	{Name: "filesystem.usage_percent", Unit: "percent", Label: "mountpoint"},
+	{Name: "filesystem.used_gb", Unit: "gb", Label: "mountpoint"},
+	{Name: "filesystem.free_gb", Unit: "gb", Label: "mountpoint"},
+
+	{Name: "network.rx_mbs", Unit: "mbps", Rollup: true},
+	{Name: "network.tx_mbs", Unit: "mbps", Rollup: true},
+
+	{Name: "gpu.avg_core_usage_percent", Unit: "percent", Rollup: true},
+	{Name: "gpu.core_usage_percent", Unit: "percent", Label: "card"},
+	{Name: "gpu.temperature", Unit: "celsius", Label: "card"},
+	{Name: "gpu.frequency_mhz", Unit: "mhz", Label: "card"},
+	{Name: "gpu.power_watt", Unit: "watt", Label: "card"},
+	{Name: "gpu.vram_used_gb", Unit: "gb", Label: "card"},
+	{Name: "gpu.vram_percent", Unit: "percent", Label: "card"},
+}
+
+func LookupMetricsQueryMetric(name string) (MetricsQueryMetric, bool) {
+	for _, metric := range MetricsQueryMetrics {
+		if metric.Name == name {
+			return metric, true
+		}
+	}
+	return MetricsQueryMetric{}, false
+}
+
+// MetricsQuery charts one metric over [From, To), averaged over Step-wide
+// steps starting at From.
+type MetricsQuery struct {
+	Metric string
+	From   time.Time
+	To     time.Time
+	Step   time.Duration
+}
+
+// Validate checks the query and defaults it to the last hour up to now, in
+// MetricsQueryDefaultPoints steps.
+func (q *MetricsQuery) Validate(now time.Time) error {
+	if _, ok := LookupMetricsQueryMetric(q.Metric); !ok {
+		return fmt.Errorf("%w: unknown metric %q", ErrInvalidMetricsQuery, q.Metric)
+	}
+
+	if q.To.IsZero() {
+		q.To = now
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-time.Hour)
+	}
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidMetricsQuery)
+	}
+
+	rng := q.To.Sub(q.From)
+	switch {
+	case q.Step == 0:
+		q.Step = max((rng / MetricsQueryDefaultPoints).Round(time.Second), MetricsQueryMinStep)
+	case q.Step < MetricsQueryMinStep:
+		return fmt.Errorf("%w: step must be at least %s", ErrInvalidMetricsQuery, MetricsQueryMinStep)
+	}
+	if q.Points() > MetricsQueryMaxPoints {
+		return fmt.Errorf("%w: at most %d steps per query, use a larger step", ErrInvalidMetricsQuery, MetricsQueryMaxPoints)
+	}
+
+	return nil
+}
+
+// Points is how many steps the query's range spans, the last one possibly
+// cut short by To.
+func (q MetricsQuery) Points() int {
+	return int((q.To.Sub(q.From) + q.Step - 1) / q.Step)
+}
+
+// MetricsQuerySample is a metric's average over one step, as the repository
+// finds it; steps without samples are missing.
+type MetricsQuerySample struct {
+	Label string
+	At    time.Time
+	Value float64
+}
+
+type MetricsPoint struct {
+	At    time.Time `json:"at"`
+	Value *float64  `json:"value"` // nil when the step has no samples
+}
+
+type MetricsSeries struct {
+	Label  string         `json:"label,omitempty"`
+	Points []MetricsPoint `json:"points"`
+}
+
+// MetricsQueryResult is what GET /servers/{id}/metrics/query returns: one
+// series per label, each with a point every step from From to To.
+// Resolution tells whether the points come from the raw samples or a
+// rollup, in which case From is aligned to and Step is a multiple of the
+// rollup's buckets.
+type MetricsQueryResult struct {
+	Metric      string            `json:"metric"`
+	Unit        string            `json:"unit"`
+	Resolution  MetricsResolution `json:"resolution"`
+	From        time.Time         `json:"from"`
+	To          time.Time         `json:"to"`
+	StepSeconds int64             `json:"step_seconds"`
+	Series      []MetricsSeries   `json:"series"`
+}
@@ -18,4 +18,8 @@ const (
 
 	PermAppRead  PermissionConst = "app_read"
 	PermAppWrite PermissionConst = "app_write"
+
+	// PermAppExec opens shells in app containers, apart from app_write:
+	// deploying an app does not imply a shell on its host.
+	PermAppExec PermissionConst = "app_exec"
 )
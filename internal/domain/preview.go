@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrPreviewsDisabled = errors.New("previews are disabled for this application")
+	ErrPreviewNotFound  = errors.New("preview not found")
+)
+
+// DefaultPreviewTTL is how long a preview lives after its last deploy when
+// the app sets no PreviewTTLHours.
+const DefaultPreviewTTL = 72 * time.Hour
+
+// PreviewTTL is the lifetime of the app's previews.
+func (a *Application) PreviewTTL() time.Duration {
+	if a.PreviewTTLHours <= 0 {
+		return DefaultPreviewTTL
+	}
+	return time.Duration(a.PreviewTTLHours) * time.Hour
+}
+
+// IsPreview reports whether the app is a preview environment of another app.
+func (a *Application) IsPreview() bool {
+	return a.ParentApplicationID != nil
+}
+
+// PullRequest is what a preview deploy needs from a PR or merge request
+// event: HeadSHA is deployed on top of the app's BaseBranch.
+type PullRequest struct {
+	Number     int    `json:"number"`
+	BaseBranch string `json:"base_branch"`
+	HeadBranch string `json:"head_branch"`
+	HeadSHA    string `json:"head_sha"`
+}
+
+type PreviewService interface {
+	List(ctx context.Context, parentID int64) ([]*Application, error)
+	// Deploy creates the PR's preview on first use and deploys its head
+	// commit, pushing the preview's expiry out by the parent's TTL.
+	Deploy(ctx context.Context, parentID int64, pr PullRequest) (*Application, *Deployment, error)
+	Destroy(ctx context.Context, parentID int64, number int) error
+	// ReapExpired destroys previews past their expiry and returns how many
+	// were torn down.
+	ReapExpired(ctx context.Context) (int, error)
+}
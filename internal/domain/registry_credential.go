@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrRegistryCredentialsNotFound = errors.New("registry credentials not found")
+
+// RegistryCredentials is the login the agent pulls an image app's image
+// with. Like GitCredentials it rides in the job payload sealed, so only the
+// agent running the job can read it.
+type RegistryCredentials struct {
+	Registry string `json:"registry"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ApplicationRegistryCredentials are an image app's stored registry login.
+// The password is encrypted at rest and never returned by the API.
+type ApplicationRegistryCredentials struct {
+	ApplicationID int64     `json:"application_id"`
+	Registry      string    `json:"registry"`
+	Username      string    `json:"username"`
+	Password      string    `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ForDeploy returns the credentials to hand to the agent, or nil when there
+// are none.
+func (c *ApplicationRegistryCredentials) ForDeploy() *RegistryCredentials {
+	if c == nil || c.Password == "" {
+		return nil
+	}
+	return &RegistryCredentials{
+		Registry: c.Registry,
+		Username: c.Username,
+		Password: c.Password,
+	}
+}
+
+// RegistryCredentialsRequest sets an app's registry login. Registry is the
+// host the image is pulled from, e.g. registry.example.com or ghcr.io.
+type RegistryCredentialsRequest struct {
+	Registry string `json:"registry" validate:"required,max=255"`
+	Username string `json:"username" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,max=4096"`
+}
+
+type RegistryCredentialRepository interface {
+	GetByApplicationID(ctx context.Context, appID int64) (*ApplicationRegistryCredentials, error)
+	Save(ctx context.Context, creds *ApplicationRegistryCredentials) error
+	Delete(ctx context.Context, appID int64) error
+}
+
+type RegistryCredentialService interface {
+	Get(ctx context.Context, appID int64) (*ApplicationRegistryCredentials, error)
+	Set(ctx context.Context, appID int64, req RegistryCredentialsRequest) (*ApplicationRegistryCredentials, error)
+	Delete(ctx context.Context, appID int64) error
+}
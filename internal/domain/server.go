@@ -18,6 +18,13 @@ type Server struct {
 	APIToken  string    `json:"-"`
 	IsOnline  bool      `json:"is_online"`
 	OSInfo    *OSInfo   `json:"os_info,omitempty"`
+
+	// AgentInfo is what the agent advertised on its last connect; nil for
+	// agents that predate the hello. AgentOutdated flags agents that are
+	// not on the server's build, set by the service for the dashboard.
+	AgentInfo     *AgentInfo `json:"agent_info,omitempty"`
+	AgentOutdated bool       `json:"agent_outdated"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -47,6 +54,7 @@ type ServerRepository interface {
 	Create(ctx context.Context, s *Server) (*Server, error)
 	Update(ctx context.Context, s *Server, serverID uuid.UUID) error
 	UpdateOSInfo(ctx context.Context, serverID uuid.UUID, osInfo OSInfo) error
+	UpdateAgentInfo(ctx context.Context, serverID uuid.UUID, info AgentInfo) error
 	UpdateStatus(ctx context.Context, serverID uuid.UUID, isOnline bool) error
 	UpdateSecret(ctx context.Context, serverID uuid.UUID, secret string) error
 	Delete(ctx context.Context, serverID uuid.UUID) error
@@ -59,6 +67,7 @@ type ServerService interface {
 	Register(ctx context.Context, req ServerSaveRequest) (*Server, string, error)
 	Update(ctx context.Context, req ServerSaveRequest, serverID uuid.UUID) error
 	UpdateOSInfo(ctx context.Context, serverID uuid.UUID, osInfo OSInfo) error
+	UpdateAgentInfo(ctx context.Context, serverID uuid.UUID, info AgentInfo) error
 	UpdateStatus(ctx context.Context, serverID uuid.UUID, status bool) error
 	Delete(ctx context.Context, serverID uuid.UUID) error
 	AuthorizeAgent(ctx context.Context, serverID uuid.UUID, secret string) (*Server, error)
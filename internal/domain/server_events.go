@@ -6,3 +6,10 @@ type EventServerStatusChanged struct {
 	ServerID uuid.UUID `json:"server_id"`
 	IsOnline bool      `json:"is_online"`
 }
+
+// EventAgentConnected is published when an agent says hello, with what it
+// advertised.
+type EventAgentConnected struct {
+	ServerID uuid.UUID `json:"server_id"`
+	Info     AgentInfo `json:"info"`
+}
@@ -0,0 +1,422 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+	"horizonx/internal/system"
+)
+
+const (
+	// Compose labels every container it creates with its project directory,
+	// which for an app deployed by this agent lies in the app's checkout.
+	composeWorkingDirLabel = "com.docker.compose.project.working_dir"
+	composeServiceLabel    = "com.docker.compose.service"
+
+	// containerRefresh is how often the container list is re-read from
+	// docker; their stats are read on every collect.
+	containerRefresh = 30 * time.Second
+)
+
+// dockerFunc runs the docker CLI and returns its stdout.
+type dockerFunc func(ctx context.Context, args ...string) ([]byte, error)
+
+func runDocker(ctx context.Context, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, "docker", args...).Output()
+}
+
+// appContainer is a running container of an app deployed under appsDir.
+type appContainer struct {
+	ID      string
+	Name    string
+	Service string
+	AppID   int64
+	Pid     int
+}
+
+// containerCounters are a container's cumulative counters at one time.
+type containerCounters struct {
+	at time.Time
+
+	cpuUsageUsec uint64
+	netRX        uint64
+	netTX        uint64
+	readBytes    uint64
+	writeBytes   uint64
+}
+
+// AppStatsReader reads the resource use of the apps' containers: from their
+// cgroup v2 groups, or from `docker stats` where those can't be read.
+type AppStatsReader struct {
+	appsDir    string
+	cgroupRoot string
+	procRoot   string
+	docker     dockerFunc
+	now        func() time.Time
+	log        logger.Logger
+
+	containers []appContainer
+	listedAt   time.Time
+	last       map[string]containerCounters
+}
+
+func NewAppStatsReader(appsDir string, log logger.Logger) *AppStatsReader {
+	return &AppStatsReader{
+		appsDir:    appsDir,
+		cgroupRoot: system.CgroupRoot,
+		procRoot:   "/proc",
+		docker:     runDocker,
+		now:        time.Now,
+		log:        log,
+		last:       make(map[string]containerCounters),
+	}
+}
+
+// Collect returns one AppMetric per app with running containers, by app ID.
+func (r *AppStatsReader) Collect(ctx context.Context) []domain.AppMetric {
+	if r.appsDir == "" {
+		return nil
+	}
+
+	now := r.now()
+	if r.listedAt.IsZero() || now.Sub(r.listedAt) >= containerRefresh {
+		containers, err := r.listContainers(ctx)
+		if err != nil {
+			// Keep the last list; retried on the next refresh.
+			r.log.Debug("failed to list app containers", "error", err.Error())
+		} else {
+			r.containers = containers
+		}
+		r.listedAt = now
+	}
+
+	if len(r.containers) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(r.containers))
+	metrics := make(map[string]domain.ContainerMetric, len(r.containers))
+	var fallback []appContainer
+
+	cgroupV2 := system.CgroupV2(r.cgroupRoot)
+	for _, c := range r.containers {
+		seen[c.ID] = true
+
+		dir, ok := "", false
+		if cgroupV2 {
+			dir, ok = system.ContainerCgroupDir(r.cgroupRoot, c.ID)
+		}
+		if !ok {
+			fallback = append(fallback, c)
+			continue
+		}
+
+		stats, err := system.ReadCgroupStats(dir)
+		if err != nil {
+			// Gone since it was listed.
+			continue
+		}
+
+		curr := containerCounters{
+			at:           now,
+			cpuUsageUsec: stats.CPUUsageUsec,
+			readBytes:    stats.ReadBytes,
+			writeBytes:   stats.WriteBytes,
+		}
+		if c.Pid > 0 {
+			curr.netRX, curr.netTX, _ = system.ReadNetDev(filepath.Join(r.procRoot, strconv.Itoa(c.Pid), "net", "dev"))
+		}
+
+		m := r.rates(c, curr)
+		m.MemoryBytes = stats.MemoryBytes
+		m.MemoryLimitBytes = stats.MemoryLimitBytes
+		metrics[c.ID] = m
+	}
+
+	if len(fallback) > 0 {
+		stats, err := r.dockerStats(ctx, fallback, now)
+		if err != nil {
+			r.log.Debug("failed to run docker stats", "error", err.Error())
+		}
+		maps.Copy(metrics, stats)
+	}
+
+	for id := range r.last {
+		if !seen[id] {
+			delete(r.last, id)
+		}
+	}
+
+	return groupByApp(r.containers, metrics)
+}
+
+// rates turns cumulative counters into rates since the container's last
+// sample, and records them for the next. The first sample has none.
+func (r *AppStatsReader) rates(c appContainer, curr containerCounters) domain.ContainerMetric {
+	m := domain.ContainerMetric{Name: c.Name, Service: c.Service}
+
+	prev, ok := r.last[c.ID]
+	r.last[c.ID] = curr
+	if !ok {
+		return m
+	}
+
+	dt := curr.at.Sub(prev.at).Seconds()
+	if dt <= 0 {
+		return m
+	}
+
+	m.CPUPercent = float64(delta(curr.cpuUsageUsec, prev.cpuUsageUsec)) / (dt * 1e6) * 100
+	m.NetRXMBs = float64(delta(curr.netRX, prev.netRX)) / 1024 / 1024 / dt
+	m.NetTXMBs = float64(delta(curr.netTX, prev.netTX)) / 1024 / 1024 / dt
+	m.BlockReadMBs = float64(delta(curr.readBytes, prev.readBytes)) / 1024 / 1024 / dt
+	m.BlockWriteMBs = float64(delta(curr.writeBytes, prev.writeBytes)) / 1024 / 1024 / dt
+
+	return m
+}
+
+// delta is curr-prev, or 0 when the counter was reset (a restart).
+func delta(curr, prev uint64) uint64 {
+	if curr < prev {
+		return 0
+	}
+	return curr - prev
+}
+
+// listContainers finds the running compose containers of apps under
+// appsDir.
+func (r *AppStatsReader) listContainers(ctx context.Context) ([]appContainer, error) {
+	out, err := r.docker(ctx, "ps", "--quiet", "--no-trunc", "--filter", "label="+composeWorkingDirLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	out, err = r.docker(ctx, append([]string{"inspect"}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var inspected []struct {
+		ID    string `json:"Id"`
+		Name  string `json:"Name"`
+		State struct {
+			Pid int `json:"Pid"`
+		} `json:"State"`
+		Config struct {
+			Labels map[string]string `json:"Labels"`
+		} `json:"Config"`
+	}
+	if err := json.Unmarshal(out, &inspected); err != nil {
+		return nil, fmt.Errorf("parse docker inspect: %w", err)
+	}
+
+	containers := make([]appContainer, 0, len(inspected))
+	for _, c := range inspected {
+		appID, ok := r.appID(c.Config.Labels[composeWorkingDirLabel])
+		if !ok {
+			continue
+		}
+		containers = append(containers, appContainer{
+			ID:      c.ID,
+			Name:    strings.TrimPrefix(c.Name, "/"),
+			Service: c.Config.Labels[composeServiceLabel],
+			AppID:   appID,
+			Pid:     c.State.Pid,
+		})
+	}
+
+	return containers, nil
+}
+
+// appID maps a compose project directory to the app checked out there:
+// appsDir/<app key>[/<project dir>].
+func (r *AppStatsReader) appID(workingDir string) (int64, bool) {
+	rel, err := filepath.Rel(r.appsDir, workingDir)
+	if err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return 0, false
+	}
+
+	key, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return domain.ParseAppKey(key)
+}
+
+// dockerStats reads containers whose cgroup could not be: `docker stats`
+// reports CPU itself and cumulative network and block IO.
+func (r *AppStatsReader) dockerStats(ctx context.Context, containers []appContainer, now time.Time) (map[string]domain.ContainerMetric, error) {
+	args := []string{"stats", "--no-stream", "--no-trunc", "--format", "{{json .}}"}
+	for _, c := range containers {
+		args = append(args, c.ID)
+	}
+
+	out, err := r.docker(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]appContainer, len(containers))
+	for _, c := range containers {
+		byID[c.ID] = c
+	}
+
+	metrics := make(map[string]domain.ContainerMetric, len(containers))
+	for line := range bytes.SplitSeq(out, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		stats, err := parseDockerStats(line)
+		if err != nil {
+			r.log.Debug("failed to parse docker stats", "error", err.Error())
+			continue
+		}
+
+		c, ok := byID[stats.id]
+		if !ok {
+			continue
+		}
+
+		m := r.rates(c, containerCounters{
+			at:         now,
+			netRX:      stats.netRX,
+			netTX:      stats.netTX,
+			readBytes:  stats.readBytes,
+			writeBytes: stats.writeBytes,
+		})
+		m.CPUPercent = stats.cpuPercent
+		m.MemoryBytes = stats.memoryBytes
+		m.MemoryLimitBytes = stats.memoryLimit
+		metrics[c.ID] = m
+	}
+
+	return metrics, nil
+}
+
+type dockerStatsLine struct {
+	id          string
+	cpuPercent  float64
+	memoryBytes uint64
+	memoryLimit uint64
+	netRX       uint64
+	netTX       uint64
+	readBytes   uint64
+	writeBytes  uint64
+}
+
+// parseDockerStats parses one `docker stats --format '{{json .}}'` line:
+// {"ID":"…","CPUPerc":"1.50%","MemUsage":"10MiB / 1GiB","NetIO":"1kB / 2kB",
+// "BlockIO":"0B / 4.1MB",…}.
+func parseDockerStats(line []byte) (dockerStatsLine, error) {
+	var raw struct {
+		ID       string `json:"ID"`
+		CPUPerc  string `json:"CPUPerc"`
+		MemUsage string `json:"MemUsage"`
+		NetIO    string `json:"NetIO"`
+		BlockIO  string `json:"BlockIO"`
+	}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return dockerStatsLine{}, err
+	}
+
+	stats := dockerStatsLine{id: raw.ID}
+	stats.cpuPercent, _ = strconv.ParseFloat(strings.TrimSuffix(raw.CPUPerc, "%"), 64)
+	stats.memoryBytes, stats.memoryLimit = parseSizePair(raw.MemUsage)
+	stats.netRX, stats.netTX = parseSizePair(raw.NetIO)
+	stats.readBytes, stats.writeBytes = parseSizePair(raw.BlockIO)
+
+	return stats, nil
+}
+
+func parseSizePair(s string) (uint64, uint64) {
+	a, b, _ := strings.Cut(s, "/")
+	return parseSize(a), parseSize(b)
+}
+
+var sizeUnits = map[string]float64{
+	"B":   1,
+	"kB":  1e3,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"KiB": 1 << 10,
+	"MiB": 1 << 20,
+	"GiB": 1 << 30,
+	"TiB": 1 << 40,
+}
+
+// parseSize parses docker's human sizes: decimal units for IO ("4.1MB"),
+// binary ones for memory ("10MiB"). Unparsable sizes are 0.
+func parseSize(s string) uint64 {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i <= 0 {
+		return 0
+	}
+
+	n, err := strconv.ParseFloat(s[:i], 64)
+	unit, ok := sizeUnits[s[i:]]
+	if err != nil || !ok {
+		return 0
+	}
+	return uint64(math.Round(n * unit))
+}
+
+// groupByApp sums container metrics per app, apps by ID and their
+// containers by name.
+func groupByApp(containers []appContainer, metrics map[string]domain.ContainerMetric) []domain.AppMetric {
+	byApp := make(map[int64]*domain.AppMetric)
+	var ids []int64
+
+	for _, c := range containers {
+		m, ok := metrics[c.ID]
+		if !ok {
+			continue
+		}
+
+		app, ok := byApp[c.AppID]
+		if !ok {
+			app = &domain.AppMetric{ApplicationID: c.AppID}
+			byApp[c.AppID] = app
+			ids = append(ids, c.AppID)
+		}
+
+		app.CPUPercent += m.CPUPercent
+		app.MemoryBytes += m.MemoryBytes
+		app.NetRXMBs += m.NetRXMBs
+		app.NetTXMBs += m.NetTXMBs
+		app.BlockReadMBs += m.BlockReadMBs
+		app.BlockWriteMBs += m.BlockWriteMBs
+		app.Containers = append(app.Containers, m)
+	}
+
+	slices.Sort(ids)
+	apps := make([]domain.AppMetric, 0, len(ids))
+	for _, id := range ids {
+		app := byApp[id]
+		slices.SortFunc(app.Containers, func(a, b domain.ContainerMetric) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		apps = append(apps, *app)
+	}
+
+	return apps
+}
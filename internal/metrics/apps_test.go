@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type noopLog struct{}
+
+func (noopLog) Debug(string, ...any) {}
+func (noopLog) Info(string, ...any)  {}
+func (noopLog) Warn(string, ...any)  {}
+func (noopLog) Error(string, ...any) {}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeDocker answers ps and inspect with the given containers, and stats
+// with statsOut.
+type fakeDocker struct {
+	inspect  string
+	statsOut string
+	calls    []string
+}
+
+func (f *fakeDocker) run(_ context.Context, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, args[0])
+	switch args[0] {
+	case "ps":
+		return []byte("aaa\nbbb\n"), nil
+	case "inspect":
+		return []byte(f.inspect), nil
+	case "stats":
+		return []byte(f.statsOut), nil
+	}
+	return nil, fmt.Errorf("unexpected docker %v", args)
+}
+
+func newTestReader(t *testing.T, docker *fakeDocker) (*AppStatsReader, string, *time.Time) {
+	appsDir := t.TempDir()
+	docker.inspect = fmt.Sprintf(`[
+		{"Id":"aaa","Name":"/shop-7-web-1","State":{"Pid":123},"Config":{"Labels":{
+			"com.docker.compose.project.working_dir":%q,"com.docker.compose.service":"web"}}},
+		{"Id":"bbb","Name":"/other-1","State":{"Pid":456},"Config":{"Labels":{
+			"com.docker.compose.project.working_dir":"/srv/other-1","com.docker.compose.service":"app"}}}
+	]`, filepath.Join(appsDir, "shop-7", "deploy"))
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewAppStatsReader(appsDir, noopLog{})
+	r.cgroupRoot = t.TempDir()
+	r.procRoot = t.TempDir()
+	r.docker = docker.run
+	r.now = func() time.Time { return now }
+	return r, r.cgroupRoot, &now
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestAppStatsReaderReadsCgroups(t *testing.T) {
+	docker := &fakeDocker{}
+	r, root, now := newTestReader(t, docker)
+
+	group := filepath.Join(root, "system.slice", "docker-aaa.scope")
+	writeFile(t, filepath.Join(root, "cgroup.controllers"), "cpu io memory\n")
+	writeFile(t, filepath.Join(group, "cpu.stat"), "usage_usec 1000000\n")
+	writeFile(t, filepath.Join(group, "memory.current"), "2097152\n")
+	writeFile(t, filepath.Join(group, "memory.max"), "max\n")
+	writeFile(t, filepath.Join(group, "io.stat"), "8:0 rbytes=0 wbytes=0\n")
+	netDev := filepath.Join(r.procRoot, "123", "net", "dev")
+	writeFile(t, netDev, "  eth0: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n")
+
+	if apps := r.Collect(context.Background()); len(apps) != 1 || apps[0].CPUPercent != 0 {
+		t.Fatalf("first collect = %+v, want app 7 without rates", apps)
+	}
+
+	// Two seconds later: one core busy half the time, 2 MiB in, 1 MiB written.
+	*now = now.Add(2 * time.Second)
+	writeFile(t, filepath.Join(group, "cpu.stat"), "usage_usec 2000000\n")
+	writeFile(t, filepath.Join(group, "io.stat"), "8:0 rbytes=0 wbytes=1048576\n")
+	writeFile(t, netDev, "  eth0: 2097152 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n")
+
+	apps := r.Collect(context.Background())
+	if len(apps) != 1 || apps[0].ApplicationID != 7 || len(apps[0].Containers) != 1 {
+		t.Fatalf("apps = %+v, want app 7's one container", apps)
+	}
+
+	web := apps[0].Containers[0]
+	if web.Name != "shop-7-web-1" || web.Service != "web" {
+		t.Fatalf("container = %+v", web)
+	}
+	if !approx(web.CPUPercent, 50) || web.MemoryBytes != 2097152 || web.MemoryLimitBytes != 0 ||
+		!approx(web.NetRXMBs, 1) || !approx(web.BlockWriteMBs, 0.5) {
+		t.Fatalf("usage = %+v", web.AppUsage)
+	}
+	if apps[0].AppUsage != web.AppUsage {
+		t.Fatalf("app = %+v, want its one container's usage", apps[0].AppUsage)
+	}
+
+	// The list is reused between refreshes.
+	if strings.Join(docker.calls, " ") != "ps inspect" {
+		t.Fatalf("docker calls = %v", docker.calls)
+	}
+}
+
+func TestAppStatsReaderFallsBackToDockerStats(t *testing.T) {
+	docker := &fakeDocker{
+		statsOut: `{"ID":"aaa","CPUPerc":"12.50%","MemUsage":"64MiB / 1GiB","NetIO":"1MB / 2kB","BlockIO":"0B / 4.1MB"}` + "\n",
+	}
+	r, _, _ := newTestReader(t, docker)
+
+	apps := r.Collect(context.Background())
+	if len(apps) != 1 || len(apps[0].Containers) != 1 {
+		t.Fatalf("apps = %+v, want app 7's one container", apps)
+	}
+
+	web := apps[0].Containers[0]
+	if web.CPUPercent != 12.5 || web.MemoryBytes != 64<<20 || web.MemoryLimitBytes != 1<<30 {
+		t.Fatalf("usage = %+v", web)
+	}
+	if strings.Join(docker.calls, " ") != "ps inspect stats" {
+		t.Fatalf("docker calls = %v", docker.calls)
+	}
+}
+
+func TestParseDockerStats(t *testing.T) {
+	stats, err := parseDockerStats([]byte(`{"ID":"aaa","CPUPerc":"0.07%","MemUsage":"10.5MiB / 1.5GiB","NetIO":"1.2kB / 648B","BlockIO":"4.1MB / 0B"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := dockerStatsLine{
+		id:          "aaa",
+		cpuPercent:  0.07,
+		memoryBytes: uint64(10.5 * (1 << 20)),
+		memoryLimit: uint64(1.5 * (1 << 30)),
+		netRX:       1200,
+		netTX:       648,
+		readBytes:   4100000,
+	}
+	if stats != want {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestAppIDFromWorkingDir(t *testing.T) {
+	r := NewAppStatsReader("/var/horizonx/apps", noopLog{})
+
+	cases := map[string]int64{
+		"/var/horizonx/apps/shop-7":          7,
+		"/var/horizonx/apps/my-shop-12/api":  12,
+		"/var/horizonx/apps/.horizonx-spool": 0,
+		"/var/horizonx/apps":                 0,
+		"/srv/shop-7":                        0,
+	}
+	for dir, want := range cases {
+		if id, _ := r.appID(dir); id != want {
+			t.Errorf("appID(%q) = %d, want %d", dir, id, want)
+		}
+	}
+}
@@ -3,11 +3,9 @@ package metrics
 
 import (
 	"context"
-	"fmt"
 	"sync"
 	"time"
 
-	"horizonx/internal/adapters/redis"
 	"horizonx/internal/config"
 	"horizonx/internal/domain"
 	"horizonx/internal/logger"
@@ -40,9 +38,9 @@ type Collector struct {
 	cfg *config.Config
 	log logger.Logger
 
-	registry       *redis.Registry
-	registryKey    string
-	registryMaxLen int64
+	// store keeps recent samples across restarts (a FileBuffer by default,
+	// Redis when the agent is configured for it).
+	store domain.MetricsBuffer
 
 	buffer   []domain.Metrics
 	bufferMu sync.Mutex
@@ -51,6 +49,7 @@ type Collector struct {
 	interval   time.Duration
 
 	reader *system.SystemReader
+	apps   *AppStatsReader
 
 	cpuPowerState CPUPowerState
 	cpuUsageState CPUUsageState
@@ -79,20 +78,19 @@ type Collector struct {
 	iface string
 }
 
-func NewCollector(cfg *config.Config, log logger.Logger, registry *redis.Registry) *Collector {
+func NewCollector(cfg *config.Config, log logger.Logger, store domain.MetricsBuffer, appsDir string) *Collector {
 	return &Collector{
 		cfg: cfg,
 		log: log,
 
-		registry:       registry,
-		registryKey:    fmt.Sprintf("metrics:agent:%s:stream", cfg.AgentServerID.String()),
-		registryMaxLen: 5000,
+		store: store,
 
 		buffer:     make([]domain.Metrics, 0, 10),
 		maxSamples: 10,
 		interval:   5 * time.Second,
 
 		reader: system.NewReader(log),
+		apps:   NewAppStatsReader(appsDir, log),
 
 		lastDiskIO: make(map[string]DiskIOState),
 		lastNet:    make(map[string]NetState),
@@ -119,8 +117,8 @@ func NewCollector(cfg *config.Config, log logger.Logger, registry *redis.Registr
 }
 
 func (c *Collector) Start(ctx context.Context) error {
-	if err := c.loadBufferFromRegistry(ctx); err != nil {
-		c.log.Error("failed to load initial metrics from registry", "err", err)
+	if err := c.loadBuffer(ctx); err != nil {
+		c.log.Error("failed to load initial metrics from buffer", "err", err)
 	}
 
 	ticker := time.NewTicker(c.interval)
@@ -131,7 +129,6 @@ func (c *Collector) Start(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
-			c.flushBufferToRegistry()
 			c.log.Info("metrics collector stopped")
 			return nil
 		case <-ticker.C:
@@ -152,32 +149,10 @@ func (c *Collector) Latest() *domain.Metrics {
 	return &m
 }
 
-func (c *Collector) flushBufferToRegistry() {
-	c.bufferMu.Lock()
-	defer c.bufferMu.Unlock()
-
-	if len(c.buffer) == 0 {
-		return
-	}
-
-	c.log.Info("flushing buffered metrics to registry")
-
-	for _, m := range c.buffer {
-		if _, err := c.registry.Append(context.Background(), c.registryKey, &m, 5000); err != nil {
-			c.log.Error("failed to flush buffered metrics to registry", "err", err)
-		}
-	}
-
-	c.buffer = c.buffer[:0]
-}
-
-func (c *Collector) loadBufferFromRegistry(ctx context.Context) error {
-	msg, err := c.registry.GetRangeDesc(ctx, c.registryKey, int64(c.maxSamples))
-	if err != nil {
-		return err
-	}
-
-	metrics, _, err := redis.ParseStreamMessages[domain.Metrics](msg)
+// loadBuffer seeds the in-memory samples from the store. Every sample is
+// stored as it is collected, so nothing needs flushing on stop.
+func (c *Collector) loadBuffer(ctx context.Context) error {
+	metrics, err := c.store.Recent(ctx, c.maxSamples)
 	if err != nil {
 		return err
 	}
@@ -193,12 +168,15 @@ func (c *Collector) loadBufferFromRegistry(ctx context.Context) error {
 		metrics = metrics[len(metrics)-c.maxSamples:]
 	}
 	c.buffer = append(c.buffer, metrics...)
-	c.log.Info("loaded initial metrics from registry", "count", len(c.buffer))
+	c.log.Info("loaded initial metrics from buffer", "count", len(c.buffer))
 
 	return nil
 }
 
 func (c *Collector) collect(ctx context.Context) {
+	// Outside the lock: listing containers runs docker.
+	apps := c.apps.Collect(ctx)
+
 	c.bufferMu.Lock()
 
 	var metrics domain.Metrics
@@ -210,6 +188,7 @@ func (c *Collector) collect(ctx context.Context) {
 	metrics.Disk = c.getDiskMetrics()
 	metrics.Network = c.getNetworkMetric()
 	metrics.UptimeSeconds = c.reader.Uptime()
+	metrics.Apps = apps
 	metrics.RecordedAt = time.Now().UTC()
 
 	c.ApplyEMA(&metrics)
@@ -221,7 +200,7 @@ func (c *Collector) collect(ctx context.Context) {
 
 	c.bufferMu.Unlock()
 
-	if _, err := c.registry.Append(ctx, c.registryKey, &metrics, 5000); err != nil {
+	if err := c.store.Append(ctx, &metrics); err != nil {
 		c.log.Error("failed to append metrics", "err", err)
 	}
 }
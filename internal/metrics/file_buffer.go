@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"horizonx/internal/domain"
+)
+
+// DefaultFileBufferSize is an hour of samples at the collector's interval.
+const DefaultFileBufferSize = 720
+
+// FileBuffer is the agent's default MetricsBuffer: a ring of the last size
+// samples, mirrored to an NDJSON file so it survives restarts without any
+// service on the host. Samples are appended to the file as they come; once it
+// holds twice the ring it is rewritten from the ring, so it never grows past
+// 2*size lines.
+type FileBuffer struct {
+	path string
+	size int
+
+	mu    sync.Mutex
+	ring  []domain.Metrics
+	next  int // ring slot the next sample goes to
+	lines int // samples in the file
+}
+
+// NewFileBuffer opens the buffer at path, loading what an earlier run left.
+// Unreadable lines (a write cut short by a crash) are dropped.
+func NewFileBuffer(path string, size int) (*FileBuffer, error) {
+	if size <= 0 {
+		size = DefaultFileBufferSize
+	}
+
+	b := &FileBuffer{
+		path: path,
+		size: size,
+		ring: make([]domain.Metrics, 0, size),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create metrics buffer directory: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read metrics buffer: %w", err)
+	}
+
+	dirty := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var m domain.Metrics
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			dirty = true
+			continue
+		}
+		b.push(m)
+		b.lines++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics buffer: %w", err)
+	}
+
+	if dirty || b.lines > len(b.ring) {
+		if err := b.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func (b *FileBuffer) Append(_ context.Context, m *domain.Metrics) error {
+	line, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.push(*m)
+
+	if b.lines+1 >= 2*b.size {
+		return b.rewrite()
+	}
+
+	f, err := os.OpenFile(b.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open metrics buffer: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append metrics: %w", err)
+	}
+	b.lines++
+
+	return nil
+}
+
+func (b *FileBuffer) Recent(_ context.Context, n int) ([]domain.Metrics, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ordered := b.ordered()
+	if n < len(ordered) {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered, nil
+}
+
+func (b *FileBuffer) push(m domain.Metrics) {
+	if len(b.ring) < b.size {
+		b.ring = append(b.ring, m)
+		return
+	}
+	b.ring[b.next] = m
+	b.next = (b.next + 1) % b.size
+}
+
+// ordered returns the ring's samples, oldest first.
+func (b *FileBuffer) ordered() []domain.Metrics {
+	out := make([]domain.Metrics, 0, len(b.ring))
+	out = append(out, b.ring[b.next:]...)
+	return append(out, b.ring[:b.next]...)
+}
+
+// rewrite replaces the file with the ring's samples.
+func (b *FileBuffer) rewrite() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, m := range b.ordered() {
+		if err := enc.Encode(&m); err != nil {
+			return fmt.Errorf("failed to marshal metrics: %w", err)
+		}
+	}
+
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write metrics buffer: %w", err)
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return fmt.Errorf("failed to write metrics buffer: %w", err)
+	}
+
+	b.lines = len(b.ring)
+	return nil
+}
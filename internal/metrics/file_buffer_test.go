@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"horizonx/internal/domain"
+)
+
+func sample(uptime float64) *domain.Metrics {
+	return &domain.Metrics{UptimeSeconds: uptime}
+}
+
+func uptimes(ms []domain.Metrics) []float64 {
+	out := make([]float64, 0, len(ms))
+	for _, m := range ms {
+		out = append(out, m.UptimeSeconds)
+	}
+	return out
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	n := 0
+	for s := bufio.NewScanner(f); s.Scan(); {
+		n++
+	}
+	return n
+}
+
+func TestFileBufferKeepsNewestSamplesAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics", "samples.ndjson")
+	ctx := context.Background()
+
+	b, err := NewFileBuffer(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		if err := b.Append(ctx, sample(float64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, _ := b.Recent(ctx, 2)
+	if want := []float64{4, 5}; !slices.Equal(uptimes(got), want) {
+		t.Fatalf("Recent(2) = %v, want %v", uptimes(got), want)
+	}
+
+	// The file never holds more than twice the ring.
+	if n := countLines(t, path); n > 6 {
+		t.Fatalf("file holds %d samples, want at most 6", n)
+	}
+
+	// A write cut short by a crash is dropped on reopen.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"uptime_seco`)
+	f.Close()
+
+	b, err = NewFileBuffer(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = b.Recent(ctx, 10)
+	if want := []float64{3, 4, 5}; !slices.Equal(uptimes(got), want) {
+		t.Fatalf("after reopen Recent = %v, want %v", uptimes(got), want)
+	}
+}
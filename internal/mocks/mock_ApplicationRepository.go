@@ -8,6 +8,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
 )
 
@@ -647,6 +649,54 @@ func (_c *MockApplicationRepository_UpdateLastDeployment_Call) RunAndReturn(run
 	return _c
 }
 
+// UpdatePreviewExpiry provides a mock function with given fields: ctx, appID, expiresAt
+func (_m *MockApplicationRepository) UpdatePreviewExpiry(ctx context.Context, appID int64, expiresAt time.Time) error {
+	ret := _m.Called(ctx, appID, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePreviewExpiry")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) error); ok {
+		r0 = rf(ctx, appID, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockApplicationRepository_UpdatePreviewExpiry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePreviewExpiry'
+type MockApplicationRepository_UpdatePreviewExpiry_Call struct {
+	*mock.Call
+}
+
+// UpdatePreviewExpiry is a helper method to define mock.On call
+//   - ctx context.Context
+//   - appID int64
+//   - expiresAt time.Time
+func (_e *MockApplicationRepository_Expecter) UpdatePreviewExpiry(ctx interface{}, appID interface{}, expiresAt interface{}) *MockApplicationRepository_UpdatePreviewExpiry_Call {
+	return &MockApplicationRepository_UpdatePreviewExpiry_Call{Call: _e.mock.On("UpdatePreviewExpiry", ctx, appID, expiresAt)}
+}
+
+func (_c *MockApplicationRepository_UpdatePreviewExpiry_Call) Run(run func(ctx context.Context, appID int64, expiresAt time.Time)) *MockApplicationRepository_UpdatePreviewExpiry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockApplicationRepository_UpdatePreviewExpiry_Call) Return(_a0 error) *MockApplicationRepository_UpdatePreviewExpiry_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockApplicationRepository_UpdatePreviewExpiry_Call) RunAndReturn(run func(context.Context, int64, time.Time) error) *MockApplicationRepository_UpdatePreviewExpiry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateStatus provides a mock function with given fields: ctx, appID, status
 func (_m *MockApplicationRepository) UpdateStatus(ctx context.Context, appID int64, status domain.ApplicationStatus) error {
 	ret := _m.Called(ctx, appID, status)
@@ -385,6 +385,66 @@ func (_c *MockDeploymentRepository_UpdateCommitInfo_Call) RunAndReturn(run func(
 	return _c
 }
 
+// UpdateImageDigest provides a mock function with given fields: ctx, deploymentID, imageDigest
+func (_m *MockDeploymentRepository) UpdateImageDigest(ctx context.Context, deploymentID int64, imageDigest string) (*domain.Deployment, error) {
+	ret := _m.Called(ctx, deploymentID, imageDigest)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateImageDigest")
+	}
+
+	var r0 *domain.Deployment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (*domain.Deployment, error)); ok {
+		return rf(ctx, deploymentID, imageDigest)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *domain.Deployment); ok {
+		r0 = rf(ctx, deploymentID, imageDigest)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Deployment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, deploymentID, imageDigest)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDeploymentRepository_UpdateImageDigest_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateImageDigest'
+type MockDeploymentRepository_UpdateImageDigest_Call struct {
+	*mock.Call
+}
+
+// UpdateImageDigest is a helper method to define mock.On call
+//   - ctx context.Context
+//   - deploymentID int64
+//   - imageDigest string
+func (_e *MockDeploymentRepository_Expecter) UpdateImageDigest(ctx interface{}, deploymentID interface{}, imageDigest interface{}) *MockDeploymentRepository_UpdateImageDigest_Call {
+	return &MockDeploymentRepository_UpdateImageDigest_Call{Call: _e.mock.On("UpdateImageDigest", ctx, deploymentID, imageDigest)}
+}
+
+func (_c *MockDeploymentRepository_UpdateImageDigest_Call) Run(run func(ctx context.Context, deploymentID int64, imageDigest string)) *MockDeploymentRepository_UpdateImageDigest_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockDeploymentRepository_UpdateImageDigest_Call) Return(_a0 *domain.Deployment, _a1 error) *MockDeploymentRepository_UpdateImageDigest_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDeploymentRepository_UpdateImageDigest_Call) RunAndReturn(run func(context.Context, int64, string) (*domain.Deployment, error)) *MockDeploymentRepository_UpdateImageDigest_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateEnvSnapshot provides a mock function with given fields: ctx, deploymentID, snapshot
 func (_m *MockDeploymentRepository) UpdateEnvSnapshot(ctx context.Context, deploymentID int64, snapshot map[string]string) (*domain.Deployment, error) {
 	ret := _m.Called(ctx, deploymentID, snapshot)
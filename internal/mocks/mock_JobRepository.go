@@ -8,6 +8,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
 )
 
@@ -24,6 +26,65 @@ func (_m *MockJobRepository) EXPECT() *MockJobRepository_Expecter {
 	return &MockJobRepository_Expecter{mock: &_m.Mock}
 }
 
+// CancelQueued provides a mock function with given fields: ctx, jobID
+func (_m *MockJobRepository) CancelQueued(ctx context.Context, jobID int64) (*domain.Job, error) {
+	ret := _m.Called(ctx, jobID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelQueued")
+	}
+
+	var r0 *domain.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.Job, error)); ok {
+		return rf(ctx, jobID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Job); ok {
+		r0 = rf(ctx, jobID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, jobID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockJobRepository_CancelQueued_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CancelQueued'
+type MockJobRepository_CancelQueued_Call struct {
+	*mock.Call
+}
+
+// CancelQueued is a helper method to define mock.On call
+//   - ctx context.Context
+//   - jobID int64
+func (_e *MockJobRepository_Expecter) CancelQueued(ctx interface{}, jobID interface{}) *MockJobRepository_CancelQueued_Call {
+	return &MockJobRepository_CancelQueued_Call{Call: _e.mock.On("CancelQueued", ctx, jobID)}
+}
+
+func (_c *MockJobRepository_CancelQueued_Call) Run(run func(ctx context.Context, jobID int64)) *MockJobRepository_CancelQueued_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *MockJobRepository_CancelQueued_Call) Return(_a0 *domain.Job, _a1 error) *MockJobRepository_CancelQueued_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockJobRepository_CancelQueued_Call) RunAndReturn(run func(context.Context, int64) (*domain.Job, error)) *MockJobRepository_CancelQueued_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CountsByStatus provides a mock function with given fields: ctx
 func (_m *MockJobRepository) CountsByStatus(ctx context.Context) (*domain.JobStatusCounts, error) {
 	ret := _m.Called(ctx)
@@ -372,9 +433,9 @@ func (_c *MockJobRepository_List_Call) RunAndReturn(run func(context.Context, do
 	return _c
 }
 
-// MarkFinished provides a mock function with given fields: ctx, jobID, status
-func (_m *MockJobRepository) MarkFinished(ctx context.Context, jobID int64, status domain.JobStatus) (*domain.Job, error) {
-	ret := _m.Called(ctx, jobID, status)
+// MarkFinished provides a mock function with given fields: ctx, jobID, status, startedAt
+func (_m *MockJobRepository) MarkFinished(ctx context.Context, jobID int64, status domain.JobStatus, startedAt *time.Time) (*domain.Job, error) {
+	ret := _m.Called(ctx, jobID, status, startedAt)
 
 	if len(ret) == 0 {
 		panic("no return value specified for MarkFinished")
@@ -382,19 +443,19 @@ func (_m *MockJobRepository) MarkFinished(ctx context.Context, jobID int64, stat
 
 	var r0 *domain.Job
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.JobStatus) (*domain.Job, error)); ok {
-		return rf(ctx, jobID, status)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.JobStatus, *time.Time) (*domain.Job, error)); ok {
+		return rf(ctx, jobID, status, startedAt)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.JobStatus) *domain.Job); ok {
-		r0 = rf(ctx, jobID, status)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.JobStatus, *time.Time) *domain.Job); ok {
+		r0 = rf(ctx, jobID, status, startedAt)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Job)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.JobStatus) error); ok {
-		r1 = rf(ctx, jobID, status)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.JobStatus, *time.Time) error); ok {
+		r1 = rf(ctx, jobID, status, startedAt)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -411,13 +472,14 @@ type MockJobRepository_MarkFinished_Call struct {
 //   - ctx context.Context
 //   - jobID int64
 //   - status domain.JobStatus
-func (_e *MockJobRepository_Expecter) MarkFinished(ctx interface{}, jobID interface{}, status interface{}) *MockJobRepository_MarkFinished_Call {
-	return &MockJobRepository_MarkFinished_Call{Call: _e.mock.On("MarkFinished", ctx, jobID, status)}
+//   - startedAt *time.Time
+func (_e *MockJobRepository_Expecter) MarkFinished(ctx interface{}, jobID interface{}, status interface{}, startedAt interface{}) *MockJobRepository_MarkFinished_Call {
+	return &MockJobRepository_MarkFinished_Call{Call: _e.mock.On("MarkFinished", ctx, jobID, status, startedAt)}
 }
 
-func (_c *MockJobRepository_MarkFinished_Call) Run(run func(ctx context.Context, jobID int64, status domain.JobStatus)) *MockJobRepository_MarkFinished_Call {
+func (_c *MockJobRepository_MarkFinished_Call) Run(run func(ctx context.Context, jobID int64, status domain.JobStatus, startedAt *time.Time)) *MockJobRepository_MarkFinished_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(int64), args[2].(domain.JobStatus))
+		run(args[0].(context.Context), args[1].(int64), args[2].(domain.JobStatus), args[3].(*time.Time))
 	})
 	return _c
 }
@@ -427,7 +489,7 @@ func (_c *MockJobRepository_MarkFinished_Call) Return(_a0 *domain.Job, _a1 error
 	return _c
 }
 
-func (_c *MockJobRepository_MarkFinished_Call) RunAndReturn(run func(context.Context, int64, domain.JobStatus) (*domain.Job, error)) *MockJobRepository_MarkFinished_Call {
+func (_c *MockJobRepository_MarkFinished_Call) RunAndReturn(run func(context.Context, int64, domain.JobStatus, *time.Time) (*domain.Job, error)) *MockJobRepository_MarkFinished_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -22,6 +22,53 @@ func (_m *MockLogRepository) EXPECT() *MockLogRepository_Expecter {
 	return &MockLogRepository_Expecter{mock: &_m.Mock}
 }
 
+// BulkInsert provides a mock function with given fields: ctx, logs
+func (_m *MockLogRepository) BulkInsert(ctx context.Context, logs []*domain.Log) error {
+	ret := _m.Called(ctx, logs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BulkInsert")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*domain.Log) error); ok {
+		r0 = rf(ctx, logs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockLogRepository_BulkInsert_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BulkInsert'
+type MockLogRepository_BulkInsert_Call struct {
+	*mock.Call
+}
+
+// BulkInsert is a helper method to define mock.On call
+//   - ctx context.Context
+//   - logs []*domain.Log
+func (_e *MockLogRepository_Expecter) BulkInsert(ctx interface{}, logs interface{}) *MockLogRepository_BulkInsert_Call {
+	return &MockLogRepository_BulkInsert_Call{Call: _e.mock.On("BulkInsert", ctx, logs)}
+}
+
+func (_c *MockLogRepository_BulkInsert_Call) Run(run func(ctx context.Context, logs []*domain.Log)) *MockLogRepository_BulkInsert_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]*domain.Log))
+	})
+	return _c
+}
+
+func (_c *MockLogRepository_BulkInsert_Call) Return(_a0 error) *MockLogRepository_BulkInsert_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockLogRepository_BulkInsert_Call) RunAndReturn(run func(context.Context, []*domain.Log) error) *MockLogRepository_BulkInsert_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Create provides a mock function with given fields: ctx, l
 func (_m *MockLogRepository) Create(ctx context.Context, l *domain.Log) (*domain.Log, error) {
 	ret := _m.Called(ctx, l)
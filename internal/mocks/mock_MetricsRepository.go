@@ -121,6 +121,413 @@ func (_c *MockMetricsRepository_Cleanup_Call) RunAndReturn(run func(context.Cont
 	return _c
 }
 
+// CleanupRollups provides a mock function with given fields: ctx, resolution, cutoff
+func (_m *MockMetricsRepository) CleanupRollups(ctx context.Context, resolution domain.MetricsResolution, cutoff time.Time) error {
+	ret := _m.Called(ctx, resolution, cutoff)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CleanupRollups")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.MetricsResolution, time.Time) error); ok {
+		r0 = rf(ctx, resolution, cutoff)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockMetricsRepository_CleanupRollups_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CleanupRollups'
+type MockMetricsRepository_CleanupRollups_Call struct {
+	*mock.Call
+}
+
+// CleanupRollups is a helper method to define mock.On call
+//   - ctx context.Context
+//   - resolution domain.MetricsResolution
+//   - cutoff time.Time
+func (_e *MockMetricsRepository_Expecter) CleanupRollups(ctx interface{}, resolution interface{}, cutoff interface{}) *MockMetricsRepository_CleanupRollups_Call {
+	return &MockMetricsRepository_CleanupRollups_Call{Call: _e.mock.On("CleanupRollups", ctx, resolution, cutoff)}
+}
+
+func (_c *MockMetricsRepository_CleanupRollups_Call) Run(run func(ctx context.Context, resolution domain.MetricsResolution, cutoff time.Time)) *MockMetricsRepository_CleanupRollups_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.MetricsResolution), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockMetricsRepository_CleanupRollups_Call) Return(_a0 error) *MockMetricsRepository_CleanupRollups_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockMetricsRepository_CleanupRollups_Call) RunAndReturn(run func(context.Context, domain.MetricsResolution, time.Time) error) *MockMetricsRepository_CleanupRollups_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListRollups provides a mock function with given fields: ctx, serverID, opts
+func (_m *MockMetricsRepository) ListRollups(ctx context.Context, serverID uuid.UUID, opts domain.MetricsRollupListOptions) ([]domain.MetricsRollup, error) {
+	ret := _m.Called(ctx, serverID, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRollups")
+	}
+
+	var r0 []domain.MetricsRollup
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, domain.MetricsRollupListOptions) ([]domain.MetricsRollup, error)); ok {
+		return rf(ctx, serverID, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, domain.MetricsRollupListOptions) []domain.MetricsRollup); ok {
+		r0 = rf(ctx, serverID, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.MetricsRollup)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, domain.MetricsRollupListOptions) error); ok {
+		r1 = rf(ctx, serverID, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockMetricsRepository_ListRollups_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRollups'
+type MockMetricsRepository_ListRollups_Call struct {
+	*mock.Call
+}
+
+// ListRollups is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serverID uuid.UUID
+//   - opts domain.MetricsRollupListOptions
+func (_e *MockMetricsRepository_Expecter) ListRollups(ctx interface{}, serverID interface{}, opts interface{}) *MockMetricsRepository_ListRollups_Call {
+	return &MockMetricsRepository_ListRollups_Call{Call: _e.mock.On("ListRollups", ctx, serverID, opts)}
+}
+
+func (_c *MockMetricsRepository_ListRollups_Call) Run(run func(ctx context.Context, serverID uuid.UUID, opts domain.MetricsRollupListOptions)) *MockMetricsRepository_ListRollups_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(domain.MetricsRollupListOptions))
+	})
+	return _c
+}
+
+func (_c *MockMetricsRepository_ListRollups_Call) Return(_a0 []domain.MetricsRollup, _a1 error) *MockMetricsRepository_ListRollups_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockMetricsRepository_ListRollups_Call) RunAndReturn(run func(context.Context, uuid.UUID, domain.MetricsRollupListOptions) ([]domain.MetricsRollup, error)) *MockMetricsRepository_ListRollups_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// OldestRollup provides a mock function with given fields: ctx, serverID, resolution
+func (_m *MockMetricsRepository) OldestRollup(ctx context.Context, serverID uuid.UUID, resolution domain.MetricsResolution) (time.Time, bool, error) {
+	ret := _m.Called(ctx, serverID, resolution)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OldestRollup")
+	}
+
+	var r0 time.Time
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, domain.MetricsResolution) (time.Time, bool, error)); ok {
+		return rf(ctx, serverID, resolution)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, domain.MetricsResolution) time.Time); ok {
+		r0 = rf(ctx, serverID, resolution)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, domain.MetricsResolution) bool); ok {
+		r1 = rf(ctx, serverID, resolution)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID, domain.MetricsResolution) error); ok {
+		r2 = rf(ctx, serverID, resolution)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockMetricsRepository_OldestRollup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'OldestRollup'
+type MockMetricsRepository_OldestRollup_Call struct {
+	*mock.Call
+}
+
+// OldestRollup is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serverID uuid.UUID
+//   - resolution domain.MetricsResolution
+func (_e *MockMetricsRepository_Expecter) OldestRollup(ctx interface{}, serverID interface{}, resolution interface{}) *MockMetricsRepository_OldestRollup_Call {
+	return &MockMetricsRepository_OldestRollup_Call{Call: _e.mock.On("OldestRollup", ctx, serverID, resolution)}
+}
+
+func (_c *MockMetricsRepository_OldestRollup_Call) Run(run func(ctx context.Context, serverID uuid.UUID, resolution domain.MetricsResolution)) *MockMetricsRepository_OldestRollup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(domain.MetricsResolution))
+	})
+	return _c
+}
+
+func (_c *MockMetricsRepository_OldestRollup_Call) Return(_a0 time.Time, _a1 bool, _a2 error) *MockMetricsRepository_OldestRollup_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockMetricsRepository_OldestRollup_Call) RunAndReturn(run func(context.Context, uuid.UUID, domain.MetricsResolution) (time.Time, bool, error)) *MockMetricsRepository_OldestRollup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// OldestSample provides a mock function with given fields: ctx, serverID
+func (_m *MockMetricsRepository) OldestSample(ctx context.Context, serverID uuid.UUID) (time.Time, bool, error) {
+	ret := _m.Called(ctx, serverID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for OldestSample")
+	}
+
+	var r0 time.Time
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (time.Time, bool, error)); ok {
+		return rf(ctx, serverID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) time.Time); ok {
+		r0 = rf(ctx, serverID)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) bool); ok {
+		r1 = rf(ctx, serverID)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID) error); ok {
+		r2 = rf(ctx, serverID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockMetricsRepository_OldestSample_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'OldestSample'
+type MockMetricsRepository_OldestSample_Call struct {
+	*mock.Call
+}
+
+// OldestSample is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serverID uuid.UUID
+func (_e *MockMetricsRepository_Expecter) OldestSample(ctx interface{}, serverID interface{}) *MockMetricsRepository_OldestSample_Call {
+	return &MockMetricsRepository_OldestSample_Call{Call: _e.mock.On("OldestSample", ctx, serverID)}
+}
+
+func (_c *MockMetricsRepository_OldestSample_Call) Run(run func(ctx context.Context, serverID uuid.UUID)) *MockMetricsRepository_OldestSample_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID))
+	})
+	return _c
+}
+
+func (_c *MockMetricsRepository_OldestSample_Call) Return(_a0 time.Time, _a1 bool, _a2 error) *MockMetricsRepository_OldestSample_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockMetricsRepository_OldestSample_Call) RunAndReturn(run func(context.Context, uuid.UUID) (time.Time, bool, error)) *MockMetricsRepository_OldestSample_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// QueryMetrics provides a mock function with given fields: ctx, serverID, resolution, q
+func (_m *MockMetricsRepository) QueryMetrics(ctx context.Context, serverID uuid.UUID, resolution domain.MetricsResolution, q domain.MetricsQuery) ([]domain.MetricsQuerySample, error) {
+	ret := _m.Called(ctx, serverID, resolution, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueryMetrics")
+	}
+
+	var r0 []domain.MetricsQuerySample
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, domain.MetricsResolution, domain.MetricsQuery) ([]domain.MetricsQuerySample, error)); ok {
+		return rf(ctx, serverID, resolution, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, domain.MetricsResolution, domain.MetricsQuery) []domain.MetricsQuerySample); ok {
+		r0 = rf(ctx, serverID, resolution, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.MetricsQuerySample)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, domain.MetricsResolution, domain.MetricsQuery) error); ok {
+		r1 = rf(ctx, serverID, resolution, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockMetricsRepository_QueryMetrics_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QueryMetrics'
+type MockMetricsRepository_QueryMetrics_Call struct {
+	*mock.Call
+}
+
+// QueryMetrics is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serverID uuid.UUID
+//   - resolution domain.MetricsResolution
+//   - q domain.MetricsQuery
+func (_e *MockMetricsRepository_Expecter) QueryMetrics(ctx interface{}, serverID interface{}, resolution interface{}, q interface{}) *MockMetricsRepository_QueryMetrics_Call {
+	return &MockMetricsRepository_QueryMetrics_Call{Call: _e.mock.On("QueryMetrics", ctx, serverID, resolution, q)}
+}
+
+func (_c *MockMetricsRepository_QueryMetrics_Call) Run(run func(ctx context.Context, serverID uuid.UUID, resolution domain.MetricsResolution, q domain.MetricsQuery)) *MockMetricsRepository_QueryMetrics_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(domain.MetricsResolution), args[3].(domain.MetricsQuery))
+	})
+	return _c
+}
+
+func (_c *MockMetricsRepository_QueryMetrics_Call) Return(_a0 []domain.MetricsQuerySample, _a1 error) *MockMetricsRepository_QueryMetrics_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockMetricsRepository_QueryMetrics_Call) RunAndReturn(run func(context.Context, uuid.UUID, domain.MetricsResolution, domain.MetricsQuery) ([]domain.MetricsQuerySample, error)) *MockMetricsRepository_QueryMetrics_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RollUp provides a mock function with given fields: ctx, resolution, from, to
+func (_m *MockMetricsRepository) RollUp(ctx context.Context, resolution domain.MetricsResolution, from time.Time, to time.Time) error {
+	ret := _m.Called(ctx, resolution, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RollUp")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.MetricsResolution, time.Time, time.Time) error); ok {
+		r0 = rf(ctx, resolution, from, to)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockMetricsRepository_RollUp_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RollUp'
+type MockMetricsRepository_RollUp_Call struct {
+	*mock.Call
+}
+
+// RollUp is a helper method to define mock.On call
+//   - ctx context.Context
+//   - resolution domain.MetricsResolution
+//   - from time.Time
+//   - to time.Time
+func (_e *MockMetricsRepository_Expecter) RollUp(ctx interface{}, resolution interface{}, from interface{}, to interface{}) *MockMetricsRepository_RollUp_Call {
+	return &MockMetricsRepository_RollUp_Call{Call: _e.mock.On("RollUp", ctx, resolution, from, to)}
+}
+
+func (_c *MockMetricsRepository_RollUp_Call) Run(run func(ctx context.Context, resolution domain.MetricsResolution, from time.Time, to time.Time)) *MockMetricsRepository_RollUp_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.MetricsResolution), args[2].(time.Time), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockMetricsRepository_RollUp_Call) Return(_a0 error) *MockMetricsRepository_RollUp_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockMetricsRepository_RollUp_Call) RunAndReturn(run func(context.Context, domain.MetricsResolution, time.Time, time.Time) error) *MockMetricsRepository_RollUp_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StaleRollups provides a mock function with given fields: ctx, resolution, end
+func (_m *MockMetricsRepository) StaleRollups(ctx context.Context, resolution domain.MetricsResolution, end time.Time) ([]time.Time, error) {
+	ret := _m.Called(ctx, resolution, end)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StaleRollups")
+	}
+
+	var r0 []time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.MetricsResolution, time.Time) ([]time.Time, error)); ok {
+		return rf(ctx, resolution, end)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.MetricsResolution, time.Time) []time.Time); ok {
+		r0 = rf(ctx, resolution, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]time.Time)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.MetricsResolution, time.Time) error); ok {
+		r1 = rf(ctx, resolution, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockMetricsRepository_StaleRollups_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StaleRollups'
+type MockMetricsRepository_StaleRollups_Call struct {
+	*mock.Call
+}
+
+// StaleRollups is a helper method to define mock.On call
+//   - ctx context.Context
+//   - resolution domain.MetricsResolution
+//   - end time.Time
+func (_e *MockMetricsRepository_Expecter) StaleRollups(ctx interface{}, resolution interface{}, end interface{}) *MockMetricsRepository_StaleRollups_Call {
+	return &MockMetricsRepository_StaleRollups_Call{Call: _e.mock.On("StaleRollups", ctx, resolution, end)}
+}
+
+func (_c *MockMetricsRepository_StaleRollups_Call) Run(run func(ctx context.Context, resolution domain.MetricsResolution, end time.Time)) *MockMetricsRepository_StaleRollups_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(domain.MetricsResolution), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockMetricsRepository_StaleRollups_Call) Return(_a0 []time.Time, _a1 error) *MockMetricsRepository_StaleRollups_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockMetricsRepository_StaleRollups_Call) RunAndReturn(run func(context.Context, domain.MetricsResolution, time.Time) ([]time.Time, error)) *MockMetricsRepository_StaleRollups_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockMetricsRepository creates a new instance of MockMetricsRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockMetricsRepository(t interface {
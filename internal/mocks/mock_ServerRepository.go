@@ -418,6 +418,54 @@ func (_c *MockServerRepository_Update_Call) RunAndReturn(run func(context.Contex
 	return _c
 }
 
+// UpdateAgentInfo provides a mock function with given fields: ctx, serverID, info
+func (_m *MockServerRepository) UpdateAgentInfo(ctx context.Context, serverID uuid.UUID, info domain.AgentInfo) error {
+	ret := _m.Called(ctx, serverID, info)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAgentInfo")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, domain.AgentInfo) error); ok {
+		r0 = rf(ctx, serverID, info)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockServerRepository_UpdateAgentInfo_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateAgentInfo'
+type MockServerRepository_UpdateAgentInfo_Call struct {
+	*mock.Call
+}
+
+// UpdateAgentInfo is a helper method to define mock.On call
+//   - ctx context.Context
+//   - serverID uuid.UUID
+//   - info domain.AgentInfo
+func (_e *MockServerRepository_Expecter) UpdateAgentInfo(ctx interface{}, serverID interface{}, info interface{}) *MockServerRepository_UpdateAgentInfo_Call {
+	return &MockServerRepository_UpdateAgentInfo_Call{Call: _e.mock.On("UpdateAgentInfo", ctx, serverID, info)}
+}
+
+func (_c *MockServerRepository_UpdateAgentInfo_Call) Run(run func(ctx context.Context, serverID uuid.UUID, info domain.AgentInfo)) *MockServerRepository_UpdateAgentInfo_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uuid.UUID), args[2].(domain.AgentInfo))
+	})
+	return _c
+}
+
+func (_c *MockServerRepository_UpdateAgentInfo_Call) Return(_a0 error) *MockServerRepository_UpdateAgentInfo_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockServerRepository_UpdateAgentInfo_Call) RunAndReturn(run func(context.Context, uuid.UUID, domain.AgentInfo) error) *MockServerRepository_UpdateAgentInfo_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateOSInfo provides a mock function with given fields: ctx, serverID, osInfo
 func (_m *MockServerRepository) UpdateOSInfo(ctx context.Context, serverID uuid.UUID, osInfo domain.OSInfo) error {
 	ret := _m.Called(ctx, serverID, osInfo)
@@ -0,0 +1,159 @@
+package system
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CgroupRoot is where the unified (v2) cgroup hierarchy is mounted.
+const CgroupRoot = "/sys/fs/cgroup"
+
+// CgroupStats are a cgroup's cumulative counters.
+type CgroupStats struct {
+	CPUUsageUsec uint64
+
+	// MemoryBytes leaves out inactive page cache, as `docker stats` does.
+	MemoryBytes      uint64
+	MemoryLimitBytes uint64 // 0 when unlimited
+
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+// CgroupV2 reports whether root is a cgroup v2 mount.
+func CgroupV2(root string) bool {
+	_, err := os.Stat(filepath.Join(root, "cgroup.controllers"))
+	return err == nil
+}
+
+// ContainerCgroupDir finds a docker container's cgroup under root, for both
+// the systemd and the cgroupfs cgroup driver.
+func ContainerCgroupDir(root, containerID string) (string, bool) {
+	for _, dir := range []string{
+		filepath.Join(root, "system.slice", "docker-"+containerID+".scope"),
+		filepath.Join(root, "docker", containerID),
+	} {
+		if _, err := os.Stat(filepath.Join(dir, "cpu.stat")); err == nil {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// ReadCgroupStats reads the counters of the cgroup v2 group in dir. A group
+// without the io controller reports no IO.
+func ReadCgroupStats(dir string) (CgroupStats, error) {
+	var stats CgroupStats
+
+	cpu, err := readKeyedFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return stats, err
+	}
+	stats.CPUUsageUsec = cpu["usage_usec"]
+
+	current, err := readUintFile(filepath.Join(dir, "memory.current"))
+	if err != nil {
+		return stats, err
+	}
+	stats.MemoryBytes = current
+	if mem, err := readKeyedFile(filepath.Join(dir, "memory.stat")); err == nil {
+		if inactive := mem["inactive_file"]; inactive < current {
+			stats.MemoryBytes = current - inactive
+		}
+	}
+
+	// "max" when unlimited, which fails to parse and stays 0.
+	stats.MemoryLimitBytes, _ = readUintFile(filepath.Join(dir, "memory.max"))
+
+	if data, err := os.ReadFile(filepath.Join(dir, "io.stat")); err == nil {
+		stats.ReadBytes, stats.WriteBytes = parseIOStat(string(data))
+	}
+
+	return stats, nil
+}
+
+// parseIOStat sums io.stat's per-device lines:
+// "8:0 rbytes=1024 wbytes=2048 rios=1 wios=2 dbytes=0 dios=0".
+func parseIOStat(data string) (read, write uint64) {
+	for line := range strings.SplitSeq(data, "\n") {
+		fields := strings.Fields(line)
+		for _, field := range fields[min(1, len(fields)):] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			n, _ := strconv.ParseUint(value, 10, 64)
+			switch key {
+			case "rbytes":
+				read += n
+			case "wbytes":
+				write += n
+			}
+		}
+	}
+	return read, write
+}
+
+// ReadNetDev sums the bytes every interface but loopback moved, from a
+// net/dev file such as /proc/<pid>/net/dev (the pid's network namespace).
+func ReadNetDev(path string) (rx, tx uint64, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for line := range strings.SplitSeq(string(data), "\n") {
+		iface, counters, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(iface) == "lo" {
+			continue
+		}
+
+		fields := strings.Fields(counters)
+		if len(fields) < 9 {
+			continue
+		}
+
+		r, _ := strconv.ParseUint(fields[0], 10, 64)
+		t, _ := strconv.ParseUint(fields[8], 10, 64)
+		rx += r
+		tx += t
+	}
+
+	return rx, tx, nil
+}
+
+// readKeyedFile reads a flat "key value" file such as cpu.stat.
+func readKeyedFile(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		values[key] = n
+	}
+
+	return values, scanner.Err()
+}
+
+func readUintFile(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
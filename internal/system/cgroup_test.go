@@ -0,0 +1,77 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCgroupStats(t *testing.T) {
+	stats, err := ReadCgroupStats("testdata/cgroup/web")
+	if err != nil {
+		t.Fatalf("ReadCgroupStats: %v", err)
+	}
+
+	want := CgroupStats{
+		CPUUsageUsec:     8250000,
+		MemoryBytes:      157286400 - 52428800,
+		MemoryLimitBytes: 536870912,
+		ReadBytes:        1048576 + 2097152,
+		WriteBytes:       4194304,
+	}
+	if stats != want {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestReadCgroupStatsUnlimitedWithoutIO(t *testing.T) {
+	stats, err := ReadCgroupStats("testdata/cgroup/worker")
+	if err != nil {
+		t.Fatalf("ReadCgroupStats: %v", err)
+	}
+
+	want := CgroupStats{CPUUsageUsec: 1000, MemoryBytes: 4096}
+	if stats != want {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestReadCgroupStatsMissingGroup(t *testing.T) {
+	if _, err := ReadCgroupStats("testdata/cgroup/missing"); err == nil {
+		t.Fatal("read a missing cgroup")
+	}
+}
+
+func TestReadNetDevSkipsLoopback(t *testing.T) {
+	rx, tx, err := ReadNetDev("testdata/net_dev")
+	if err != nil {
+		t.Fatalf("ReadNetDev: %v", err)
+	}
+	if rx != 5242880+2048 || tx != 1048576+512 {
+		t.Fatalf("rx=%d tx=%d", rx, tx)
+	}
+}
+
+func TestContainerCgroupDir(t *testing.T) {
+	root := t.TempDir()
+	systemd := filepath.Join(root, "system.slice", "docker-abc.scope")
+	cgroupfs := filepath.Join(root, "docker", "def")
+	for _, dir := range []string{systemd, cgroupfs} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cpu.stat"), []byte("usage_usec 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if dir, ok := ContainerCgroupDir(root, "abc"); !ok || dir != systemd {
+		t.Fatalf("abc: %q %v, want the systemd scope", dir, ok)
+	}
+	if dir, ok := ContainerCgroupDir(root, "def"); !ok || dir != cgroupfs {
+		t.Fatalf("def: %q %v, want the cgroupfs group", dir, ok)
+	}
+	if _, ok := ContainerCgroupDir(root, "ghi"); ok {
+		t.Fatal("found a cgroup for an unknown container")
+	}
+}
@@ -3,7 +3,10 @@
 // does not match its own, so stale agents are visible before they drift.
 package version
 
-import "runtime/debug"
+import (
+	"runtime/debug"
+	"strings"
+)
 
 // Version is the semantic version of this build. Overridable at build time:
 //
@@ -22,3 +25,9 @@ func BuildInfo() string {
 	}
 	return Version
 }
+
+// Equal reports whether a and b name the same release. Release tags carry a
+// leading "v" ("v0.3.12") that user input and older stamps may not.
+func Equal(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
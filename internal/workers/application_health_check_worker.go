@@ -55,6 +55,7 @@ func (w *ApplicationHealthCheckWorker) Run(ctx context.Context) error {
 			payload.Applications = append(payload.Applications, domain.AppInfo{
 				ApplicationID: app.ID,
 				AppKey:        domain.GetAppKey(app),
+				Compose:       app.Compose,
 			})
 		}
 
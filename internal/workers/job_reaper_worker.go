@@ -9,7 +9,7 @@ import (
 	"horizonx/internal/logger"
 )
 
-// JobReaperWorker marks long-running jobs as failed when the agent that owned
+// JobReaperWorker marks long-running jobs as expired when the agent that owned
 // them died mid-execution (P0-6). The agent itself enforces per-job timeouts
 // (job_worker.go jobTimeouts; the longest is deploy = 15m), so if a job is
 // still 'running' after reapAfter it can only mean the agent went away without
@@ -55,9 +55,11 @@ func (w *JobReaperWorker) Run(ctx context.Context) error {
 			continue
 		}
 
-		// The agent is gone or wedged — mark the job failed so it stops
-		// blocking the deployment history and queue.
-		if _, err := w.job.Finish(ctx, job.ID, domain.JobFailed); err != nil {
+		// The agent is gone or wedged — expire the job so it stops blocking
+		// the deployment history and queue. Expired counts as failed, but an
+		// agent that was only cut off still reports the real outcome from
+		// its spool once it reconnects.
+		if _, err := w.job.Finish(ctx, job.ID, domain.JobExpired, job.StartedAt); err != nil {
 			w.log.Error("failed to reap stuck job",
 				"job_id", job.ID,
 				"error", err.Error(),
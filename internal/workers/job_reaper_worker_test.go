@@ -13,6 +13,7 @@ import (
 type fakeJobService struct {
 	jobs      []*domain.Job
 	finished  []int64
+	statuses  []domain.JobStatus
 	failError error
 }
 
@@ -33,12 +34,17 @@ func (f *fakeJobService) Retry(context.Context, int64, *domain.Job) (*domain.Job
 }
 func (f *fakeJobService) Start(context.Context, int64) (*domain.Job, error) { return nil, nil }
 
-func (f *fakeJobService) Finish(_ context.Context, jobID int64, _ domain.JobStatus) (*domain.Job, error) {
+func (f *fakeJobService) Finish(_ context.Context, jobID int64, status domain.JobStatus, _ *time.Time) (*domain.Job, error) {
 	if f.failError != nil {
 		return nil, f.failError
 	}
 
 	f.finished = append(f.finished, jobID)
+	f.statuses = append(f.statuses, status)
+	return nil, nil
+}
+
+func (f *fakeJobService) Cancel(context.Context, int64, int64) (*domain.Job, error) {
 	return nil, nil
 }
 
@@ -68,6 +74,10 @@ func TestJobReaperReapsStuckJobs(t *testing.T) {
 	if len(svc.finished) != 1 || svc.finished[0] != 1 {
 		t.Fatalf("expected only job 1 reaped, got %v", svc.finished)
 	}
+	// Expired, not failed: the agent may still report the real outcome.
+	if svc.statuses[0] != domain.JobExpired {
+		t.Fatalf("expected job 1 expired, got %s", svc.statuses[0])
+	}
 }
 
 func TestJobReaperSurvivesErrors(t *testing.T) {
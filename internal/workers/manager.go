@@ -14,6 +14,7 @@ type Manager struct {
 
 	scheduler *Scheduler
 	services  *ManagerServices
+	retention domain.MetricsRetention
 }
 
 type ManagerServices struct {
@@ -21,6 +22,7 @@ type ManagerServices struct {
 	Server      domain.ServerService
 	Metrics     domain.MetricsService
 	Application domain.ApplicationService
+	Preview     domain.PreviewService
 }
 
 type Worker interface {
@@ -28,12 +30,13 @@ type Worker interface {
 	Run(ctx context.Context) error
 }
 
-func NewManager(log logger.Logger, scheduler *Scheduler, services *ManagerServices) *Manager {
+func NewManager(log logger.Logger, scheduler *Scheduler, services *ManagerServices, retention domain.MetricsRetention) *Manager {
 	return &Manager{
 		log: log,
 
 		scheduler: scheduler,
 		services:  services,
+		retention: retention,
 	}
 }
 
@@ -46,11 +49,17 @@ func (m *Manager) Start(ctx context.Context) {
 		log:    m.log,
 	})
 
-	m.scheduler.RunDaily(ctx, DailySchedule{Hour: 2, Minute: 0}, &MetricsCleanupWorker{
-		metrics: m.services.Metrics,
-		server:  m.services.Server,
-		log:     m.log,
-	})
+	m.scheduler.RunByDuration(ctx, 15*time.Minute, NewMetricsRollupWorker(
+		m.services.Metrics,
+		m.log,
+	))
+
+	m.scheduler.RunDaily(ctx, DailySchedule{Hour: 2, Minute: 0}, NewMetricsCleanupWorker(
+		m.services.Metrics,
+		m.services.Server,
+		m.retention,
+		m.log,
+	))
 
 	m.scheduler.RunByDuration(ctx, 5*time.Minute, &ApplicationHealthCheckWorker{
 		app: m.services.Application,
@@ -62,4 +71,9 @@ func (m *Manager) Start(ctx context.Context) {
 		m.services.Job,
 		m.log,
 	))
+
+	m.scheduler.RunByDuration(ctx, 5*time.Minute, &PreviewReaperWorker{
+		preview: m.services.Preview,
+		log:     m.log,
+	})
 }
@@ -10,16 +10,18 @@ import (
 )
 
 type MetricsCleanupWorker struct {
-	metrics domain.MetricsService
-	server  domain.ServerService
-	log     logger.Logger
+	metrics   domain.MetricsService
+	server    domain.ServerService
+	retention domain.MetricsRetention
+	log       logger.Logger
 }
 
-func NewMetricsCleanupWorker(metrics domain.MetricsService, server domain.ServerService, log logger.Logger) Worker {
+func NewMetricsCleanupWorker(metrics domain.MetricsService, server domain.ServerService, retention domain.MetricsRetention, log logger.Logger) Worker {
 	return &MetricsCleanupWorker{
-		metrics: metrics,
-		server:  server,
-		log:     log,
+		metrics:   metrics,
+		server:    server,
+		retention: retention,
+		log:       log,
 	}
 }
 
@@ -37,7 +39,8 @@ func (w *MetricsCleanupWorker) Run(ctx context.Context) error {
 	}
 
 	now := time.Now().UTC()
-	cutoffTime := time.Date(now.Year(), now.Month(), now.Day(), 2, 0, 0, 0, now.Location()).AddDate(0, 0, -7)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	cutoffTime := today.AddDate(0, 0, -w.retention.RawDays)
 
 	for _, srv := range servers.Data {
 		if err := w.metrics.Cleanup(ctx, srv.ID, cutoffTime); err != nil {
@@ -45,5 +48,15 @@ func (w *MetricsCleanupWorker) Run(ctx context.Context) error {
 		}
 	}
 
+	rollups := map[domain.MetricsResolution]int{
+		domain.MetricsHourly: w.retention.HourlyDays,
+		domain.MetricsDaily:  w.retention.DailyDays,
+	}
+	for resolution, days := range rollups {
+		if err := w.metrics.CleanupRollups(ctx, resolution, today.AddDate(0, 0, -days)); err != nil {
+			w.log.Error("failed to cleanup metrics rollups", "resolution", resolution, "error", err.Error())
+		}
+	}
+
 	return nil
 }
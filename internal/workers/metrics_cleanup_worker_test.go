@@ -0,0 +1,69 @@
+package workers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"horizonx/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type fakeMetricsService struct {
+	domain.MetricsService
+
+	cleaned        map[uuid.UUID]time.Time
+	cleanedRollups map[domain.MetricsResolution]time.Time
+}
+
+func (f *fakeMetricsService) Cleanup(_ context.Context, serverID uuid.UUID, cutoff time.Time) error {
+	f.cleaned[serverID] = cutoff
+	return nil
+}
+
+func (f *fakeMetricsService) CleanupRollups(_ context.Context, resolution domain.MetricsResolution, cutoff time.Time) error {
+	f.cleanedRollups[resolution] = cutoff
+	return nil
+}
+
+type fakeServerService struct {
+	domain.ServerService
+	servers []*domain.Server
+}
+
+func (f *fakeServerService) List(context.Context, domain.ServerListOptions) (*domain.ListResult[*domain.Server], error) {
+	return &domain.ListResult[*domain.Server]{Data: f.servers}, nil
+}
+
+func TestMetricsCleanupAppliesEachRetention(t *testing.T) {
+	metrics := &fakeMetricsService{
+		cleaned:        make(map[uuid.UUID]time.Time),
+		cleanedRollups: make(map[domain.MetricsResolution]time.Time),
+	}
+	servers := &fakeServerService{servers: []*domain.Server{{ID: uuid.New()}, {ID: uuid.New()}}}
+
+	w := NewMetricsCleanupWorker(metrics, servers, domain.MetricsRetention{
+		RawDays:    3,
+		HourlyDays: 30,
+		DailyDays:  365,
+	}, noopLog{})
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for _, srv := range servers.servers {
+		if got, want := metrics.cleaned[srv.ID], today.AddDate(0, 0, -3); !got.Equal(want) {
+			t.Errorf("raw cutoff for %s = %v, want %v", srv.ID, got, want)
+		}
+	}
+	if got, want := metrics.cleanedRollups[domain.MetricsHourly], today.AddDate(0, 0, -30); !got.Equal(want) {
+		t.Errorf("hourly cutoff = %v, want %v", got, want)
+	}
+	if got, want := metrics.cleanedRollups[domain.MetricsDaily], today.AddDate(0, 0, -365); !got.Equal(want) {
+		t.Errorf("daily cutoff = %v, want %v", got, want)
+	}
+}
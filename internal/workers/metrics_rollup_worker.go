@@ -0,0 +1,40 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+)
+
+// MetricsRollupWorker keeps the hourly and daily rollups up to date with the
+// raw samples, so their history outlives the raw retention.
+type MetricsRollupWorker struct {
+	metrics domain.MetricsService
+	log     logger.Logger
+}
+
+func NewMetricsRollupWorker(metrics domain.MetricsService, log logger.Logger) Worker {
+	return &MetricsRollupWorker{
+		metrics: metrics,
+		log:     log,
+	}
+}
+
+func (w *MetricsRollupWorker) Name() string {
+	return "metrics_rollup"
+}
+
+func (w *MetricsRollupWorker) Run(ctx context.Context) error {
+	now := time.Now()
+
+	for _, resolution := range []domain.MetricsResolution{domain.MetricsHourly, domain.MetricsDaily} {
+		if err := w.metrics.RollUp(ctx, resolution, now); err != nil {
+			return fmt.Errorf("failed to roll up %s metrics: %w", resolution, err)
+		}
+	}
+
+	return nil
+}
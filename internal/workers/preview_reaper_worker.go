@@ -0,0 +1,32 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+
+	"horizonx/internal/domain"
+	"horizonx/internal/logger"
+)
+
+// PreviewReaperWorker tears down preview environments whose TTL ran out, so
+// previews of abandoned PRs (or PRs whose close event never arrived) don't
+// keep running forever.
+type PreviewReaperWorker struct {
+	preview domain.PreviewService
+	log     logger.Logger
+}
+
+func (w *PreviewReaperWorker) Name() string {
+	return "preview_reaper"
+}
+
+func (w *PreviewReaperWorker) Run(ctx context.Context) error {
+	reaped, err := w.preview.ReapExpired(ctx)
+	if reaped > 0 {
+		w.log.Info("reaped expired previews", "reaped", reaped)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reap expired previews: %w", err)
+	}
+	return nil
+}
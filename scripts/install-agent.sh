@@ -14,7 +14,8 @@ Options:
   --ws-url <url>              Override HORIZONX_WS_URL
   --api-token <token>         Override HORIZONX_SERVER_API_TOKEN
   --server-id <id>            Override HORIZONX_SERVER_ID
-  --redis-addr <host:port>    Override REDIS_ADDR
+  --metrics-buffer <kind>     Override AGENT_METRICS_BUFFER (file|redis)
+  --redis-addr <host:port>    Override REDIS_ADDR (metrics buffer redis only)
   --redis-username <user>     Override REDIS_USERNAME
   --redis-password <pass>     Override REDIS_PASSWORD
   --redis-db <db>             Override REDIS_DB
@@ -44,6 +45,7 @@ CFG_API_URL="http://localhost:3000"
 CFG_WS_URL="ws://localhost:3000/agent/ws"
 CFG_API_TOKEN="hzx_secret"
 CFG_SERVER_ID="123abc"
+CFG_METRICS_BUFFER="file"
 CFG_REDIS_ADDR="localhost:6379"
 CFG_REDIS_USERNAME=""
 CFG_REDIS_PASSWORD=""
@@ -61,6 +63,7 @@ OVERRIDE_API_URL=""
 OVERRIDE_WS_URL=""
 OVERRIDE_API_TOKEN=""
 OVERRIDE_SERVER_ID=""
+OVERRIDE_METRICS_BUFFER=""
 OVERRIDE_REDIS_ADDR=""
 OVERRIDE_REDIS_USERNAME=""
 OVERRIDE_REDIS_PASSWORD=""
@@ -86,6 +89,9 @@ while [[ $# -gt 0 ]]; do
     --server-id)
       [[ -z "${2:-}" ]] && { echo "[!] --server-id requires a value"; exit 1; }
       OVERRIDE_SERVER_ID="$2"; shift 2 ;;
+    --metrics-buffer)
+      [[ -z "${2:-}" ]] && { echo "[!] --metrics-buffer requires a value"; exit 1; }
+      OVERRIDE_METRICS_BUFFER="$2"; shift 2 ;;
     --redis-addr)
       [[ -z "${2:-}" ]] && { echo "[!] --redis-addr requires a value"; exit 1; }
       OVERRIDE_REDIS_ADDR="$2"; shift 2 ;;
@@ -157,6 +163,7 @@ load_env_file() {
       HORIZONX_WS_URL)           CFG_WS_URL="$val" ;;
       HORIZONX_SERVER_API_TOKEN) CFG_API_TOKEN="$val" ;;
       HORIZONX_SERVER_ID)        CFG_SERVER_ID="$val" ;;
+      AGENT_METRICS_BUFFER)      CFG_METRICS_BUFFER="$val" ;;
       REDIS_ADDR)                CFG_REDIS_ADDR="$val" ;;
       REDIS_USERNAME)            CFG_REDIS_USERNAME="$val" ;;
       REDIS_PASSWORD)            CFG_REDIS_PASSWORD="$val" ;;
@@ -175,6 +182,7 @@ apply_overrides() {
   if [[ -n "${OVERRIDE_WS_URL:-}" ]];           then CFG_WS_URL="$OVERRIDE_WS_URL"; fi
   if [[ -n "${OVERRIDE_API_TOKEN:-}" ]];        then CFG_API_TOKEN="$OVERRIDE_API_TOKEN"; fi
   if [[ -n "${OVERRIDE_SERVER_ID:-}" ]];        then CFG_SERVER_ID="$OVERRIDE_SERVER_ID"; fi
+  if [[ -n "${OVERRIDE_METRICS_BUFFER:-}" ]];   then CFG_METRICS_BUFFER="$OVERRIDE_METRICS_BUFFER"; fi
   if [[ -n "${OVERRIDE_REDIS_ADDR:-}" ]];       then CFG_REDIS_ADDR="$OVERRIDE_REDIS_ADDR"; fi
   if [[ -n "${OVERRIDE_REDIS_USERNAME:-}" ]];   then CFG_REDIS_USERNAME="$OVERRIDE_REDIS_USERNAME"; fi
   if [[ -n "${OVERRIDE_REDIS_PASSWORD:-}" ]];   then CFG_REDIS_PASSWORD="$OVERRIDE_REDIS_PASSWORD"; fi
@@ -219,16 +227,25 @@ validate_config() {
     errors=$((errors + 1))
   fi
 
-  # REDIS_ADDR must be host:port
-  if [[ ! "$CFG_REDIS_ADDR" =~ ^.+:[0-9]+$ ]]; then
-    echo "[!] Invalid REDIS_ADDR: '$CFG_REDIS_ADDR'. Expected format: host:port"
+  # AGENT_METRICS_BUFFER
+  if [[ ! "$CFG_METRICS_BUFFER" =~ ^(file|redis)$ ]]; then
+    echo "[!] Invalid AGENT_METRICS_BUFFER: '$CFG_METRICS_BUFFER'. Must be: file, redis"
     errors=$((errors + 1))
   fi
 
-  # REDIS_DB must be an integer
-  if [[ ! "$CFG_REDIS_DB" =~ ^[0-9]+$ ]]; then
-    echo "[!] Invalid REDIS_DB: '$CFG_REDIS_DB'. Must be a non-negative integer"
-    errors=$((errors + 1))
+  # Redis settings only matter when metrics are buffered in Redis
+  if [[ "$CFG_METRICS_BUFFER" == "redis" ]]; then
+    # REDIS_ADDR must be host:port
+    if [[ ! "$CFG_REDIS_ADDR" =~ ^.+:[0-9]+$ ]]; then
+      echo "[!] Invalid REDIS_ADDR: '$CFG_REDIS_ADDR'. Expected format: host:port"
+      errors=$((errors + 1))
+    fi
+
+    # REDIS_DB must be an integer
+    if [[ ! "$CFG_REDIS_DB" =~ ^[0-9]+$ ]]; then
+      echo "[!] Invalid REDIS_DB: '$CFG_REDIS_DB'. Must be a non-negative integer"
+      errors=$((errors + 1))
+    fi
   fi
 
   # AGENT_JOB_WORKER_COUNT must be an integer
@@ -307,14 +324,17 @@ echo "    HORIZONX_API_URL          = $CFG_API_URL"
 echo "    HORIZONX_WS_URL           = $CFG_WS_URL"
 echo "    HORIZONX_SERVER_API_TOKEN = ${CFG_API_TOKEN:0:4}***"
 echo "    HORIZONX_SERVER_ID        = $CFG_SERVER_ID"
-echo "    REDIS_ADDR                = $CFG_REDIS_ADDR"
-echo "    REDIS_USERNAME            = ${CFG_REDIS_USERNAME:-<empty>}"
-if [[ -n "${CFG_REDIS_PASSWORD:-}" ]]; then
-  echo "    REDIS_PASSWORD            = ********"
-else
-  echo "    REDIS_PASSWORD            = <empty>"
+echo "    AGENT_METRICS_BUFFER      = $CFG_METRICS_BUFFER"
+if [[ "$CFG_METRICS_BUFFER" == "redis" ]]; then
+  echo "    REDIS_ADDR                = $CFG_REDIS_ADDR"
+  echo "    REDIS_USERNAME            = ${CFG_REDIS_USERNAME:-<empty>}"
+  if [[ -n "${CFG_REDIS_PASSWORD:-}" ]]; then
+    echo "    REDIS_PASSWORD            = ********"
+  else
+    echo "    REDIS_PASSWORD            = <empty>"
+  fi
+  echo "    REDIS_DB                  = $CFG_REDIS_DB"
 fi
-echo "    REDIS_DB                  = $CFG_REDIS_DB"
 echo "    AGENT_JOB_WORKER_COUNT    = $CFG_JOB_WORKER_COUNT"
 echo "    LOG_LEVEL                 = $CFG_LOG_LEVEL"
 echo "    LOG_FORMAT                = $CFG_LOG_FORMAT"
@@ -550,6 +570,7 @@ HORIZONX_API_URL=$CFG_API_URL
 HORIZONX_WS_URL=$CFG_WS_URL
 HORIZONX_SERVER_API_TOKEN=$CFG_API_TOKEN
 HORIZONX_SERVER_ID=$CFG_SERVER_ID
+AGENT_METRICS_BUFFER="$CFG_METRICS_BUFFER"
 REDIS_ADDR="$CFG_REDIS_ADDR"
 REDIS_USERNAME="$CFG_REDIS_USERNAME"
 REDIS_PASSWORD="$CFG_REDIS_PASSWORD"